@@ -0,0 +1,223 @@
+// Package galaxy serves a Galaxy site from inside an existing Go program.
+//
+//	site := galaxy.New(cfg)
+//	site.FS = os.DirFS("site")
+//	mux.Handle("/site/", site.Handler())
+//
+// Pages, markdown and components are read from FS and rendered per request.
+// Set cfg.Base to the mount path so asset URLs resolve under it.
+package galaxy
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/withgalaxy/galaxy/pkg/assets"
+	"github.com/withgalaxy/galaxy/pkg/compiler"
+	"github.com/withgalaxy/galaxy/pkg/compress"
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/endpoints"
+	"github.com/withgalaxy/galaxy/pkg/middleware"
+	"github.com/withgalaxy/galaxy/pkg/router"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/session"
+)
+
+// Galaxy is a site mounted in a host application. Set its fields before the
+// first call to Handler or Load.
+type Galaxy struct {
+	Config *config.Config
+	// FS holds the project: Config.SrcDir with pages and components, and
+	// public. Defaults to the working directory.
+	FS fs.FS
+	// Locals, if set, supplies per-request values from the host app. They
+	// are visible to middleware, endpoints and page frontmatter.
+	Locals func(r *http.Request) map[string]any
+	// AssetsDir is where bundled styles and scripts are written and served
+	// from. Defaults to .galaxy.
+	AssetsDir string
+
+	Router   *router.Router
+	Compiler *compiler.ComponentCompiler
+	Bundler  *assets.Bundler
+
+	middleware  []middleware.Middleware
+	chain       *middleware.Chain
+	endpoints   map[string]*endpoints.LoadedEndpoint
+	rateLimiter *security.RateLimiter
+	forwarded   *security.ForwardedHostValidator
+	public      *assets.FileServer
+	bundled     *assets.FileServer
+	wasmExec    string
+
+	loadOnce sync.Once
+	loadErr  error
+}
+
+func New(cfg *config.Config) *Galaxy {
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	return &Galaxy{
+		Config:    cfg,
+		endpoints: make(map[string]*endpoints.LoadedEndpoint),
+	}
+}
+
+// Use appends middleware that runs after the configured security middleware
+// and before the page or endpoint.
+func (g *Galaxy) Use(m middleware.Middleware) *Galaxy {
+	g.middleware = append(g.middleware, m)
+	return g
+}
+
+// Endpoint registers the handlers for the endpoint route with pattern, such
+// as "/api/users". Endpoint files are Go code, so the host app compiles them
+// in and passes their handlers here.
+func (g *Galaxy) Endpoint(pattern string, handlers map[endpoints.HTTPMethod]endpoints.HandlerFunc) *Galaxy {
+	g.endpoints[pattern] = &endpoints.LoadedEndpoint{Handlers: handlers}
+	return g
+}
+
+// Load discovers routes and sets up the configured middleware. Handler
+// calls it on first use; call it directly to fail fast at startup.
+func (g *Galaxy) Load() error {
+	g.loadOnce.Do(func() {
+		g.loadErr = g.load()
+	})
+	return g.loadErr
+}
+
+func (g *Galaxy) load() error {
+	cfg := g.Config
+	if g.FS == nil {
+		g.FS = os.DirFS(".")
+	}
+	if g.AssetsDir == "" {
+		g.AssetsDir = ".galaxy"
+	}
+
+	srcDir := filepath.Clean(cfg.SrcDir)
+	if g.Router == nil {
+		g.Router = router.NewRouterFS(g.FS, filepath.Join(srcDir, "pages"))
+	}
+	if err := g.Router.Discover(); err != nil {
+		return fmt.Errorf("discover routes: %w", err)
+	}
+	g.Router.Sort()
+
+	if g.Compiler == nil {
+		g.Compiler = compiler.NewComponentCompilerFS(g.FS, srcDir)
+	}
+	if g.Bundler == nil {
+		g.Bundler = assets.NewBundler(g.AssetsDir)
+	}
+	g.Bundler.Base = cfg.Base
+	g.Bundler.CSPNonce = security.CSPNonceEnabled(cfg)
+	g.Compiler.Bundler = g.Bundler
+
+	public, err := fs.Sub(g.FS, "public")
+	if err != nil {
+		return err
+	}
+	g.public = assets.NewFileServer(public)
+	g.bundled = assets.NewFileServer(os.DirFS(g.AssetsDir))
+	g.wasmExec = wasmExecPath()
+
+	return g.loadMiddleware()
+}
+
+// loadMiddleware puts the security middleware from cfg ahead of the host's,
+// in the order the dev server runs them.
+func (g *Galaxy) loadMiddleware() error {
+	cfg := g.Config
+	chain := middleware.NewChain()
+
+	if cfg.Security.BodyLimit.Enabled {
+		maxBytes := cfg.Security.BodyLimit.MaxBytes
+		if maxBytes == 0 {
+			maxBytes = 10 * 1024 * 1024
+		}
+		chain.Use(security.NewBodyLimitMiddleware(maxBytes).Middleware)
+	}
+	if len(cfg.Security.AllowedDomains) > 0 {
+		g.forwarded = security.NewForwardedHostValidator(cfg.Security.AllowedDomains)
+	}
+	if security.CORSEnabled(cfg) {
+		chain.Use(security.NewCORSMiddleware(cfg.Security.CORS).Middleware)
+	}
+	if cfg.Session.Enabled {
+		manager, err := session.NewManager(cfg.Session)
+		if err != nil {
+			return fmt.Errorf("sessions: %w", err)
+		}
+		chain.Use(manager.Middleware)
+	}
+	if security.CSRFEnabled(cfg) {
+		chain.Use(security.NewCSRFMiddleware(security.NewCSRFConfig(cfg)).Middleware)
+	}
+	if cfg.Security.Headers.Enabled {
+		chain.Use(security.NewHeadersMiddleware(cfg.Security.Headers).Middleware)
+	}
+
+	if rl := cfg.Security.RateLimit; rl.Enabled && len(rl.Rules) > 0 {
+		limiter, err := security.NewRateLimiter(rl, nil)
+		if err != nil {
+			return fmt.Errorf("rate limiting: %w", err)
+		}
+		if rl.Page != "" {
+			page, err := fs.ReadFile(g.FS, filepath.ToSlash(filepath.Clean(rl.Page)))
+			if err != nil {
+				return fmt.Errorf("read rate limit page: %w", err)
+			}
+			limiter.Page = string(page)
+		}
+		g.rateLimiter = limiter
+	}
+
+	for _, m := range g.middleware {
+		chain.Use(m)
+	}
+	g.chain = chain
+	return nil
+}
+
+// Handler serves the site. Requests outside Config.Base are not found.
+func (g *Galaxy) Handler() http.Handler {
+	var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := g.Load(); err != nil {
+			log.Printf("galaxy: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		// Serve a copy, the host may still use r once we return.
+		r = r.Clone(r.Context())
+
+		base := strings.TrimSuffix(g.Config.Base, "/")
+		if base != "" {
+			rest, ok := strings.CutPrefix(r.URL.Path, base)
+			if !ok || (rest != "" && rest[0] != '/') {
+				http.NotFound(w, r)
+				return
+			}
+			if rest == "" {
+				rest = "/"
+			}
+			r.URL.Path = rest
+			r.URL.RawPath = ""
+		}
+
+		g.serve(w, r)
+	})
+	if g.Config.Compression.Enabled {
+		h = compress.Handler(g.Config.Compression, h)
+	}
+	return h
+}
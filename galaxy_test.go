@@ -0,0 +1,115 @@
+package galaxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/endpoints"
+	"github.com/withgalaxy/galaxy/pkg/middleware"
+)
+
+func TestHandler(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Base = "/site/"
+
+	site := New(cfg)
+	site.AssetsDir = t.TempDir()
+	site.FS = fstest.MapFS{
+		"src/pages/index.gxc":         {Data: []byte("<html><head></head><body><Greeting name={Locals.user} /></body></html>\n<style>h1 { color: red; }</style>")},
+		"src/pages/[slug].gxc":        {Data: []byte("<p>{slug} {Locals.theme}</p>")},
+		"src/pages/api/ping.go":       {Data: []byte("package api")},
+		"src/components/Greeting.gxc": {Data: []byte("<h1>Hello {name}</h1>")},
+		"public/robots.txt":           {Data: []byte("User-agent: *")},
+	}
+	site.Locals = func(r *http.Request) map[string]any {
+		return map[string]any{"user": r.Header.Get("X-User")}
+	}
+	site.Use(func(ctx *middleware.Context, next func() error) error {
+		ctx.Set("theme", "dark")
+		return next()
+	})
+	site.Endpoint("/api/ping", map[endpoints.HTTPMethod]endpoints.HandlerFunc{
+		endpoints.GET: func(ctx *endpoints.Context) error {
+			return ctx.Text(http.StatusOK, "pong "+ctx.Locals["theme"].(string))
+		},
+	})
+	if err := site.Load(); err != nil {
+		t.Fatal(err)
+	}
+	h := site.Handler()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("X-User", "ada")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/site/")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "<h1>Hello ada</h1>") {
+		t.Fatalf("index: %d %q", w.Code, w.Body.String())
+	}
+	_, href, ok := strings.Cut(w.Body.String(), `href="`)
+	href, _, _ = strings.Cut(href, `"`)
+	if !ok || !strings.HasPrefix(href, "/site/_assets/") {
+		t.Fatalf("asset URLs should be under the base path: %q", w.Body.String())
+	}
+	if w := get(href); !strings.Contains(w.Body.String(), "color") {
+		t.Errorf("bundled stylesheet: %d %q", w.Code, w.Body.String())
+	}
+
+	req := httptest.NewRequest("GET", "/site/", nil)
+	req.Header.Set("X-User", "ada")
+	req.Header.Set("If-None-Match", w.Header().Get("ETag"))
+	cond := httptest.NewRecorder()
+	h.ServeHTTP(cond, req)
+	if cond.Code != http.StatusNotModified {
+		t.Errorf("conditional request: got %d", cond.Code)
+	}
+
+	if w := get("/site/hello"); !strings.Contains(w.Body.String(), "<p>hello dark</p>") {
+		t.Errorf("dynamic page: %q", w.Body.String())
+	}
+	if w := get("/site/api/ping"); w.Body.String() != "pong dark" {
+		t.Errorf("endpoint: %q", w.Body.String())
+	}
+	if w := get("/site/robots.txt"); w.Body.String() != "User-agent: *" {
+		t.Errorf("public file: %q", w.Body.String())
+	}
+	if w := get("/hello"); w.Code != http.StatusNotFound {
+		t.Errorf("path outside base: got %d", w.Code)
+	}
+}
+
+func TestHandler_Session(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Session.Enabled = true
+	cfg.Session.Secret = "test-secret"
+	cfg.Security.AllowedDomains = []config.RemotePattern{{Hostname: "example.com"}}
+
+	site := New(cfg)
+	site.AssetsDir = t.TempDir()
+	site.FS = fstest.MapFS{"src/pages/api/login.go": {Data: []byte("package api")}}
+	site.Endpoint("/api/login", map[endpoints.HTTPMethod]endpoints.HandlerFunc{
+		endpoints.POST: func(ctx *endpoints.Context) error {
+			ctx.Session().Set("user", "ada")
+			return nil
+		},
+	})
+
+	req := httptest.NewRequest("POST", "/api/login", nil)
+	w := httptest.NewRecorder()
+	site.Handler().ServeHTTP(w, req)
+
+	if len(w.Result().Cookies()) != 1 {
+		t.Errorf("session should be saved when the endpoint writes nothing: %v", w.Header())
+	}
+	if req.URL.Scheme != "" || req.URL.Host != "" {
+		t.Errorf("host request was modified: %s", req.URL)
+	}
+}
@@ -5,6 +5,7 @@ go 1.23
 require (
 	github.com/AlecAivazis/survey/v2 v2.3.7
 	github.com/BurntSushi/toml v1.5.0
+	github.com/andybalholm/brotli v1.2.6
 	github.com/spf13/cobra v1.10.1
 	github.com/withgalaxy/orbit v0.1.1
 	github.com/yuin/goldmark v1.7.8
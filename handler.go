@@ -0,0 +1,257 @@
+package galaxy
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/withgalaxy/galaxy/pkg/compiler"
+	"github.com/withgalaxy/galaxy/pkg/endpoints"
+	"github.com/withgalaxy/galaxy/pkg/executor"
+	"github.com/withgalaxy/galaxy/pkg/httpcache"
+	"github.com/withgalaxy/galaxy/pkg/middleware"
+	"github.com/withgalaxy/galaxy/pkg/parser"
+	"github.com/withgalaxy/galaxy/pkg/router"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/session"
+	"github.com/withgalaxy/galaxy/pkg/ssr"
+	"github.com/withgalaxy/galaxy/pkg/template"
+)
+
+func (g *Galaxy) serve(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/wasm_exec.js":
+		http.ServeFile(w, r, g.wasmExec)
+		return
+	case r.URL.Path == ssr.HydrationPath:
+		ssr.ServeHydration(w, r)
+		return
+	case r.URL.Path == ssr.RouterPath:
+		ssr.ServeRouter(w, r)
+		return
+	case r.URL.Path == ssr.PrefetchPath:
+		ssr.ServePrefetch(w, r)
+		return
+	case strings.HasPrefix(r.URL.Path, "/_assets/"):
+		g.bundled.ServeHTTP(w, r)
+		return
+	case filepath.Ext(r.URL.Path) != "":
+		g.public.ServeHTTP(w, r)
+		return
+	}
+
+	route, params := g.Router.Match(r.URL.Path)
+	if route == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	mwCtx := middleware.NewContext(w, r)
+	mwCtx.Params = params
+	if g.Locals != nil {
+		for k, v := range g.Locals(r) {
+			mwCtx.Set(k, v)
+		}
+	}
+
+	if g.forwarded != nil {
+		currentURL := mwCtx.Request.URL
+		if currentURL.Scheme == "" {
+			currentURL.Scheme = "http"
+		}
+		if currentURL.Host == "" {
+			currentURL.Host = mwCtx.Request.Host
+		}
+		mwCtx.Request.URL = g.forwarded.ValidateForwardedHost(mwCtx.Request, currentURL)
+	}
+
+	err := g.chain.Execute(mwCtx, func(ctx *middleware.Context) error {
+		if g.rateLimiter != nil && !g.rateLimiter.Allow(ctx.Response, ctx.Request, ctx.Locals) {
+			return nil
+		}
+		switch {
+		case route.IsEndpoint:
+			g.handleEndpoint(route, ctx, params)
+		case route.Type == router.RouteMarkdown:
+			g.handleMarkdownPage(route, ctx)
+		default:
+			g.handlePage(route, ctx, params)
+		}
+		return nil
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (g *Galaxy) handleEndpoint(route *router.Route, mwCtx *middleware.Context, params map[string]string) {
+	endpoint, ok := g.endpoints[route.Pattern]
+	if !ok {
+		http.Error(mwCtx.Response, fmt.Sprintf("No handlers registered for endpoint %s", route.Pattern), http.StatusNotImplemented)
+		return
+	}
+
+	if err := endpoints.HandleEndpoint(endpoint, mwCtx.Response, mwCtx.Request, params, mwCtx.Locals); err != nil {
+		http.Error(mwCtx.Response, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (g *Galaxy) handlePage(route *router.Route, mwCtx *middleware.Context, params map[string]string) {
+	content, err := g.readFile(route.FilePath)
+	if err != nil {
+		http.Error(mwCtx.Response, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	comp, err := parser.Parse(string(content))
+	if err != nil {
+		http.Error(mwCtx.Response, fmt.Sprintf("Parse error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	render := g.Compiler.NewSession(route.FilePath, compiler.PageImports(comp))
+
+	ctx := executor.NewContext()
+	ctx.SetRequest(ssr.NewRequestContext(mwCtx.Request, params))
+	ctx.SetLocals(mwCtx.Locals)
+	if sess := session.FromRequest(mwCtx.Request); sess != nil {
+		ctx.SetSession(sess)
+	}
+	if strings.Contains(comp.Frontmatter+comp.Template, "CSRFToken") {
+		ctx.SetCSRFToken(security.CSRFToken(mwCtx.Request))
+	}
+	ctx.SetParams(params)
+	for k, v := range params {
+		ctx.Set(k, v)
+	}
+
+	if comp.Frontmatter != "" {
+		if err := ctx.Execute(comp.Frontmatter); err != nil {
+			http.Error(mwCtx.Response, fmt.Sprintf("Execution error: %v", err), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if ctx.ShouldRedirect {
+		http.Redirect(mwCtx.Response, mwCtx.Request, ctx.RedirectURL, ctx.RedirectStatus)
+		return
+	}
+
+	processedTemplate := render.ProcessComponentTags(comp.Template, ctx)
+
+	rendered, err := template.NewEngine(ctx).Render(processedTemplate, nil)
+	if err != nil {
+		http.Error(mwCtx.Response, fmt.Sprintf("Render error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	allStyles := append(comp.Styles, render.CollectedStyles...)
+	compWithStyles := &parser.Component{
+		Frontmatter: comp.Frontmatter,
+		Template:    comp.Template,
+		Scripts:     comp.Scripts,
+		Styles:      allStyles,
+		Imports:     comp.Imports,
+	}
+
+	cssPath, err := g.Bundler.BundleStyles(compWithStyles, route.FilePath)
+	if err != nil {
+		http.Error(mwCtx.Response, fmt.Sprintf("Style bundle error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	jsPath, err := g.Bundler.BundleScripts(comp, route.FilePath)
+	if err != nil {
+		http.Error(mwCtx.Response, fmt.Sprintf("Script bundle error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	wasmAssets, err := g.Bundler.BundleWasmScripts(comp, route.FilePath, render.WasmSources()...)
+	if err != nil {
+		http.Error(mwCtx.Response, fmt.Sprintf("WASM bundle error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	scopeID := ""
+	for _, style := range allStyles {
+		if style.Scoped {
+			scopeID = g.Bundler.GenerateScopeID(route.FilePath)
+			break
+		}
+	}
+
+	rendered = g.Bundler.InjectAssetsWithWasm(rendered, cssPath, jsPath, scopeID, wasmAssets)
+	rendered = ssr.InjectState(rendered, ctx.State)
+	rendered = ssr.InjectPrefetch(rendered, g.Config.Prefetch)
+	rendered = security.InjectCSRFToken(rendered, mwCtx.Request)
+	rendered = security.InjectCSPNonce(rendered, mwCtx.Request)
+
+	mwCtx.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
+	policy := httpcache.For(g.Config.Cache.Routes, mwCtx.Request.URL.Path, httpcache.PagePolicy(ctx))
+	httpcache.Write(mwCtx.Response, mwCtx.Request, []byte(rendered), policy)
+}
+
+func (g *Galaxy) handleMarkdownPage(route *router.Route, mwCtx *middleware.Context) {
+	content, err := g.readFile(route.FilePath)
+	if err != nil {
+		http.Error(mwCtx.Response, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	doc, err := parser.ParseMarkdownWithYAMLFrontmatter(string(content))
+	if err != nil {
+		http.Error(mwCtx.Response, fmt.Sprintf("Markdown parse error: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	html := doc.HTML
+
+	if doc.Layout != "" {
+		layoutPath := filepath.Join(filepath.Dir(route.FilePath), doc.Layout)
+
+		props := make(map[string]interface{})
+		for k, v := range doc.Frontmatter {
+			props[k] = v
+		}
+		props["content"] = doc.HTML
+
+		slots := map[string]string{
+			"default": doc.HTML,
+		}
+
+		rendered, err := g.Compiler.NewSession(layoutPath, nil).Compile(layoutPath, props, slots)
+		if err != nil {
+			http.Error(mwCtx.Response, fmt.Sprintf("Layout compile error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		html = rendered
+	}
+
+	mwCtx.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
+	policy := httpcache.For(g.Config.Cache.Routes, mwCtx.Request.URL.Path, nil)
+	httpcache.Write(mwCtx.Response, mwCtx.Request, []byte(html), policy)
+}
+
+func (g *Galaxy) readFile(name string) ([]byte, error) {
+	return fs.ReadFile(g.FS, filepath.ToSlash(filepath.Clean(name)))
+}
+
+// wasmExecPath finds the wasm_exec.js of the Go toolchain.
+func wasmExecPath() string {
+	goRoot := os.Getenv("GOROOT")
+	if goRoot == "" {
+		output, _ := exec.Command("go", "env", "GOROOT").Output()
+		goRoot = strings.TrimSpace(string(output))
+	}
+
+	path := filepath.Join(goRoot, "misc", "wasm", "wasm_exec.js")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		path = filepath.Join(goRoot, "lib", "wasm", "wasm_exec.js")
+	}
+	return path
+}
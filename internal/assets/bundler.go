@@ -119,51 +119,62 @@ func (b *Bundler) BundleScripts(comp *parser.Component, pagePath string) (string
 	return "/_assets/" + filename, nil
 }
 
+// BundleWasmScripts links the Go scripts of comp into a single module, see
+// wasm.Link. The result is empty or has one asset.
 func (b *Bundler) BundleWasmScripts(comp *parser.Component, pagePath string) ([]WasmAsset, error) {
-	var assets []WasmAsset
-
+	var scripts []wasm.Script
+	pageId := filepath.Base(pagePath)
 	for _, script := range comp.Scripts {
 		if script.Language != "go" {
 			continue
 		}
-
-		module, err := b.WasmCompiler.Compile(script.Content, pagePath)
-		if err != nil {
-			return nil, fmt.Errorf("compile wasm: %w", err)
+		moduleID := pageId
+		if len(scripts) > 0 {
+			moduleID = fmt.Sprintf("%s#%d", pageId, len(scripts))
 		}
+		scripts = append(scripts, wasm.Script{ModuleID: moduleID, Content: script.Content})
+	}
+	if len(scripts) == 0 {
+		return nil, nil
+	}
 
-		wasmFilename := fmt.Sprintf("script-%s.wasm", module.Hash)
-		loaderFilename := fmt.Sprintf("script-%s-loader.js", module.Hash)
+	module, err := b.WasmCompiler.CompileBundle(scripts)
+	if err != nil {
+		return nil, fmt.Errorf("compile wasm: %w", err)
+	}
 
-		wasmDest := filepath.Join(b.OutDir, "_assets", "wasm", wasmFilename)
-		if err := os.MkdirAll(filepath.Dir(wasmDest), 0755); err != nil {
-			return nil, err
-		}
+	wasmFilename := filepath.Base(module.WasmPath)
+	loaderFilename := fmt.Sprintf("script-%s-loader.js", module.Hash)
 
-		if module.WasmPath != wasmDest {
-			data, err := os.ReadFile(module.WasmPath)
-			if err != nil {
-				return nil, err
-			}
-			if err := os.WriteFile(wasmDest, data, 0644); err != nil {
-				return nil, err
-			}
-		}
+	wasmDest := filepath.Join(b.OutDir, "_assets", "wasm", wasmFilename)
+	if err := os.MkdirAll(filepath.Dir(wasmDest), 0755); err != nil {
+		return nil, err
+	}
 
-		pageId := filepath.Base(pagePath)
-		loaderContent := wasm.GenerateLoader("/_assets/wasm/"+wasmFilename, pageId)
-		loaderPath := filepath.Join(b.OutDir, "_assets", loaderFilename)
-		if err := os.WriteFile(loaderPath, []byte(loaderContent), 0644); err != nil {
+	if module.WasmPath != wasmDest {
+		data, err := os.ReadFile(module.WasmPath)
+		if err != nil {
 			return nil, err
 		}
+		if err := os.WriteFile(wasmDest, data, 0644); err != nil {
+			return nil, err
+		}
+	}
 
-		assets = append(assets, WasmAsset{
-			WasmPath:   "/_assets/wasm/" + wasmFilename,
-			LoaderPath: "/_assets/" + loaderFilename,
-		})
+	moduleIDs := make([]string, len(scripts))
+	for i, script := range scripts {
+		moduleIDs[i] = script.ModuleID
+	}
+	loaderContent := wasm.GenerateLoader("/_assets/wasm/"+wasmFilename, moduleIDs)
+	loaderPath := filepath.Join(b.OutDir, "_assets", loaderFilename)
+	if err := os.WriteFile(loaderPath, []byte(loaderContent), 0644); err != nil {
+		return nil, err
 	}
 
-	return assets, nil
+	return []WasmAsset{{
+		WasmPath:   "/_assets/wasm/" + wasmFilename,
+		LoaderPath: "/_assets/" + loaderFilename,
+	}}, nil
 }
 
 func (b *Bundler) scopeCSS(css, pagePath string) string {
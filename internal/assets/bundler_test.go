@@ -335,8 +335,8 @@ fmt.Println("Hello WASM")`,
 		t.Fatalf("Expected 1 WASM asset, got %d", len(assets))
 	}
 
-	if !strings.HasPrefix(assets[0].WasmPath, "/_assets/wasm/script-") {
-		t.Errorf("Expected WasmPath to start with /_assets/wasm/script-, got %s", assets[0].WasmPath)
+	if !strings.HasPrefix(assets[0].WasmPath, "/_assets/wasm/bundle-") {
+		t.Errorf("Expected WasmPath to start with /_assets/wasm/bundle-, got %s", assets[0].WasmPath)
 	}
 
 	if !strings.HasSuffix(assets[0].WasmPath, ".wasm") {
@@ -446,12 +446,19 @@ fmt.Println("WASM 2")`,
 		t.Fatalf("BundleWasmScripts failed: %v", err)
 	}
 
-	if len(assets) != 2 {
-		t.Fatalf("Expected 2 WASM assets, got %d", len(assets))
+	if len(assets) != 1 {
+		t.Fatalf("Expected the scripts linked into 1 WASM asset, got %d", len(assets))
 	}
 
-	if assets[0].WasmPath == assets[1].WasmPath {
-		t.Error("Expected different WASM paths for different scripts")
+	loader, err := os.ReadFile(filepath.Join(tmpDir, strings.TrimPrefix(assets[0].LoaderPath, "/")))
+	if err != nil {
+		t.Fatalf("Failed to read loader file: %v", err)
+	}
+	if strings.Count(string(loader), "new Go()") != 1 {
+		t.Error("Expected the loader to start a single Go runtime")
+	}
+	if !strings.Contains(string(loader), `["test.gxc","test.gxc#1"]`) {
+		t.Errorf("Expected a module ID per script, got %s", loader)
 	}
 }
 
@@ -0,0 +1,160 @@
+package wasm
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Cache keeps compiled modules across builds and projects, addressed by a
+// key covering everything that went into them, see Compiler.cacheKey. It is
+// safe for concurrent use, by several processes too.
+type Cache struct {
+	Dir string
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// CacheStats counts Cache lookups since the process started.
+type CacheStats struct {
+	Hits   int
+	Misses int
+}
+
+// CacheInfo describes what a Cache holds.
+type CacheInfo struct {
+	Modules int
+	Size    int64
+}
+
+// DefaultCache is the cache Galaxy's builds share, in DefaultCacheDir.
+var DefaultCache = NewCache(DefaultCacheDir())
+
+func NewCache(dir string) *Cache {
+	return &Cache{Dir: dir}
+}
+
+// DefaultCacheDir is GALAXY_CACHE_DIR, for a project or CI cache, or
+// galaxy/wasm in the user's cache directory.
+func DefaultCacheDir() string {
+	if dir := os.Getenv("GALAXY_CACHE_DIR"); dir != "" {
+		return filepath.Join(dir, "wasm")
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "galaxy", "wasm")
+	}
+	return filepath.Join(".galaxy", "cache", "wasm")
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key[:2], key+".wasm")
+}
+
+// Get returns the path of the module stored under key. Modules are marked
+// as used when found, so Clean with an age keeps the ones still built.
+func (c *Cache) Get(key string) (string, bool) {
+	path := c.path(key)
+	if _, err := os.Stat(path); err != nil {
+		c.misses.Add(1)
+		return "", false
+	}
+	now := time.Now()
+	os.Chtimes(path, now, now)
+	c.hits.Add(1)
+	return path, true
+}
+
+// Put stores a copy of the module at src under key.
+func (c *Cache) Put(key, src string) error {
+	return copyFile(src, c.path(key))
+}
+
+func (c *Cache) Stats() CacheStats {
+	return CacheStats{Hits: int(c.hits.Load()), Misses: int(c.misses.Load())}
+}
+
+func (c *Cache) Info() (CacheInfo, error) {
+	var info CacheInfo
+	err := c.walk(func(path string, fi fs.FileInfo) error {
+		info.Modules++
+		info.Size += fi.Size()
+		return nil
+	})
+	return info, err
+}
+
+// Clean removes the modules not used in the last olderThan, or every module
+// when it is zero, and reports what it removed.
+func (c *Cache) Clean(olderThan time.Duration) (CacheInfo, error) {
+	var removed CacheInfo
+	cutoff := time.Now().Add(-olderThan)
+	err := c.walk(func(path string, fi fs.FileInfo) error {
+		if olderThan > 0 && fi.ModTime().After(cutoff) {
+			return nil
+		}
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		removed.Modules++
+		removed.Size += fi.Size()
+		os.Remove(filepath.Dir(path))
+		return nil
+	})
+	return removed, err
+}
+
+func (c *Cache) walk(fn func(path string, fi fs.FileInfo) error) error {
+	err := filepath.WalkDir(c.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".wasm") {
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return fn(path, fi)
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// copyFile writes a copy of src to dst through a temporary file, so readers
+// never see it half written.
+func copyFile(src, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return fmt.Errorf("write %s: %w", dst, err)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package wasm
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	key := strings.Repeat("ab", 32)
+
+	if _, ok := cache.Get(key); ok {
+		t.Fatal("Expected an empty cache to miss")
+	}
+
+	src := filepath.Join(t.TempDir(), "module.wasm")
+	os.WriteFile(src, []byte("wasm"), 0644)
+	if err := cache.Put(key, src); err != nil {
+		t.Fatal(err)
+	}
+
+	path, ok := cache.Get(key)
+	if !ok {
+		t.Fatal("Expected the stored module")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "wasm" {
+		t.Errorf("Expected the stored content, got %q", data)
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Expected 1 hit and 1 miss, got %+v", stats)
+	}
+
+	info, err := cache.Info()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Modules != 1 || info.Size != 4 {
+		t.Errorf("Expected one 4 byte module, got %+v", info)
+	}
+}
+
+func TestCacheClean(t *testing.T) {
+	cache := NewCache(t.TempDir())
+	src := filepath.Join(t.TempDir(), "module.wasm")
+	os.WriteFile(src, []byte("wasm"), 0644)
+
+	oldKey := strings.Repeat("0", 64)
+	newKey := strings.Repeat("1", 64)
+	cache.Put(oldKey, src)
+	cache.Put(newKey, src)
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(cache.path(oldKey), old, old)
+
+	removed, err := cache.Clean(24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed.Modules != 1 {
+		t.Errorf("Expected the unused module removed, got %+v", removed)
+	}
+	if _, err := os.Stat(cache.path(newKey)); err != nil {
+		t.Error("Expected the recent module kept")
+	}
+
+	if removed, _ := cache.Clean(0); removed.Modules != 1 {
+		t.Errorf("Expected every module removed, got %+v", removed)
+	}
+	if info, _ := cache.Info(); info.Modules != 0 {
+		t.Errorf("Expected an empty cache, got %+v", info)
+	}
+}
+
+func TestCacheKey(t *testing.T) {
+	projectDir := t.TempDir()
+	os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte("module example.com/site\n"), 0644)
+	c := NewCompiler(t.TempDir(), t.TempDir())
+	c.ModulePath = t.TempDir()
+	c.ProjectDir = projectDir
+
+	files := map[string]string{"main.go": "package main\n"}
+	key := c.cacheKey(files)
+	if key != c.cacheKey(map[string]string{"main.go": "package main\n"}) {
+		t.Error("Expected the same key for the same inputs")
+	}
+	if key == c.cacheKey(map[string]string{"main.go": "package main\n\nfunc init() {}\n"}) {
+		t.Error("Expected the source in the key")
+	}
+
+	os.WriteFile(filepath.Join(projectDir, "go.sum"), []byte("example.com/dep v1.0.0 h1:x\n"), 0644)
+	if key == c.cacheKey(files) {
+		t.Error("Expected the project's go.sum in the key")
+	}
+	key = c.cacheKey(files)
+
+	t.Setenv("GOFLAGS", "-trimpath")
+	if key == c.cacheKey(files) {
+		t.Error("Expected build flags in the key")
+	}
+}
+
+func TestCompileFromCache(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	cache := NewCache(t.TempDir())
+	scripts := [][]Script{
+		{{ModuleID: "pages/a.gxc", Content: `println("a")`}},
+		{{ModuleID: "pages/b.gxc", Content: `println("b")`}},
+		{{ModuleID: "pages/a.gxc", Content: `println("a")`}},
+	}
+
+	compiler := NewCompiler(t.TempDir(), t.TempDir())
+	compiler.Cache = cache
+	modules, err := compiler.CompileBundles(scripts)
+	if err != nil {
+		t.Fatalf("CompileBundles failed: %v", err)
+	}
+	if modules[0].WasmPath != modules[2].WasmPath || modules[0].WasmPath == modules[1].WasmPath {
+		t.Errorf("Expected identical bundles to share a module: %v %v %v", modules[0].WasmPath, modules[1].WasmPath, modules[2].WasmPath)
+	}
+	if stats := cache.Stats(); stats.Misses != 2 || stats.Hits != 0 {
+		t.Errorf("Expected 2 modules compiled, got %+v", stats)
+	}
+
+	// A later build writing to a fresh output directory reuses them.
+	next := NewCompiler(t.TempDir(), t.TempDir())
+	next.Cache = cache
+	module, err := next.CompileBundle(scripts[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := cache.Stats(); stats.Hits != 1 {
+		t.Errorf("Expected a cache hit, got %+v", stats)
+	}
+	if module.Hash != modules[1].Hash {
+		t.Errorf("Expected the same module, got %s and %s", module.Hash, modules[1].Hash)
+	}
+	if _, err := os.Stat(module.WasmPath); err != nil {
+		t.Errorf("Expected the module copied to %s", module.WasmPath)
+	}
+}
@@ -2,6 +2,7 @@ package wasm
 
 import (
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -10,16 +11,43 @@ import (
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
-	"github.com/withgalaxy/galaxy/pkg/cli"
 	"github.com/withgalaxy/galaxy/pkg/moduleutil"
+	"github.com/withgalaxy/galaxy/pkg/version"
 )
 
 type Compiler struct {
-	TempDir   string
+	TempDir string
+	// CacheDir is where compiled modules are written, named after their
+	// cache key.
 	CacheDir  string
 	UseTinyGo bool
+	// ModulePath, if set, is the local Galaxy checkout scripts build
+	// against instead of the one found by moduleutil.FindGalaxyModuleRoot.
+	ModulePath string
+	// Cache, if set, keeps modules across builds.
+	Cache *Cache
+	// ProjectDir holds the go.mod and go.sum that are part of each module's
+	// cache key. It defaults to the working directory.
+	ProjectDir string
+	// Jobs limits how many modules CompileBundles builds at once. It
+	// defaults to GOMAXPROCS.
+	Jobs int
+
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a module being compiled, which other callers wanting the same
+// one wait for.
+type flight struct {
+	done   chan struct{}
+	module *CompiledModule
+	err    error
 }
 
 type CompiledModule struct {
@@ -36,39 +64,210 @@ func NewCompiler(tempDir, cacheDir string) *Compiler {
 }
 
 func (c *Compiler) Compile(script, pagePath string) (*CompiledModule, error) {
-	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(script)))[:8]
+	moduleID := filepath.Base(pagePath)
+	scriptWithHMR := injectHMRHelpers(script, moduleID)
+	preparedScript, err := prepareScript(scriptWithHMR)
+	if err != nil {
+		return nil, fmt.Errorf("prepare script: %w", err)
+	}
+
+	return c.compile(map[string]string{"main.go": preparedScript}, "script")
+}
 
-	cachedWasm := filepath.Join(c.CacheDir, fmt.Sprintf("script-%s.wasm", hash))
-	if _, err := os.Stat(cachedWasm); err == nil {
-		return &CompiledModule{
-			WasmPath: cachedWasm,
-			Hash:     hash,
-		}, nil
+// CompileBundle links scripts into a single module, see Link. Bundles are
+// cached by their linked source, so pages using the same set of scripts
+// share one.
+func (c *Compiler) CompileBundle(scripts []Script) (*CompiledModule, error) {
+	files, err := Link(scripts)
+	if err != nil {
+		return nil, err
 	}
+	return c.compile(files, "bundle")
+}
 
-	buildDir := filepath.Join(c.TempDir, hash)
-	if err := os.MkdirAll(buildDir, 0755); err != nil {
-		return nil, fmt.Errorf("create build dir: %w", err)
+// CompileBundles compiles each set of scripts as CompileBundle does, up to
+// Jobs at a time.
+func (c *Compiler) CompileBundles(bundles [][]Script) ([]*CompiledModule, error) {
+	jobs := c.Jobs
+	if jobs <= 0 {
+		jobs = runtime.GOMAXPROCS(0)
 	}
-	defer os.RemoveAll(buildDir)
 
-	moduleID := filepath.Base(pagePath)
-	scriptWithHMR := injectHMRHelpers(script, moduleID)
-	preparedScript, err := prepareScript(scriptWithHMR, hash, c.UseTinyGo && isTinyGoAvailable())
+	modules := make([]*CompiledModule, len(bundles))
+	errs := make([]error, len(bundles))
+	sem := make(chan struct{}, jobs)
+	var wg sync.WaitGroup
+	for i, scripts := range bundles {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			modules[i], errs[i] = c.CompileBundle(scripts)
+		}()
+	}
+	wg.Wait()
+	return modules, errors.Join(errs...)
+}
+
+// compile builds the program made of files as prefix-<hash>.wasm in
+// CacheDir, unless it is already there or in Cache. Concurrent calls for
+// the same program share one build.
+func (c *Compiler) compile(files map[string]string, prefix string) (*CompiledModule, error) {
+	key := c.cacheKey(files)
+	hash := key[:8]
+	name := prefix + "-" + hash
+
+	if cached, ok := c.cached(name); ok {
+		return &CompiledModule{WasmPath: cached, Hash: hash}, nil
+	}
+
+	c.mu.Lock()
+	if f, ok := c.flights[key]; ok {
+		c.mu.Unlock()
+		<-f.done
+		return f.module, f.err
+	}
+	if c.flights == nil {
+		c.flights = make(map[string]*flight)
+	}
+	f := &flight{done: make(chan struct{})}
+	c.flights[key] = f
+	c.mu.Unlock()
+
+	f.module, f.err = c.compileUncached(files, key, name)
+
+	c.mu.Lock()
+	delete(c.flights, key)
+	c.mu.Unlock()
+	close(f.done)
+	return f.module, f.err
+}
+
+func (c *Compiler) compileUncached(files map[string]string, key, name string) (*CompiledModule, error) {
+	hash := key[:8]
+	finalWasm := filepath.Join(c.CacheDir, name+".wasm")
+
+	if c.Cache != nil {
+		if stored, ok := c.Cache.Get(key); ok {
+			if err := copyFile(stored, finalWasm); err != nil {
+				return nil, fmt.Errorf("copy cached wasm: %w", err)
+			}
+			return &CompiledModule{WasmPath: finalWasm, Hash: hash}, nil
+		}
+	}
+
+	module, err := c.build(files, hash, name)
 	if err != nil {
-		return nil, fmt.Errorf("prepare script: %w", err)
+		return nil, err
+	}
+	if c.Cache != nil {
+		if err := c.Cache.Put(key, module.WasmPath); err != nil {
+			return nil, fmt.Errorf("cache wasm: %w", err)
+		}
 	}
+	return module, nil
+}
+
+// cacheKey addresses the module built from files: their content, the
+// go.mod it is built with, the go.mod and go.sum of the project and of a
+// local Galaxy checkout, and the toolchain and flags.
+func (c *Compiler) cacheKey(files map[string]string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "galaxy-wasm\x00%s\x00%s\x00", c.toolchain(), c.goMod())
 
-	mainGo := filepath.Join(buildDir, "main.go")
-	if err := os.WriteFile(mainGo, []byte(preparedScript), 0644); err != nil {
-		return nil, fmt.Errorf("write main.go: %w", err)
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%s\x00", name, files[name])
 	}
 
-	goMod := filepath.Join(buildDir, "go.mod")
-	moduleRoot := moduleutil.FindGalaxyModuleRoot()
-	moduleContent := "module wasmscript\n\ngo 1.21\n\n" + moduleutil.GetGalaxyModuleRequirement(moduleRoot, cli.Version)
+	projectDir := c.ProjectDir
+	if projectDir == "" {
+		projectDir, _ = os.Getwd()
+	}
+	for _, dir := range []string{projectDir, c.moduleRoot()} {
+		if dir == "" {
+			continue
+		}
+		for _, name := range []string{"go.mod", "go.sum"} {
+			data, _ := os.ReadFile(filepath.Join(dir, name))
+			fmt.Fprintf(h, "%s\x00%s\x00", name, data)
+		}
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// toolchain names the compiler modules are built with, its version and
+// flags.
+func (c *Compiler) toolchain() string {
+	if c.useTinyGo() {
+		return "tinygo " + tinyGoVersion() + " -target wasm"
+	}
+	return "gc " + goVersion() + " GOOS=js GOARCH=wasm GOFLAGS=" + os.Getenv("GOFLAGS")
+}
 
-	if err := os.WriteFile(goMod, []byte(moduleContent), 0644); err != nil {
+func (c *Compiler) useTinyGo() bool {
+	return c.UseTinyGo && isTinyGoAvailable()
+}
+
+var (
+	goVersion     = sync.OnceValue(func() string { return commandOutput("go", "env", "GOVERSION") })
+	tinyGoVersion = sync.OnceValue(func() string { return commandOutput("tinygo", "version") })
+	galaxyRoot    = sync.OnceValue(moduleutil.FindGalaxyModuleRoot)
+)
+
+func commandOutput(name string, args ...string) string {
+	out, _ := exec.Command(name, args...).Output()
+	return strings.TrimSpace(string(out))
+}
+
+func (c *Compiler) moduleRoot() string {
+	if c.ModulePath != "" {
+		return c.ModulePath
+	}
+	return galaxyRoot()
+}
+
+func (c *Compiler) goMod() string {
+	return "module wasmscript\n\ngo 1.21\n\n" + moduleutil.GetGalaxyModuleRequirement(c.moduleRoot(), version.Version)
+}
+
+func (c *Compiler) cached(name string) (string, bool) {
+	path := filepath.Join(c.CacheDir, name+".wasm")
+	if _, err := os.Stat(path); err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// build compiles the program made of files, keyed by path relative to the
+// module root, into CacheDir as name.wasm.
+func (c *Compiler) build(files map[string]string, hash, name string) (*CompiledModule, error) {
+	if err := os.MkdirAll(c.TempDir, 0755); err != nil {
+		return nil, fmt.Errorf("create build dir: %w", err)
+	}
+	buildDir, err := os.MkdirTemp(c.TempDir, hash+"-")
+	if err != nil {
+		return nil, fmt.Errorf("create build dir: %w", err)
+	}
+	defer os.RemoveAll(buildDir)
+
+	for path, content := range files {
+		dest := filepath.Join(buildDir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return nil, fmt.Errorf("create build dir: %w", err)
+		}
+		if err := os.WriteFile(dest, []byte(content), 0644); err != nil {
+			return nil, fmt.Errorf("write %s: %w", path, err)
+		}
+	}
+
+	goMod := filepath.Join(buildDir, "go.mod")
+	if err := os.WriteFile(goMod, []byte(c.goMod()), 0644); err != nil {
 		return nil, fmt.Errorf("write go.mod: %w", err)
 	}
 
@@ -85,7 +284,7 @@ func (c *Compiler) Compile(script, pagePath string) (*CompiledModule, error) {
 	}
 
 	var cmd *exec.Cmd
-	if c.UseTinyGo && isTinyGoAvailable() {
+	if c.useTinyGo() {
 		cmd = exec.Command("tinygo", "build", "-o", absOutWasm, "-target", "wasm", ".")
 		cmd.Dir = buildDir
 	} else {
@@ -115,7 +314,7 @@ func (c *Compiler) Compile(script, pagePath string) (*CompiledModule, error) {
 		return nil, fmt.Errorf("create cache dir: %w", err)
 	}
 
-	finalWasm := filepath.Join(c.CacheDir, fmt.Sprintf("script-%s.wasm", hash))
+	finalWasm := filepath.Join(c.CacheDir, name+".wasm")
 	if err := os.Rename(outWasm, finalWasm); err != nil {
 		data, err := os.ReadFile(outWasm)
 		if err != nil {
@@ -132,7 +331,13 @@ func (c *Compiler) Compile(script, pagePath string) (*CompiledModule, error) {
 	}, nil
 }
 
-func prepareScript(script, hash string, useTinyGo bool) (string, error) {
+func prepareScript(script string) (string, error) {
+	return prepareSource(script, "main"), nil
+}
+
+// prepareSource turns script into the source of package pkg. A main package
+// runs the script; any other exports a Start function that does, for Link.
+func prepareSource(script, pkg string) string {
 	imports := extractImports(script)
 	body := removeImports(script)
 	body = removePackageDecl(body)
@@ -154,7 +359,7 @@ func prepareScript(script, hash string, useTinyGo bool) (string, error) {
 	}
 
 	var final strings.Builder
-	final.WriteString("package main\n\n")
+	final.WriteString("package " + pkg + "\n\n")
 
 	if len(imports) > 0 {
 		final.WriteString("import (\n")
@@ -188,23 +393,37 @@ func prepareScript(script, hash string, useTinyGo bool) (string, error) {
 		}
 		final.WriteString("}\n\n")
 
-		final.WriteString("func main() {\n")
+		if pkg == "main" {
+			final.WriteString("func main() {\n")
+		} else {
+			final.WriteString("func Start() {\n")
+		}
 		final.WriteString("\t__galaxyRun()\n")
 		final.WriteString("\t\n")
 		final.WriteString("\t// Auto-register HMR accept handler if not manually registered\n")
 		final.WriteString("\tif !__hmrManuallyRegistered {\n")
 		final.WriteString("\t\thmrAccept(__galaxyRun)\n")
 		final.WriteString("\t}\n")
-		final.WriteString("\t\n")
-		final.WriteString("\tselect {}\n")
+		if pkg == "main" {
+			final.WriteString("\t\n")
+			final.WriteString("\tselect {}\n")
+		}
 		final.WriteString("}\n")
-	} else {
+	} else if pkg == "main" {
 		final.WriteString(body)
+	} else {
+		// The script's own main blocks, so it runs alongside the others.
+		final.WriteString(mainFuncRegex.ReplaceAllString(body, "func __galaxyMain()"))
+		final.WriteString("\n\nfunc Start() {\n")
+		final.WriteString("\tgo __galaxyMain()\n")
+		final.WriteString("}\n")
 	}
 
-	return final.String(), nil
+	return final.String()
 }
 
+var mainFuncRegex = regexp.MustCompile(`(?m)^func\s+main\s*\(\s*\)`)
+
 func extractImports(script string) []string {
 	var imports []string
 	importRegex := regexp.MustCompile(`(?m)^import\s+(.+)$`)
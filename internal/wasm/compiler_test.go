@@ -290,7 +290,7 @@ func TestPrepareScriptSimple(t *testing.T) {
 
 fmt.Println("hello")`
 
-	result, err := prepareScript(script, "abc12345", false)
+	result, err := prepareScript(script)
 	if err != nil {
 		t.Fatalf("prepareScript failed: %v", err)
 	}
@@ -328,7 +328,7 @@ func main() {
 	select {}
 }`
 
-	result, err := prepareScript(script, "xyz98765", false)
+	result, err := prepareScript(script)
 	if err != nil {
 		t.Fatalf("prepareScript failed: %v", err)
 	}
@@ -343,21 +343,21 @@ func main() {
 	}
 }
 
-func TestPrepareScriptTinyGo(t *testing.T) {
+func TestPrepareScriptPackageMain(t *testing.T) {
 	script := `import "fmt"
 fmt.Println("tinygo test")`
 
-	result, err := prepareScript(script, "def45678", true)
+	result, err := prepareScript(script)
 	if err != nil {
 		t.Fatalf("prepareScript failed: %v", err)
 	}
 
 	if !strings.Contains(result, "package main") {
-		t.Error("Expected 'package main' for TinyGo")
+		t.Error("Expected 'package main'")
 	}
 
 	if strings.Contains(result, "wasmscript_") {
-		t.Error("TinyGo should not use custom package name")
+		t.Error("Scripts should not use a custom package name")
 	}
 }
 
@@ -375,7 +375,7 @@ func anotherHelper() {
 result := helper(5)
 fmt.Println(result)`
 
-	result, err := prepareScript(script, "abc12345", false)
+	result, err := prepareScript(script)
 	if err != nil {
 		t.Fatalf("prepareScript failed: %v", err)
 	}
@@ -432,7 +432,7 @@ func increment() {
 counter = js.Global().Get("document").Call("getElementById", "counter")
 increment()`
 
-	result, err := prepareScript(script, "test1234", false)
+	result, err := prepareScript(script)
 	if err != nil {
 		t.Fatalf("prepareScript failed: %v", err)
 	}
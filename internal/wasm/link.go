@@ -0,0 +1,107 @@
+package wasm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Script is one Go <script> block linked into a page's module.
+type Script struct {
+	// ModuleID names the script to HMR, normally the path of the component
+	// declaring it.
+	ModuleID string
+	Content  string
+	// Island, if set, is the ID of the island component the script belongs
+	// to. It then runs once per island, when the hydration runtime hands it
+	// over, rather than when the module loads.
+	Island string
+}
+
+// Link turns scripts into the files of a single program, keyed by path
+// relative to the module root. Each script becomes its own package, so
+// declarations in one cannot clash with another, and keeps its own HMR
+// helpers and module ID. main starts them in order on the one Go runtime,
+// and registers island scripts with the hydration runtime.
+func Link(scripts []Script) (map[string]string, error) {
+	if len(scripts) == 0 {
+		return nil, fmt.Errorf("no scripts to link")
+	}
+
+	files := make(map[string]string, len(scripts)+1)
+	var imports, starts strings.Builder
+	hasIslands := false
+	for i, script := range scripts {
+		pkg := fmt.Sprintf("s%d", i)
+		files["scripts/"+pkg+"/script.go"] = prepareSource(injectHMRHelpers(script.Content, script.ModuleID), pkg)
+
+		imports.WriteString(fmt.Sprintf("\t%s \"wasmscript/scripts/%s\"\n", pkg, pkg))
+		if script.Island != "" {
+			hasIslands = true
+			starts.WriteString(fmt.Sprintf("\thydrate(%q, %q, %s.Start)\n", script.Island, script.ModuleID, pkg))
+		} else {
+			starts.WriteString(fmt.Sprintf("\t%s.Start() // %s\n", pkg, script.ModuleID))
+		}
+	}
+
+	var main strings.Builder
+	main.WriteString("package main\n\n")
+	main.WriteString("import (\n")
+	if hasIslands {
+		main.WriteString("\t\"sync\"\n")
+		main.WriteString("\t\"syscall/js\"\n\n")
+	}
+	main.WriteString(imports.String())
+	main.WriteString(")\n\n")
+	main.WriteString("func main() {\n")
+	main.WriteString(starts.String())
+	main.WriteString("\n")
+	main.WriteString("\tselect {}\n")
+	main.WriteString("}\n")
+	if hasIslands {
+		main.WriteString(hydrateFunc)
+	}
+	files["main.go"] = main.String()
+
+	return files, nil
+}
+
+// hydrateFunc registers an island script's Start as one of the hydrators of
+// its island, see ssr.HydrationRuntime. Islands run one at a time, so
+// wasmdom.CurrentIsland knows which one a script is starting for.
+const hydrateFunc = `
+var hydrating sync.Mutex
+
+func hydrate(island, moduleID string, start func()) {
+	state := js.Global().Get("__galaxyHydration")
+	if state.IsUndefined() {
+		state = js.Global().Get("Object").New()
+		state.Set("hydrators", js.Global().Get("Object").New())
+		state.Set("islands", js.Global().Get("Object").New())
+		js.Global().Set("__galaxyHydration", state)
+	}
+
+	run := func(current js.Value) {
+		hydrating.Lock()
+		defer hydrating.Unlock()
+		state.Set("current", current)
+		defer state.Delete("current")
+		start()
+	}
+
+	hydrators := state.Get("hydrators").Get(island)
+	if hydrators.IsUndefined() {
+		hydrators = js.Global().Get("Object").New()
+		state.Get("hydrators").Set(island, hydrators)
+	}
+	hydrators.Set(moduleID, js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		go run(args[0])
+		return nil
+	}))
+
+	if islands := state.Get("islands").Get(island); !islands.IsUndefined() {
+		for i := 0; i < islands.Length(); i++ {
+			run(islands.Index(i))
+		}
+	}
+}
+`
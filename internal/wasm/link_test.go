@@ -0,0 +1,122 @@
+package wasm
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+func TestLink(t *testing.T) {
+	files, err := Link([]Script{
+		{ModuleID: "pages/index.gxc", Content: `import "fmt"
+
+var count = 0
+
+func render() {
+	fmt.Println(count)
+}
+
+render()`},
+		{ModuleID: "components/Nav.gxc", Content: `var count = 1
+
+func render() {}
+
+render()`},
+		{ModuleID: "components/Widget.gxc", Content: `func main() {
+	select {}
+}`},
+	})
+	if err != nil {
+		t.Fatalf("Link failed: %v", err)
+	}
+
+	if len(files) != 4 {
+		t.Fatalf("Expected main.go and a package per script, got %v", len(files))
+	}
+
+	fset := token.NewFileSet()
+	for name, src := range files {
+		f, err := parser.ParseFile(fset, name, src, 0)
+		if err != nil {
+			t.Fatalf("%s does not parse: %v\n%s", name, err, src)
+		}
+		if name == "main.go" {
+			continue
+		}
+		if want := strings.TrimSuffix(strings.TrimPrefix(name, "scripts/"), "/script.go"); f.Name.Name != want {
+			t.Errorf("Expected %s to be package %s, got %s", name, want, f.Name.Name)
+		}
+		if !strings.Contains(src, "func Start()") {
+			t.Errorf("Expected %s to export Start", name)
+		}
+		if strings.Contains(src, "func main()") {
+			t.Errorf("Expected no main function in %s", name)
+		}
+	}
+
+	for i, id := range []string{"pages/index.gxc", "components/Nav.gxc", "components/Widget.gxc"} {
+		pkg := []string{"s0", "s1", "s2"}[i]
+		if !strings.Contains(files["scripts/"+pkg+"/script.go"], `var __hmrModuleID = "`+id+`"`) {
+			t.Errorf("Expected %s to have HMR module ID %s", pkg, id)
+		}
+	}
+
+	main := files["main.go"]
+	if strings.Index(main, "s0.Start()") > strings.Index(main, "s1.Start()") || !strings.Contains(main, "s2.Start()") {
+		t.Errorf("Expected main to start every script in order:\n%s", main)
+	}
+	if strings.Count(main, "select {}") != 1 {
+		t.Error("Expected main to block once")
+	}
+	if !strings.Contains(files["scripts/s2/script.go"], "go __galaxyMain()") {
+		t.Error("Expected a script's own main to run in the background")
+	}
+}
+
+func TestLinkIslands(t *testing.T) {
+	files, err := Link([]Script{
+		{ModuleID: "pages/index.gxc", Content: `println("page")`},
+		{ModuleID: "components/Counter.gxc", Content: `println("counter")`, Island: "components/Counter.gxc"},
+		{ModuleID: "components/Counter.gxc#1", Content: `println("more")`, Island: "components/Counter.gxc"},
+	})
+	if err != nil {
+		t.Fatalf("Link failed: %v", err)
+	}
+
+	main := files["main.go"]
+	if _, err := parser.ParseFile(token.NewFileSet(), "main.go", main, 0); err != nil {
+		t.Fatalf("main.go does not parse: %v\n%s", err, main)
+	}
+	if !strings.Contains(main, "s0.Start()") {
+		t.Error("Expected the page script to start on load")
+	}
+	for _, want := range []string{
+		`hydrate("components/Counter.gxc", "components/Counter.gxc", s1.Start)`,
+		`hydrate("components/Counter.gxc", "components/Counter.gxc#1", s2.Start)`,
+	} {
+		if !strings.Contains(main, want) {
+			t.Errorf("Expected island scripts to wait for hydration: %s", want)
+		}
+	}
+}
+
+func TestLinkEmpty(t *testing.T) {
+	if _, err := Link(nil); err == nil {
+		t.Error("Expected an error linking no scripts")
+	}
+}
+
+func TestGenerateLoaderSharesRuntime(t *testing.T) {
+	loader := GenerateLoader("/_assets/wasm/bundle-abc.wasm", []string{"pages/index.gxc", "components/Nav.gxc"})
+
+	if strings.Count(loader, "new Go()") != 1 {
+		t.Error("Expected one Go runtime")
+	}
+	if !strings.Contains(loader, `["pages/index.gxc","components/Nav.gxc"]`) {
+		t.Error("Expected every module ID in the loader")
+	}
+	if !strings.Contains(loader, `"/_assets/wasm/bundle-abc.wasm"`) {
+		t.Error("Expected the loader to reference the bundle")
+	}
+}
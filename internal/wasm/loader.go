@@ -1,76 +1,98 @@
 package wasm
 
 import (
+	"encoding/json"
 	"fmt"
 )
 
-func GenerateLoader(wasmPath, pageId string) string {
+// GenerateLoader returns the script that runs the module at wasmPath, which
+// links the scripts named by moduleIDs. They share one Go runtime; a hot
+// update of any of them disposes and reloads them all.
+func GenerateLoader(wasmPath string, moduleIDs []string) string {
+	ids, _ := json.Marshal(moduleIDs)
 	return fmt.Sprintf(`
 (function() {
-	const wasmPath = "%s";
-	const moduleId = "%s";
-	
+	const wasmPath = %q;
+	const moduleIds = %s;
+
 	window.__galaxyWasmModules = window.__galaxyWasmModules || {};
 	window.__galaxyWasmAcceptHandlers = window.__galaxyWasmAcceptHandlers || {};
 	window.__galaxyWasmState = window.__galaxyWasmState || {};
-	
+	window.__galaxyWasmBundles = window.__galaxyWasmBundles || {};
+
+	moduleIds.forEach(function(id) { window.__galaxyWasmBundles[id] = moduleIds; });
+
 	window.loadWasmModule = async function(modId, path, hash, isHotUpdate = false) {
-		const oldModule = window.__galaxyWasmModules[modId];
-		
-		if (isHotUpdate && oldModule) {
-			console.log('[WASM HMR] Disposing old module:', modId);
-			
-			if (oldModule.disposeHandler) {
-				try {
-					await oldModule.disposeHandler();
-				} catch (e) {
-					console.warn('[WASM HMR] Dispose failed:', e);
+		const ids = window.__galaxyWasmBundles[modId] || [modId];
+
+		if (isHotUpdate) {
+			for (const id of ids) {
+				const oldModule = window.__galaxyWasmModules[id];
+				if (!oldModule) {
+					continue;
 				}
-			}
-			
-			if (oldModule.listeners) {
-				oldModule.listeners.forEach(({ el, event, handler }) => {
+				console.log('[WASM HMR] Disposing old module:', id);
+
+				if (oldModule.disposeHandler) {
 					try {
-						el.removeEventListener(event, handler);
+						await oldModule.disposeHandler();
 					} catch (e) {
-						console.warn('[WASM HMR] Listener cleanup failed:', e);
+						console.warn('[WASM HMR] Dispose failed:', e);
 					}
-				});
+				}
+
+				if (oldModule.listeners) {
+					oldModule.listeners.forEach(({ el, event, handler }) => {
+						try {
+							el.removeEventListener(event, handler);
+						} catch (e) {
+							console.warn('[WASM HMR] Listener cleanup failed:', e);
+						}
+					});
+				}
 			}
 		}
-		
+
 		try {
 			const go = new Go();
-			const cacheBuster = hash || Date.now();
+			// Bundled modules are content hashed, so only hot updates need to
+			// bypass the cache; the initial fetch can reuse a prefetched copy.
+			const url = isHotUpdate ? path + '?t=' + (hash || Date.now()) : path;
 			const result = await WebAssembly.instantiateStreaming(
-				fetch(path + '?t=' + cacheBuster),
+				fetch(url),
 				go.importObject
 			);
-			
-			window.__galaxyWasmModules[modId] = {
-				instance: result.instance,
-				go: go,
-				listeners: [],
-				disposeHandler: null
-			};
-			
+
+			for (const id of ids) {
+				window.__galaxyWasmModules[id] = {
+					instance: result.instance,
+					go: go,
+					listeners: [],
+					disposeHandler: null
+				};
+			}
+
 			go.run(result.instance);
-			
-			if (isHotUpdate && window.__galaxyWasmAcceptHandlers[modId]) {
-				console.log('[WASM HMR] Calling accept handler for:', modId);
-				await window.__galaxyWasmAcceptHandlers[modId]();
+
+			if (isHotUpdate) {
+				for (const id of ids) {
+					if (window.__galaxyWasmAcceptHandlers[id]) {
+						console.log('[WASM HMR] Calling accept handler for:', id);
+						await window.__galaxyWasmAcceptHandlers[id]();
+					}
+				}
 			}
-			
-			console.log('[WASM] Module loaded:', modId);
+
+			console.log('[WASM] Module loaded:', ids.join(', '));
 		} catch (err) {
 			console.error('[WASM] Failed to load module:', err);
 			throw err;
 		}
 	};
-	
-	loadWasmModule(moduleId, wasmPath, null, false);
+
+	loadWasmModule(moduleIds[0], wasmPath, null, false);
 })();
-`, wasmPath)
+`, wasmPath, ids)
 }
 
 func GetWasmExecJS() string {
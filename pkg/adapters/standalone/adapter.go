@@ -9,6 +9,8 @@ import (
 	"text/template"
 
 	"github.com/withgalaxy/galaxy/pkg/adapters"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/ssr"
 	"github.com/withgalaxy/galaxy/pkg/version"
 )
 
@@ -45,6 +47,10 @@ func (a *StandaloneAdapter) generateMain(cfg *adapters.BuildConfig) error {
 		return fmt.Errorf("copy project files: %w", err)
 	}
 
+	if err := a.generatePages(cfg); err != nil {
+		return fmt.Errorf("compile pages: %w", err)
+	}
+
 	endpoints := a.buildEndpointData(cfg)
 	imports := a.buildEndpointImports(cfg)
 	hasMiddleware := a.checkMiddleware(cfg)
@@ -69,12 +75,7 @@ func (a *StandaloneAdapter) generateMain(cfg *adapters.BuildConfig) error {
 		})
 	}
 
-	hasSecurity := cfg.Config.Security.CheckOrigin && cfg.Config.IsSSR()
-
-	securityAllowOrigins := []string{}
-	if hasSecurity {
-		securityAllowOrigins = cfg.Config.Security.AllowOrigins
-	}
+	hasSecurity := security.CSRFEnabled(cfg.Config)
 
 	hasBodyLimit := cfg.Config.Security.BodyLimit.Enabled
 	bodyLimitMaxBytes := cfg.Config.Security.BodyLimit.MaxBytes
@@ -84,29 +85,70 @@ func (a *StandaloneAdapter) generateMain(cfg *adapters.BuildConfig) error {
 
 	hasForwardedHost := len(cfg.Config.Security.AllowedDomains) > 0
 	hasHeaders := cfg.Config.Security.Headers.Enabled
+	hasSession := cfg.Config.Session.Enabled && cfg.Config.IsSSR()
+
+	rateLimiter, err := security.NewRateLimiterFromConfig(cfg.Config, filepath.Dir(filepath.Dir(cfg.PagesDir)))
+	if err != nil {
+		return err
+	}
+	nonceAttr := ""
+	if security.CSPNonceEnabled(cfg.Config) {
+		nonceAttr = security.CSPNonceAttr
+	}
+
+	prefetchScript := ssr.PrefetchScript(cfg.Config.Prefetch)
+	if nonceAttr == "" {
+		prefetchScript = strings.ReplaceAll(prefetchScript, security.CSPNonceAttr, "")
+	}
+
+	rateLimitPage := ""
+	if rateLimiter != nil {
+		rateLimitPage = rateLimiter.Page
+	}
+
+	scheme := "http"
+	if cfg.Config.Server.TLS.Enabled() {
+		scheme = "https"
+	}
 
 	data := map[string]interface{}{
-		"Port":                 cfg.Config.Server.Port,
-		"Host":                 cfg.Config.Server.Host,
-		"SiteURL":              cfg.Config.Site,
-		"PublicDir":            filepath.Join(cfg.OutDir, "public"),
-		"StaticDir":            cfg.OutDir,
-		"PagesDir":             cfg.PagesDir,
-		"Routes":               routes,
-		"Endpoints":            endpoints,
-		"EndpointImports":      imports,
-		"HasMiddleware":        hasMiddleware,
-		"HasSequence":          hasSequence,
-		"HasLifecycle":         hasLifecycle,
-		"HasSecurity":          hasSecurity,
-		"SecurityCheckOrigin":  cfg.Config.Security.CheckOrigin,
-		"SecurityAllowOrigins": securityAllowOrigins,
-		"HasBodyLimit":         hasBodyLimit,
-		"BodyLimitMaxBytes":    bodyLimitMaxBytes,
-		"HasForwardedHost":     hasForwardedHost,
-		"AllowedDomains":       cfg.Config.Security.AllowedDomains,
-		"HasHeaders":           hasHeaders,
-		"HeadersConfig":        cfg.Config.Security.Headers,
+		"Port":              cfg.Config.Server.Port,
+		"Host":              cfg.Config.Server.Host,
+		"ServerConfig":      fmt.Sprintf("%#v", cfg.Config.Server),
+		"Scheme":            scheme,
+		"HasCompression":    cfg.Config.Compression.Enabled,
+		"CompressionConfig": fmt.Sprintf("%#v", cfg.Config.Compression),
+		"CacheRoutes":       fmt.Sprintf("%#v", cfg.Config.Cache.Routes),
+		"SiteURL":           cfg.Config.Site,
+		"PublicDir":         filepath.Join(cfg.OutDir, "public"),
+		"StaticDir":         cfg.OutDir,
+		"PagesDir":          cfg.PagesDir,
+		"Routes":            routes,
+		"Endpoints":         endpoints,
+		"EndpointImports":   imports,
+		"HasMiddleware":     hasMiddleware,
+		"HasSequence":       hasSequence,
+		"HasLifecycle":      hasLifecycle,
+		"HasSecurity":       hasSecurity,
+		"CSRFConfig":        fmt.Sprintf("%#v", security.NewCSRFConfig(cfg.Config.WithoutSecrets())),
+		"HasBodyLimit":      hasBodyLimit,
+		"BodyLimitMaxBytes": bodyLimitMaxBytes,
+		"HasForwardedHost":  hasForwardedHost,
+		"AllowedDomains":    cfg.Config.Security.AllowedDomains,
+		"HasHeaders":        hasHeaders,
+		"HeadersConfig":     cfg.Config.Security.Headers,
+		"HasSession":        hasSession,
+		"SessionConfig":     fmt.Sprintf("%#v", cfg.Config.WithoutSecrets().Session),
+		"HasCORS":           security.CORSEnabled(cfg.Config),
+		"HasCSPNonce":       nonceAttr != "",
+		"NonceAttr":         fmt.Sprintf("%q", nonceAttr),
+		"HasIntegrity":      cfg.Config.Security.Headers.Integrity,
+		"CORSConfig":        fmt.Sprintf("%#v", cfg.Config.Security.CORS),
+		"HasRateLimit":      rateLimiter != nil,
+		"RateLimitConfig":   fmt.Sprintf("%#v", cfg.Config.Security.RateLimit),
+		"RateLimitPage":     fmt.Sprintf("%q", rateLimitPage),
+		"HasPrefetch":       prefetchScript != "",
+		"PrefetchScript":    fmt.Sprintf("%q", prefetchScript),
 	}
 
 	return tmpl.Execute(f, data)
@@ -357,7 +399,7 @@ func (a *StandaloneAdapter) getGalaxyModulePath() (string, error) {
 		}
 		return "", fmt.Errorf("cannot find galaxy module")
 	}
-	return string(out), nil
+	return strings.TrimSpace(string(out)), nil
 }
 
 func (a *StandaloneAdapter) compile(cfg *adapters.BuildConfig) error {
@@ -386,30 +428,29 @@ import (
 	"log"
 	"net/http"
 	"os"
-	{{if .HasLifecycle}}
-	"os/signal"
-	{{end}}
 	"path/filepath"
 	"strings"
-	{{if .HasLifecycle}}
-	"syscall"
-	{{end}}
 
+	"github.com/withgalaxy/galaxy/pkg/assets"
+	"github.com/withgalaxy/galaxy/pkg/cache"
 	"github.com/withgalaxy/galaxy/pkg/compiler"
-	{{if or .HasBodyLimit .HasForwardedHost .HasHeaders}}
-	"github.com/withgalaxy/galaxy/pkg/config"
+	{{if .HasCompression}}
+	"github.com/withgalaxy/galaxy/pkg/compress"
 	{{end}}
+	"github.com/withgalaxy/galaxy/pkg/config"
 	"github.com/withgalaxy/galaxy/pkg/endpoints"
 	"github.com/withgalaxy/galaxy/pkg/executor"
-	{{if .HasLifecycle}}
+	"github.com/withgalaxy/galaxy/pkg/httpcache"
 	"github.com/withgalaxy/galaxy/pkg/lifecycle"
-	{{end}}
 	"github.com/withgalaxy/galaxy/pkg/middleware"
 	"github.com/withgalaxy/galaxy/pkg/parser"
 	"github.com/withgalaxy/galaxy/pkg/router"
-	{{if or .HasSecurity .HasBodyLimit .HasForwardedHost .HasHeaders}}
+	{{if or .HasSecurity .HasBodyLimit .HasForwardedHost .HasHeaders .HasRateLimit .HasCORS .HasIntegrity}}
 	"github.com/withgalaxy/galaxy/pkg/security"
 	{{end}}
+	{{if .HasSession}}
+	"github.com/withgalaxy/galaxy/pkg/session"
+	{{end}}
 	"github.com/withgalaxy/galaxy/pkg/ssr"
 	"github.com/withgalaxy/galaxy/pkg/template"
 	"github.com/withgalaxy/galaxy/pkg/wasm"
@@ -425,12 +466,20 @@ import (
 	{{end}}
 )
 
+// cspNonceAttr marks injected tags for security.InjectCSPNonce.
+const cspNonceAttr = {{.NonceAttr}}
+
 var (
 	rt                     *router.Router
 	comp                   *compiler.ComponentCompiler
 	baseDir                string
 	pagesDir               = "pages"
+	assetFiles             *assets.FileServer
+	publicFiles            = assets.NewFileServer(os.DirFS("{{.PublicDir}}"))
+	staticFiles            = assets.NewFileServer(os.DirFS("{{.StaticDir}}"))
 	wasmManifest           *wasm.WasmManifest
+	prefetchManifest       http.HandlerFunc
+	cacheRoutes            = {{.CacheRoutes}}
 	{{if .HasBodyLimit}}
 	bodyLimitMiddleware    *security.BodyLimitMiddleware
 	{{end}}
@@ -443,6 +492,15 @@ var (
 	{{if .HasHeaders}}
 	headersMiddleware      *security.HeadersMiddleware
 	{{end}}
+	{{if .HasSession}}
+	sessionManager         *session.Manager
+	{{end}}
+	{{if .HasRateLimit}}
+	rateLimiter            *security.RateLimiter
+	{{end}}
+	{{if .HasCORS}}
+	corsMiddleware         *security.CORSMiddleware
+	{{end}}
 	endpointHandlers = map[string]map[string]endpoints.HandlerFunc{
 		{{range .Endpoints}}
 		"{{.Pattern}}": {
@@ -460,6 +518,7 @@ func main() {
 		log.Fatal(err)
 	}
 	baseDir = filepath.Dir(exePath)
+	assetFiles = assets.NewFileServer(os.DirFS(baseDir))
 	comp = compiler.NewComponentCompiler(baseDir)
 
 	rt = router.NewRouter(filepath.Join(baseDir, pagesDir))
@@ -470,6 +529,7 @@ func main() {
 
 	manifestPath := filepath.Join(baseDir, "_assets", "wasm-manifest.json")
 	wasmManifest, _ = wasm.LoadManifest(manifestPath)
+	prefetchManifest = ssr.ServePrefetchManifest(wasmManifest)
 
 	{{if .HasBodyLimit}}
 	bodyLimitMiddleware = security.NewBodyLimitMiddleware({{.BodyLimitMaxBytes}})
@@ -488,12 +548,15 @@ func main() {
 	forwardedHostValidator = security.NewForwardedHostValidator(allowedDomains)
 	{{end}}
 
+	{{if or .HasSecurity .HasSession}}
+	// Secrets are read at startup, never compiled in.
+	secrets := config.LoadSecrets(".")
+	{{end}}
+
 	{{if .HasSecurity}}
-	csrfMiddleware = security.NewCSRFMiddleware(&security.CSRFConfig{
-		CheckOrigin:  {{.SecurityCheckOrigin}},
-		AllowOrigins: []string{ {{range .SecurityAllowOrigins}}"{{.}}",{{end}} },
-		SiteURL:      "{{.SiteURL}}",
-	})
+	csrfConfig := {{.CSRFConfig}}
+	csrfConfig.Secret = secrets.CSRF
+	csrfMiddleware = security.NewCSRFMiddleware(csrfConfig)
 	{{end}}
 
 	{{if .HasHeaders}}
@@ -506,66 +569,85 @@ func main() {
 		StrictTransportSecurity: "{{.HeadersConfig.StrictTransportSecurity}}",
 		ContentSecurityPolicy: "{{.HeadersConfig.ContentSecurityPolicy}}",
 		PermissionsPolicy: "{{.HeadersConfig.PermissionsPolicy}}",
+		CSPNonce: {{.HeadersConfig.CSPNonce}},
 	})
 	{{end}}
 
-	{{if .HasLifecycle}}
-	lc := lifecycle.NewLifecycle()
-	lc.Register(userlc.Lifecycle())
-	
-	if err := lc.ExecuteStartup(); err != nil {
-		log.Fatalf("Startup failed: %v", err)
-	}
-	
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	go func() {
-		<-sigChan
-		log.Println("Shutting down gracefully...")
-		if err := lc.ExecuteShutdown(); err != nil {
-			log.Printf("Shutdown error: %v", err)
-		}
-		os.Exit(0)
-	}()
+	{{if .HasSession}}
+	sessionConfig := {{.SessionConfig}}
+	sessionConfig.Secret, sessionConfig.PreviousSecrets = secrets.Session, secrets.PreviousSession
+	sessionManager, err = session.NewManager(sessionConfig)
+	if err != nil {
+		log.Fatalf("Session setup failed: %v", err)
+	}
+	{{end}}
+
+	{{if .HasCORS}}
+	corsMiddleware = security.NewCORSMiddleware({{.CORSConfig}})
+	{{end}}
+
+	{{if .HasRateLimit}}
+	rateLimiter, err = security.NewRateLimiter({{.RateLimitConfig}}, nil)
+	if err != nil {
+		log.Fatalf("Rate limit setup failed: %v", err)
+	}
+	rateLimiter.Page = {{.RateLimitPage}}
 	{{end}}
 
 	http.HandleFunc("/", handleRequest)
 
-	addr := "{{.Host}}:{{.Port}}"
-	log.Printf("🚀 Server running at http://%s\n", addr)
-	
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	var handler http.Handler = http.DefaultServeMux
+	{{if .HasCompression}}
+	handler = compress.Handler({{.CompressionConfig}}, handler)
+	{{end}}
+	srv := lifecycle.NewServer({{.ServerConfig}}, handler)
+	srv.HTTP.Addr = "{{.Host}}:{{.Port}}"
+	// The data cache is registered first so it shuts down after user hooks.
+	srv.Lifecycle = lifecycle.NewLifecycle().Register(cache.Default)
+	{{if .HasLifecycle}}
+	srv.Lifecycle.Register(userlc.Lifecycle())
+	{{end}}
+
+	log.Printf("🚀 Server running at {{.Scheme}}://%s\n", srv.HTTP.Addr)
+
+	if err := srv.ListenAndServe(); err != nil {
 		log.Fatal(err)
 	}
 }
 
 func handleRequest(w http.ResponseWriter, r *http.Request) {
-	if strings.HasPrefix(r.URL.Path, "/_assets/") {
-		assetsPath := filepath.Join(baseDir, r.URL.Path)
-		http.ServeFile(w, r, assetsPath)
+	// Precompressed .br and .gz siblings are served when accepted.
+	if strings.HasPrefix(r.URL.Path, "/_assets/") || r.URL.Path == "/wasm_exec.js" {
+		assetFiles.ServeHTTP(w, r)
 		return
 	}
 
-	if r.URL.Path == "/wasm_exec.js" {
-		wasmExecPath := filepath.Join(baseDir, "wasm_exec.js")
-		http.ServeFile(w, r, wasmExecPath)
+	if r.URL.Path == ssr.HydrationPath {
+		ssr.ServeHydration(w, r)
 		return
 	}
 
-	if filepath.Ext(r.URL.Path) != "" {
-		http.ServeFile(w, r, filepath.Join("{{.PublicDir}}", r.URL.Path))
+	if r.URL.Path == ssr.RouterPath {
+		ssr.ServeRouter(w, r)
 		return
 	}
 
-	staticPath := filepath.Join("{{.StaticDir}}", r.URL.Path)
-	if r.URL.Path == "/" {
-		staticPath = filepath.Join("{{.StaticDir}}", "index.html")
-	} else {
-		staticPath = filepath.Join("{{.StaticDir}}", r.URL.Path, "index.html")
+	if r.URL.Path == ssr.PrefetchPath {
+		ssr.ServePrefetch(w, r)
+		return
 	}
-	
-	if _, err := os.Stat(staticPath); err == nil {
-		http.ServeFile(w, r, staticPath)
+
+	if r.URL.Path == ssr.PrefetchManifestPath {
+		prefetchManifest(w, r)
+		return
+	}
+
+	if filepath.Ext(r.URL.Path) != "" {
+		publicFiles.ServeHTTP(w, r)
+		return
+	}
+
+	if staticFiles.Serve(w, r, r.URL.Path) {
 		return
 	}
 
@@ -596,6 +678,27 @@ func handleRequest(w http.ResponseWriter, r *http.Request) {
 	mwCtx.Request.URL = validatedURL
 	{{end}}
 
+	{{if .HasCORS}}
+	if err := corsMiddleware.Middleware(mwCtx, func() error { return nil }); err != nil {
+		return
+	}
+	{{end}}
+
+	{{if .HasSession}}
+	// The session is saved once the route is served, even if it wrote
+	// nothing.
+	sessionManager.Middleware(mwCtx, func() error {
+		serveRoute(w, route, mwCtx)
+		return nil
+	})
+	{{else}}
+	serveRoute(w, route, mwCtx)
+	{{end}}
+}
+
+// serveRoute runs the remaining middleware and the route's handler.
+func serveRoute(w http.ResponseWriter, route *router.Route, mwCtx *middleware.Context) {
+
 	{{if .HasSecurity}}
 	if err := csrfMiddleware.Middleware(mwCtx, func() error { return nil }); err != nil {
 		return
@@ -615,6 +718,11 @@ func handleRequest(w http.ResponseWriter, r *http.Request) {
 		chain.Use(mw)
 	}
 	if err := chain.Execute(mwCtx, func(ctx *middleware.Context) error {
+		{{if .HasRateLimit}}
+		if !rateLimiter.Allow(mwCtx.Response, mwCtx.Request, mwCtx.Locals) {
+			return nil
+		}
+		{{end}}
 		if route.IsEndpoint {
 			handleEndpoint(route.Pattern, mwCtx)
 		} else {
@@ -627,6 +735,11 @@ func handleRequest(w http.ResponseWriter, r *http.Request) {
 	}
 	{{else}}
 	if err := usermw.OnRequest(mwCtx, func() error {
+		{{if .HasRateLimit}}
+		if !rateLimiter.Allow(mwCtx.Response, mwCtx.Request, mwCtx.Locals) {
+			return nil
+		}
+		{{end}}
 		if route.IsEndpoint {
 			handleEndpoint(route.Pattern, mwCtx)
 		} else {
@@ -639,6 +752,11 @@ func handleRequest(w http.ResponseWriter, r *http.Request) {
 	}
 	{{end}}
 	{{else}}
+	{{if .HasRateLimit}}
+	if !rateLimiter.Allow(mwCtx.Response, mwCtx.Request, mwCtx.Locals) {
+		return
+	}
+	{{end}}
 	if route.IsEndpoint {
 		handleEndpoint(route.Pattern, mwCtx)
 		return
@@ -683,24 +801,23 @@ func handlePage(filePath string, mwCtx *middleware.Context) {
 		return
 	}
 
-	resolver := comp.Resolver
-	resolver.SetCurrentFile(filePath)
-
-	imports := make([]compiler.Import, len(parsed.Imports))
-	for i, imp := range parsed.Imports {
-		imports[i] = compiler.Import{
-			Path:        imp.Path,
-			Alias:       imp.Alias,
-			IsComponent: imp.IsComponent,
-		}
-	}
-	resolver.ParseImports(imports)
+	// Each request renders in its own session; comp only holds caches
+	// shared by all of them.
+	render := comp.NewSession(filePath, compiler.PageImports(parsed))
 
 	ctx := executor.NewContext()
 
 	reqCtx := ssr.NewRequestContext(mwCtx.Request, mwCtx.Params)
 	ctx.SetRequest(reqCtx)
 	ctx.SetLocals(mwCtx.Locals)
+	{{if .HasSession}}
+	ctx.SetSession(session.FromRequest(mwCtx.Request))
+	{{end}}
+	{{if .HasSecurity}}
+	if strings.Contains(parsed.Frontmatter+parsed.Template, "CSRFToken") {
+		ctx.SetCSRFToken(security.CSRFToken(mwCtx.Request))
+	}
+	{{end}}
 
 	ctx.SetParams(mwCtx.Params)
 
@@ -720,47 +837,70 @@ func handlePage(filePath string, mwCtx *middleware.Context) {
 		return
 	}
 
-	comp.CollectedStyles = nil
-	processedTemplate := comp.ProcessComponentTags(parsed.Template, ctx)
+	var rendered string
+	relPath, _ := filepath.Rel(filepath.Join(baseDir, pagesDir), filePath)
+	if compiled, ok := compiledPages["/"+filepath.ToSlash(relPath)]; ok {
+		var b strings.Builder
+		compiled(&b, ctx.Variables)
+		rendered = b.String()
+	} else {
+		processedTemplate := render.ProcessComponentTags(parsed.Template, ctx)
 
-	engine := template.NewEngine(ctx)
-	rendered, err := engine.Render(processedTemplate, nil)
-	if err != nil {
-		http.Error(mwCtx.Response, fmt.Sprintf("Render error: %v", err), http.StatusInternalServerError)
-		return
+		engine := template.NewEngine(ctx)
+		rendered, err = engine.Render(processedTemplate, nil)
+		if err != nil {
+			http.Error(mwCtx.Response, fmt.Sprintf("Render error: %v", err), http.StatusInternalServerError)
+			return
+		}
 	}
 
-	allStyles := append(parsed.Styles, comp.CollectedStyles...)
+	allStyles := append(parsed.Styles, render.CollectedStyles...)
 	if len(allStyles) > 0 {
 		var styleContent string
 		for _, style := range allStyles {
 			styleContent += style.Content + "\n"
 		}
-		styleTag := "<style>" + styleContent + "</style>"
+		styleTag := "<style" + cspNonceAttr + ">" + styleContent + "</style>"
 		rendered = strings.Replace(rendered, "</head>", styleTag+"\n</head>", 1)
 	}
 
 	if wasmManifest != nil {
 		pageAssets, ok := wasmManifest.Assets[filePath]
 		if ok && len(pageAssets.WasmModules) > 0 {
-			wasmExecTag := "<script src=\"/wasm_exec.js\"></script>"
+			wasmExecTag := "<script src=\"/wasm_exec.js\"" + cspNonceAttr + "></script>"
 			rendered = strings.Replace(rendered, "</body>", wasmExecTag+"\n</body>", 1)
 
 			for _, mod := range pageAssets.WasmModules {
-				loaderTag := fmt.Sprintf("<script src=\"%s\"></script>", mod.LoaderPath)
+				loaderTag := fmt.Sprintf("<script src=\"%s\"%s></script>", mod.LoaderPath, cspNonceAttr)
 				rendered = strings.Replace(rendered, "</body>", loaderTag+"\n</body>", 1)
 			}
 		}
 
 		if len(pageAssets.JSScripts) > 0 {
 			for _, jsPath := range pageAssets.JSScripts {
-				jsTag := fmt.Sprintf("<script type=\"module\" src=\"%s\"></script>", jsPath)
+				jsTag := fmt.Sprintf("<script type=\"module\" src=\"%s\"%s></script>", jsPath, cspNonceAttr)
 				rendered = strings.Replace(rendered, "</body>", jsTag+"\n</body>", 1)
 			}
 		}
 	}
 
+	rendered = ssr.InjectState(rendered, ctx.State)
+	{{if .HasPrefetch}}
+	rendered = strings.Replace(rendered, "</head>", {{.PrefetchScript}}+"\n</head>", 1)
+	{{end}}
+
+	{{if .HasSecurity}}
+	rendered = security.InjectCSRFToken(rendered, mwCtx.Request)
+	{{end}}
+	{{if .HasCSPNonce}}
+	rendered = security.InjectCSPNonce(rendered, mwCtx.Request)
+	{{end}}
+	{{if .HasIntegrity}}
+	rendered = security.AddIntegrity(rendered, baseDir, "")
+	{{end}}
+
 	mwCtx.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
-	mwCtx.Response.Write([]byte(rendered))
+	policy := httpcache.For(cacheRoutes, mwCtx.Request.URL.Path, httpcache.PagePolicy(ctx))
+	httpcache.Write(mwCtx.Response, mwCtx.Request, []byte(rendered), policy)
 }
 `
@@ -0,0 +1,114 @@
+package standalone
+
+import (
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/withgalaxy/galaxy/pkg/adapters"
+	"github.com/withgalaxy/galaxy/pkg/config"
+)
+
+const benchPage = `---
+title := "Posts"
+posts := []string{"one", "two", "three"}
+---
+<html>
+<head><title>{title}</title></head>
+<body>
+	<h1>{title}</h1>
+	<ul><li galaxy:for={post in posts}>{post}</li></ul>
+	<p galaxy:if={len(posts) > 2}>Many posts</p>
+</body>
+</html>
+`
+
+func newBuildConfig(t testing.TB, pages map[string]string) *adapters.BuildConfig {
+	pagesDir := filepath.Join(t.TempDir(), "pages")
+	os.MkdirAll(pagesDir, 0755)
+	cfg := &adapters.BuildConfig{
+		Config:    config.DefaultConfig(),
+		ServerDir: t.TempDir(),
+		OutDir:    t.TempDir(),
+		PagesDir:  pagesDir,
+	}
+	cfg.Config.Output.Type = config.OutputServer
+	for name, content := range pages {
+		path := filepath.Join(pagesDir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		cfg.Routes = append(cfg.Routes, adapters.RouteInfo{Pattern: "/" + strings.TrimSuffix(name, ".gxc"), FilePath: path})
+	}
+	return cfg
+}
+
+func TestGeneratePages(t *testing.T) {
+	cfg := newBuildConfig(t, map[string]string{
+		"index.gxc":    benchPage,
+		"imported.gxc": "---\nimport Card \"../components/Card.gxc\"\n---\n<Card title=\"x\" />",
+		"resolved.gxc": "<div><Card title=\"x\" /></div>",
+		"builtin.gxc":  "<form><CSRFInput /></form>",
+	})
+	if err := New().generatePages(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(cfg.ServerDir, "pages_gen.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := string(data)
+	if !strings.Contains(src, `"/index.gxc": func(`) {
+		t.Errorf("Expected the page without components precompiled:\n%s", src)
+	}
+	for _, page := range []string{"imported", "resolved", "builtin"} {
+		if strings.Contains(src, `"/`+page+`.gxc"`) {
+			t.Errorf("Expected %s.gxc to render per request", page)
+		}
+	}
+}
+
+var handlePageBenchRan bool
+
+// BenchmarkHandlePage times the generated server's request path, for pages
+// precompiled and rendered by the template engine. The handler only exists
+// in the generated server, so this generates one and runs the benchmark in
+// testdata/handle_page_test.go there, logging its results.
+func BenchmarkHandlePage(b *testing.B) {
+	if handlePageBenchRan {
+		// The runner calls benchmarks again with a larger b.N.
+		return
+	}
+	handlePageBenchRan = true
+
+	cfg := newBuildConfig(b, map[string]string{"index.gxc": benchPage})
+	a := New()
+	if err := a.generateMain(cfg); err != nil {
+		b.Fatal(err)
+	}
+	if err := a.generateGoMod(cfg); err != nil {
+		b.Fatal(err)
+	}
+	for src, dst := range map[string]string{
+		"testdata/handle_page_test.go": "handle_page_test.go",
+		"../../../go.sum":              "go.sum",
+	} {
+		data, err := os.ReadFile(src)
+		if err != nil {
+			b.Fatal(err)
+		}
+		os.WriteFile(filepath.Join(cfg.ServerDir, dst), data, 0644)
+	}
+
+	cmd := exec.Command("go", "test", "-run=^$", "-bench=HandlePage", "-benchmem",
+		"-benchtime="+flag.Lookup("test.benchtime").Value.String())
+	cmd.Dir = cfg.ServerDir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		b.Fatalf("%v\n%s", err, out)
+	}
+	b.Log("\n" + string(out))
+}
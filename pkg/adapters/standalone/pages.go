@@ -0,0 +1,74 @@
+package standalone
+
+import (
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/withgalaxy/galaxy/pkg/adapters"
+	"github.com/withgalaxy/galaxy/pkg/parser"
+	"github.com/withgalaxy/galaxy/pkg/template"
+)
+
+// generatePages writes pages_gen.go, holding a precompiled render function
+// for every page without component tags. Pages using components, imported
+// or resolved by name, keep rendering through the template engine, since
+// their output depends on props evaluated per request.
+func (a *StandaloneAdapter) generatePages(cfg *adapters.BuildConfig) error {
+	renders := map[string]string{}
+	for _, r := range cfg.Routes {
+		if r.IsEndpoint {
+			continue
+		}
+		content, err := os.ReadFile(r.FilePath)
+		if err != nil {
+			return err
+		}
+		parsed, err := parser.Parse(string(content))
+		if err != nil {
+			return fmt.Errorf("parse %s: %w", r.FilePath, err)
+		}
+		if componentTagRegex.MatchString(parsed.Template) {
+			continue
+		}
+
+		relPath, _ := filepath.Rel(cfg.PagesDir, r.FilePath)
+		renders["/"+filepath.ToSlash(relPath)] = template.Compile(parsed.Template, template.CompileOptions{
+			Builder:  "b",
+			Fallback: "vars",
+		})
+	}
+
+	keys := make([]string, 0, len(renders))
+	for k := range renders {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var src strings.Builder
+	src.WriteString("// Code generated by galaxy build; DO NOT EDIT.\n\npackage main\n\nimport (\n\t\"strings\"\n")
+	if len(keys) > 0 {
+		src.WriteString("\n\t\"github.com/withgalaxy/galaxy/pkg/template\"\n")
+	}
+	src.WriteString(")\n\n")
+	src.WriteString("// compiledPages renders pages by their path relative to the pages directory.\n")
+	src.WriteString("var compiledPages = map[string]func(b *strings.Builder, vars map[string]interface{}){\n")
+	for _, k := range keys {
+		fmt.Fprintf(&src, "%q: func(b *strings.Builder, vars map[string]interface{}) {\n%s},\n", k, renders[k])
+	}
+	src.WriteString("}\n")
+
+	formatted, err := format.Source([]byte(src.String()))
+	if err != nil {
+		return fmt.Errorf("format compiled pages: %w", err)
+	}
+	return os.WriteFile(filepath.Join(cfg.ServerDir, "pages_gen.go"), formatted, 0644)
+}
+
+// componentTagRegex matches the tags compiler.ProcessComponentTags expands,
+// built-ins such as <CSRFInput/> included.
+var componentTagRegex = regexp.MustCompile(`<[A-Z]\w+`)
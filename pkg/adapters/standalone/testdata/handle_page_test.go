@@ -0,0 +1,33 @@
+package main
+
+import (
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/withgalaxy/galaxy/pkg/compiler"
+	"github.com/withgalaxy/galaxy/pkg/middleware"
+)
+
+// BenchmarkHandlePage is copied into a generated server by the standalone
+// adapter's BenchmarkHandlePage.
+func BenchmarkHandlePage(b *testing.B) {
+	baseDir = "."
+	comp = compiler.NewComponentCompiler(baseDir)
+	page := filepath.Join(baseDir, pagesDir, "index.gxc")
+
+	run := func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			w := httptest.NewRecorder()
+			handlePage(page, middleware.NewContext(w, httptest.NewRequest("GET", "/", nil)))
+			if w.Code != 200 {
+				b.Fatalf("Expected 200, got %d: %s", w.Code, w.Body)
+			}
+		}
+	}
+
+	b.Run("compiled", run)
+	delete(compiledPages, "/index.gxc")
+	b.Run("engine", run)
+}
@@ -3,10 +3,15 @@ package vercel
 import (
 	"encoding/json"
 	"fmt"
+	"mime"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	"github.com/withgalaxy/galaxy/pkg/adapters"
+	"github.com/withgalaxy/galaxy/pkg/compress"
 )
 
 type VercelAdapter struct{}
@@ -123,13 +128,18 @@ func (a *VercelAdapter) generateConfig(cfg *adapters.BuildConfig, configPath str
 		Version: 3,
 		Routes: []Route{
 			{
-				Src:     "^/_assets/(.*)$",
-				Headers: map[string]string{"cache-control": "public, max-age=31536000, immutable"},
+				Src:      "^/_assets/(.*)$",
+				Headers:  map[string]string{"cache-control": "public, max-age=31536000, immutable"},
+				Continue: true,
 			},
-			{Handle: "filesystem"},
 		},
 	}
 
+	if cfg.Config.Compression.Precompress {
+		config.Routes = append(config.Routes, precompressedRoutes()...)
+	}
+	config.Routes = append(config.Routes, Route{Handle: "filesystem"})
+
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return err
@@ -137,3 +147,59 @@ func (a *VercelAdapter) generateConfig(cfg *adapters.BuildConfig, configPath str
 
 	return os.WriteFile(configPath, data, 0644)
 }
+
+// precompressedRoutes serve the .br or .gz sibling of a static file, or of
+// a page's index.html, to clients accepting that encoding. The routes check
+// that the sibling exists, so files left uncompressed fall through to the
+// filesystem.
+func precompressedRoutes() []Route {
+	exts := make([]string, 0, len(compress.PrecompressExts))
+	for ext := range compress.PrecompressExts {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+
+	// A sibling's own extension would give it the wrong type.
+	var routes []Route
+	files := make([]string, len(exts))
+	for i, ext := range exts {
+		files[i] = regexp.QuoteMeta(strings.TrimPrefix(ext, "."))
+		if contentType := mime.TypeByExtension(ext); contentType != "" {
+			routes = append(routes, Route{
+				Src:      "^/.+" + regexp.QuoteMeta(ext) + "$",
+				Headers:  map[string]string{"content-type": contentType},
+				Continue: true,
+			})
+		}
+	}
+
+	for _, encoding := range []string{compress.Brotli, compress.Gzip} {
+		ext := compress.Extensions[encoding]
+		has := []RouteCondition{{
+			Type:  "header",
+			Key:   "accept-encoding",
+			Value: fmt.Sprintf(`.*\b%s\b.*`, encoding),
+		}}
+		routes = append(routes,
+			Route{
+				Src:     `^(/.+\.(?:` + strings.Join(files, "|") + `))$`,
+				Dest:    "$1" + ext,
+				Headers: map[string]string{"content-encoding": encoding, "vary": "Accept-Encoding"},
+				Has:     has,
+				Check:   true,
+			},
+			Route{
+				Src:  `^((?:/[^/.]+)*)/?$`,
+				Dest: "$1/index.html" + ext,
+				Headers: map[string]string{
+					"content-encoding": encoding,
+					"content-type":     mime.TypeByExtension(".html"),
+					"vary":             "Accept-Encoding",
+				},
+				Has:   has,
+				Check: true,
+			},
+		)
+	}
+	return routes
+}
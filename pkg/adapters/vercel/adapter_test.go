@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/withgalaxy/galaxy/pkg/adapters"
@@ -340,3 +342,51 @@ func TestVercelAdapter_RouteConfiguration(t *testing.T) {
 		}
 	})
 }
+
+func TestVercelAdapter_PrecompressedRoutes(t *testing.T) {
+	routes := precompressedRoutes()
+
+	// dest returns where the Accept-Encoding route for encoding sends path.
+	dest := func(encoding, path string) (string, map[string]string) {
+		for _, r := range routes {
+			if len(r.Has) == 0 || !strings.Contains(r.Has[0].Value, encoding) {
+				continue
+			}
+			re := regexp.MustCompile(r.Src)
+			if m := re.FindStringSubmatchIndex(path); m != nil {
+				if !r.Check || r.Has[0].Key != "accept-encoding" {
+					t.Errorf("route should check its destination on Accept-Encoding: %+v", r)
+				}
+				return string(re.ExpandString(nil, r.Dest, path, m)), r.Headers
+			}
+		}
+		return "", nil
+	}
+
+	for _, tt := range []struct{ encoding, path, want string }{
+		{"br", "/_assets/app.css", "/_assets/app.css.br"},
+		{"gzip", "/_assets/app.css", "/_assets/app.css.gz"},
+		{"br", "/", "/index.html.br"},
+		{"br", "/about", "/about/index.html.br"},
+		{"gzip", "/docs/intro/", "/docs/intro/index.html.gz"},
+		{"br", "/about/index.html", "/about/index.html.br"},
+		{"br", "/robots.txt", ""},
+	} {
+		got, headers := dest(tt.encoding, tt.path)
+		if got != tt.want {
+			t.Errorf("%s %s: got %q, want %q", tt.encoding, tt.path, got, tt.want)
+		}
+		if got != "" && headers["content-encoding"] != tt.encoding {
+			t.Errorf("%s %s: content-encoding = %q", tt.encoding, tt.path, headers["content-encoding"])
+		}
+	}
+
+	if _, headers := dest("br", "/about"); !strings.HasPrefix(headers["content-type"], "text/html") {
+		t.Errorf("pages should be served as HTML, got %q", headers["content-type"])
+	}
+	for _, r := range routes {
+		if r.Continue && regexp.MustCompile(r.Src).MatchString("/_assets/app.css") && !strings.HasPrefix(r.Headers["content-type"], "text/css") {
+			t.Errorf("precompressed CSS should keep its type: %+v", r)
+		}
+	}
+}
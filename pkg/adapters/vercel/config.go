@@ -6,12 +6,22 @@ type VercelConfig struct {
 }
 
 type Route struct {
-	Src     string            `json:"src,omitempty"`
-	Dest    string            `json:"dest,omitempty"`
-	Headers map[string]string `json:"headers,omitempty"`
-	Methods []string          `json:"methods,omitempty"`
-	Status  int               `json:"status,omitempty"`
-	Handle  string            `json:"handle,omitempty"`
+	Src      string            `json:"src,omitempty"`
+	Dest     string            `json:"dest,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Methods  []string          `json:"methods,omitempty"`
+	Status   int               `json:"status,omitempty"`
+	Handle   string            `json:"handle,omitempty"`
+	Has      []RouteCondition  `json:"has,omitempty"`
+	Continue bool              `json:"continue,omitempty"`
+	// Check makes a route fall through when its Dest is not a file.
+	Check bool `json:"check,omitempty"`
+}
+
+type RouteCondition struct {
+	Type  string `json:"type"`
+	Key   string `json:"key,omitempty"`
+	Value string `json:"value,omitempty"`
 }
 
 func NewVercelConfig() *VercelConfig {
@@ -6,10 +6,11 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/withgalaxy/galaxy/internal/wasm"
 	"github.com/withgalaxy/galaxy/pkg/parser"
 	"github.com/withgalaxy/galaxy/pkg/plugins"
+	"github.com/withgalaxy/galaxy/pkg/security"
 	"github.com/withgalaxy/orbit/bundler"
-	"github.com/withgalaxy/orbit/wasm"
 )
 
 type Bundler struct {
@@ -17,6 +18,10 @@ type Bundler struct {
 	wasmCompiler  *wasm.Compiler
 	DevMode       bool
 	PluginManager *plugins.Manager
+	// CSPNonce marks injected tags with security.CSPNonceAttr.
+	CSPNonce bool
+	// Base prefixes injected asset URLs, for sites mounted below the root.
+	Base string
 }
 
 type WasmAsset struct {
@@ -27,10 +32,17 @@ type WasmAsset struct {
 func NewBundler(outDir string) *Bundler {
 	return &Bundler{
 		orbitBundler: bundler.New(outDir),
-		wasmCompiler: wasm.NewCompiler(".galaxy/wasm-build", outDir+"/_assets/wasm"),
+		wasmCompiler: newWasmCompiler(outDir),
 	}
 }
 
+func newWasmCompiler(outDir string) *wasm.Compiler {
+	c := wasm.NewCompiler(".galaxy/wasm-build", outDir+"/_assets/wasm")
+	c.ModulePath = localGalaxyPath()
+	c.Cache = wasm.DefaultCache
+	return c
+}
+
 func (b *Bundler) BundleStyles(comp *parser.Component, pagePath string) (string, error) {
 	if len(comp.Styles) == 0 {
 		return "", nil
@@ -114,167 +126,148 @@ func (b *Bundler) BundleScripts(comp *parser.Component, pagePath string) (string
 	return asset.Path, nil
 }
 
-func (b *Bundler) BundleWasmScripts(comp *parser.Component, pagePath string) ([]WasmAsset, error) {
-	var assets []WasmAsset
-
-	for _, script := range comp.Scripts {
-		if script.Language != "go" {
-			continue
-		}
-
-		moduleID := pagePath
-		preparedScript := b.prepareWasmScript(script.Content, moduleID)
-
-		// Determine galaxy module path for local development
-		galaxyPath := os.Getenv("GALAXY_PATH")
-		if galaxyPath == "" {
-			// Try to find galaxy in parent directories
-			cwd, _ := os.Getwd()
-			testPaths := []string{
-				filepath.Join(cwd, "..", "galaxy"),
-				filepath.Join(cwd, "../..", "galaxy"),
-				filepath.Join(cwd, "../../..", "galaxy"),
-			}
-			for _, p := range testPaths {
-				absPath, _ := filepath.Abs(p)
-				if _, err := os.Stat(absPath); err == nil {
-					galaxyPath = absPath
-					break
-				}
-			}
-		}
+// WasmSource is a component rendered on a page, whose Go scripts are linked
+// into the page's module.
+type WasmSource struct {
+	Path      string
+	Component *parser.Component
+	// Island is set for components rendered with a client: directive, whose
+	// scripts wait to be hydrated.
+	Island bool
+}
 
-		module, err := b.wasmCompiler.Compile(preparedScript, pagePath, &wasm.CompileOptions{
-			UseTinyGo:     false,
-			ModuleName:    "github.com/withgalaxy/galaxy",
-			ModuleVersion: "v0.0.0",
-			ModulePath:    galaxyPath,
-		})
-		if err != nil {
-			return nil, fmt.Errorf("compile wasm: %w", err)
-		}
+// BundleWasmScripts links the Go scripts of the page comp and of the
+// components it rendered into a single module, so the page boots one Go
+// runtime. Each script keeps its own HMR module ID, see WasmModuleID. Pages
+// rendering the same scripts share the module. The result is empty or has
+// one asset.
+func (b *Bundler) BundleWasmScripts(comp *parser.Component, pagePath string, components ...WasmSource) ([]WasmAsset, error) {
+	scripts := wasmScripts(comp, pagePath, components)
+	if len(scripts) == 0 {
+		return nil, nil
+	}
 
-		loaderContent := GenerateWasmLoader("/_assets/wasm/script-"+module.Hash+".wasm", moduleID)
-		loaderAsset, err := b.orbitBundler.BundleJS(loaderContent, pagePath+"-loader", nil)
-		if err != nil {
-			return nil, err
-		}
+	module, err := b.wasmCompiler.CompileBundle(scripts)
+	if err != nil {
+		return nil, fmt.Errorf("compile wasm: %w", err)
+	}
 
-		assets = append(assets, WasmAsset{
-			WasmPath:   "/_assets/wasm/script-" + module.Hash + ".wasm",
-			LoaderPath: loaderAsset.Path,
-		})
+	wasmPath := "/_assets/wasm/" + filepath.Base(module.WasmPath)
+	moduleIDs := make([]string, len(scripts))
+	for i, script := range scripts {
+		moduleIDs[i] = script.ModuleID
+	}
+	loaderContent := wasm.GenerateLoader(wasmPath, moduleIDs)
+	loaderAsset, err := b.orbitBundler.BundleJS(loaderContent, pagePath+"-loader", nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return assets, nil
+	return []WasmAsset{{
+		WasmPath:   wasmPath,
+		LoaderPath: loaderAsset.Path,
+	}}, nil
 }
 
-func (b *Bundler) prepareWasmScript(script, moduleID string) string {
-	// Split imports, functions, variables, and main code
-	lines := strings.Split(script, "\n")
-	var imports []string
-	var variables []string
-	var functions []string
-	var mainCode []string
-
-	inFunction := false
-	braceCount := 0
-	currentFunc := []string{}
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
+// WasmPage is a page whose module PrecompileWasm builds.
+type WasmPage struct {
+	Path       string
+	Component  *parser.Component
+	Components []WasmSource
+}
 
-		// Track imports
-		if strings.HasPrefix(trimmed, "import ") {
-			imports = append(imports, line)
-			continue
+// PrecompileWasm compiles the modules of pages in parallel, so that the
+// BundleWasmScripts calls rendering them find them built.
+func (b *Bundler) PrecompileWasm(pages []WasmPage) error {
+	var bundles [][]wasm.Script
+	for _, page := range pages {
+		if scripts := wasmScripts(page.Component, page.Path, page.Components); len(scripts) > 0 {
+			bundles = append(bundles, scripts)
 		}
+	}
+	if _, err := b.wasmCompiler.CompileBundles(bundles); err != nil {
+		return fmt.Errorf("compile wasm: %w", err)
+	}
+	return nil
+}
 
-		// Detect package-level variable declarations
-		if strings.HasPrefix(trimmed, "var ") || strings.HasPrefix(trimmed, "const ") {
-			variables = append(variables, line)
+// wasmScripts lists the Go scripts of the page comp and of the components
+// it rendered, in the order they are linked.
+func wasmScripts(comp *parser.Component, pagePath string, components []WasmSource) []wasm.Script {
+	sources := append([]WasmSource{{Path: pagePath, Component: comp}}, components...)
+	seen := make(map[string]bool)
+	var scripts []wasm.Script
+	for _, src := range sources {
+		if src.Component == nil || seen[src.Path] {
 			continue
 		}
+		seen[src.Path] = true
 
-		// Detect function definitions
-		if strings.HasPrefix(trimmed, "func ") && !inFunction {
-			inFunction = true
-			currentFunc = []string{line}
-			braceCount = strings.Count(line, "{") - strings.Count(line, "}")
-			if braceCount == 0 {
-				// Single-line function (unlikely but handle it)
-				functions = append(functions, line)
-				inFunction = false
-				currentFunc = []string{}
+		n := 0
+		for _, script := range src.Component.Scripts {
+			if script.Language != "go" {
+				continue
 			}
-			continue
-		}
-
-		if inFunction {
-			currentFunc = append(currentFunc, line)
-			braceCount += strings.Count(line, "{") - strings.Count(line, "}")
-			if braceCount == 0 {
-				functions = append(functions, strings.Join(currentFunc, "\n"))
-				inFunction = false
-				currentFunc = []string{}
+			moduleID := WasmModuleID(src.Path)
+			island := ""
+			if src.Island {
+				island = moduleID
 			}
-			continue
-		}
-
-		// Everything else goes in main
-		if trimmed != "" {
-			mainCode = append(mainCode, line)
+			if n > 0 {
+				moduleID = fmt.Sprintf("%s#%d", moduleID, n)
+			}
+			n++
+			scripts = append(scripts, wasm.Script{ModuleID: moduleID, Content: script.Content, Island: island})
 		}
 	}
+	return scripts
+}
 
-	// Build complete Go program
-	var builder strings.Builder
-	builder.WriteString("package main\n\n")
-
-	if len(imports) > 0 {
-		builder.WriteString("import (\n")
-		for _, imp := range imports {
-			imp = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(imp), "import "))
-			builder.WriteString("\t")
-			builder.WriteString(imp)
-			builder.WriteString("\n")
+// WasmModuleID is the HMR module ID of the first Go script in the component
+// at path; later ones add "#1", "#2" and so on.
+func WasmModuleID(path string) string {
+	if cwd, err := os.Getwd(); err == nil {
+		if rel, err := filepath.Rel(cwd, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
 		}
-		builder.WriteString(")\n\n")
-	}
-
-	// Add package-level variables
-	for _, v := range variables {
-		builder.WriteString(v)
-		builder.WriteString("\n")
-	}
-	if len(variables) > 0 {
-		builder.WriteString("\n")
-	}
-
-	// Add functions at package level
-	for _, fn := range functions {
-		builder.WriteString(fn)
-		builder.WriteString("\n\n")
 	}
+	return filepath.Base(path)
+}
 
-	// Add main with blocking select
-	builder.WriteString(fmt.Sprintf("func main() {\n\t// Module: %s\n", moduleID))
-	for _, line := range mainCode {
-		builder.WriteString("\t")
-		builder.WriteString(line)
-		builder.WriteString("\n")
+// localGalaxyPath finds a Galaxy checkout for scripts to build against, from
+// GALAXY_PATH or next to the project.
+func localGalaxyPath() string {
+	galaxyPath := os.Getenv("GALAXY_PATH")
+	if galaxyPath == "" {
+		// Try to find galaxy in parent directories
+		cwd, _ := os.Getwd()
+		testPaths := []string{
+			filepath.Join(cwd, "..", "galaxy"),
+			filepath.Join(cwd, "../..", "galaxy"),
+			filepath.Join(cwd, "../../..", "galaxy"),
+		}
+		for _, p := range testPaths {
+			absPath, _ := filepath.Abs(p)
+			if _, err := os.Stat(absPath); err == nil {
+				galaxyPath = absPath
+				break
+			}
+		}
 	}
-	builder.WriteString("\t// Block forever to keep event listeners active\n")
-	builder.WriteString("\tselect {}\n")
-	builder.WriteString("}\n")
-
-	return builder.String()
+	return galaxyPath
 }
 
 func (b *Bundler) GenerateScopeID(pagePath string) string {
 	return b.orbitBundler.GenerateScopeID(pagePath)
 }
 
+func (b *Bundler) url(p string) string {
+	if b.Base == "" || b.Base == "/" {
+		return p
+	}
+	return strings.TrimSuffix(b.Base, "/") + p
+}
+
 func (b *Bundler) InjectAssets(html, cssPath, jsPath, scopeID string) string {
 	return b.InjectAssetsWithWasm(html, cssPath, jsPath, scopeID, nil)
 }
@@ -285,30 +278,39 @@ func (b *Bundler) InjectAssetsWithWasm(html, cssPath, jsPath, scopeID string, wa
 		html = strings.Replace(html, "<body>", fmt.Sprintf(`<body %s>`, bodyScopeAttr), 1)
 	}
 
+	nonce := ""
+	if b.CSPNonce {
+		nonce = security.CSPNonceAttr
+	}
+
 	if b.DevMode {
-		hmrScript := `<script src="/__hmr/client.js"></script>`
+		hmrScript := fmt.Sprintf(`<script src="%s"%s></script>`, b.url("/__hmr/client.js"), nonce)
 		html = strings.Replace(html, "</head>", hmrScript+"\n</head>", 1)
 	}
 
 	if cssPath != "" {
-		cssTag := fmt.Sprintf(`<link rel="stylesheet" href="%s">`, cssPath)
+		cssTag := fmt.Sprintf(`<link rel="stylesheet" href="%s"%s>`, b.url(cssPath), nonce)
 		html = strings.Replace(html, "</head>", cssTag+"\n</head>", 1)
 	}
 
 	if len(wasmAssets) > 0 {
-		wasmExecTag := `<script src="/wasm_exec.js"></script>`
+		wasmExecTag := fmt.Sprintf(`<script src="%s"%s></script>`, b.url("/wasm_exec.js"), nonce)
 		html = strings.Replace(html, "</body>", wasmExecTag+"\n</body>", 1)
 
 		for _, asset := range wasmAssets {
-			loaderTag := fmt.Sprintf(`<script src="%s"></script>`, asset.LoaderPath)
+			loaderTag := fmt.Sprintf(`<script src="%s"%s></script>`, b.url(asset.LoaderPath), nonce)
 			html = strings.Replace(html, "</body>", loaderTag+"\n</body>", 1)
 		}
 	}
 
 	if jsPath != "" {
-		jsTag := fmt.Sprintf(`<script type="module" src="%s"></script>`, jsPath)
+		jsTag := fmt.Sprintf(`<script type="module" src="%s"%s></script>`, b.url(jsPath), nonce)
 		html = strings.Replace(html, "</body>", jsTag+"\n</body>", 1)
 	}
 
+	if b.PluginManager != nil {
+		html = plugins.ApplyTags(html, b.PluginManager.InjectTags(), nonce)
+	}
+
 	return html
 }
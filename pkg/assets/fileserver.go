@@ -0,0 +1,145 @@
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/withgalaxy/galaxy/pkg/compress"
+	"github.com/withgalaxy/galaxy/pkg/httpcache"
+)
+
+// FileServer serves files from an fs.FS with content-based ETags, so
+// conditional requests work for embedded files too.
+type FileServer struct {
+	fsys fs.FS
+	// ModTime is sent as Last-Modified for files without a modification
+	// time, such as those in an embed.FS.
+	ModTime time.Time
+
+	etags sync.Map
+}
+
+type etagEntry struct {
+	modTime time.Time
+	size    int64
+	etag    string
+}
+
+func NewFileServer(fsys fs.FS) *FileServer {
+	return &FileServer{fsys: fsys}
+}
+
+func (s *FileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.Serve(w, r, r.URL.Path) {
+		http.NotFound(w, r)
+	}
+}
+
+// Serve writes the named file, or index.html for a directory, and reports
+// whether it was found.
+func (s *FileServer) Serve(w http.ResponseWriter, r *http.Request, name string) bool {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if name == "" {
+		name = "."
+	}
+
+	f, info, ok := s.open(name)
+	if ok && info.IsDir() {
+		f.Close()
+		name = path.Join(name, "index.html")
+		f, info, ok = s.open(name)
+	}
+	if !ok {
+		return false
+	}
+	defer f.Close()
+	if info.IsDir() {
+		return false
+	}
+
+	// Serve a precompressed sibling if the client accepts one.
+	file := name
+	if compress.PrecompressExts[path.Ext(name)] {
+		w.Header().Add("Vary", "Accept-Encoding")
+		for _, encoding := range compress.Accepted(r) {
+			sf, sinfo, ok := s.open(name + compress.Extensions[encoding])
+			if !ok {
+				continue
+			}
+			if sinfo.IsDir() {
+				sf.Close()
+				continue
+			}
+			defer sf.Close()
+			f, info, file = sf, sinfo, name+compress.Extensions[encoding]
+			w.Header().Set("Content-Encoding", encoding)
+			break
+		}
+	}
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(f)
+		if err != nil {
+			return false
+		}
+		content = bytes.NewReader(data)
+	}
+
+	modTime := info.ModTime()
+	if modTime.IsZero() {
+		modTime = s.ModTime
+	}
+	etag, err := s.etag(file, info, content)
+	if err != nil {
+		w.Header().Del("Content-Encoding")
+		return false
+	}
+	w.Header().Set("ETag", etag)
+	if strings.HasPrefix(name, "_assets/") && httpcache.Hashed(name) {
+		w.Header().Set("Cache-Control", httpcache.Immutable)
+	}
+	http.ServeContent(w, r, name, modTime, content)
+	return true
+}
+
+func (s *FileServer) open(name string) (fs.File, fs.FileInfo, bool) {
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return nil, nil, false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, false
+	}
+	return f, info, true
+}
+
+func (s *FileServer) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	if cached, ok := s.etags.Load(name); ok {
+		e := cached.(etagEntry)
+		if e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+			return e.etag, nil
+		}
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(h.Sum(nil))[:32] + `"`
+	s.etags.Store(name, etagEntry{modTime: info.ModTime(), size: info.Size(), etag: etag})
+	return etag, nil
+}
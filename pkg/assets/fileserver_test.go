@@ -0,0 +1,76 @@
+package assets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestFileServer(t *testing.T) {
+	fsys := fstest.MapFS{
+		"_assets/app.css":             {Data: []byte("body{}")},
+		"_assets/app.css.br":          {Data: []byte("brotli")},
+		"_assets/styles-1a2b3c4d.css": {Data: []byte("h1{}")},
+		"static/index.html":           {Data: []byte("<h1>Home</h1>")},
+	}
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := NewFileServer(fsys)
+	s.ModTime = modTime
+
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/_assets/app.css", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "body{}" {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Error("expected an ETag")
+	}
+	if w.Header().Get("Cache-Control") != "" {
+		t.Error("unhashed assets must not be cached as immutable")
+	}
+	if got := w.Header().Get("Last-Modified"); got != modTime.Format(http.TimeFormat) {
+		t.Errorf("Last-Modified = %q", got)
+	}
+
+	req := httptest.NewRequest("GET", "/_assets/app.css", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("expected 304 for matching ETag, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest("GET", "/_assets/app.css", nil)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Body.String() != "brotli" || w.Header().Get("Content-Encoding") != "br" {
+		t.Errorf("expected the brotli sibling, got %q encoded %q", w.Body.String(), w.Header().Get("Content-Encoding"))
+	}
+	if w.Header().Get("Content-Type") != "text/css; charset=utf-8" || w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+	if w.Header().Get("ETag") == etag {
+		t.Error("encoded responses need their own ETag")
+	}
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/_assets/styles-1a2b3c4d.css", nil))
+	if got := w.Header().Get("Cache-Control"); got != "public, max-age=31536000, immutable" {
+		t.Errorf("hashed asset Cache-Control = %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	if !s.Serve(w, httptest.NewRequest("GET", "/", nil), "static/") || w.Body.String() != "<h1>Home</h1>" {
+		t.Errorf("expected directory index, got %q", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest("GET", "/../_assets/missing.css", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", w.Code)
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/withgalaxy/galaxy/pkg/codegen"
 	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/isr"
 	"github.com/withgalaxy/galaxy/pkg/router"
 )
 
@@ -101,6 +102,10 @@ func (b *HybridBuilder) Build() error {
 		return fmt.Errorf("copy assets: %w", err)
 	}
 
+	if err := precompress(b.Config, b.OutDir); err != nil {
+		return fmt.Errorf("precompress: %w", err)
+	}
+
 	return nil
 }
 
@@ -119,6 +124,10 @@ func (b *HybridBuilder) shouldPrerender(route *router.Route) bool {
 	}
 
 	src := string(content)
+	// Pages with a revalidate interval are rendered on demand and cached.
+	if _, ok := isr.ParseRoute(src); ok {
+		return false
+	}
 	return !strings.Contains(src, "prerender = false") && !strings.Contains(src, "prerender=false")
 }
 
@@ -129,5 +138,8 @@ func (b *HybridBuilder) generateServerForDynamicRoutes(serverDir string, routes
 	}
 
 	codegenBuilder := codegen.NewCodegenBuilder(routes, b.PagesDir, b.OutDir, moduleName, b.PublicDir)
+	codegenBuilder.Config = b.Config
+	codegenBuilder.StaticDir = b.OutDir
+	codegenBuilder.Precompress = b.Config.Compression.Precompress
 	return codegenBuilder.Build()
 }
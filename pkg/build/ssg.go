@@ -1,6 +1,7 @@
 package build
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
@@ -14,6 +15,7 @@ import (
 	"github.com/withgalaxy/galaxy/pkg/adapters/vercel"
 	"github.com/withgalaxy/galaxy/pkg/assets"
 	"github.com/withgalaxy/galaxy/pkg/compiler"
+	"github.com/withgalaxy/galaxy/pkg/compress"
 	"github.com/withgalaxy/galaxy/pkg/config"
 	"github.com/withgalaxy/galaxy/pkg/content"
 	"github.com/withgalaxy/galaxy/pkg/executor"
@@ -21,7 +23,10 @@ import (
 	"github.com/withgalaxy/galaxy/pkg/plugins"
 	"github.com/withgalaxy/galaxy/pkg/plugins/tailwind"
 	"github.com/withgalaxy/galaxy/pkg/router"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/ssr"
 	"github.com/withgalaxy/galaxy/pkg/template"
+	"github.com/withgalaxy/galaxy/pkg/wasm"
 )
 
 type SSGBuilder struct {
@@ -34,6 +39,9 @@ type SSGBuilder struct {
 	Bundler       *assets.Bundler
 	Compiler      *compiler.ComponentCompiler
 	PluginManager *plugins.Manager
+
+	// prefetch collects each page's assets for ssr.PrefetchManifestPath.
+	prefetch *wasm.WasmManifest
 }
 
 func NewSSGBuilder(cfg *config.Config, srcDir, pagesDir, outDir, publicDir string) *SSGBuilder {
@@ -92,6 +100,18 @@ func (b *SSGBuilder) Build() error {
 	resolver := compiler.NewComponentResolver(b.SrcDir, nil)
 	b.Compiler.SetResolver(resolver)
 
+	if err := b.precompileWasm(resolver); err != nil {
+		return err
+	}
+
+	// Integrity hashes are computed as pages are written, so wasm_exec.js
+	// has to be in place first.
+	if b.Config.Security.Headers.Integrity {
+		if err := b.copyWasmExec(); err != nil {
+			return fmt.Errorf("copy wasm_exec.js: %w", err)
+		}
+	}
+
 	for _, route := range b.Router.Routes {
 		if route.IsEndpoint {
 			continue
@@ -119,10 +139,26 @@ func (b *SSGBuilder) Build() error {
 		return fmt.Errorf("copy assets: %w", err)
 	}
 
+	if err := b.writeHydrationRuntime(); err != nil {
+		return fmt.Errorf("write hydration runtime: %w", err)
+	}
+
+	if err := b.writeRouterRuntime(); err != nil {
+		return fmt.Errorf("write router runtime: %w", err)
+	}
+
+	if err := b.writePrefetchRuntime(); err != nil {
+		return fmt.Errorf("write prefetch runtime: %w", err)
+	}
+
 	if err := b.PluginManager.BuildEnd(buildCtx); err != nil {
 		return fmt.Errorf("plugin BuildEnd: %w", err)
 	}
 
+	if err := precompress(b.Config, b.OutDir); err != nil {
+		return fmt.Errorf("precompress: %w", err)
+	}
+
 	if b.Config.Adapter.Name == config.AdapterVercel {
 		if err := b.runVercelAdapter(); err != nil {
 			return fmt.Errorf("vercel adapter: %w", err)
@@ -144,6 +180,14 @@ func (b *SSGBuilder) Build() error {
 	return nil
 }
 
+// precompress writes .gz and .br siblings of the static files in outDir.
+func precompress(cfg *config.Config, outDir string) error {
+	if !cfg.Compression.Precompress {
+		return nil
+	}
+	return compress.PrecompressDir(outDir, cfg.Compression.MinSize)
+}
+
 func (b *SSGBuilder) buildStaticRoute(route *router.Route) error {
 	content, err := os.ReadFile(route.FilePath)
 	if err != nil {
@@ -176,6 +220,7 @@ func (b *SSGBuilder) buildStaticRoute(route *router.Route) error {
 	}
 
 	b.Compiler.CollectedStyles = nil
+	b.Compiler.ResetComponentTracking()
 	processedTemplate := b.Compiler.ProcessComponentTags(comp.Template, ctx)
 
 	engine := template.NewEngine(ctx)
@@ -203,7 +248,7 @@ func (b *SSGBuilder) buildStaticRoute(route *router.Route) error {
 		return err
 	}
 
-	wasmAssets, err := b.Bundler.BundleWasmScripts(comp, route.FilePath)
+	wasmAssets, err := b.Bundler.BundleWasmScripts(comp, route.FilePath, b.Compiler.WasmSources()...)
 	if err != nil {
 		return err
 	}
@@ -216,6 +261,8 @@ func (b *SSGBuilder) buildStaticRoute(route *router.Route) error {
 		}
 	}
 
+	b.recordPrefetch(route, cssPath, jsPath, wasmAssets)
+
 	// Convert absolute asset paths to relative paths for static sites
 	outPath := b.getOutputPath(route.Pattern)
 	cssPath = b.makePathRelative(cssPath, outPath)
@@ -226,6 +273,9 @@ func (b *SSGBuilder) buildStaticRoute(route *router.Route) error {
 	}
 
 	rendered = b.Bundler.InjectAssetsWithWasm(rendered, cssPath, jsPath, scopeID, wasmAssets)
+	rendered = ssr.InjectState(rendered, ctx.State)
+	rendered = ssr.InjectPrefetch(rendered, b.Config.Prefetch)
+	rendered = b.secureHTML(rendered, outPath)
 
 	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
 		return err
@@ -307,6 +357,7 @@ func (b *SSGBuilder) buildDynamicRoute(route *router.Route) error {
 		resolver.ParseImports(imports)
 
 		b.Compiler.CollectedStyles = nil
+		b.Compiler.ResetComponentTracking()
 		processedTemplate := b.Compiler.ProcessComponentTags(comp.Template, ctx)
 
 		engine := template.NewEngine(ctx)
@@ -334,7 +385,7 @@ func (b *SSGBuilder) buildDynamicRoute(route *router.Route) error {
 			return err
 		}
 
-		wasmAssets, err := b.Bundler.BundleWasmScripts(comp, route.FilePath)
+		wasmAssets, err := b.Bundler.BundleWasmScripts(comp, route.FilePath, b.Compiler.WasmSources()...)
 		if err != nil {
 			return err
 		}
@@ -347,6 +398,8 @@ func (b *SSGBuilder) buildDynamicRoute(route *router.Route) error {
 			}
 		}
 
+		b.recordPrefetch(route, cssPath, jsPath, wasmAssets)
+
 		// Create output path by replacing [param] with actual value
 		pattern := strings.Replace(route.Pattern, "["+paramName+"]", slug, 1)
 		outPath := b.getOutputPath(pattern)
@@ -360,6 +413,9 @@ func (b *SSGBuilder) buildDynamicRoute(route *router.Route) error {
 		}
 
 		rendered = b.Bundler.InjectAssetsWithWasm(rendered, cssPath, jsPath, scopeID, wasmAssets)
+		rendered = ssr.InjectState(rendered, ctx.State)
+		rendered = ssr.InjectPrefetch(rendered, b.Config.Prefetch)
+		rendered = b.secureHTML(rendered, outPath)
 
 		if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
 			return err
@@ -375,6 +431,34 @@ func (b *SSGBuilder) buildDynamicRoute(route *router.Route) error {
 	return nil
 }
 
+// precompileWasm builds the modules of every page in parallel, before
+// pages are rendered one at a time.
+func (b *SSGBuilder) precompileWasm(resolver *compiler.ComponentResolver) error {
+	var paths []string
+	for _, route := range b.Router.Routes {
+		if !route.IsEndpoint && route.Type != router.RouteMarkdown {
+			paths = append(paths, route.FilePath)
+		}
+	}
+	comps := compiler.NewComponentCompiler(b.SrcDir)
+	comps.SetResolver(resolver)
+	return b.Bundler.PrecompileWasm(comps.WasmPages(paths))
+}
+
+// secureHTML adds integrity attributes and, since static pages can't carry a
+// per-request nonce, a hash-based CSP meta tag. Servers that serve the page
+// move the tag to a header.
+func (b *SSGBuilder) secureHTML(html, outPath string) string {
+	headers := b.Config.Security.Headers
+	if headers.Integrity {
+		html = security.AddIntegrity(html, b.OutDir, filepath.Dir(outPath))
+	}
+	if security.StaticCSPEnabled(headers) {
+		return security.InjectStaticCSP(html, headers.ContentSecurityPolicy)
+	}
+	return security.InjectCSPNonce(html, nil)
+}
+
 func (b *SSGBuilder) detectCollectionFromFrontmatter(frontmatter string, paramName string) string {
 	// Look for Galaxy.Content.Get("collectionName", Galaxy.Params["paramName"])
 	pattern := regexp.MustCompile(`Galaxy\.Content\.Get\("([^"]+)",\s*Galaxy\.Params\["` + paramName + `"\]`)
@@ -452,6 +536,73 @@ func (b *SSGBuilder) copyPublicAssets() error {
 	return b.copyWasmExec()
 }
 
+// writeHydrationRuntime emits the script islands import, see
+// ssr.HydrationPath.
+func (b *SSGBuilder) writeHydrationRuntime() error {
+	return b.writeRuntime(ssr.HydrationPath, ssr.HydrationRuntime)
+}
+
+// writeRouterRuntime emits the script <ClientRouter/> loads, see
+// ssr.RouterPath.
+func (b *SSGBuilder) writeRouterRuntime() error {
+	return b.writeRuntime(ssr.RouterPath, ssr.RouterRuntime)
+}
+
+// writePrefetchRuntime emits the prefetch script and the files each page
+// loads, see ssr.PrefetchPath, when [prefetch] is enabled.
+func (b *SSGBuilder) writePrefetchRuntime() error {
+	if !b.Config.Prefetch.Enabled {
+		return nil
+	}
+	if err := b.writeRuntime(ssr.PrefetchPath, ssr.PrefetchRuntime); err != nil {
+		return err
+	}
+	routes := map[string][]string{}
+	if b.prefetch != nil {
+		routes = b.prefetch.Prefetch()
+	}
+	data, err := json.Marshal(routes)
+	if err != nil {
+		return err
+	}
+	return b.writeRuntime(ssr.PrefetchManifestPath, string(data))
+}
+
+// recordPrefetch notes the assets of route's pages, before they are made
+// relative to each page.
+func (b *SSGBuilder) recordPrefetch(route *router.Route, cssPath, jsPath string, wasmAssets []assets.WasmAsset) {
+	pageAssets := wasm.WasmPageAssets{}
+	if cssPath != "" {
+		pageAssets.CSS = []string{cssPath}
+	}
+	for _, asset := range wasmAssets {
+		pageAssets.WasmModules = append(pageAssets.WasmModules, wasm.WasmModule{
+			WasmPath:   asset.WasmPath,
+			LoaderPath: asset.LoaderPath,
+		})
+	}
+	if jsPath != "" {
+		pageAssets.JSScripts = []string{jsPath}
+	}
+
+	relPath, err := filepath.Rel(b.PagesDir, route.FilePath)
+	if err != nil {
+		relPath = route.FilePath
+	}
+	if b.prefetch == nil {
+		b.prefetch = wasm.NewManifest()
+	}
+	b.prefetch.Assets["pages/"+filepath.ToSlash(relPath)] = pageAssets
+}
+
+func (b *SSGBuilder) writeRuntime(path, script string) error {
+	dest := filepath.Join(b.OutDir, filepath.FromSlash(strings.TrimPrefix(path, "/")))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dest, []byte(script), 0644)
+}
+
 func (b *SSGBuilder) copyWasmExec() error {
 	goRoot := os.Getenv("GOROOT")
 	if goRoot == "" {
@@ -9,6 +9,7 @@ import (
 
 	"github.com/withgalaxy/galaxy/pkg/assets"
 	"github.com/withgalaxy/galaxy/pkg/codegen"
+	"github.com/withgalaxy/galaxy/pkg/compiler"
 	"github.com/withgalaxy/galaxy/pkg/config"
 	"github.com/withgalaxy/galaxy/pkg/parser"
 	"github.com/withgalaxy/galaxy/pkg/plugins"
@@ -107,6 +108,10 @@ func (b *SSRBuilder) Build() error {
 		return fmt.Errorf("compile server: %w", err)
 	}
 
+	if err := precompress(b.Config, b.OutDir); err != nil {
+		return fmt.Errorf("precompress: %w", err)
+	}
+
 	if err := b.PluginManager.BuildEnd(buildCtx); err != nil {
 		return fmt.Errorf("plugin BuildEnd: %w", err)
 	}
@@ -121,6 +126,8 @@ func (b *SSRBuilder) generateServerCode(serverDir string) error {
 	}
 
 	codegenBuilder := codegen.NewCodegenBuilder(b.Router.Routes, b.PagesDir, b.OutDir, moduleName, b.PublicDir)
+	codegenBuilder.Config = b.Config
+	codegenBuilder.Precompress = b.Config.Compression.Precompress
 	return codegenBuilder.Build()
 }
 
@@ -186,6 +193,17 @@ func detectModuleName() (string, error) {
 
 func (b *SSRBuilder) precompileWasmScripts() error {
 	manifest := wasm.NewManifest()
+	comps := compiler.NewComponentCompiler(b.SrcDir)
+
+	var paths []string
+	for _, route := range b.Router.Routes {
+		if !route.IsEndpoint {
+			paths = append(paths, route.FilePath)
+		}
+	}
+	if err := b.Bundler.PrecompileWasm(comps.WasmPages(paths)); err != nil {
+		return err
+	}
 
 	for _, route := range b.Router.Routes {
 		if route.IsEndpoint {
@@ -202,7 +220,7 @@ func (b *SSRBuilder) precompileWasmScripts() error {
 			continue
 		}
 
-		wasmAssets, err := b.Bundler.BundleWasmScripts(comp, route.FilePath)
+		wasmAssets, err := b.Bundler.BundleWasmScripts(comp, route.FilePath, comps.PageWasmSources(comp, route.FilePath)...)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,191 @@
+// Package cache memoizes slow lookups made by pages and endpoints, such as
+// calls to internal services, across requests.
+package cache
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Entry is a cached value.
+type Entry struct {
+	Value any
+	// Expires is when the entry stops being served. Zero never expires.
+	Expires time.Time
+	Tags    []string
+}
+
+// Expired reports whether e is past its expiry at now.
+func (e Entry) Expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !now.Before(e.Expires)
+}
+
+// Store holds cache entries. A store that also implements io.Closer is
+// closed when the cache shuts down.
+type Store interface {
+	Get(key string) (Entry, bool)
+	Set(key string, entry Entry)
+	Delete(keys ...string)
+	// DeleteTagged removes the entries carrying any of tags and returns how
+	// many were removed.
+	DeleteTagged(tags ...string) int
+}
+
+// DefaultSize is how many entries the Default cache keeps in memory.
+const DefaultSize = 1000
+
+// Default is the cache behind Galaxy.Cache and endpoints' Context.Cache.
+var Default = New(NewMemory(DefaultSize))
+
+// Cache reads through to a Store and runs one fetch at a time per key, so
+// concurrent requests for a missing key share a single call.
+type Cache struct {
+	mu    sync.Mutex
+	store Store
+	calls map[string]*call
+	// invalidations counts Invalidate and InvalidateTags calls so fetches
+	// that started before one don't store what it removed.
+	invalidations int
+}
+
+type call struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+// New returns a cache backed by store.
+func New(store Store) *Cache {
+	return &Cache{store: store, calls: map[string]*call{}}
+}
+
+// Use replaces the cache's store, dropping everything held by the old one.
+// Call it from a lifecycle OnStartup hook to back Default with a shared store.
+func (c *Cache) Use(store Store) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store = store
+	c.invalidations++
+}
+
+// Get returns the value cached at key, if it has not expired.
+func (c *Cache) Get(key string) (any, bool) {
+	c.mu.Lock()
+	store := c.store
+	c.mu.Unlock()
+
+	entry, ok := store.Get(key)
+	if !ok || entry.Expired(time.Now()) {
+		return nil, false
+	}
+	return entry.Value, true
+}
+
+// Set caches value at key for ttl, or until it is invalidated when ttl is
+// zero.
+func (c *Cache) Set(key string, value any, ttl time.Duration, tags ...string) {
+	entry := Entry{Value: value, Tags: tags}
+	if ttl > 0 {
+		entry.Expires = time.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	store := c.store
+	c.mu.Unlock()
+	store.Set(key, entry)
+}
+
+// GetOrSet returns the value cached at key, calling fn and caching its
+// result for ttl on a miss. Callers asking for the same key while fn runs
+// wait for it instead of calling fn again. Errors are returned to every
+// waiting caller but not cached.
+func (c *Cache) GetOrSet(key string, ttl time.Duration, fn func() (any, error), tags ...string) (any, error) {
+	if value, ok := c.Get(key); ok {
+		return value, nil
+	}
+
+	c.mu.Lock()
+	if inflight, ok := c.calls[key]; ok {
+		c.mu.Unlock()
+		<-inflight.done
+		return inflight.value, inflight.err
+	}
+	fetch := &call{done: make(chan struct{})}
+	c.calls[key] = fetch
+	invalidations := c.invalidations
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, key)
+		c.mu.Unlock()
+		close(fetch.done)
+	}()
+
+	fetch.value, fetch.err = fn()
+	if fetch.err != nil {
+		return nil, fetch.err
+	}
+
+	c.mu.Lock()
+	current := c.invalidations == invalidations
+	c.mu.Unlock()
+	if current {
+		c.Set(key, fetch.value, ttl, tags...)
+	}
+	return fetch.value, nil
+}
+
+// Invalidate removes the entries at keys.
+func (c *Cache) Invalidate(keys ...string) {
+	c.mu.Lock()
+	c.invalidations++
+	store := c.store
+	c.mu.Unlock()
+	store.Delete(keys...)
+}
+
+// InvalidateTags removes the entries carrying any of tags and returns how
+// many were removed.
+func (c *Cache) InvalidateTags(tags ...string) int {
+	c.mu.Lock()
+	c.invalidations++
+	store := c.store
+	c.mu.Unlock()
+	return store.DeleteTagged(tags...)
+}
+
+// OnStartup implements lifecycle.LifecycleHook.
+func (c *Cache) OnStartup() error {
+	return nil
+}
+
+// OnShutdown implements lifecycle.LifecycleHook, closing the store if it
+// holds connections or files.
+func (c *Cache) OnShutdown() error {
+	c.mu.Lock()
+	store := c.store
+	c.mu.Unlock()
+	if closer, ok := store.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// GetOrSet is Cache.GetOrSet for values of a known type.
+func GetOrSet[T any](c *Cache, key string, ttl time.Duration, fn func() (T, error), tags ...string) (T, error) {
+	value, err := c.GetOrSet(key, ttl, func() (any, error) {
+		return fn()
+	}, tags...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	typed, ok := value.(T)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("cache: %s holds %T, not %T", key, value, zero)
+	}
+	return typed, nil
+}
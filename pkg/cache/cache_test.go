@@ -0,0 +1,144 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCache_GetOrSet(t *testing.T) {
+	c := New(NewMemory(10))
+	var calls atomic.Int32
+	fetch := func() (any, error) {
+		calls.Add(1)
+		return "value", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		v, err := c.GetOrSet("key", time.Minute, fetch)
+		if err != nil || v != "value" {
+			t.Fatalf("got %v %v", v, err)
+		}
+	}
+	if calls.Load() != 1 {
+		t.Errorf("fn called %d times", calls.Load())
+	}
+
+	if _, err := c.GetOrSet("failing", time.Minute, func() (any, error) {
+		return nil, errors.New("unavailable")
+	}); err == nil {
+		t.Error("expected the fetch error")
+	}
+	if _, ok := c.Get("failing"); ok {
+		t.Error("errors should not be cached")
+	}
+}
+
+func TestCache_GetOrSetDeduplicates(t *testing.T) {
+	c := New(NewMemory(10))
+	var calls atomic.Int32
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrSet("slow", time.Minute, func() (any, error) {
+				calls.Add(1)
+				<-release
+				return 42, nil
+			})
+			if err != nil || v != 42 {
+				t.Errorf("got %v %v", v, err)
+			}
+		}()
+	}
+	for deadline := time.Now().Add(2 * time.Second); calls.Load() == 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if calls.Load() != 1 {
+		t.Errorf("concurrent fetches of one key made %d calls", calls.Load())
+	}
+}
+
+func TestCache_Expiry(t *testing.T) {
+	c := New(NewMemory(10))
+	c.Set("short", "v", time.Nanosecond)
+	c.Set("forever", "v", 0)
+	time.Sleep(time.Millisecond)
+
+	if _, ok := c.Get("short"); ok {
+		t.Error("expired entry was served")
+	}
+	if _, ok := c.Get("forever"); !ok {
+		t.Error("entry without a ttl should not expire")
+	}
+}
+
+func TestCache_InvalidateTags(t *testing.T) {
+	c := New(NewMemory(10))
+	c.Set("post:1", "a", time.Minute, "posts")
+	c.Set("post:2", "b", time.Minute, "posts", "featured")
+	c.Set("user:1", "c", time.Minute, "users")
+
+	if n := c.InvalidateTags("posts"); n != 2 {
+		t.Errorf("InvalidateTags removed %d entries", n)
+	}
+	if _, ok := c.Get("post:2"); ok {
+		t.Error("tagged entry survived")
+	}
+	if _, ok := c.Get("user:1"); !ok {
+		t.Error("untagged entry was removed")
+	}
+
+	c.Invalidate("user:1")
+	if _, ok := c.Get("user:1"); ok {
+		t.Error("Invalidate left the entry")
+	}
+}
+
+func TestCache_InvalidateDuringFetch(t *testing.T) {
+	c := New(NewMemory(10))
+	c.GetOrSet("key", time.Minute, func() (any, error) {
+		c.InvalidateTags("posts")
+		return "stale", nil
+	}, "posts")
+	if _, ok := c.Get("key"); ok {
+		t.Error("a value fetched before an invalidation was cached")
+	}
+}
+
+func TestMemory_EvictsLeastRecentlyUsed(t *testing.T) {
+	m := NewMemory(2)
+	m.Set("a", Entry{Value: 1})
+	m.Set("b", Entry{Value: 2})
+	m.Get("a")
+	m.Set("c", Entry{Value: 3})
+
+	if _, ok := m.Get("b"); ok {
+		t.Error("least recently used entry was kept")
+	}
+	if _, ok := m.Get("a"); !ok {
+		t.Error("recently read entry was evicted")
+	}
+	if m.Len() != 2 {
+		t.Errorf("Len() = %d", m.Len())
+	}
+}
+
+func TestGetOrSetTyped(t *testing.T) {
+	c := New(NewMemory(10))
+	n, err := GetOrSet(c, "count", time.Minute, func() (int, error) { return 7, nil })
+	if err != nil || n != 7 {
+		t.Fatalf("got %d %v", n, err)
+	}
+	if _, err := GetOrSet(c, "count", time.Minute, func() (string, error) { return "", nil }); err == nil {
+		t.Error("expected a type mismatch error")
+	}
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Memory is an in-memory Store that evicts the least recently used entry
+// once it holds its maximum.
+type Memory struct {
+	mu      sync.Mutex
+	max     int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry Entry
+}
+
+// NewMemory returns a store holding at most max entries, or any number when
+// max is zero.
+func NewMemory(max int) *Memory {
+	return &Memory{max: max, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (m *Memory) Get(key string) (Entry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	el, ok := m.entries[key]
+	if !ok {
+		return Entry{}, false
+	}
+	item := el.Value.(*memoryItem)
+	if item.entry.Expired(time.Now()) {
+		m.remove(el)
+		return Entry{}, false
+	}
+	m.order.MoveToFront(el)
+	return item.entry, true
+}
+
+func (m *Memory) Set(key string, entry Entry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if el, ok := m.entries[key]; ok {
+		el.Value.(*memoryItem).entry = entry
+		m.order.MoveToFront(el)
+		return
+	}
+	m.entries[key] = m.order.PushFront(&memoryItem{key: key, entry: entry})
+	if m.max > 0 && m.order.Len() > m.max {
+		m.remove(m.order.Back())
+	}
+}
+
+func (m *Memory) Delete(keys ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := m.entries[key]; ok {
+			m.remove(el)
+		}
+	}
+}
+
+func (m *Memory) DeleteTagged(tags ...string) int {
+	tagged := map[string]bool{}
+	for _, tag := range tags {
+		tagged[tag] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	removed := 0
+	for el := m.order.Front(); el != nil; {
+		next := el.Next()
+		for _, tag := range el.Value.(*memoryItem).entry.Tags {
+			if tagged[tag] {
+				m.remove(el)
+				removed++
+				break
+			}
+		}
+		el = next
+	}
+	return removed
+}
+
+// Len returns how many entries the store holds, including expired ones not
+// yet evicted.
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+func (m *Memory) remove(el *list.Element) {
+	m.order.Remove(el)
+	delete(m.entries, el.Value.(*memoryItem).key)
+}
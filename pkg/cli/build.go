@@ -6,6 +6,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/withgalaxy/galaxy/internal/wasm"
 	"github.com/withgalaxy/galaxy/pkg/build"
 	"github.com/withgalaxy/galaxy/pkg/config"
 	"github.com/spf13/cobra"
@@ -87,6 +88,12 @@ func runBuild(cmd *cobra.Command, args []string) error {
 		fmt.Println()
 	}
 
+	if buildForce {
+		if _, err := wasm.DefaultCache.Clean(0); err != nil {
+			return fmt.Errorf("clean cache: %w", err)
+		}
+	}
+
 	var buildErr error
 
 	if cfg.IsStatic() {
@@ -109,6 +116,9 @@ func runBuild(cmd *cobra.Command, args []string) error {
 	duration := time.Since(start)
 
 	if !silent {
+		if stats := wasm.DefaultCache.Stats(); stats.Hits+stats.Misses > 0 {
+			fmt.Printf("\n🧩 WASM modules: %d compiled, %d from cache\n", stats.Misses, stats.Hits)
+		}
 		fmt.Printf("\n✅ Build complete in %v\n", duration.Round(time.Millisecond))
 		fmt.Printf("📂 Output: %s\n", outDir)
 	}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/withgalaxy/galaxy/internal/wasm"
+)
+
+var cacheCleanOlderThan time.Duration
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the WebAssembly build cache",
+	Long: `Galaxy keeps compiled WebAssembly modules across builds and projects,
+in your user cache directory or in $GALAXY_CACHE_DIR when it is set.`,
+}
+
+var cacheInfoCmd = &cobra.Command{
+	Use:   "info",
+	Short: "Show where the build cache is and how large it is",
+	RunE:  runCacheInfo,
+}
+
+var cacheCleanCmd = &cobra.Command{
+	Use:   "clean",
+	Short: "Remove cached WebAssembly modules",
+	RunE:  runCacheClean,
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheInfoCmd)
+	cacheCmd.AddCommand(cacheCleanCmd)
+	cacheCleanCmd.Flags().DurationVar(&cacheCleanOlderThan, "older-than", 0, "only remove modules unused for this long, e.g. 720h")
+}
+
+func runCacheInfo(cmd *cobra.Command, args []string) error {
+	info, err := wasm.DefaultCache.Info()
+	if err != nil {
+		return fmt.Errorf("read cache: %w", err)
+	}
+	fmt.Printf("Directory                %s\n", wasm.DefaultCache.Dir)
+	fmt.Printf("Modules                  %d\n", info.Modules)
+	fmt.Printf("Size                     %s\n", formatBytes(info.Size))
+	return nil
+}
+
+func runCacheClean(cmd *cobra.Command, args []string) error {
+	removed, err := wasm.DefaultCache.Clean(cacheCleanOlderThan)
+	if err != nil {
+		return fmt.Errorf("clean cache: %w", err)
+	}
+	if !silent {
+		fmt.Printf("🧹 Removed %d cached modules (%s)\n", removed.Modules, formatBytes(removed.Size))
+	}
+	return nil
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
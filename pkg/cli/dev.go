@@ -49,7 +49,7 @@ func runDev(cmd *cobra.Command, args []string) error {
 		cwd = rootDir
 	}
 
-	_, err = config.LoadFromDir(cwd)
+	galaxyCfg, err := config.LoadFromDir(cwd)
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
@@ -89,6 +89,9 @@ func runDev(cmd *cobra.Command, args []string) error {
 	}
 
 	galaxyPlugin := galaxyOrbit.NewGalaxyPlugin(cwd, pagesDir, publicDir)
+	if err := galaxyPlugin.SetConfig(galaxyCfg); err != nil {
+		return err
+	}
 	if devNoCodegen {
 		galaxyPlugin.UseCodegen = false
 	} else {
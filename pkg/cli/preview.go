@@ -7,6 +7,9 @@ import (
 	"path/filepath"
 
 	"github.com/spf13/cobra"
+	"github.com/withgalaxy/galaxy/pkg/assets"
+	"github.com/withgalaxy/galaxy/pkg/compress"
+	"github.com/withgalaxy/galaxy/pkg/config"
 )
 
 var (
@@ -44,8 +47,17 @@ func runPreview(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("dist directory not found. Run 'galaxy build' first")
 	}
 
-	fs := http.FileServer(http.Dir(distDir))
-	http.Handle("/", fs)
+	cfg, err := config.LoadFromDir(cwd)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	// Serves the .br and .gz siblings written by the build when accepted.
+	var handler http.Handler = assets.NewFileServer(os.DirFS(distDir))
+	if cfg.Compression.Enabled {
+		handler = compress.Handler(cfg.Compression, handler)
+	}
+	http.Handle("/", handler)
 
 	addr := fmt.Sprintf("%s:%d", previewHost, previewPort)
 
@@ -11,9 +11,12 @@ import (
 
 	"github.com/withgalaxy/galaxy/pkg/assets"
 	"github.com/withgalaxy/galaxy/pkg/compiler"
+	"github.com/withgalaxy/galaxy/pkg/compress"
+	"github.com/withgalaxy/galaxy/pkg/config"
 	"github.com/withgalaxy/galaxy/pkg/executor"
 	"github.com/withgalaxy/galaxy/pkg/parser"
 	"github.com/withgalaxy/galaxy/pkg/router"
+	"github.com/withgalaxy/galaxy/pkg/security"
 	"github.com/withgalaxy/galaxy/pkg/version"
 	"github.com/withgalaxy/galaxy/pkg/wasm"
 )
@@ -27,6 +30,16 @@ type CodegenBuilder struct {
 	PublicDir      string
 	Bundler        *assets.Bundler
 	ManifestPath   string
+	Config         *config.Config
+	// StaticDir holds prerendered pages to embed alongside the server.
+	StaticDir string
+	// Precompress writes .gz and .br siblings of the server's assets.
+	// Production builds set it from [compression] precompress.
+	Precompress bool
+
+	// cssPaths maps each page's file to its bundled stylesheet, listed in
+	// the manifest for prefetching.
+	cssPaths map[string]string
 }
 
 func NewCodegenBuilder(routes []*router.Route, pagesDir, outDir, moduleName, publicDir string) *CodegenBuilder {
@@ -81,7 +94,7 @@ func (b *CodegenBuilder) Build() error {
 		}
 
 		// Process component tags (<Layout>, <Nav>, etc.) before codegen
-		processedComp, err := b.processComponentTags(comp, route)
+		processedComp, state, err := b.processComponentTags(comp, route)
 		if err != nil {
 			return fmt.Errorf("process components for %s: %w", route.Pattern, err)
 		}
@@ -94,9 +107,14 @@ func (b *CodegenBuilder) Build() error {
 				return fmt.Errorf("bundle styles for %s: %w", route.Pattern, err)
 			}
 		}
+		if b.cssPaths == nil {
+			b.cssPaths = make(map[string]string)
+		}
+		b.cssPaths[route.FilePath] = cssPath
 
 		gen := NewHandlerGenerator(processedComp, route, b.ModuleName, b.PagesDir)
 		gen.CSSPath = cssPath
+		gen.ComponentState = state
 		handler, err := gen.Generate()
 		if err != nil {
 			return fmt.Errorf("generate handler for %s: %w", route.Pattern, err)
@@ -115,6 +133,17 @@ func (b *CodegenBuilder) Build() error {
 	mainGen := NewMainGenerator(handlers, nonEndpointRoutes, b.ModuleName, manifestPath)
 	mainGen.HasMiddleware = hasMiddleware
 	mainGen.Endpoints = endpoints
+	mainGen.Config = b.Config
+	mainGen.StaticPages = b.embed() && b.StaticDir != ""
+	if b.Config != nil && b.Config.Security.RateLimit.Enabled {
+		limiter, err := security.NewRateLimiterFromConfig(b.Config, filepath.Dir(filepath.Dir(b.PagesDir)))
+		if err != nil {
+			return err
+		}
+		if limiter != nil {
+			mainGen.RateLimitPage = limiter.Page
+		}
+	}
 	mainGo := mainGen.Generate()
 
 	if err := os.WriteFile(filepath.Join(serverDir, "main.go"), []byte(mainGo), 0644); err != nil {
@@ -155,6 +184,24 @@ func (b *CodegenBuilder) Build() error {
 		return fmt.Errorf("copy wasm exec: %w", err)
 	}
 
+	if b.embed() {
+		if err := b.copyStaticPages(serverDir); err != nil {
+			return fmt.Errorf("copy static pages: %w", err)
+		}
+		if err := b.writeEmbed(serverDir); err != nil {
+			return fmt.Errorf("write embed: %w", err)
+		}
+	} else {
+		os.Remove(filepath.Join(serverDir, "embed.go"))
+	}
+
+	// Siblings are written before compiling so embed mode includes them.
+	if b.Precompress && b.Config != nil {
+		if err := compress.PrecompressDir(serverDir, b.Config.Compression.MinSize); err != nil {
+			return fmt.Errorf("precompress: %w", err)
+		}
+	}
+
 	if err := b.compile(serverDir); err != nil {
 		return err
 	}
@@ -162,6 +209,10 @@ func (b *CodegenBuilder) Build() error {
 	return nil
 }
 
+func (b *CodegenBuilder) embed() bool {
+	return b.Config != nil && b.Config.Embed()
+}
+
 func (b *CodegenBuilder) copyMiddleware(serverDir string) error {
 	if _, err := os.Stat(b.MiddlewarePath); os.IsNotExist(err) {
 		return nil
@@ -347,6 +398,15 @@ func (b *CodegenBuilder) copyPublicAssets(serverDir string) error {
 func (b *CodegenBuilder) compileWasmScripts(routes []*router.Route) error {
 	manifest := wasm.NewManifest()
 
+	paths := make([]string, len(routes))
+	for i, route := range routes {
+		paths[i] = route.FilePath
+	}
+	comps := compiler.NewComponentCompiler(filepath.Dir(b.PagesDir))
+	if err := b.Bundler.PrecompileWasm(comps.WasmPages(paths)); err != nil {
+		return err
+	}
+
 	for _, route := range routes {
 
 		content, err := os.ReadFile(route.FilePath)
@@ -359,7 +419,7 @@ func (b *CodegenBuilder) compileWasmScripts(routes []*router.Route) error {
 			continue
 		}
 
-		wasmAssets, err := b.Bundler.BundleWasmScripts(comp, route.FilePath)
+		wasmAssets, err := b.Bundler.BundleWasmScripts(comp, route.FilePath, comps.PageWasmSources(comp, route.FilePath)...)
 		if err != nil {
 			return err
 		}
@@ -369,11 +429,15 @@ func (b *CodegenBuilder) compileWasmScripts(routes []*router.Route) error {
 			return err
 		}
 
-		if len(wasmAssets) == 0 && jsPath == "" {
+		cssPath := b.cssPaths[route.FilePath]
+		if len(wasmAssets) == 0 && jsPath == "" && cssPath == "" {
 			continue
 		}
 
 		pageAssets := wasm.WasmPageAssets{}
+		if cssPath != "" {
+			pageAssets.CSS = []string{cssPath}
+		}
 		for _, asset := range wasmAssets {
 			hash := extractHash(asset.LoaderPath)
 			pageAssets.WasmModules = append(pageAssets.WasmModules, wasm.WasmModule{
@@ -445,7 +509,9 @@ func extractHash(loaderPath string) string {
 	return ""
 }
 
-func (b *CodegenBuilder) processComponentTags(comp *parser.Component, route *router.Route) (*parser.Component, error) {
+// processComponentTags renders the page's components at build time. It also
+// returns the state they registered with Galaxy.SetState, as JSON.
+func (b *CodegenBuilder) processComponentTags(comp *parser.Component, route *router.Route) (*parser.Component, map[string]json.RawMessage, error) {
 	// Create a compiler instance for component resolution
 	srcDir := filepath.Dir(b.PagesDir)
 	compilerInstance := compiler.NewComponentCompiler(srcDir)
@@ -472,6 +538,15 @@ func (b *CodegenBuilder) processComponentTags(comp *parser.Component, route *rou
 	compilerInstance.CollectedStyles = nil
 	processedTemplate := compilerInstance.ProcessComponentTags(comp.Template, ctx)
 
+	state := make(map[string]json.RawMessage, len(ctx.State))
+	for key, value := range ctx.State {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, nil, fmt.Errorf("component state %q: %w", key, err)
+		}
+		state[key] = data
+	}
+
 	// Return new component with processed template and collected styles
 	return &parser.Component{
 		Frontmatter: comp.Frontmatter,
@@ -479,7 +554,7 @@ func (b *CodegenBuilder) processComponentTags(comp *parser.Component, route *rou
 		Scripts:     comp.Scripts,
 		Styles:      append(comp.Styles, compilerInstance.CollectedStyles...),
 		Imports:     comp.Imports,
-	}, nil
+	}, state, nil
 }
 
 func (b *CodegenBuilder) copyWasmExec(serverDir string) error {
@@ -554,7 +629,7 @@ func (b *CodegenBuilder) RebuildPage(changedFilePath string) error {
 	}
 
 	// Process component tags
-	processedComp, err := b.processComponentTags(comp, changedRoute)
+	processedComp, state, err := b.processComponentTags(comp, changedRoute)
 	if err != nil {
 		return fmt.Errorf("process components: %w", err)
 	}
@@ -571,6 +646,7 @@ func (b *CodegenBuilder) RebuildPage(changedFilePath string) error {
 	// Generate new handler
 	gen := NewHandlerGenerator(processedComp, changedRoute, b.ModuleName, b.PagesDir)
 	gen.CSSPath = cssPath
+	gen.ComponentState = state
 	handler, err := gen.Generate()
 	if err != nil {
 		return fmt.Errorf("generate handler: %w", err)
@@ -592,7 +668,8 @@ func (b *CodegenBuilder) RebuildPage(changedFilePath string) error {
 	}
 
 	// Rebuild WASM assets if needed
-	wasmAssets, err := b.Bundler.BundleWasmScripts(comp, changedRoute.FilePath)
+	components := compiler.NewComponentCompiler(filepath.Dir(b.PagesDir)).PageWasmSources(comp, changedRoute.FilePath)
+	wasmAssets, err := b.Bundler.BundleWasmScripts(comp, changedRoute.FilePath, components...)
 	if err != nil {
 		return fmt.Errorf("bundle wasm: %w", err)
 	}
@@ -601,9 +678,11 @@ func (b *CodegenBuilder) RebuildPage(changedFilePath string) error {
 		if err := b.copyWasmAssets(serverDir); err != nil {
 			return fmt.Errorf("copy wasm assets: %w", err)
 		}
+	}
 
-		// Update WASM manifest with new assets
-		if err := b.updateManifestForPage(changedRoute, wasmAssets); err != nil {
+	// Update WASM manifest with new assets
+	if len(wasmAssets) > 0 || cssPath != "" {
+		if err := b.updateManifestForPage(changedRoute, cssPath, wasmAssets); err != nil {
 			return fmt.Errorf("update manifest: %w", err)
 		}
 	}
@@ -616,7 +695,7 @@ func (b *CodegenBuilder) RebuildPage(changedFilePath string) error {
 	return nil
 }
 
-func (b *CodegenBuilder) updateManifestForPage(route *router.Route, wasmAssets []assets.WasmAsset) error {
+func (b *CodegenBuilder) updateManifestForPage(route *router.Route, cssPath string, wasmAssets []assets.WasmAsset) error {
 	// Load existing manifest
 	var manifest *wasm.WasmManifest
 	if data, err := os.ReadFile(b.ManifestPath); err == nil {
@@ -630,6 +709,9 @@ func (b *CodegenBuilder) updateManifestForPage(route *router.Route, wasmAssets [
 
 	// Update the page's assets
 	pageAssets := wasm.WasmPageAssets{}
+	if cssPath != "" {
+		pageAssets.CSS = []string{cssPath}
+	}
 	for _, asset := range wasmAssets {
 		hash := extractHash(asset.LoaderPath)
 		pageAssets.WasmModules = append(pageAssets.WasmModules, wasm.WasmModule{
@@ -653,15 +735,12 @@ func (b *CodegenBuilder) updateManifestForPage(route *router.Route, wasmAssets [
 func replaceHandlerFunction(mainContent string, handler *GeneratedHandler) string {
 	funcName := handler.FunctionName
 
-	// Pattern matches:
-	// 1. func HandleXxx(...) { ... }
-	// 2. Followed by: const templateHandleXxx = `...`
-	// The (?s) makes . match newlines
+	// Pattern matches func HandleXxx(...) { ... } up to the response write
+	// that ends every generated handler. The (?s) makes . match newlines
 	funcPattern := regexp.MustCompile(
-		`(?s)func ` + regexp.QuoteMeta(funcName) + `\([^)]+\) \{.*?\n\}\n\nconst template` +
-			regexp.QuoteMeta(funcName) + ` = ` + "`" + `.*?` + "`",
+		`(?s)func ` + regexp.QuoteMeta(funcName) + `\(.*?\n\truntime\.WritePage\(w, r, html, \w+\)\n\}\n`,
 	)
 
 	// Replace with new handler code
-	return funcPattern.ReplaceAllString(mainContent, handler.Code)
+	return funcPattern.ReplaceAllLiteralString(mainContent, handler.Code)
 }
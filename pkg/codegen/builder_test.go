@@ -3,8 +3,10 @@ package codegen
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/withgalaxy/galaxy/pkg/parser"
 	"github.com/withgalaxy/galaxy/pkg/router"
 )
 
@@ -206,3 +208,74 @@ func hasSubstring(s, substr string) bool {
 	}
 	return false
 }
+
+func TestReplaceHandlerFunction(t *testing.T) {
+	route := &router.Route{Pattern: "/about", FilePath: "/p/pages/about.gxc"}
+	generate := func(src string) *GeneratedHandler {
+		comp, err := parser.Parse(src)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h, err := NewHandlerGenerator(comp, route, "example.com/app", "/p/pages").Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	old := generate("<h1>Old</h1>")
+	other := "func HandleOther(w http.ResponseWriter, r *http.Request) {\n\tw.Write([]byte(html))\n}\n"
+	mainContent := "package main\n\n" + old.Code + "\n" + other
+
+	updated := replaceHandlerFunction(mainContent, generate("<h1>New $1</h1>"))
+	if strings.Contains(updated, "Old") || !strings.Contains(updated, "<h1>New $1</h1>") {
+		t.Errorf("handler was not replaced:\n%s", updated)
+	}
+	if !strings.HasSuffix(updated, "\n"+other) {
+		t.Errorf("following code should be kept:\n%s", updated)
+	}
+}
+
+func TestCodegenBuilder_ComponentState(t *testing.T) {
+	srcDir := t.TempDir()
+	pagesDir := filepath.Join(srcDir, "pages")
+	os.MkdirAll(pagesDir, 0755)
+	os.MkdirAll(filepath.Join(srcDir, "components"), 0755)
+	os.WriteFile(filepath.Join(srcDir, "components", "Cart.gxc"), []byte("---\nGalaxy.SetState(\"cart\", 3)\n---\n<span>cart</span>"), 0644)
+	pagePath := filepath.Join(pagesDir, "index.gxc")
+
+	comp, err := parser.Parse("---\nimport Cart from \"../components/Cart.gxc\"\n---\n<Cart />")
+	if err != nil {
+		t.Fatal(err)
+	}
+	route := &router.Route{Pattern: "/", FilePath: pagePath}
+	b := NewCodegenBuilder([]*router.Route{route}, pagesDir, t.TempDir(), "example.com/app", "public")
+
+	processed, state, err := b.processComponentTags(comp, route)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(state["cart"]) != "3" {
+		t.Fatalf("expected the component's state, got %s", state)
+	}
+
+	gen := NewHandlerGenerator(processed, route, b.ModuleName, pagesDir)
+	gen.ComponentState = state
+	h, err := gen.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"pageState := ssr.State{}",
+		`pageState.Set("cart", json.RawMessage("3"))`,
+		"html = ssr.InjectState(html, pageState)",
+	} {
+		if !strings.Contains(h.Code, want) {
+			t.Errorf("handler missing %q\n%s", want, h.Code)
+		}
+	}
+	if !strings.Contains(strings.Join(h.Imports, "\n"), `"encoding/json"`) {
+		t.Errorf("handler should import encoding/json: %v", h.Imports)
+	}
+	vetGenerated(t, NewMainGenerator([]*GeneratedHandler{h}, []*router.Route{route}, b.ModuleName, ""), nil)
+}
@@ -0,0 +1,117 @@
+package codegen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/withgalaxy/galaxy/pkg/security"
+)
+
+// embeddedPaths are the server directory entries compiled into the binary
+// when [adapter.config] embed is on. Directories use all: so files starting
+// with . or _ are kept.
+var embeddedPaths = []string{"all:_assets", "all:public", "all:static", "wasm_exec.js"}
+
+// copyStaticPages copies the prerendered pages in b.StaticDir to the server's
+// static directory, skipping the server's own output. Their CSP meta tags are
+// moved to csp.go, to be sent as the header in place of the nonce policy.
+func (b *CodegenBuilder) copyStaticPages(serverDir string) error {
+	if b.StaticDir == "" {
+		return nil
+	}
+
+	policies := make(map[string]string)
+	staticOutDir := filepath.Join(serverDir, "static")
+	err := filepath.Walk(b.StaticDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path == serverDir || info.Name() == "_build" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".html" {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(b.StaticDir, path)
+		if err != nil {
+			return err
+		}
+		destPath := filepath.Join(staticOutDir, relPath)
+		if err := os.MkdirAll(filepath.Dir(destPath), 0755); err != nil {
+			return err
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		page, policy, ok := security.ExtractStaticCSP(string(data))
+		if ok {
+			policies["/"+filepath.ToSlash(relPath)] = policy
+		}
+		return os.WriteFile(destPath, []byte(page), 0644)
+	})
+	if err != nil {
+		return err
+	}
+
+	if b.Config == nil || !security.StaticCSPEnabled(b.Config.Security.Headers) {
+		os.Remove(filepath.Join(serverDir, "csp.go"))
+		return nil
+	}
+	return writeStaticCSP(serverDir, policies)
+}
+
+// writeStaticCSP generates csp.go, declaring the policies of the prerendered
+// pages by the path of their file.
+func writeStaticCSP(serverDir string, policies map[string]string) error {
+	src := fmt.Sprintf(`// Code generated by galaxy build; DO NOT EDIT.
+
+package main
+
+// staticCSP holds the Content-Security-Policy of each prerendered page, which
+// can't use the per-request nonce.
+var staticCSP = %#v
+`, policies)
+
+	return os.WriteFile(filepath.Join(serverDir, "csp.go"), []byte(src), 0644)
+}
+
+// writeEmbed generates embed.go, declaring the embedded FS the server reads
+// its assets from. go:embed rejects patterns matching nothing, so only
+// existing entries are listed.
+func (b *CodegenBuilder) writeEmbed(serverDir string) error {
+	var patterns []string
+	for _, pattern := range embeddedPaths {
+		if _, err := os.Stat(filepath.Join(serverDir, strings.TrimPrefix(pattern, "all:"))); err == nil {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	src := fmt.Sprintf(`// Code generated by galaxy build; DO NOT EDIT.
+
+package main
+
+import (
+	"embed"
+	"time"
+)
+
+// embedded holds the server's assets so the binary runs from any directory.
+//
+//go:embed %s
+var embedded embed.FS
+
+// embeddedAt is sent as Last-Modified for embedded files.
+var embeddedAt = time.Unix(%d, 0)
+`, strings.Join(patterns, " "), time.Now().Unix())
+
+	return os.WriteFile(filepath.Join(serverDir, "embed.go"), []byte(src), 0644)
+}
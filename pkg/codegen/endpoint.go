@@ -131,7 +131,7 @@ func (g *MainGenerator) generateEndpointRoutes() string {
 
 			for _, ep := range endpointGroup {
 				for _, method := range ep.Methods {
-					funcName := fmt.Sprintf("handle%s_%s", ep.PackageName, method)
+					funcName := g.handlerRef(fmt.Sprintf("handle%s_%s", ep.PackageName, method))
 					routes.WriteString(fmt.Sprintf("\t\t\tif r.Method == \"%s\" {\n", method))
 					if g.HasMiddleware {
 						routes.WriteString(fmt.Sprintf("\t\t\t\tchain.Execute(w, r, func(w http.ResponseWriter, r *http.Request, locals map[string]interface{}) {\n"))
@@ -154,7 +154,7 @@ func (g *MainGenerator) generateEndpointRoutes() string {
 
 			for _, ep := range endpointGroup {
 				for _, method := range ep.Methods {
-					funcName := fmt.Sprintf("handle%s_%s", ep.PackageName, method)
+					funcName := g.handlerRef(fmt.Sprintf("handle%s_%s", ep.PackageName, method))
 					routes.WriteString(fmt.Sprintf("\t\tif r.Method == \"%s\" {\n", method))
 					if g.HasMiddleware {
 						routes.WriteString(fmt.Sprintf("\t\t\tchain.Execute(w, r, func(w http.ResponseWriter, r *http.Request, locals map[string]interface{}) {\n"))
@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/withgalaxy/galaxy/pkg/executor"
+	"github.com/withgalaxy/galaxy/pkg/isr"
 	"github.com/withgalaxy/galaxy/pkg/parser"
 	"github.com/withgalaxy/galaxy/pkg/router"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/template"
 )
 
 func NewHandlerGenerator(comp *parser.Component, route *router.Route, moduleName, baseDir string) *HandlerGenerator {
@@ -32,6 +36,14 @@ func (g *HandlerGenerator) Generate() (*GeneratedHandler, error) {
 	}
 
 	handler.Code = g.generateHandlerFunc(funcName, code, imports)
+	// Templates with nothing to evaluate compile to plain writes.
+	if strings.Contains(handler.Code, "template.") {
+		handler.Imports = ensureImport(handler.Imports, "github.com/withgalaxy/galaxy/pkg/template")
+	}
+
+	if route, ok := isr.ParseRoute(g.Component.Frontmatter); ok {
+		handler.ISR = &route
+	}
 
 	return handler, nil
 }
@@ -65,21 +77,152 @@ func (g *HandlerGenerator) extractImports() []string {
 
 	// Auto-import content package if Galaxy.Content is used
 	if strings.Contains(g.Component.Frontmatter, "Galaxy.Content.") {
-		hasContent := false
-		for _, imp := range result {
-			if strings.Contains(imp, "galaxy/pkg/content") {
-				hasContent = true
-				break
-			}
-		}
-		if !hasContent {
-			result = append(result, `"github.com/withgalaxy/galaxy/pkg/content"`)
-		}
+		result = ensureImport(result, "github.com/withgalaxy/galaxy/pkg/content")
+	}
+
+	if g.usesSession() {
+		result = ensureImport(result, "github.com/withgalaxy/galaxy/pkg/session")
+	}
+
+	if g.usesCSRF() {
+		result = ensureImport(result, "github.com/withgalaxy/galaxy/pkg/security")
+	}
+
+	if strings.Contains(g.Component.Frontmatter, "fmt.") {
+		result = ensureImport(result, "fmt")
+	}
+
+	if g.usesGalaxy() || g.usesDataCache() {
+		result = ensureImport(result, "github.com/withgalaxy/galaxy/pkg/executor")
 	}
 
+	if g.setsCache() {
+		result = ensureImport(result, "github.com/withgalaxy/galaxy/pkg/httpcache")
+	}
+
+	if g.setsState() {
+		result = ensureImport(result, "github.com/withgalaxy/galaxy/pkg/ssr")
+	}
+
+	if len(g.ComponentState) > 0 {
+		result = ensureImport(result, "encoding/json")
+	}
+
+	result = ensureImport(result, "strings")
+
 	return result
 }
 
+func (g *HandlerGenerator) usesSession() bool {
+	return strings.Contains(g.Component.Frontmatter, "Galaxy.Session") ||
+		strings.Contains(g.Component.Template, "Galaxy.Session")
+}
+
+func (g *HandlerGenerator) usesCSRF() bool {
+	return strings.Contains(g.Component.Frontmatter, "Galaxy.CSRFToken") ||
+		strings.Contains(g.Component.Template, "Galaxy.CSRFToken") ||
+		strings.Contains(g.Component.Template, security.CSRFPlaceholder)
+}
+
+func (g *HandlerGenerator) setsCache() bool {
+	return strings.Contains(g.Component.Frontmatter, "Galaxy.SetCache(")
+}
+
+func (g *HandlerGenerator) setsState() bool {
+	return strings.Contains(g.Component.Frontmatter, "Galaxy.SetState(") || len(g.ComponentState) > 0
+}
+
+func (g *HandlerGenerator) usesDataCache() bool {
+	return strings.Contains(g.Component.Frontmatter, "Galaxy.Cache.")
+}
+
+// pageCache is the handler variable Galaxy.SetCache assigns to.
+func (g *HandlerGenerator) pageCache() (decl, name string) {
+	if !g.setsCache() {
+		return "", "nil"
+	}
+	return "var pageCache *httpcache.Policy", "pageCache"
+}
+
+// pageState declares the state Galaxy.SetState registers values in, and
+// serializes it into the page. Components set theirs after the page's
+// frontmatter ran, as they do when rendered per request.
+func (g *HandlerGenerator) pageState() (decl, inject string) {
+	if !g.setsState() {
+		return "", ""
+	}
+	keys := make([]string, 0, len(g.ComponentState))
+	for key := range g.ComponentState {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		fmt.Fprintf(&b, "pageState.Set(%q, json.RawMessage(%q))\n\t", key, g.ComponentState[key])
+	}
+	b.WriteString("html = ssr.InjectState(html, pageState)")
+	return "pageState := ssr.State{}", b.String()
+}
+
+func (g *HandlerGenerator) usesGalaxy() bool {
+	return strings.Contains(g.Component.Template, "Galaxy.")
+}
+
+// generateGalaxyBinding exposes request-scoped Galaxy APIs to the template.
+func (g *HandlerGenerator) generateGalaxyBinding() string {
+	if !g.usesGalaxy() {
+		return ""
+	}
+	fields := []string{"Locals: locals"}
+	if strings.Contains(g.Component.Template, "Galaxy.Session") {
+		fields = append(fields, "Session: session.FromRequest(r)")
+	}
+	if strings.Contains(g.Component.Template, "Galaxy.CSRFToken") {
+		fields = append(fields, "CSRFToken: security.CSRFToken(r)")
+	}
+	return "galaxy := &executor.GalaxyAPI{" + strings.Join(fields, ", ") + "}\n\t_ = galaxy"
+}
+
+// compileTemplate returns Go statements rendering the page template into b.
+// Frontmatter variables and route params are referenced directly; other
+// names are looked up in locals at request time.
+func (g *HandlerGenerator) compileTemplate() string {
+	vars := make(map[string]string)
+	for _, param := range extractRouteParams(g.Route.Pattern) {
+		vars[param] = param
+	}
+	for _, name := range g.extractVariableNames() {
+		vars[name] = name
+	}
+	if g.usesGalaxy() {
+		vars["Galaxy"] = "galaxy"
+	}
+
+	code := template.Compile(g.Component.Template, template.CompileOptions{
+		Builder:  "b",
+		Vars:     vars,
+		Fallback: "locals",
+	})
+	return "\t" + strings.ReplaceAll(strings.TrimSuffix(code, "\n"), "\n", "\n\t")
+}
+
+func (g *HandlerGenerator) generateCSRFInjection() string {
+	if !strings.Contains(g.Component.Template, security.CSRFPlaceholder) {
+		return ""
+	}
+	return "html = security.InjectCSRFToken(html, r)"
+}
+
+func ensureImport(imports []string, path string) []string {
+	for _, imp := range imports {
+		if strings.Contains(imp, `"`+path+`"`) {
+			return imports
+		}
+	}
+	return append(imports, `"`+path+`"`)
+}
+
 func (g *HandlerGenerator) extractCode() string {
 	_, code := executor.ExtractImports(g.Component.Frontmatter)
 	code = g.transformCode(code)
@@ -97,6 +240,13 @@ func (g *HandlerGenerator) transformCode(code string) string {
 	code = regexp.MustCompile(`Galaxy\.[Rr]edirect\(([^,]+),\s*(\d+)\)`).ReplaceAllString(code,
 		"http.Redirect(w, r, $1, $2); return")
 
+	code = regexp.MustCompile(`Galaxy\.SetCache\(([^,]+),\s*([^)]+)\)`).ReplaceAllString(code,
+		"pageCache = &httpcache.Policy{MaxAge: $1, StaleWhileRevalidate: $2}")
+
+	code = regexp.MustCompile(`Galaxy\.SetState\(`).ReplaceAllString(code, "pageState.Set(")
+
+	code = regexp.MustCompile(`Galaxy\.Cache\.`).ReplaceAllString(code, "executor.Cache.")
+
 	code = regexp.MustCompile(`Galaxy\.Locals\.(\w+)`).ReplaceAllString(code, "locals[\"$1\"]")
 
 	code = regexp.MustCompile(`Locals\.(\w+)`).ReplaceAllString(code, "locals[\"$1\"]")
@@ -105,6 +255,9 @@ func (g *HandlerGenerator) transformCode(code string) string {
 	code = regexp.MustCompile(`Galaxy\.Content\.Get\(`).ReplaceAllString(code, "content.Get(")
 	code = regexp.MustCompile(`Galaxy\.Content\.GetCollection\(`).ReplaceAllString(code, "content.GetCollection(")
 
+	code = regexp.MustCompile(`Galaxy\.Session\b`).ReplaceAllString(code, "session.FromRequest(r)")
+	code = regexp.MustCompile(`Galaxy\.CSRFToken\b`).ReplaceAllString(code, "security.CSRFToken(r)")
+
 	// Transform entry.field to entry["field"] for content entry access
 	// This handles variables assigned from content.Get()
 	code = transformContentEntryAccess(code)
@@ -137,46 +290,38 @@ func (g *HandlerGenerator) functionName() string {
 }
 
 func (g *HandlerGenerator) generateHandlerFunc(funcName, frontmatterCode string, imports []string) string {
-	template := escapeTemplate(g.Component.Template)
 	paramExtraction := g.generateParamExtraction()
+	cacheDecl, cacheVar := g.pageCache()
+	stateDecl, stateInjection := g.pageState()
 
 	return fmt.Sprintf(`func %s(w http.ResponseWriter, r *http.Request, params map[string]string, locals map[string]interface{}) {
 	%s
 	_ = locals
-	
 	%s
 	%s
 	
-	// Create executor context for template engine
-	ctx := executor.NewContext()
-	for k, v := range params {
-		ctx.Set(k, v)
-	}
-	for k, v := range locals {
-		ctx.Set(k, v)
-	}
-	
+	%s
+	%s
 	%s
 	
-	// Use Galaxy template engine for full directive support (galaxy:for, galaxy:if, etc.)
-	engine := template.NewEngine(ctx)
-	html, err := engine.Render(template%s, nil)
-	if err != nil {
-		http.Error(w, fmt.Sprintf("Template render error: %%v", err), http.StatusInternalServerError)
-		return
-	}
+	// Render the template compiled at build time
+	var b strings.Builder
+%s
+	html := b.String()
 	
 	// Inject CSS if present
 	html = runtime.InjectCSS(html, %q)
 	
 	// Inject WASM assets if present
 	html = runtime.InjectWasmAssets(html, r.URL.Path)
+	html = runtime.InjectPrefetch(html)
+	%s
+	html = runtime.SecureHTML(html, r)
+	%s
 	
-	w.Write([]byte(html))
+	runtime.WritePage(w, r, html, %s)
 }
-
-const template%s = %s
-`, funcName, paramExtraction, frontmatterCode, g.generateUseStatements(), g.generateVarAssignments(), funcName, g.CSSPath, funcName, template)
+`, funcName, paramExtraction, cacheDecl, stateDecl, frontmatterCode, g.generateUseStatements(), g.generateGalaxyBinding(), g.compileTemplate(), g.CSSPath, stateInjection, g.generateCSRFInjection(), cacheVar)
 }
 
 func (g *HandlerGenerator) getRoutePath() string {
@@ -198,20 +343,6 @@ func (g *HandlerGenerator) generateUseStatements() string {
 	return strings.Join(statements, "\n")
 }
 
-func (g *HandlerGenerator) generateVarAssignments() string {
-	varNames := g.extractVariableNames()
-	if len(varNames) == 0 {
-		return ""
-	}
-
-	var assignments []string
-	for _, name := range varNames {
-		assignments = append(assignments, fmt.Sprintf("\tctx.Set(%q, %s)", name, name))
-	}
-
-	return strings.Join(assignments, "\n")
-}
-
 func (g *HandlerGenerator) extractVariableNames() []string {
 	code := g.extractCode()
 
@@ -290,7 +421,8 @@ func (g *HandlerGenerator) generateParamExtraction() string {
 
 	var lines []string
 	for _, param := range params {
-		lines = append(lines, fmt.Sprintf("\t%s := params[%q]", param, param))
+		// Pages need not use every parameter of their route.
+		lines = append(lines, fmt.Sprintf("\t%s := params[%q]\n\t_ = %s", param, param, param))
 	}
 	return strings.Join(lines, "\n")
 }
@@ -329,9 +461,3 @@ func extractRouteParams(pattern string) []string {
 
 	return params
 }
-
-func escapeTemplate(template string) string {
-	escaped := strings.ReplaceAll(template, "\\", "\\\\")
-	escaped = strings.ReplaceAll(escaped, "`", "` + \"`\" + `")
-	return "`" + escaped + "`"
-}
@@ -1,8 +1,10 @@
 package codegen
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/withgalaxy/galaxy/pkg/parser"
 	"github.com/withgalaxy/galaxy/pkg/router"
 )
 
@@ -95,6 +97,31 @@ func TestTransformCode(t *testing.T) {
 			input:    `val := Locals.myValue`,
 			expected: `val := locals["myValue"]`,
 		},
+		{
+			name:     "transform Galaxy.Session",
+			input:    `user := Galaxy.Session.GetString("user")`,
+			expected: `user := session.FromRequest(r).GetString("user")`,
+		},
+		{
+			name:     "transform Galaxy.CSRFToken",
+			input:    `token := Galaxy.CSRFToken`,
+			expected: `token := security.CSRFToken(r)`,
+		},
+		{
+			name:     "transform Galaxy.SetCache",
+			input:    `Galaxy.SetCache(60, 300)`,
+			expected: `pageCache = &httpcache.Policy{MaxAge: 60, StaleWhileRevalidate: 300}`,
+		},
+		{
+			name:     "transform Galaxy.SetState",
+			input:    `Galaxy.SetState("cart", cart)`,
+			expected: `pageState.Set("cart", cart)`,
+		},
+		{
+			name:     "transform Galaxy.Cache",
+			input:    `stats, err := Galaxy.Cache.GetOrSet("stats", 60, loadStats)`,
+			expected: `stats, err := executor.Cache.GetOrSet("stats", 60, loadStats)`,
+		},
 		{
 			name:     "combined transformations",
 			input:    `entry := Galaxy.Content.Get("blog", slug); var title = entry.title`,
@@ -116,3 +143,54 @@ func TestTransformCode(t *testing.T) {
 		})
 	}
 }
+
+func TestHandlerGenerator_CompilesTemplate(t *testing.T) {
+	comp, err := parser.Parse("---\ntitle := \"Post \" + slug\n---\n<h1>{title}</h1><p>{user}</p>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	route := &router.Route{Pattern: "/blog/{slug}", FilePath: "/p/pages/blog/[slug].gxc"}
+	h, err := NewHandlerGenerator(comp, route, "example.com/app", "/p/pages").Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		`b.WriteString(template.Format(title))`,
+		`if v, ok := locals["user"]; ok {`,
+	} {
+		if !strings.Contains(h.Code, want) {
+			t.Errorf("handler missing %q\n%s", want, h.Code)
+		}
+	}
+	if strings.Contains(h.Code, "template.NewEngine") {
+		t.Error("expected the template to be compiled, not rendered by the engine")
+	}
+	vetGenerated(t, NewMainGenerator([]*GeneratedHandler{h}, []*router.Route{route}, "example.com/app", ""), nil)
+	if h.ISR != nil {
+		t.Error("pages without a revalidate comment are not regenerated")
+	}
+}
+
+func TestHandlerGenerator_ISR(t *testing.T) {
+	comp, err := parser.Parse("---\n// revalidate = 60\n// tags = blog\n---\n<h1>Post</h1>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	route := &router.Route{Pattern: "/blog/{slug}", FilePath: "/p/pages/blog/[slug].gxc"}
+	h, err := NewHandlerGenerator(comp, route, "example.com/app", "/p/pages").Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.ISR == nil || h.ISR.Revalidate != 60 || len(h.ISR.Tags) != 1 {
+		t.Errorf("ISR = %+v", h.ISR)
+	}
+	// The page uses neither its slug nor the template package.
+	if !strings.Contains(h.Code, "_ = slug") {
+		t.Errorf("unused route parameters should not break the build:\n%s", h.Code)
+	}
+	if strings.Contains(strings.Join(h.Imports, "\n"), "pkg/template") {
+		t.Errorf("static templates should not import the template package: %v", h.Imports)
+	}
+	vetGenerated(t, NewMainGenerator([]*GeneratedHandler{h}, []*router.Route{route}, "example.com/app", ""), nil)
+}
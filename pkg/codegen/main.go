@@ -5,7 +5,9 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/withgalaxy/galaxy/pkg/config"
 	"github.com/withgalaxy/galaxy/pkg/router"
+	"github.com/withgalaxy/galaxy/pkg/security"
 )
 
 func NewMainGenerator(handlers []*GeneratedHandler, routes []*router.Route, moduleName, manifestPath string) *MainGenerator {
@@ -40,47 +42,43 @@ func (g *MainGenerator) Generate() string {
 	"github.com/withgalaxy/galaxy/pkg/middleware"`
 	}
 
+	serverImports := g.collectServerImports()
+
+	assetsSource := "from the executable directory"
+	if g.embed() {
+		assetsSource = "embedded in the binary"
+	}
+
 	return fmt.Sprintf(`package main
 
 import (
-	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	%s
-	"strings"
-	"github.com/withgalaxy/galaxy/pkg/executor"
-	"github.com/withgalaxy/galaxy/pkg/template"%s
+	"strings"%s%s
 	"%s/runtime"
+	"github.com/withgalaxy/galaxy/pkg/assets"
+	"github.com/withgalaxy/galaxy/pkg/cache"
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/lifecycle"
+	"github.com/withgalaxy/galaxy/pkg/ssr"
 	%s
 	%s
 )
 
 func main() {
-	// Detect executable path for asset resolution
-	exePath, err := os.Executable()
-	if err != nil {
-		log.Fatal("Failed to get executable path:", err)
-	}
-	baseDir := filepath.Dir(exePath)
-	
 	%s
 	
-	// Serve static assets from executable directory
-	assetsDir := filepath.Join(baseDir, "_assets")
-	http.Handle("/_assets/", http.StripPrefix("/_assets/", http.FileServer(http.Dir(assetsDir))))
-	
-	wasmExecPath := filepath.Join(baseDir, "wasm_exec.js")
-	http.Handle("/wasm_exec.js", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, wasmExecPath)
-	}))
-	
-	// Health check
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	})
+	// Serve static assets %s
+	%s
+	http.Handle("/_assets/", files)
+	http.Handle("/wasm_exec.js", files)
+	http.HandleFunc(ssr.HydrationPath, ssr.ServeHydration)
+	http.HandleFunc(ssr.RouterPath, ssr.ServeRouter)
+	http.HandleFunc(ssr.PrefetchPath, ssr.ServePrefetch)
+	http.HandleFunc(ssr.PrefetchManifestPath, runtime.ServePrefetchManifest)
 	
 	// HMR endpoint for dev mode
 	if os.Getenv("DEV_MODE") == "true" {
@@ -93,12 +91,15 @@ func main() {
 	%s
 	%s
 	
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "4322"
+	var handler http.Handler = http.DefaultServeMux
+	%s
+	// Liveness and readiness probes are answered by the server itself.
+	srv := lifecycle.NewServer(%s, handler)
+	srv.Lifecycle = lifecycle.NewLifecycle().Register(cache.Default)
+	if port := os.Getenv("PORT"); port != "" {
+		srv.HTTP.Addr = ":" + port
 	}
-	addr := ":" + port
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	if err := srv.ListenAndServe(); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -108,20 +109,61 @@ func main() {
 %s
 
 %s
-`, regexpImport, middlewareImport, g.ModuleName, imports, endpointImports, g.generateMiddlewareSetup(), endpointRoutes, routeRegistrations, helpers, handlerFunctions, endpointHandlers)
+`, regexpImport, middlewareImport, serverImports, g.ModuleName, imports, endpointImports, g.generateSecrets()+g.generateMiddlewareSetup()+g.generateISRSetup(), assetsSource, g.generateFileServer(), endpointRoutes, routeRegistrations, g.generateServerWrappers(), g.serverConfig(), helpers, handlerFunctions, endpointHandlers)
+}
+
+// serverConfig is the [server] section the generated server runs with.
+func (g *MainGenerator) serverConfig() string {
+	cfg := config.DefaultConfig().Server
+	if g.Config != nil {
+		cfg = g.Config.Server
+	}
+	return fmt.Sprintf("%#v", cfg)
+}
+
+func (g *MainGenerator) embed() bool {
+	return g.Config != nil && g.Config.Embed()
+}
+
+func (g *MainGenerator) generateFileServer() string {
+	if !g.embed() {
+		return "files := assets.NewFileServer(runtime.Files)"
+	}
+	return `runtime.UseFS(embedded)
+	files := assets.NewFileServer(runtime.Files)
+	files.ModTime = embeddedAt`
+}
+
+func (g *MainGenerator) generateStaticPageServing() string {
+	if !g.StaticPages {
+		return ""
+	}
+	csp := ""
+	if g.Config != nil && security.StaticCSPEnabled(g.Config.Security.Headers) {
+		csp = `
+		name := r.URL.Path
+		if filepath.Ext(name) == "" {
+			name = strings.TrimSuffix(name, "/") + "/index.html"
+		}
+		if policy, ok := staticCSP[name]; ok {
+			// Replaces the nonce policy, so the page is under only one.
+			w.Header().Set("Content-Security-Policy", policy)
+		}`
+	}
+	return `	// Prerendered pages
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {` + csp + `
+		return files.Serve(w, r, "static"+r.URL.Path)
+	}
+`
 }
 
 func (g *MainGenerator) generateHelpers() string {
-	helpers := `func tryServeStatic(w http.ResponseWriter, r *http.Request, baseDir string) bool {
+	helpers := `func tryServeStatic(w http.ResponseWriter, r *http.Request, files *assets.FileServer) bool {
 	// Check if this looks like a static file (has extension)
-	if filepath.Ext(r.URL.Path) != "" {
-		publicPath := filepath.Join(baseDir, "public", r.URL.Path)
-		if _, err := os.Stat(publicPath); err == nil {
-			http.ServeFile(w, r, publicPath)
-			return true
-		}
+	if filepath.Ext(r.URL.Path) != "" && files.Serve(w, r, "public"+r.URL.Path) {
+		return true
 	}
-	return false
+` + g.generateStaticPageServing() + `	return false
 }
 
 func extractParams(path, pattern string) map[string]string {
@@ -186,21 +228,50 @@ func (c *MiddlewareChain) Execute(w http.ResponseWriter, r *http.Request, handle
 `
 	}
 
+	if rl := g.generateRateLimitHelpers(); rl != "" {
+		helpers += "\n\n" + rl
+	}
+
+	if isr := g.generateISRHelpers(); isr != "" {
+		helpers += "\n\n" + isr
+	}
+
 	return helpers
 }
 
+// mainImports are always imported by the generated main.go.
+var mainImports = map[string]bool{
+	`"log"`:           true,
+	`"net/http"`:      true,
+	`"os"`:            true,
+	`"path/filepath"`: true,
+	`"strings"`:       true,
+
+	`"github.com/withgalaxy/galaxy/pkg/cache"`:     true,
+	`"github.com/withgalaxy/galaxy/pkg/config"`:    true,
+	`"github.com/withgalaxy/galaxy/pkg/lifecycle"`: true,
+	`"github.com/withgalaxy/galaxy/pkg/ssr"`:       true,
+}
+
 func (g *MainGenerator) collectImports() string {
 	importMap := make(map[string]bool)
+	for _, imp := range g.serverImports() {
+		importMap[imp] = false
+	}
 
 	for _, handler := range g.Handlers {
 		for _, imp := range handler.Imports {
-			importMap[imp] = true
+			if _, seen := importMap[imp]; !seen && !mainImports[imp] {
+				importMap[imp] = true
+			}
 		}
 	}
 
 	var imports []string
-	for imp := range importMap {
-		imports = append(imports, "\t"+imp)
+	for imp, fromHandler := range importMap {
+		if fromHandler {
+			imports = append(imports, "\t"+imp)
+		}
 	}
 
 	if len(imports) == 0 {
@@ -226,29 +297,29 @@ func (g *MainGenerator) generateRouteRegistrations() string {
 			if g.HasMiddleware {
 				dynamicRoutes = append(dynamicRoutes,
 					fmt.Sprintf("\t\tif %s {\n\t\t\tparams := %s\n\t\t\tchain.Execute(w, r, func(w http.ResponseWriter, r *http.Request, locals map[string]interface{}) {\n\t\t\t\t%s(w, r, params, locals)\n\t\t\t})\n\t\t\treturn\n\t\t}",
-						matcher, extractor, handler.FunctionName))
+						matcher, extractor, g.pageRef(handler)))
 			} else {
 				dynamicRoutes = append(dynamicRoutes,
 					fmt.Sprintf("\t\tif %s {\n\t\t\tparams := %s\n\t\t\t%s(w, r, params, make(map[string]interface{}))\n\t\t\treturn\n\t\t}",
-						matcher, extractor, handler.FunctionName))
+						matcher, extractor, g.pageRef(handler)))
 			}
 		} else if pattern == "/" {
 			if g.HasMiddleware {
 				indexHandler = fmt.Sprintf("\t\tif r.URL.Path == \"/\" {\n\t\t\tparams := make(map[string]string)\n\t\t\tchain.Execute(w, r, func(w http.ResponseWriter, r *http.Request, locals map[string]interface{}) {\n\t\t\t\t%s(w, r, params, locals)\n\t\t\t})\n\t\t\treturn\n\t\t}",
-					handler.FunctionName)
+					g.pageRef(handler))
 			} else {
 				indexHandler = fmt.Sprintf("\t\tif r.URL.Path == \"/\" {\n\t\t\tparams := make(map[string]string)\n\t\t\t%s(w, r, params, make(map[string]interface{}))\n\t\t\treturn\n\t\t}",
-					handler.FunctionName)
+					g.pageRef(handler))
 			}
 		} else {
 			if g.HasMiddleware {
 				staticRoutes = append(staticRoutes,
 					fmt.Sprintf("\thttp.HandleFunc(%q, func(w http.ResponseWriter, r *http.Request) {\n\t\tparams := make(map[string]string)\n\t\tchain.Execute(w, r, func(w http.ResponseWriter, r *http.Request, locals map[string]interface{}) {\n\t\t\t%s(w, r, params, locals)\n\t\t})\n\t})",
-						pattern, handler.FunctionName))
+						pattern, g.pageRef(handler)))
 			} else {
 				staticRoutes = append(staticRoutes,
 					fmt.Sprintf("\thttp.HandleFunc(%q, func(w http.ResponseWriter, r *http.Request) {\n\t\tparams := make(map[string]string)\n\t\t%s(w, r, params, make(map[string]interface{}))\n\t})",
-						pattern, handler.FunctionName))
+						pattern, g.pageRef(handler)))
 			}
 		}
 	}
@@ -256,14 +327,14 @@ func (g *MainGenerator) generateRouteRegistrations() string {
 	var all []string
 	all = append(all, staticRoutes...)
 
-	if len(dynamicRoutes) > 0 || indexHandler != "" {
+	if len(dynamicRoutes) > 0 || indexHandler != "" || g.StaticPages {
 		var checks []string
 		if indexHandler != "" {
 			checks = append(checks, indexHandler)
 		}
 		checks = append(checks, dynamicRoutes...)
 
-		all = append(all, fmt.Sprintf("\thttp.HandleFunc(\"/\", func(w http.ResponseWriter, r *http.Request) {\n\t\t// Try serving static file first\n\t\tif tryServeStatic(w, r, baseDir) {\n\t\t\treturn\n\t\t}\n%s\n\t\thttp.NotFound(w, r)\n\t})",
+		all = append(all, fmt.Sprintf("\thttp.HandleFunc(\"/\", func(w http.ResponseWriter, r *http.Request) {\n\t\t// Try serving static file first\n\t\tif tryServeStatic(w, r, files) {\n\t\t\treturn\n\t\t}\n%s\n\t\thttp.NotFound(w, r)\n\t})",
 			strings.Join(checks, "\n")))
 	}
 
@@ -317,3 +388,176 @@ func (g *MainGenerator) generateHandlerFunctions() string {
 
 	return strings.Join(functions, "\n\n")
 }
+
+func (g *MainGenerator) collectServerImports() string {
+	imports := g.serverImports()
+	if len(imports) == 0 {
+		return ""
+	}
+	return "\n\t" + strings.Join(imports, "\n\t")
+}
+
+func (g *MainGenerator) serverImports() []string {
+	var imports []string
+	if g.Config != nil && (security.CSRFEnabled(g.Config) || g.rateLimited() || security.CORSEnabled(g.Config) || g.Config.Security.Headers.Enabled) {
+		imports = append(imports, `"github.com/withgalaxy/galaxy/pkg/security"`)
+	}
+	if g.Config != nil && g.Config.Session.Enabled {
+		imports = append(imports, `"github.com/withgalaxy/galaxy/pkg/session"`)
+	}
+	if g.Config != nil && g.Config.Compression.Enabled {
+		imports = append(imports, `"github.com/withgalaxy/galaxy/pkg/compress"`)
+	}
+	if g.regenerates() {
+		imports = append(imports, `"github.com/withgalaxy/galaxy/pkg/isr"`)
+	}
+	return imports
+}
+
+// generateServerWrappers wraps the mux with request-level services configured
+// in galaxy.config.toml.
+func (g *MainGenerator) generateServerWrappers() string {
+	if g.Config == nil {
+		return ""
+	}
+
+	// Wrappers run outside-in in reverse order of declaration: the session
+	// must be loaded before CSRF tokens can be read from it.
+	var b strings.Builder
+	if g.rateLimited() {
+		fmt.Fprintf(&b, `var err error
+	rateLimiter, err = security.NewRateLimiter(%#v, nil)
+	if err != nil {
+		log.Fatal("Failed to initialize rate limiting:", err)
+	}
+	rateLimiter.Page = %q
+	`, g.Config.Security.RateLimit, g.RateLimitPage)
+	}
+	if g.Config.Security.Headers.Enabled {
+		fmt.Fprintf(&b, `handler = security.NewHeadersMiddleware(%#v).Handler(handler)
+	`, g.Config.Security.Headers)
+	}
+	if security.CSRFEnabled(g.Config) {
+		csrf := security.NewCSRFConfig(g.Config)
+		if g.regenerates() {
+			// The revalidation endpoint authenticates with its own secret.
+			csrf.Exempt = append(csrf.Exempt[:len(csrf.Exempt):len(csrf.Exempt)], g.Config.ISR.Path)
+		}
+		csrf.Secret = ""
+		fmt.Fprintf(&b, `csrfConfig := %#v
+	csrfConfig.Secret = secrets.CSRF
+	handler = security.NewCSRFMiddleware(csrfConfig).Handler(handler)
+	`, csrf)
+	}
+	if g.Config.Session.Enabled {
+		fmt.Fprintf(&b, `sessionConfig := %#v
+	sessionConfig.Secret, sessionConfig.PreviousSecrets = secrets.Session, secrets.PreviousSession
+	sessionManager, err := session.NewManager(sessionConfig)
+	if err != nil {
+		log.Fatal("Failed to initialize sessions:", err)
+	}
+	handler = sessionManager.Handler(handler)
+	`, g.Config.WithoutSecrets().Session)
+	}
+	if security.CORSEnabled(g.Config) {
+		fmt.Fprintf(&b, `handler = security.NewCORSMiddleware(%#v).Handler(handler)
+	`, g.Config.Security.CORS)
+	}
+	if g.Config.Compression.Enabled {
+		fmt.Fprintf(&b, `handler = compress.Handler(%#v, handler)
+	`, g.Config.Compression)
+	}
+	return b.String()
+}
+
+func (g *MainGenerator) rateLimited() bool {
+	return g.Config != nil && g.Config.IsSSR() &&
+		g.Config.Security.RateLimit.Enabled && len(g.Config.Security.RateLimit.Rules) > 0
+}
+
+// handlerRef returns the expression registered for a route handler. Rate
+// limits are checked per handler, after the middleware chain, so rules can
+// key on Locals.
+func (g *MainGenerator) handlerRef(name string) string {
+	if g.rateLimited() {
+		return "rateLimited(" + name + ")"
+	}
+	return name
+}
+
+// pageRef is handlerRef for a page, serving it from the ISR cache when its
+// frontmatter sets a revalidate interval.
+func (g *MainGenerator) pageRef(handler *GeneratedHandler) string {
+	name := handler.FunctionName
+	if handler.ISR != nil {
+		name = fmt.Sprintf("regenerated(%#v, %s)", *handler.ISR, name)
+	}
+	return g.handlerRef(name)
+}
+
+func (g *MainGenerator) regenerates() bool {
+	for _, handler := range g.Handlers {
+		if handler.ISR != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isrConfig is the [isr] section the generated server runs with.
+func (g *MainGenerator) isrConfig() config.ISRConfig {
+	if g.Config == nil {
+		return config.DefaultConfig().ISR
+	}
+	return g.Config.ISR
+}
+
+func (g *MainGenerator) generateISRSetup() string {
+	if !g.regenerates() {
+		return ""
+	}
+	return fmt.Sprintf(`http.Handle(%q, isrCache.RevalidateHandler(secrets.Revalidate))
+	`, g.isrConfig().Path)
+}
+
+// generateSecrets reads the secrets of galaxy.config.toml, or of the
+// environment, when the server starts, so they are never compiled in.
+func (g *MainGenerator) generateSecrets() string {
+	if !g.regenerates() && (g.Config == nil || (!security.CSRFEnabled(g.Config) && !g.Config.Session.Enabled)) {
+		return ""
+	}
+	return `secrets := config.LoadSecrets(".")
+	`
+}
+
+func (g *MainGenerator) generateISRHelpers() string {
+	if !g.regenerates() {
+		return ""
+	}
+	return fmt.Sprintf(`// isrCache holds pages regenerated in the background.
+var isrCache = isr.New(%q)
+
+func regenerated(route isr.Route, h func(http.ResponseWriter, *http.Request, map[string]string, map[string]interface{})) func(http.ResponseWriter, *http.Request, map[string]string, map[string]interface{}) {
+	return func(w http.ResponseWriter, r *http.Request, params map[string]string, locals map[string]interface{}) {
+		isrCache.Serve(w, r, route, func(w http.ResponseWriter, r *http.Request) {
+			h(w, r, params, locals)
+		})
+	}
+}`, g.isrConfig().Dir)
+}
+
+func (g *MainGenerator) generateRateLimitHelpers() string {
+	if !g.rateLimited() {
+		return ""
+	}
+	return `var rateLimiter *security.RateLimiter
+
+func rateLimited(h func(http.ResponseWriter, *http.Request, map[string]string, map[string]interface{})) func(http.ResponseWriter, *http.Request, map[string]string, map[string]interface{}) {
+	return func(w http.ResponseWriter, r *http.Request, params map[string]string, locals map[string]interface{}) {
+		if rateLimiter != nil && !rateLimiter.Allow(w, r, locals) {
+			return
+		}
+		h(w, r, params, locals)
+	}
+}`
+}
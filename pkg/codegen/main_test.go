@@ -0,0 +1,336 @@
+package codegen
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/isr"
+	"github.com/withgalaxy/galaxy/pkg/router"
+	"github.com/withgalaxy/galaxy/pkg/security"
+)
+
+// stubHandler is the code of a page handler named name.
+func stubHandler(name string) string {
+	return "func " + name + "(w http.ResponseWriter, r *http.Request, params map[string]string, locals map[string]interface{}) {}"
+}
+
+// vetGenerated type-checks the server gen generates against this checkout of
+// galaxy. prepare, if set, adds files to the server's directory first.
+func vetGenerated(t *testing.T, gen *MainGenerator, prepare func(dir string)) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("vets a generated server")
+	}
+
+	root, err := filepath.Abs("../..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	goSum, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	all := map[string]string{
+		"go.mod":             "module " + gen.ModuleName + "\n\ngo 1.23\n\nrequire github.com/withgalaxy/galaxy v0.0.0\n\nreplace github.com/withgalaxy/galaxy => " + root + "\n",
+		"go.sum":             string(goSum),
+		"main.go":            gen.Generate(),
+		"runtime/runtime.go": gen.GenerateRuntime(),
+	}
+	for name, src := range all {
+		os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), 0755)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if prepare != nil {
+		prepare(dir)
+	}
+
+	cmd := exec.Command("go", "vet", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("generated server does not vet: %v\n%s\n%s", err, out, all["main.go"])
+	}
+}
+
+func TestMainGenerator_SecurityWrappers(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Session.Enabled = true
+	cfg.Session.Secret = "s3cret"
+	cfg.Session.PreviousSecrets = []string{"0ld-s3cret"}
+	cfg.Output.Type = config.OutputServer
+	cfg.Security.CSRF.Enabled = true
+	cfg.Security.CSRF.Secret = "csrf-s3cret"
+
+	gen := NewMainGenerator(nil, nil, "example.com/app", "")
+	gen.Config = cfg
+	src := gen.Generate()
+	vetGenerated(t, gen, nil)
+
+	for _, want := range []string{
+		`"github.com/withgalaxy/galaxy/pkg/session"`,
+		`secrets := config.LoadSecrets(".")`,
+		"sessionConfig := config.SessionConfig{",
+		"sessionConfig.Secret, sessionConfig.PreviousSecrets = secrets.Session, secrets.PreviousSession",
+		"session.NewManager(sessionConfig)",
+		"handler = sessionManager.Handler(handler)",
+		"csrfConfig := &security.CSRFConfig{",
+		"csrfConfig.Secret = secrets.CSRF",
+		"srv := lifecycle.NewServer(config.ServerConfig{",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated main.go missing %q", want)
+		}
+	}
+	for _, secret := range []string{"s3cret", "0ld-s3cret", "csrf-s3cret"} {
+		if strings.Contains(src, `"`+secret+`"`) {
+			t.Errorf("generated main.go embeds the secret %q", secret)
+		}
+	}
+}
+
+func TestMainGenerator_Server(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Server.WriteTimeout = 15
+	cfg.Server.TLS = config.TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}
+
+	gen := NewMainGenerator(nil, nil, "example.com/app", "")
+	gen.Config = cfg
+	src := gen.Generate()
+	vetGenerated(t, gen, nil)
+
+	for _, want := range []string{
+		"WriteTimeout:15",
+		`TLS:config.TLSConfig{CertFile:"cert.pem", KeyFile:"key.pem"}`,
+		"srv.ListenAndServe()",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated main.go missing %q", want)
+		}
+	}
+	if strings.Contains(src, "http.ListenAndServe(") {
+		t.Error("generated main.go should serve through lifecycle.Server")
+	}
+}
+
+func TestMainGenerator_ISR(t *testing.T) {
+	route := &router.Route{Pattern: "/blog/{slug}", FilePath: "/p/pages/blog/[slug].gxc"}
+	h := &GeneratedHandler{FunctionName: "HandleBlogSlug", Code: stubHandler("HandleBlogSlug"), ISR: &isr.Route{Revalidate: 60, Tags: []string{"blog"}}}
+
+	gen := NewMainGenerator([]*GeneratedHandler{h}, []*router.Route{route}, "example.com/app", "")
+	gen.Config = config.DefaultConfig()
+	gen.Config.ISR.Secret = "isr-s3cret"
+	src := gen.Generate()
+	vetGenerated(t, gen, nil)
+
+	if strings.Contains(src, "isr-s3cret") {
+		t.Error("generated main.go embeds the revalidation secret")
+	}
+	for _, want := range []string{
+		`regenerated(isr.Route{Revalidate:60, Tags:[]string{"blog"}}, HandleBlogSlug)`,
+		`secrets := config.LoadSecrets(".")`,
+		`http.Handle("/_galaxy/revalidate", isrCache.RevalidateHandler(secrets.Revalidate))`,
+		`var isrCache = isr.New("")`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated main.go missing %q", want)
+		}
+	}
+}
+
+func TestMainGenerator_NoSession(t *testing.T) {
+	gen := NewMainGenerator(nil, nil, "example.com/app", "")
+	gen.Config = config.DefaultConfig()
+	src := gen.Generate()
+	vetGenerated(t, gen, nil)
+
+	if strings.Contains(src, "pkg/session") {
+		t.Error("session package imported while sessions are disabled")
+	}
+}
+
+func TestMainGenerator_RateLimit(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Output.Type = config.OutputServer
+	cfg.Security.RateLimit.Enabled = true
+	cfg.Security.RateLimit.Rules = []config.RateLimitRule{
+		{Path: "/api/*", Limit: 10, Window: 60, Algorithm: config.RateLimitTokenBucket, Key: "ip"},
+	}
+
+	gen := NewMainGenerator(
+		[]*GeneratedHandler{{FunctionName: "HandleAbout", Code: stubHandler("HandleAbout")}},
+		[]*router.Route{{Pattern: "/about"}},
+		"example.com/app", "")
+	gen.Config = cfg
+	gen.RateLimitPage = "<h1>Slow down</h1>"
+	src := gen.Generate()
+	vetGenerated(t, gen, nil)
+
+	for _, want := range []string{
+		`"github.com/withgalaxy/galaxy/pkg/config"`,
+		"security.NewRateLimiter(config.RateLimitConfig{",
+		`rateLimiter.Page = "<h1>Slow down</h1>"`,
+		"rateLimited(HandleAbout)(w, r, params, make(map[string]interface{}))",
+		"func rateLimited(",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated main.go missing %q", want)
+		}
+	}
+}
+
+func TestMainGenerator_CORS(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Output.Type = config.OutputServer
+	cfg.Security.CORS.Enabled = true
+	cfg.Security.CORS.Rules = []config.CORSRule{
+		{Path: "/api/*", AllowOrigins: []string{"https://app.example.com"}, AllowMethods: []string{"GET"}},
+	}
+
+	gen := NewMainGenerator(nil, nil, "example.com/app", "")
+	gen.Config = cfg
+	src := gen.Generate()
+	vetGenerated(t, gen, nil)
+
+	for _, want := range []string{
+		`"github.com/withgalaxy/galaxy/pkg/config"`,
+		"handler = security.NewCORSMiddleware(config.CORSConfig{",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated main.go missing %q", want)
+		}
+	}
+}
+
+func TestMainGenerator_CSPNonce(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Output.Type = config.OutputServer
+	cfg.Security.Headers.Enabled = true
+	cfg.Security.Headers.ContentSecurityPolicy = "default-src 'self'"
+	cfg.Security.Headers.CSPNonce = true
+	cfg.Security.Headers.Integrity = true
+
+	gen := NewMainGenerator(nil, nil, "example.com/app", "")
+	gen.Config = cfg
+	src := gen.Generate()
+	rt := gen.GenerateRuntime()
+	vetGenerated(t, gen, nil)
+
+	if !strings.Contains(src, "handler = security.NewHeadersMiddleware(config.HeadersConfig{") {
+		t.Error("generated main.go missing headers middleware")
+	}
+	for _, want := range []string{"cspNonce             = true", "subresourceIntegrity = true"} {
+		if !strings.Contains(rt, want) {
+			t.Errorf("generated runtime.go missing %q", want)
+		}
+	}
+}
+
+func TestMainGenerator_Embed(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Output.Type = config.OutputHybrid
+	cfg.Adapter.Config["embed"] = true
+
+	gen := NewMainGenerator(nil, nil, "example.com/app", "")
+	gen.Config = cfg
+	gen.StaticPages = true
+	src := gen.Generate()
+	vetGenerated(t, gen, func(dir string) {
+		os.MkdirAll(filepath.Join(dir, "static", "about"), 0755)
+		os.WriteFile(filepath.Join(dir, "static", "about", "index.html"), []byte("<h1>About</h1>"), 0644)
+		if err := (&CodegenBuilder{}).writeEmbed(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	for _, want := range []string{
+		"runtime.UseFS(embedded)",
+		"files.ModTime = embeddedAt",
+		`return files.Serve(w, r, "static"+r.URL.Path)`,
+		`if tryServeStatic(w, r, files) {`,
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated main.go missing %q", want)
+		}
+	}
+	if strings.Contains(src, "os.Executable") {
+		t.Error("embedded servers should not read files next to the executable")
+	}
+
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "_assets"), 0755)
+	os.WriteFile(filepath.Join(dir, "wasm_exec.js"), nil, 0644)
+	if err := (&CodegenBuilder{}).writeEmbed(dir); err != nil {
+		t.Fatal(err)
+	}
+	embedGo, _ := os.ReadFile(filepath.Join(dir, "embed.go"))
+	if !strings.Contains(string(embedGo), "//go:embed all:_assets wasm_exec.js\n") {
+		t.Errorf("embed.go should only list existing files:\n%s", embedGo)
+	}
+}
+
+func TestCodegenBuilder_StaticCSP(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Output.Type = config.OutputHybrid
+	cfg.Adapter.Config["embed"] = true
+	cfg.Security.Headers.Enabled = true
+	cfg.Security.Headers.ContentSecurityPolicy = "default-src 'self'"
+	cfg.Security.Headers.CSPNonce = true
+
+	staticDir := t.TempDir()
+	page := "<html><head><script>run()</script></head></html>"
+	os.MkdirAll(filepath.Join(staticDir, "about"), 0755)
+	os.WriteFile(filepath.Join(staticDir, "about", "index.html"), []byte(security.InjectStaticCSP(page, "default-src 'self'")), 0644)
+
+	serverDir := t.TempDir()
+	b := &CodegenBuilder{Config: cfg, StaticDir: staticDir}
+	if err := b.copyStaticPages(serverDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// Served pages carry the policy in a header only; both would intersect.
+	served, _ := os.ReadFile(filepath.Join(serverDir, "static", "about", "index.html"))
+	if string(served) != page {
+		t.Errorf("served page should lose its CSP meta tag:\n%s", served)
+	}
+	cspGo, _ := os.ReadFile(filepath.Join(serverDir, "csp.go"))
+	if _, err := parser.ParseFile(token.NewFileSet(), "csp.go", cspGo, 0); err != nil {
+		t.Fatalf("generated csp.go does not parse: %v\n%s", err, cspGo)
+	}
+	if !strings.Contains(string(cspGo), `"/about/index.html":"default-src 'self'; script-src 'self' 'sha256-`) {
+		t.Errorf("csp.go missing the page's policy:\n%s", cspGo)
+	}
+
+	gen := NewMainGenerator(nil, nil, "example.com/app", "")
+	gen.Config = cfg
+	gen.StaticPages = true
+	src := gen.Generate()
+	vetGenerated(t, gen, func(dir string) {
+		if err := b.copyStaticPages(dir); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.writeEmbed(dir); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !strings.Contains(src, `w.Header().Set("Content-Security-Policy", policy)`) {
+		t.Error("generated main.go should send prerendered pages' policy as the header")
+	}
+
+	cfg.Security.Headers.CSPNonce = false
+	if err := b.copyStaticPages(serverDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(serverDir, "csp.go")); !os.IsNotExist(err) {
+		t.Error("csp.go should be removed when pages have no static policy")
+	}
+}
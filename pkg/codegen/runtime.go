@@ -1,18 +1,30 @@
 package codegen
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/ssr"
+)
 
 func (g *MainGenerator) GenerateRuntime() string {
 	return fmt.Sprintf(`package runtime
 
 import (
 	"encoding/json"
+	"io/fs"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 	
 	"github.com/withgalaxy/galaxy/pkg/compiler"
+	"github.com/withgalaxy/galaxy/pkg/config"
 	"github.com/withgalaxy/galaxy/pkg/executor"
+	"github.com/withgalaxy/galaxy/pkg/httpcache"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/ssr"
 	"github.com/withgalaxy/galaxy/pkg/template"
 	"github.com/withgalaxy/galaxy/pkg/wasm"
 )
@@ -21,6 +33,28 @@ var comp *compiler.ComponentCompiler
 var wasmManifest *wasm.WasmManifest
 var baseDir string
 
+// Files holds the server's assets: the executable's directory, or the files
+// embedded in the binary after UseFS.
+var Files fs.FS
+
+const (
+	cspNonce             = %t
+	subresourceIntegrity = %t
+)
+
+// cacheRoutes are the [[cache.routes]] entries from galaxy.config.toml.
+var cacheRoutes = %s
+
+// prefetchScript loads ssr.PrefetchRuntime as [prefetch] sets it up.
+const prefetchScript = %q
+
+func nonceAttr() string {
+	if cspNonce {
+		return security.CSPNonceAttr
+	}
+	return ""
+}
+
 func init() {
 	// Detect executable path
 	exePath, err := os.Executable()
@@ -31,12 +65,19 @@ func init() {
 	}
 	
 	comp = compiler.NewComponentCompiler(baseDir)
+	Files = os.DirFS(baseDir)
+	loadWasmManifest()
+}
+
+// UseFS serves assets from fsys instead of the executable's directory.
+func UseFS(fsys fs.FS) {
+	Files = fsys
+	wasmManifest = nil
 	loadWasmManifest()
 }
 
 func loadWasmManifest() {
-	manifestPath := filepath.Join(baseDir, "_assets", "wasm-manifest.json")
-	data, err := os.ReadFile(manifestPath)
+	data, err := fs.ReadFile(Files, "_assets/wasm-manifest.json")
 	if err != nil {
 		return
 	}
@@ -68,7 +109,7 @@ func RenderTemplate(ctx *RenderContext, templateHTML string) string {
 
 func InjectCSS(html, cssPath string) string {
 	if cssPath != "" {
-		cssTag := "<link rel=\"stylesheet\" href=\"" + cssPath + "\">"
+		cssTag := "<link rel=\"stylesheet\" href=\"" + cssPath + "\"" + nonceAttr() + ">"
 		html = strings.Replace(html, "</head>", "\t" + cssTag + "\n</head>", 1)
 	}
 	return html
@@ -102,20 +143,20 @@ func InjectWasmAssets(html, urlPath string) string {
 	
 	// Add wasm_exec.js once
 	if len(assets.WasmModules) > 0 {
-		scripts = append(scripts, "<script src=\"/wasm_exec.js\"></script>")
+		scripts = append(scripts, "<script src=\"/wasm_exec.js\"" + nonceAttr() + "></script>")
 	}
 	
 	for _, mod := range assets.WasmModules {
-		scripts = append(scripts, "<script src=\"" + mod.LoaderPath + "\"></script>")
+		scripts = append(scripts, "<script src=\"" + mod.LoaderPath + "\"" + nonceAttr() + "></script>")
 	}
 	
 	for _, js := range assets.JSScripts {
-		scripts = append(scripts, "<script src=\"" + js + "\"></script>")
+		scripts = append(scripts, "<script src=\"" + js + "\"" + nonceAttr() + "></script>")
 	}
 	
 	// Inject HMR client in dev mode at end of head
 	if os.Getenv("DEV_MODE") == "true" {
-		hmrScript := "\t<script src=\"/__hmr/client.js\"></script>"
+		hmrScript := "\t<script src=\"/__hmr/client.js\"" + nonceAttr() + "></script>"
 		html = strings.Replace(html, "</head>", hmrScript + "\n</head>", 1)
 	}
 	
@@ -128,6 +169,39 @@ func InjectWasmAssets(html, urlPath string) string {
 	return html
 }
 
+// InjectPrefetch adds the prefetch script when [prefetch] is enabled.
+func InjectPrefetch(html string) string {
+	if prefetchScript == "" {
+		return html
+	}
+	return strings.Replace(html, "</head>", prefetchScript + "\n</head>", 1)
+}
+
+// ServePrefetchManifest lists the files each page loads, for the prefetch
+// script to fetch along with it.
+func ServePrefetchManifest(w http.ResponseWriter, r *http.Request) {
+	if wasmManifest == nil {
+		loadWasmManifest()
+	}
+	ssr.ServePrefetchManifest(wasmManifest)(w, r)
+}
+
+// SecureHTML fills CSP nonces for r and adds integrity attributes to
+// bundled assets.
+func SecureHTML(html string, r *http.Request) string {
+	html = security.InjectCSPNonce(html, r)
+	if subresourceIntegrity {
+		html = security.AddIntegrityFS(html, Files)
+	}
+	return html
+}
+
+// WritePage sends a rendered page with its route's caching policy. page is
+// the policy set by Galaxy.SetCache, if any.
+func WritePage(w http.ResponseWriter, r *http.Request, html string, page *httpcache.Policy) {
+	httpcache.Write(w, r, []byte(html), httpcache.For(cacheRoutes, r.URL.Path, page))
+}
+
 func matchesRoute(manifestKey, urlPath string) bool {
 	// manifestKey is like "pages/login.gxc"
 	// urlPath is like "/login"
@@ -143,5 +217,20 @@ func matchesRoute(manifestKey, urlPath string) bool {
 	
 	return route == urlPath
 }
-`)
+`, g.Config != nil && security.CSPNonceEnabled(g.Config), g.Config != nil && g.Config.Security.Headers.Integrity, g.cacheRoutes(), g.prefetchScript())
+}
+
+func (g *MainGenerator) cacheRoutes() string {
+	var routes []config.CacheRoute
+	if g.Config != nil {
+		routes = g.Config.Cache.Routes
+	}
+	return fmt.Sprintf("%#v", routes)
+}
+
+func (g *MainGenerator) prefetchScript() string {
+	if g.Config == nil {
+		return ""
+	}
+	return ssr.PrefetchScript(g.Config.Prefetch)
 }
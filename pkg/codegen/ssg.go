@@ -124,8 +124,6 @@ import (
 	"os"
 	"path/filepath"
 	
-	"github.com/withgalaxy/galaxy/pkg/executor"
-	"github.com/withgalaxy/galaxy/pkg/template"
 	%s
 	"%s/runtime"
 )
@@ -174,11 +172,22 @@ func (w *responseWriter) WriteHeader(statusCode int) {}
 `, imports, b.ModuleName, strings.Join(renderCalls, "\n"), strings.Join(handlerFuncs, "\n\n"))
 }
 
+// ssgImports are always imported by the generated pre-render program.
+var ssgImports = map[string]bool{
+	`"fmt"`:           true,
+	`"net/http"`:      true,
+	`"net/url"`:       true,
+	`"os"`:            true,
+	`"path/filepath"`: true,
+}
+
 func (b *SSGCodegenBuilder) collectImports(handlers []*GeneratedHandler) string {
 	importMap := make(map[string]bool)
 	for _, handler := range handlers {
 		for _, imp := range handler.Imports {
-			importMap[imp] = true
+			if !ssgImports[imp] {
+				importMap[imp] = true
+			}
 		}
 	}
 
@@ -1,6 +1,10 @@
 package codegen
 
 import (
+	"encoding/json"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/isr"
 	"github.com/withgalaxy/galaxy/pkg/parser"
 	"github.com/withgalaxy/galaxy/pkg/router"
 )
@@ -11,6 +15,9 @@ type HandlerGenerator struct {
 	ModuleName string
 	BaseDir    string
 	CSSPath    string
+	// ComponentState is what the page's components registered with
+	// Galaxy.SetState when they were rendered at build time, as JSON.
+	ComponentState map[string]json.RawMessage
 }
 
 type GeneratedHandler struct {
@@ -18,6 +25,8 @@ type GeneratedHandler struct {
 	Imports      []string
 	FunctionName string
 	Code         string
+	// ISR is set for pages regenerated in the background.
+	ISR *isr.Route
 }
 
 type EndpointHandler struct {
@@ -34,4 +43,9 @@ type MainGenerator struct {
 	ModuleName    string
 	ManifestPath  string
 	HasMiddleware bool
+	Config        *config.Config
+	// RateLimitPage is the custom 429 page embedded into the server.
+	RateLimitPage string
+	// StaticPages serves the embedded prerendered pages.
+	StaticPages bool
 }
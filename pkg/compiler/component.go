@@ -1,33 +1,64 @@
 package compiler
 
 import (
-	"fmt"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"regexp"
-	"strings"
+	"sync"
 
 	"github.com/withgalaxy/galaxy/pkg/assets"
+	"github.com/withgalaxy/galaxy/pkg/cache"
 	"github.com/withgalaxy/galaxy/pkg/executor"
 	"github.com/withgalaxy/galaxy/pkg/parser"
-	tmpl "github.com/withgalaxy/galaxy/pkg/template"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/ssr"
 )
 
 type ComponentCompiler struct {
-	BaseDir         string
+	BaseDir string
+	// FS, if set, is read instead of the OS filesystem; paths are then
+	// relative to its root.
+	FS              fs.FS
 	Cache           map[string]*parser.Component
 	Bundler         *assets.Bundler
 	Resolver        *ComponentResolver
 	CollectedStyles []parser.Style
 	UsedComponents  []string
+	Islands         []*ssr.Island
 	componentsSeen  map[string]bool
+	// Fragments holds the rendered output of components declared cacheable.
+	// Nil disables fragment caching.
+	Fragments *cache.Cache
+
+	cacheMu sync.RWMutex
+	// sources are the hashes of loaded components' source, so cached
+	// fragments can tell when a component changed.
+	sources map[string]string
 }
 
 func NewComponentCompiler(baseDir string) *ComponentCompiler {
 	return &ComponentCompiler{
-		BaseDir:  baseDir,
-		Cache:    make(map[string]*parser.Component),
-		Bundler:  assets.NewBundler(".galaxy"),
-		Resolver: NewComponentResolver(baseDir, nil),
+		BaseDir:   baseDir,
+		Cache:     make(map[string]*parser.Component),
+		Bundler:   assets.NewBundler(".galaxy"),
+		Resolver:  NewComponentResolver(baseDir, nil),
+		Fragments: cache.New(cache.NewMemory(DefaultFragmentEntries)),
+	}
+}
+
+// NewComponentCompilerFS compiles components read from fsys, with baseDir
+// relative to its root.
+func NewComponentCompilerFS(fsys fs.FS, baseDir string) *ComponentCompiler {
+	return &ComponentCompiler{
+		BaseDir:   baseDir,
+		FS:        fsys,
+		Cache:     make(map[string]*parser.Component),
+		Bundler:   assets.NewBundler(".galaxy"),
+		Resolver:  NewComponentResolverFS(fsys, baseDir, nil),
+		Fragments: cache.New(cache.NewMemory(DefaultFragmentEntries)),
 	}
 }
 
@@ -35,15 +66,23 @@ func (c *ComponentCompiler) SetResolver(resolver *ComponentResolver) {
 	c.Resolver = resolver
 }
 
+// ClearCache drops parsed components so they are read again. Cached
+// fragments are kept; they are re-rendered if their components' source
+// changed.
 func (c *ComponentCompiler) ClearCache() {
+	c.cacheMu.Lock()
 	c.Cache = make(map[string]*parser.Component)
+	c.sources = nil
+	c.cacheMu.Unlock()
 	c.CollectedStyles = nil
 	c.UsedComponents = nil
+	c.Islands = nil
 	c.componentsSeen = nil
 }
 
 func (c *ComponentCompiler) ResetComponentTracking() {
 	c.UsedComponents = nil
+	c.Islands = nil
 	c.componentsSeen = make(map[string]bool)
 }
 
@@ -57,137 +96,152 @@ func (c *ComponentCompiler) trackComponent(componentPath string) {
 	}
 }
 
-func (c *ComponentCompiler) Compile(filePath string, props map[string]interface{}, slots map[string]string) (string, error) {
-	return c.CompileWithContext(filePath, props, slots, nil)
+// WasmSources returns the components in UsedComponents, for linking their
+// Go scripts into the page's module.
+func (c *ComponentCompiler) WasmSources() []assets.WasmSource {
+	return c.wasmSources(c.UsedComponents, c.Islands)
 }
 
-func (c *ComponentCompiler) CompileWithContext(filePath string, props map[string]interface{}, slots map[string]string, parentCtx *executor.Context) (string, error) {
-	comp, err := c.loadComponent(filePath)
-	if err != nil {
-		return "", err
-	}
-
-	copiedStyles := make([]parser.Style, len(comp.Styles))
-	copy(copiedStyles, comp.Styles)
-	c.CollectedStyles = append(c.CollectedStyles, copiedStyles...)
-
-	var ctx *executor.Context
-	if parentCtx != nil {
-		ctx = parentCtx.Clone()
-	} else {
-		ctx = executor.NewContext()
-	}
+// PageWasmSources returns the components the page comp at pagePath renders,
+// found by rendering its component tags with an empty context, for builds
+// that link a page's scripts without rendering it.
+func (c *ComponentCompiler) PageWasmSources(comp *parser.Component, pagePath string) []assets.WasmSource {
+	s := c.NewSession(pagePath, PageImports(comp))
+	s.ProcessComponentTags(comp.Template, executor.NewContext())
+	return s.WasmSources()
+}
 
-	for k, v := range props {
-		ctx.SetProp(k, v)
-		ctx.Set(k, v)
+// WasmPages reads the pages at paths, with the components found by
+// PageWasmSources, for assets.Bundler.PrecompileWasm. Pages that can't be
+// read or parsed are left for rendering to report.
+func (c *ComponentCompiler) WasmPages(paths []string) []assets.WasmPage {
+	var pages []assets.WasmPage
+	for _, path := range paths {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		comp, err := parser.Parse(string(content))
+		if err != nil {
+			continue
+		}
+		pages = append(pages, assets.WasmPage{Path: path, Component: comp, Components: c.PageWasmSources(comp, path)})
 	}
+	return pages
+}
 
-	if comp.Frontmatter != "" {
-		if err := ctx.Execute(comp.Frontmatter); err != nil {
-			return "", err
+func (c *ComponentCompiler) wasmSources(paths []string, islands []*ssr.Island) []assets.WasmSource {
+	var sources []assets.WasmSource
+	for _, path := range paths {
+		comp, err := c.loadComponent(path)
+		if err != nil {
+			continue
 		}
+		sources = append(sources, assets.WasmSource{Path: path, Component: comp, Island: isIsland(islands, path)})
 	}
+	return sources
+}
 
-	processedTemplate := c.ProcessComponentTags(comp.Template, ctx)
-
-	engine := tmpl.NewEngine(ctx)
-	rendered, err := engine.Render(processedTemplate, &tmpl.RenderOptions{
-		Props:     props,
-		Slots:     slots,
-		ParentCtx: parentCtx,
-	})
-	if err != nil {
-		return "", err
-	}
+func (c *ComponentCompiler) Compile(filePath string, props map[string]interface{}, slots map[string]string) (string, error) {
+	return c.CompileWithContext(filePath, props, slots, nil)
+}
 
-	return rendered, nil
+// CompileWithContext renders a component, recording its styles and
+// components on c. Servers rendering concurrently should use a Session.
+func (c *ComponentCompiler) CompileWithContext(filePath string, props map[string]interface{}, slots map[string]string, parentCtx *executor.Context) (string, error) {
+	s := c.sharedSession()
+	rendered, err := s.CompileWithContext(filePath, props, slots, parentCtx)
+	c.collect(s)
+	return rendered, err
 }
 
 func (c *ComponentCompiler) loadComponent(filePath string) (*parser.Component, error) {
-	if comp, ok := c.Cache[filePath]; ok {
+	c.cacheMu.RLock()
+	comp, ok := c.Cache[filePath]
+	c.cacheMu.RUnlock()
+	if ok {
 		return comp, nil
 	}
 
-	content, err := os.ReadFile(filePath)
+	content, err := readFile(c.FS, filePath)
 	if err != nil {
 		return nil, err
 	}
 
-	comp, err := parser.Parse(string(content))
+	comp, err = parser.Parse(string(content))
 	if err != nil {
 		return nil, err
 	}
 
+	sum := sha256.Sum256(content)
+	c.cacheMu.Lock()
 	c.Cache[filePath] = comp
+	if c.sources == nil {
+		c.sources = make(map[string]string)
+	}
+	c.sources[filePath] = hex.EncodeToString(sum[:8])
+	c.cacheMu.Unlock()
 	return comp, nil
 }
 
+// sourceHash returns the hash of the component at filePath as it is on disk
+// now, or "" if it cannot be read.
+func (c *ComponentCompiler) sourceHash(filePath string) string {
+	if _, err := c.loadComponent(filePath); err != nil {
+		return ""
+	}
+	c.cacheMu.RLock()
+	defer c.cacheMu.RUnlock()
+	return c.sources[filePath]
+}
+
+func readFile(fsys fs.FS, name string) ([]byte, error) {
+	if fsys == nil {
+		return os.ReadFile(name)
+	}
+	return fs.ReadFile(fsys, filepath.ToSlash(filepath.Clean(name)))
+}
+
 var (
 	componentOpenCloseRegex = regexp.MustCompile(`(?s)<([A-Z]\w+)([^>]*)>(.*?)</([A-Z]\w+)>`)
 	componentSelfCloseRegex = regexp.MustCompile(`<([A-Z]\w+)([^/>]*)/?>`)
-)
-
-func (c *ComponentCompiler) ProcessComponentTags(template string, ctx *executor.Context) string {
-	result := componentOpenCloseRegex.ReplaceAllStringFunc(template, func(match string) string {
-		matches := componentOpenCloseRegex.FindStringSubmatch(match)
-
-		componentName := matches[1]
-		attrs := matches[2]
-		content := matches[3]
-		closingTag := matches[4]
-
-		if componentName != closingTag {
-			return match
-		}
-
-		componentPath, err := c.Resolver.Resolve(componentName)
-		if err != nil {
-			return fmt.Sprintf("<!-- Component resolution error: %v -->", err)
-		}
-
-		c.trackComponent(componentPath)
-
-		props := c.parseAttributes(attrs, ctx)
-
-		slots := make(map[string]string)
-		trimmedContent := strings.TrimSpace(content)
-		if trimmedContent != "" {
-			slots["default"] = trimmedContent
-		}
-
-		rendered, err := c.CompileWithContext(componentPath, props, slots, ctx)
-		if err != nil {
-			return fmt.Sprintf("<!-- Error rendering %s: %v -->", componentName, err)
-		}
+	csrfInputRegex          = regexp.MustCompile(`<CSRFInput\s*(/>|>\s*</CSRFInput>)`)
+	clientRouterRegex       = regexp.MustCompile(`<ClientRouter\s*(/>|>\s*</ClientRouter>)`)
 
-		return rendered
-	})
-
-	result = componentSelfCloseRegex.ReplaceAllStringFunc(result, func(match string) string {
-		matches := componentSelfCloseRegex.FindStringSubmatch(match)
-
-		componentName := matches[1]
-		attrs := matches[2]
-
-		componentPath, err := c.Resolver.Resolve(componentName)
-		if err != nil {
-			return fmt.Sprintf("<!-- Component resolution error: %v -->", err)
-		}
-
-		c.trackComponent(componentPath)
+	clientRouterScript = `<script type="module" src="` + ssr.RouterPath + `"` + security.CSPNonceAttr + `></script>`
+)
 
-		props := c.parseAttributes(attrs, ctx)
+// processBuiltins expands framework-provided components. <CSRFInput/> renders
+// a placeholder that the server swaps for the request's token, so it also
+// works inside components pre-rendered at build time. <ClientRouter/> loads
+// the client router, see ssr.RouterRuntime.
+func processBuiltins(template string) string {
+	template = csrfInputRegex.ReplaceAllString(template, security.CSRFInput(security.CSRFPlaceholder))
+	return clientRouterRegex.ReplaceAllLiteralString(template, clientRouterScript)
+}
 
-		rendered, err := c.CompileWithContext(componentPath, props, make(map[string]string), ctx)
-		if err != nil {
-			return fmt.Sprintf("<!-- Error rendering %s: %v -->", componentName, err)
-		}
+// ProcessComponentTags renders the components used in template, recording
+// their styles and paths on c. Servers rendering concurrently should use a
+// Session.
+func (c *ComponentCompiler) ProcessComponentTags(template string, ctx *executor.Context) string {
+	s := c.sharedSession()
+	result := s.ProcessComponentTags(template, ctx)
+	c.collect(s)
+	return result
+}
 
-		return rendered
-	})
+// sharedSession returns a session resolving through c.Resolver's current
+// file and imports.
+func (c *ComponentCompiler) sharedSession() *Session {
+	return &Session{compiler: c, resolve: c.Resolver.Resolve}
+}
 
-	return result
+func (c *ComponentCompiler) collect(s *Session) {
+	c.CollectedStyles = append(c.CollectedStyles, s.CollectedStyles...)
+	c.Islands = append(c.Islands, s.Islands...)
+	for _, path := range s.UsedComponents {
+		c.trackComponent(path)
+	}
 }
 
 func (c *ComponentCompiler) parseAttributes(attrs string, ctx *executor.Context) map[string]interface{} {
@@ -392,6 +392,30 @@ func TestComponentCompiler_CompileWithContext_InheritsContext(t *testing.T) {
 	}
 }
 
+func TestComponentCompiler_ProcessComponentTags_CSRFInput(t *testing.T) {
+	cc := NewComponentCompiler(t.TempDir())
+	want := `<input type="hidden" name="_csrf" value="__GALAXY_CSRF_TOKEN__">`
+
+	for _, tag := range []string{"<CSRFInput/>", "<CSRFInput />", "<CSRFInput></CSRFInput>"} {
+		result := cc.ProcessComponentTags("<form>"+tag+"</form>", executor.NewContext())
+		if result != "<form>"+want+"</form>" {
+			t.Errorf("%s: got %s", tag, result)
+		}
+	}
+}
+
+func TestComponentCompiler_ProcessComponentTags_ClientRouter(t *testing.T) {
+	cc := NewComponentCompiler(t.TempDir())
+	want := `<script type="module" src="/_galaxy/router.js" nonce="__GALAXY_CSP_NONCE__"></script>`
+
+	for _, tag := range []string{"<ClientRouter/>", "<ClientRouter />", "<ClientRouter></ClientRouter>"} {
+		result := cc.ProcessComponentTags("<head>"+tag+"</head>", executor.NewContext())
+		if result != "<head>"+want+"</head>" {
+			t.Errorf("%s: got %s", tag, result)
+		}
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) > 0 && len(substr) > 0 && (s == substr || len(s) >= len(substr) && findSubstring(s, substr))
 }
@@ -0,0 +1,144 @@
+package compiler
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/withgalaxy/galaxy/pkg/executor"
+	"github.com/withgalaxy/galaxy/pkg/parser"
+	"github.com/withgalaxy/galaxy/pkg/ssr"
+)
+
+const (
+	// DefaultFragmentEntries is how many rendered fragments a compiler keeps.
+	DefaultFragmentEntries = 500
+	// MaxFragmentSize is the largest rendered fragment, in bytes, that is
+	// cached.
+	MaxFragmentSize = 256 << 10
+)
+
+var cacheComment = regexp.MustCompile(`(?m)^\s*//\s*cache\s*=\s*(\S+)\s*$`)
+
+// fragmentPolicy is how a component's rendered output is cached, declared
+// with attributes where it is used:
+//
+//	<MegaMenu cache="5m" cacheKey={locale}/>
+//
+// or for every use in its own frontmatter with `// cache = 5m`. Without a
+// cacheKey, fragments are keyed by their props and slots.
+type fragmentPolicy struct {
+	ttl   time.Duration
+	key   string
+	props map[string]interface{}
+}
+
+// fragmentPolicyFor returns the caching policy of comp rendered with props.
+// ok is false for components that are not cached.
+func fragmentPolicyFor(comp *parser.Component, props map[string]interface{}) (policy fragmentPolicy, ok bool) {
+	ttl, hasAttr := props["cache"].(string)
+	if !hasAttr {
+		m := cacheComment.FindStringSubmatch(comp.Frontmatter)
+		if m == nil {
+			return fragmentPolicy{}, false
+		}
+		ttl = m[1]
+	}
+	d, err := time.ParseDuration(ttl)
+	if err != nil || d <= 0 {
+		return fragmentPolicy{}, false
+	}
+
+	policy = fragmentPolicy{ttl: d, props: make(map[string]interface{}, len(props))}
+	for k, v := range props {
+		if k != "cache" && k != "cacheKey" {
+			policy.props[k] = v
+		}
+	}
+	if key, ok := props["cacheKey"]; ok {
+		policy.key = fmt.Sprint(key)
+	} else {
+		policy.key = fmt.Sprint(policy.props)
+	}
+	return policy, true
+}
+
+// fragment is a component's cached output.
+type fragment struct {
+	html       string
+	styles     []parser.Style
+	components []string
+	islands    []*ssr.Island
+	// state is what the component registered with Galaxy.SetState.
+	state map[string]interface{}
+	// sources are the source hashes of the component and those it rendered.
+	sources map[string]string
+}
+
+// compileFragment renders comp, found at filePath, through the compiler's
+// fragment cache, recording its styles and components on s either way.
+func (s *Session) compileFragment(filePath string, comp *parser.Component, policy fragmentPolicy, slots map[string]string, parentCtx *executor.Context) (string, error) {
+	key := filePath + "\x00" + policy.key + "\x00" + fmt.Sprint(slots)
+	if cached, ok := s.compiler.Fragments.Get(key); ok {
+		if f := cached.(*fragment); s.compiler.current(f) {
+			s.use(f, parentCtx)
+			return f.html, nil
+		}
+	}
+
+	// Render against state of its own, so it can be set again on reuse.
+	state := make(map[string]interface{})
+	renderCtx := parentCtx
+	if parentCtx != nil {
+		scoped := *parentCtx
+		scoped.State = state
+		renderCtx = &scoped
+	}
+
+	child := &Session{compiler: s.compiler, resolve: s.resolve}
+	html, err := child.render(comp, policy.props, slots, renderCtx)
+	if err != nil {
+		return "", err
+	}
+
+	f := &fragment{
+		html:       html,
+		styles:     child.CollectedStyles,
+		components: child.UsedComponents,
+		islands:    child.Islands,
+		state:      state,
+		sources:    map[string]string{filePath: s.compiler.sourceHash(filePath)},
+	}
+	for _, path := range child.UsedComponents {
+		f.sources[path] = s.compiler.sourceHash(path)
+	}
+	if len(html) <= MaxFragmentSize {
+		s.compiler.Fragments.Set(key, f, policy.ttl, filePath)
+	}
+	s.use(f, parentCtx)
+	return html, nil
+}
+
+// current reports whether none of the components f was rendered from have
+// changed since, so edits show up in dev without a restart.
+func (c *ComponentCompiler) current(f *fragment) bool {
+	for path, hash := range f.sources {
+		if hash == "" || c.sourceHash(path) != hash {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Session) use(f *fragment, ctx *executor.Context) {
+	s.CollectedStyles = append(s.CollectedStyles, f.styles...)
+	s.Islands = append(s.Islands, f.islands...)
+	if ctx != nil {
+		for k, v := range f.state {
+			ctx.State[k] = v
+		}
+	}
+	for _, path := range f.components {
+		s.trackComponent(path)
+	}
+}
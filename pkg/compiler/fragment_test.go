@@ -0,0 +1,99 @@
+package compiler
+
+import (
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/withgalaxy/galaxy/pkg/executor"
+)
+
+func TestSession_CachesFragments(t *testing.T) {
+	var renders atomic.Int32
+	executor.RegisterGlobalFunc("fragments", "Render", func(args ...interface{}) (interface{}, error) {
+		return renders.Add(1), nil
+	})
+
+	tmpDir := t.TempDir()
+	link := filepath.Join(tmpDir, "components", "Link.gxc")
+	writeFile(t, filepath.Join(tmpDir, "components", "Menu.gxc"),
+		"---\nn, _ := fragments.Render()\n---\n<nav>{label} <Link/></nav>\n<style>nav { color: red; }</style>")
+	writeFile(t, link, "<a>home</a>\n<style>a { color: blue; }</style>")
+
+	cc := NewComponentCompiler(tmpDir)
+	render := func(tag, locale string) (string, *Session) {
+		ctx := executor.NewContext()
+		ctx.Set("locale", locale)
+		s := cc.NewSession(filepath.Join(tmpDir, "pages", "index.gxc"), nil)
+		return s.ProcessComponentTags(tag, ctx), s
+	}
+	const cached = `<Menu cache="5m" cacheKey={locale} label={locale}/>`
+
+	first, _ := render(cached, "en")
+	second, s := render(cached, "en")
+	if first != second || !strings.Contains(second, "en") || !strings.Contains(second, "home") {
+		t.Fatalf("cached render %q differs from %q", second, first)
+	}
+	if renders.Load() != 1 {
+		t.Errorf("expected one render, got %d", renders.Load())
+	}
+	if len(s.CollectedStyles) != 2 || len(s.UsedComponents) != 2 {
+		t.Errorf("cache hit should record styles and components: %d styles, %v", len(s.CollectedStyles), s.UsedComponents)
+	}
+
+	if got, _ := render(cached, "fr"); !strings.Contains(got, "fr") || renders.Load() != 2 {
+		t.Errorf("a new cacheKey should render again, got %q after %d renders", got, renders.Load())
+	}
+
+	// The dev server drops parsed components between requests; an edited
+	// nested component invalidates the fragment.
+	writeFile(t, link, "<a>changed</a>")
+	cc.ClearCache()
+	if got, _ := render(cached, "en"); !strings.Contains(got, "changed") {
+		t.Errorf("expected the edited component, got %q", got)
+	}
+
+	before := renders.Load()
+	render(`<Menu label="x"/>`, "en")
+	render(`<Menu label="x"/>`, "en")
+	if renders.Load() != before+2 {
+		t.Error("components without a cache attribute should render every time")
+	}
+}
+
+func TestSession_CachesFragmentsFromFrontmatter(t *testing.T) {
+	var renders atomic.Int32
+	executor.RegisterGlobalFunc("footer", "Render", func(args ...interface{}) (interface{}, error) {
+		return renders.Add(1), nil
+	})
+
+	tmpDir := t.TempDir()
+	writeFile(t, filepath.Join(tmpDir, "components", "Footer.gxc"),
+		"---\n// cache = 1h\nn, _ := footer.Render()\n---\n<footer>{year}</footer>")
+
+	cc := NewComponentCompiler(tmpDir)
+	s := cc.NewSession(filepath.Join(tmpDir, "pages", "index.gxc"), nil)
+	for _, tag := range []string{`<Footer year="2024"/>`, `<Footer year="2024"/>`, `<Footer year="2025"/>`} {
+		s.ProcessComponentTags(tag, executor.NewContext())
+	}
+	if renders.Load() != 2 {
+		t.Errorf("expected fragments keyed by props, got %d renders", renders.Load())
+	}
+}
+
+func TestSession_CachedFragmentsSetState(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFile(t, filepath.Join(tmpDir, "components", "Cart.gxc"),
+		"---\n// cache = 1h\nGalaxy.SetState(\"cart\", count)\n---\n<span>{count}</span>")
+
+	cc := NewComponentCompiler(tmpDir)
+	s := cc.NewSession(filepath.Join(tmpDir, "pages", "index.gxc"), nil)
+	for i := 0; i < 2; i++ {
+		ctx := executor.NewContext()
+		s.ProcessComponentTags(`<Cart count="3"/>`, ctx)
+		if ctx.State["cart"] != "3" {
+			t.Errorf("render %d: expected the component's state on the page, got %v", i, ctx.State)
+		}
+	}
+}
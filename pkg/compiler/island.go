@@ -0,0 +1,60 @@
+package compiler
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/withgalaxy/galaxy/pkg/assets"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/ssr"
+)
+
+var clientDirectiveRegex = regexp.MustCompile(`\s*\bclient:(load|idle|visible|media)(?:="([^"]*)"|='([^']*)')?`)
+
+// clientDirective removes a client: directive from a component tag's
+// attributes, returning the hydration strategy it asks for, if any:
+//
+//	<Counter client:visible start={5}/>
+//	<Sidebar client:media="(min-width: 800px)"/>
+func clientDirective(attrs string) (rest, strategy, media string) {
+	m := clientDirectiveRegex.FindStringSubmatch(attrs)
+	if m == nil {
+		return attrs, "", ""
+	}
+	media = m[2] + m[3]
+	return clientDirectiveRegex.ReplaceAllString(attrs, ""), m[1], media
+}
+
+// island wraps the rendered component at componentPath in an island, so its
+// script starts when strategy fires. The hydration script is loaded under
+// the compiler's Bundler.Base.
+func (s *Session) island(componentPath string, props map[string]interface{}, strategy, media, rendered string) string {
+	island := ssr.NewIsland(assets.WasmModuleID(componentPath), props, strategy)
+	island.Media = media
+	island.Nonce = security.CSPNoncePlaceholder
+	island.Base = s.compiler.Bundler.Base
+
+	// Identical islands on a page still need their own root.
+	n := 0
+	for _, other := range s.Islands {
+		if other.ComponentPath == island.ComponentPath && fmt.Sprint(other.Props) == fmt.Sprint(island.Props) {
+			n++
+		}
+	}
+	if n > 0 {
+		island.ID = fmt.Sprintf("%s-%d", island.ID, n)
+	}
+
+	s.Islands = append(s.Islands, island)
+	return island.WrapContent(rendered)
+}
+
+func isIsland(islands []*ssr.Island, componentPath string) bool {
+	id := assets.WasmModuleID(componentPath)
+	for _, island := range islands {
+		if island.ComponentPath == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package compiler
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/withgalaxy/galaxy/pkg/executor"
+	"github.com/withgalaxy/galaxy/pkg/ssr"
+)
+
+func TestSession_ClientDirectives(t *testing.T) {
+	tmpDir := t.TempDir()
+	counter := filepath.Join(tmpDir, "components", "Counter.gxc")
+	writeFile(t, counter, "<button>{start}</button>\n<script>\nprintln(1)\n</script>")
+	writeFile(t, filepath.Join(tmpDir, "components", "Footer.gxc"), "<footer/>\n<script>\nprintln(2)\n</script>")
+
+	cc := NewComponentCompiler(tmpDir)
+	s := cc.NewSession(filepath.Join(tmpDir, "pages", "index.gxc"), nil)
+	ctx := executor.NewContext()
+	ctx.Set("n", 5)
+
+	html := s.ProcessComponentTags(`<Counter client:media="(min-width: 800px)" start="1"></Counter>
+<Counter client:visible start={n}/><Counter client:visible start={n}/><Footer/>`, ctx)
+
+	if len(s.Islands) != 3 {
+		t.Fatalf("expected 3 islands, got %d", len(s.Islands))
+	}
+	media, first, second := s.Islands[0], s.Islands[1], s.Islands[2]
+	if first.Strategy != ssr.StrategyVisible || first.Props["start"] != 5 {
+		t.Errorf("unexpected island %+v", first)
+	}
+	if first.ID == second.ID {
+		t.Error("identical islands should get their own IDs")
+	}
+	if media.Strategy != ssr.StrategyMedia || media.Media != "(min-width: 800px)" {
+		t.Errorf("unexpected media island %+v", media)
+	}
+	if _, ok := media.Props["media"]; ok {
+		t.Error("the directive should not be passed as a prop")
+	}
+
+	if !strings.Contains(html, `<div data-island-id="`+first.ID+`" data-island-strategy="visible"><button>5</button></div>`) {
+		t.Errorf("expected the server-rendered component in its island, got %s", html)
+	}
+	if !strings.Contains(html, `hydrate('`+first.ID+`', '`+first.ComponentPath+`', {"start":5}, 'visible')`) {
+		t.Errorf("expected a hydration script with the island's props, got %s", html)
+	}
+	if strings.Count(html, "data-island-id") != 3 {
+		t.Error("components without a directive should not be islands")
+	}
+
+	for _, src := range s.WasmSources() {
+		if want := src.Path == counter; src.Island != want {
+			t.Errorf("%s: expected Island %v", src.Path, want)
+		}
+	}
+}
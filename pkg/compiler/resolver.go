@@ -2,28 +2,40 @@ package compiler
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 )
 
 type ComponentResolver struct {
 	BaseDir        string
+	FS             fs.FS
 	ComponentDirs  []string
 	CurrentFile    string
 	ExplicitPaths  map[string]string
 	Cache          map[string]string
 	ComponentIndex map[string]string
+
+	cacheMu sync.RWMutex
 }
 
 func NewComponentResolver(baseDir string, componentDirs []string) *ComponentResolver {
+	return NewComponentResolverFS(nil, baseDir, componentDirs)
+}
+
+// NewComponentResolverFS resolves components in fsys, or the OS filesystem
+// if fsys is nil.
+func NewComponentResolverFS(fsys fs.FS, baseDir string, componentDirs []string) *ComponentResolver {
 	if componentDirs == nil {
 		componentDirs = []string{"components"}
 	}
 
 	resolver := &ComponentResolver{
 		BaseDir:        baseDir,
+		FS:             fsys,
 		ComponentDirs:  componentDirs,
 		ExplicitPaths:  make(map[string]string),
 		Cache:          make(map[string]string),
@@ -35,7 +47,7 @@ func NewComponentResolver(baseDir string, componentDirs []string) *ComponentReso
 }
 
 func (r *ComponentResolver) buildComponentIndex() {
-	filepath.Walk(r.BaseDir, func(path string, info os.FileInfo, err error) error {
+	walk(r.FS, r.BaseDir, func(path string, info fs.FileInfo, err error) error {
 		if err != nil {
 			return nil
 		}
@@ -96,28 +108,38 @@ func (r *ComponentResolver) ExtractComponentRefs(template string) []string {
 }
 
 func (r *ComponentResolver) Resolve(name string) (string, error) {
-	if cached, ok := r.Cache[name]; ok {
+	r.cacheMu.RLock()
+	cached, ok := r.Cache[name]
+	r.cacheMu.RUnlock()
+	if ok {
 		return cached, nil
 	}
 
-	if path, ok := r.ExplicitPaths[name]; ok {
-		resolved, err := r.resolveImportPath(path)
-		if err != nil {
-			return "", err
-		}
-		r.Cache[name] = resolved
-		return resolved, nil
+	resolved, err := r.ResolveFrom(name, r.CurrentFile, r.ExplicitPaths)
+	if err != nil {
+		return "", err
+	}
+	r.cacheMu.Lock()
+	r.Cache[name] = resolved
+	r.cacheMu.Unlock()
+	return resolved, nil
+}
+
+// ResolveFrom resolves name for the page at currentFile, whose component
+// imports map aliases to paths. It ignores CurrentFile, ExplicitPaths and
+// Cache, so concurrent renders can share one resolver.
+func (r *ComponentResolver) ResolveFrom(name, currentFile string, imports map[string]string) (string, error) {
+	if path, ok := imports[name]; ok {
+		return r.resolveImportPath(path, currentFile)
 	}
 
 	if path, ok := r.ComponentIndex[name]; ok {
-		r.Cache[name] = path
 		return path, nil
 	}
 
-	if r.CurrentFile != "" {
-		path := filepath.Join(filepath.Dir(r.CurrentFile), name+".gxc")
-		if fileExists(path) {
-			r.Cache[name] = path
+	if currentFile != "" {
+		path := filepath.Join(filepath.Dir(currentFile), name+".gxc")
+		if r.exists(path) {
 			return path, nil
 		}
 	}
@@ -125,13 +147,13 @@ func (r *ComponentResolver) Resolve(name string) (string, error) {
 	return "", fmt.Errorf("component %s not found in %s", name, r.BaseDir)
 }
 
-func (r *ComponentResolver) resolveImportPath(importPath string) (string, error) {
+func (r *ComponentResolver) resolveImportPath(importPath, currentFile string) (string, error) {
 	if strings.HasPrefix(importPath, "./") || strings.HasPrefix(importPath, "../") {
-		if r.CurrentFile == "" {
+		if currentFile == "" {
 			return "", fmt.Errorf("relative import requires current file context")
 		}
-		resolved := filepath.Join(filepath.Dir(r.CurrentFile), importPath)
-		if fileExists(resolved) {
+		resolved := filepath.Join(filepath.Dir(currentFile), importPath)
+		if r.exists(resolved) {
 			return resolved, nil
 		}
 		return "", fmt.Errorf("import path not found: %s", importPath)
@@ -139,21 +161,39 @@ func (r *ComponentResolver) resolveImportPath(importPath string) (string, error)
 
 	if strings.HasPrefix(importPath, "@/") {
 		resolved := filepath.Join(r.BaseDir, strings.TrimPrefix(importPath, "@/"))
-		if fileExists(resolved) {
+		if r.exists(resolved) {
 			return resolved, nil
 		}
 		return "", fmt.Errorf("import path not found: %s", importPath)
 	}
 
 	resolved := filepath.Join(r.BaseDir, importPath)
-	if fileExists(resolved) {
+	if r.exists(resolved) {
 		return resolved, nil
 	}
 
 	return "", fmt.Errorf("import path not found: %s", importPath)
 }
 
-func fileExists(path string) bool {
-	_, err := os.Stat(path)
+func (r *ComponentResolver) exists(path string) bool {
+	if r.FS == nil {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+	_, err := fs.Stat(r.FS, filepath.ToSlash(filepath.Clean(path)))
 	return err == nil
 }
+
+// walk is filepath.Walk over fsys, or the OS filesystem if fsys is nil.
+func walk(fsys fs.FS, root string, fn filepath.WalkFunc) error {
+	if fsys == nil {
+		return filepath.Walk(root, fn)
+	}
+	return fs.WalkDir(fsys, filepath.ToSlash(filepath.Clean(root)), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(filepath.FromSlash(path), nil, err)
+		}
+		info, err := d.Info()
+		return fn(filepath.FromSlash(path), info, err)
+	})
+}
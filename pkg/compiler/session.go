@@ -0,0 +1,211 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/withgalaxy/galaxy/pkg/assets"
+	"github.com/withgalaxy/galaxy/pkg/executor"
+	"github.com/withgalaxy/galaxy/pkg/parser"
+	"github.com/withgalaxy/galaxy/pkg/ssr"
+	tmpl "github.com/withgalaxy/galaxy/pkg/template"
+)
+
+// Session holds the state of a single page render. Sessions share their
+// compiler's component cache and resolver index but nothing else, so any
+// number of them can render concurrently.
+type Session struct {
+	// CollectedStyles are the styles of every component rendered so far.
+	CollectedStyles []parser.Style
+	// UsedComponents lists the paths of rendered components in first-use
+	// order.
+	UsedComponents []string
+	// Islands are the components to hydrate on the client.
+	Islands []*ssr.Island
+
+	compiler *ComponentCompiler
+	resolve  func(name string) (string, error)
+	seen     map[string]bool
+}
+
+// NewSession starts a render of the page at currentFile, resolving its
+// component imports relative to it.
+func (c *ComponentCompiler) NewSession(currentFile string, imports []Import) *Session {
+	explicit := make(map[string]string)
+	for _, imp := range imports {
+		if imp.IsComponent {
+			explicit[imp.Alias] = imp.Path
+		}
+	}
+	resolver := c.Resolver
+	return &Session{
+		compiler: c,
+		resolve: func(name string) (string, error) {
+			return resolver.ResolveFrom(name, currentFile, explicit)
+		},
+	}
+}
+
+// PageImports converts a parsed page's imports for NewSession.
+func PageImports(comp *parser.Component) []Import {
+	imports := make([]Import, len(comp.Imports))
+	for i, imp := range comp.Imports {
+		imports[i] = Import{
+			Path:        imp.Path,
+			Alias:       imp.Alias,
+			IsComponent: imp.IsComponent,
+		}
+	}
+	return imports
+}
+
+func (s *Session) trackComponent(componentPath string) {
+	if s.seen == nil {
+		s.seen = make(map[string]bool)
+	}
+	if !s.seen[componentPath] {
+		s.UsedComponents = append(s.UsedComponents, componentPath)
+		s.seen[componentPath] = true
+	}
+}
+
+// WasmSources returns the components rendered so far, for linking their Go
+// scripts into the page's module.
+func (s *Session) WasmSources() []assets.WasmSource {
+	return s.compiler.wasmSources(s.UsedComponents, s.Islands)
+}
+
+func (s *Session) Compile(filePath string, props map[string]interface{}, slots map[string]string) (string, error) {
+	return s.CompileWithContext(filePath, props, slots, nil)
+}
+
+func (s *Session) CompileWithContext(filePath string, props map[string]interface{}, slots map[string]string, parentCtx *executor.Context) (string, error) {
+	comp, err := s.compiler.loadComponent(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return s.render(comp, props, slots, parentCtx)
+}
+
+// compileTag renders a component used in a template, through the fragment
+// cache if it is declared cacheable.
+func (s *Session) compileTag(filePath string, props map[string]interface{}, slots map[string]string, ctx *executor.Context) (string, error) {
+	comp, err := s.compiler.loadComponent(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	if policy, ok := fragmentPolicyFor(comp, props); ok && s.compiler.Fragments != nil {
+		return s.compileFragment(filePath, comp, policy, slots, ctx)
+	}
+	return s.render(comp, props, slots, ctx)
+}
+
+func (s *Session) render(comp *parser.Component, props map[string]interface{}, slots map[string]string, parentCtx *executor.Context) (string, error) {
+	copiedStyles := make([]parser.Style, len(comp.Styles))
+	copy(copiedStyles, comp.Styles)
+	s.CollectedStyles = append(s.CollectedStyles, copiedStyles...)
+
+	var ctx *executor.Context
+	if parentCtx != nil {
+		ctx = parentCtx.Clone()
+	} else {
+		ctx = executor.NewContext()
+	}
+
+	for k, v := range props {
+		ctx.SetProp(k, v)
+		ctx.Set(k, v)
+	}
+
+	if comp.Frontmatter != "" {
+		if err := ctx.Execute(comp.Frontmatter); err != nil {
+			return "", err
+		}
+	}
+
+	processedTemplate := s.ProcessComponentTags(comp.Template, ctx)
+
+	engine := tmpl.NewEngine(ctx)
+	rendered, err := engine.Render(processedTemplate, &tmpl.RenderOptions{
+		Props:     props,
+		Slots:     slots,
+		ParentCtx: parentCtx,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return rendered, nil
+}
+
+func (s *Session) ProcessComponentTags(template string, ctx *executor.Context) string {
+	template = processBuiltins(template)
+
+	result := componentOpenCloseRegex.ReplaceAllStringFunc(template, func(match string) string {
+		matches := componentOpenCloseRegex.FindStringSubmatch(match)
+
+		componentName := matches[1]
+		attrs, strategy, media := clientDirective(matches[2])
+		content := matches[3]
+		closingTag := matches[4]
+
+		if componentName != closingTag {
+			return match
+		}
+
+		componentPath, err := s.resolve(componentName)
+		if err != nil {
+			return fmt.Sprintf("<!-- Component resolution error: %v -->", err)
+		}
+
+		s.trackComponent(componentPath)
+
+		props := s.compiler.parseAttributes(attrs, ctx)
+
+		slots := make(map[string]string)
+		trimmedContent := strings.TrimSpace(content)
+		if trimmedContent != "" {
+			slots["default"] = trimmedContent
+		}
+
+		rendered, err := s.compileTag(componentPath, props, slots, ctx)
+		if err != nil {
+			return fmt.Sprintf("<!-- Error rendering %s: %v -->", componentName, err)
+		}
+		if strategy != "" {
+			return s.island(componentPath, props, strategy, media, rendered)
+		}
+
+		return rendered
+	})
+
+	result = componentSelfCloseRegex.ReplaceAllStringFunc(result, func(match string) string {
+		matches := componentSelfCloseRegex.FindStringSubmatch(match)
+
+		componentName := matches[1]
+		attrs, strategy, media := clientDirective(matches[2])
+
+		componentPath, err := s.resolve(componentName)
+		if err != nil {
+			return fmt.Sprintf("<!-- Component resolution error: %v -->", err)
+		}
+
+		s.trackComponent(componentPath)
+
+		props := s.compiler.parseAttributes(attrs, ctx)
+
+		rendered, err := s.compileTag(componentPath, props, make(map[string]string), ctx)
+		if err != nil {
+			return fmt.Sprintf("<!-- Error rendering %s: %v -->", componentName, err)
+		}
+		if strategy != "" {
+			return s.island(componentPath, props, strategy, media, rendered)
+		}
+
+		return rendered
+	})
+
+	return result
+}
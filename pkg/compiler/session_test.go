@@ -0,0 +1,100 @@
+package compiler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/withgalaxy/galaxy/pkg/executor"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSession_ResolvesRelativeToPage(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFile(t, filepath.Join(tmpDir, "pages", "a", "Badge.gxc"), `<b>a</b>`)
+	writeFile(t, filepath.Join(tmpDir, "pages", "b", "Badge.gxc"), `<b>b</b>`)
+
+	cc := NewComponentCompiler(tmpDir)
+	a := cc.NewSession(filepath.Join(tmpDir, "pages", "a", "index.gxc"), nil)
+	b := cc.NewSession(filepath.Join(tmpDir, "pages", "b", "index.gxc"), nil)
+
+	if got := a.ProcessComponentTags(`<Badge />`, executor.NewContext()); got != "<b>a</b>" {
+		t.Errorf("page a rendered %q", got)
+	}
+	if got := b.ProcessComponentTags(`<Badge />`, executor.NewContext()); got != "<b>b</b>" {
+		t.Errorf("page b rendered %q", got)
+	}
+	if cc.CollectedStyles != nil || cc.UsedComponents != nil {
+		t.Error("sessions should not record state on the compiler")
+	}
+}
+
+func TestSession_ConcurrentRenders(t *testing.T) {
+	tmpDir := t.TempDir()
+	writeFile(t, filepath.Join(tmpDir, "components", "Nav.gxc"), "<nav>{title}</nav>\n<style>nav { color: red; }</style>")
+	for _, page := range []string{"a", "b"} {
+		writeFile(t, filepath.Join(tmpDir, "pages", page, "Badge.gxc"),
+			fmt.Sprintf("<b>%s {name}</b>\n<style>.badge-%s { color: blue; }</style>", page, page))
+	}
+
+	cc := NewComponentCompiler(tmpDir)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			page, other := "a", "b"
+			if i%2 == 1 {
+				page, other = other, page
+			}
+
+			for j := 0; j < 20; j++ {
+				if i == 0 && j%5 == 0 {
+					cc.ClearCache()
+				}
+
+				render := cc.NewSession(filepath.Join(tmpDir, "pages", page, "index.gxc"), nil)
+				ctx := executor.NewContext()
+				ctx.Set("title", page)
+				ctx.Set("user", fmt.Sprintf("user%d", i))
+				got := render.ProcessComponentTags(`<Nav title={title} /><Badge name={user} />`, ctx)
+
+				want := fmt.Sprintf("<nav>%s</nav><b>%s user%d</b>", page, page, i)
+				if !strings.Contains(got, want) {
+					errs <- fmt.Errorf("session for page %s rendered %q, want %q", page, got, want)
+					return
+				}
+				if len(render.CollectedStyles) != 2 || len(render.UsedComponents) != 2 {
+					errs <- fmt.Errorf("session collected %d styles and %d components, want 2 each", len(render.CollectedStyles), len(render.UsedComponents))
+					return
+				}
+				for _, style := range render.CollectedStyles {
+					if strings.Contains(style.Content, "badge-"+other) {
+						errs <- fmt.Errorf("page %s collected page %s's styles", page, other)
+						return
+					}
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,301 @@
+// Package compress negotiates gzip and brotli for dynamic responses and
+// writes precompressed siblings of static files at build time.
+package compress
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/middleware"
+)
+
+const (
+	Brotli = "br"
+	Gzip   = "gzip"
+)
+
+// Extensions maps each supported encoding to the suffix of its
+// precompressed sibling.
+var Extensions = map[string]string{
+	Brotli: ".br",
+	Gzip:   ".gz",
+}
+
+// Negotiate returns the preferred encoding accepted by r, or "" for none.
+func Negotiate(r *http.Request) string {
+	if accepted := Accepted(r); len(accepted) > 0 {
+		return accepted[0]
+	}
+	return ""
+}
+
+// Accepted lists the encodings r accepts, most preferred first. Brotli wins
+// ties since it compresses text better.
+func Accepted(r *http.Request) []string {
+	header := r.Header.Get("Accept-Encoding")
+	if header == "" {
+		return nil
+	}
+
+	q := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		weight := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				weight = parsed
+			}
+		}
+
+		switch name {
+		case Brotli, Gzip:
+			q[name] = weight
+		case "*":
+			for _, enc := range []string{Brotli, Gzip} {
+				if _, ok := q[enc]; !ok {
+					q[enc] = weight
+				}
+			}
+		}
+	}
+
+	var accepted []string
+	for _, enc := range []string{Brotli, Gzip} {
+		if q[enc] > 0 {
+			accepted = append(accepted, enc)
+		}
+	}
+	if len(accepted) == 2 && q[Gzip] > q[Brotli] {
+		accepted[0], accepted[1] = accepted[1], accepted[0]
+	}
+	return accepted
+}
+
+// Compressible reports whether responses of contentType benefit from
+// compression.
+func Compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/javascript", "application/xml",
+		"application/wasm", "image/svg+xml", "application/manifest+json":
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+var (
+	gzipPool   = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	brotliPool = sync.Pool{New: func() any { return brotli.NewWriterLevel(io.Discard, 5) }}
+)
+
+// Handler compresses compressible responses of at least cfg.MinSize bytes.
+// Responses that already set Content-Encoding pass through untouched.
+func Handler(cfg config.CompressionConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cw := newWriter(w, r, cfg.MinSize)
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// Middleware is Handler for a middleware.Chain.
+func Middleware(cfg config.CompressionConfig) middleware.Middleware {
+	return func(ctx *middleware.Context, next func() error) error {
+		cw := newWriter(ctx.Response, ctx.Request, cfg.MinSize)
+		defer cw.Close()
+		ctx.Response = cw
+		return next()
+	}
+}
+
+// writer buffers the start of a response until it knows whether to
+// compress it: the body reaches minSize, or the handler flushes or returns.
+type writer struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status      int
+	buf         []byte
+	decided     bool
+	compressor  io.WriteCloser
+	wroteHeader bool
+}
+
+func newWriter(w http.ResponseWriter, r *http.Request, minSize int) *writer {
+	return &writer{ResponseWriter: w, encoding: Negotiate(r), minSize: minSize}
+}
+
+func (w *writer) WriteHeader(status int) {
+	if w.status != 0 || w.wroteHeader {
+		return
+	}
+	// Informational responses go straight through.
+	if status >= 100 && status < 200 {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if w.decided {
+		return w.write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.decide(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *writer) write(p []byte) (int, error) {
+	if w.compressor != nil {
+		return w.compressor.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// decide picks compressed or identity output, writes the header and any
+// buffered body.
+func (w *writer) decide() error {
+	w.decided = true
+	h := w.Header()
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+	compressible := Compressible(h.Get("Content-Type"))
+	if compressible && !varies(h) {
+		h.Add("Vary", "Accept-Encoding")
+	}
+
+	if w.encoding != "" && compressible && len(w.buf) >= w.minSize &&
+		h.Get("Content-Encoding") == "" && w.status != http.StatusNoContent &&
+		w.status != http.StatusNotModified && w.status >= http.StatusOK {
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			h.Set("ETag", "W/"+etag)
+		}
+		w.compressor = w.newCompressor()
+	}
+
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(w.status)
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.write(buf)
+	return err
+}
+
+func varies(h http.Header) bool {
+	for _, v := range h.Values("Vary") {
+		for _, field := range strings.Split(v, ",") {
+			if f := strings.TrimSpace(field); f == "*" || strings.EqualFold(f, "Accept-Encoding") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (w *writer) newCompressor() io.WriteCloser {
+	if w.encoding == Brotli {
+		bw := brotliPool.Get().(*brotli.Writer)
+		bw.Reset(w.ResponseWriter)
+		return &pooled{WriteCloser: bw, put: func() { brotliPool.Put(bw) }}
+	}
+	gw := gzipPool.Get().(*gzip.Writer)
+	gw.Reset(w.ResponseWriter)
+	return &pooled{WriteCloser: gw, put: func() { gzipPool.Put(gw) }}
+}
+
+// Close flushes the response. Handlers that never wrote still get their
+// status sent.
+func (w *writer) Close() error {
+	if !w.decided {
+		if w.status == 0 {
+			return nil
+		}
+		if err := w.decide(); err != nil {
+			return err
+		}
+	}
+	if w.compressor != nil {
+		err := w.compressor.Close()
+		w.compressor = nil
+		return err
+	}
+	return nil
+}
+
+func (w *writer) Flush() {
+	if !w.decided && w.status != 0 {
+		w.decide()
+	}
+	if f, ok := w.compressor.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *writer) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := w.ResponseWriter.(http.Hijacker); ok {
+		w.decided = true
+		return h.Hijack()
+	}
+	return nil, nil, http.ErrNotSupported
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+type pooled struct {
+	io.WriteCloser
+	put func()
+}
+
+func (p *pooled) Flush() error {
+	if f, ok := p.WriteCloser.(interface{ Flush() error }); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (p *pooled) Close() error {
+	err := p.WriteCloser.Close()
+	p.put()
+	return err
+}
@@ -0,0 +1,128 @@
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/withgalaxy/galaxy/pkg/config"
+)
+
+func TestAccepted(t *testing.T) {
+	tests := map[string][]string{
+		"":                      nil,
+		"gzip":                  {Gzip},
+		"gzip, deflate, br":     {Brotli, Gzip},
+		"br;q=0.5, gzip":        {Gzip, Brotli},
+		"br;q=0, gzip":          {Gzip},
+		"*":                     {Brotli, Gzip},
+		"identity, gzip;q=0, *": {Brotli},
+		"deflate":               nil,
+	}
+	for header, want := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Header.Set("Accept-Encoding", header)
+		if got := Accepted(r); strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("Accepted(%q) = %v, want %v", header, got, want)
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	page := strings.Repeat("<p>hello</p>", 200)
+	h := Handler(config.CompressionConfig{Enabled: true, MinSize: 1024}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small":
+			w.Write([]byte("<p>hi</p>"))
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte(page))
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(page[:100]))
+			w.Write([]byte(page[100:]))
+		}
+	}))
+
+	for encoding, decode := range map[string]func(io.Reader) (io.Reader, error){
+		Brotli: func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+		Gzip:   func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+	} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", encoding)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+
+		if got := w.Header().Get("Content-Encoding"); got != encoding {
+			t.Fatalf("Content-Encoding = %q, want %q", got, encoding)
+		}
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Error("expected Vary: Accept-Encoding")
+		}
+		r, err := decode(w.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(r)
+		if err != nil || string(body) != page {
+			t.Errorf("%s body did not round-trip: %v", encoding, err)
+		}
+	}
+
+	for _, path := range []string{"/small", "/image"} {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		if w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("%s should not be compressed", path)
+		}
+	}
+}
+
+func TestPrecompressDir(t *testing.T) {
+	dir := t.TempDir()
+	css := strings.Repeat("body { color: red; }\n", 100)
+	for name, data := range map[string]string{
+		"_assets/app.css": css,
+		"tiny.js":         "x()",
+		"photo.png":       css,
+	} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := PrecompressDir(dir, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"_assets/app.css.br", "_assets/app.css.gz"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("expected %s", name)
+		}
+	}
+	for _, name := range []string{"tiny.js.gz", "photo.png.gz"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("did not expect %s", name)
+		}
+	}
+
+	f, _ := os.Open(filepath.Join(dir, "_assets/app.css.gz"))
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := io.ReadAll(zr); string(data) != css {
+		t.Error("gzip sibling does not match its source")
+	}
+}
@@ -0,0 +1,91 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/andybalholm/brotli"
+)
+
+// PrecompressExts are the static file types given .gz and .br siblings.
+var PrecompressExts = map[string]bool{
+	".css":  true,
+	".js":   true,
+	".mjs":  true,
+	".wasm": true,
+	".html": true,
+	".svg":  true,
+}
+
+// PrecompressDir writes .gz and .br siblings next to the files in dir with
+// a PrecompressExts extension and at least minSize bytes. Siblings that are
+// newer than their source are kept, and encodings that don't shrink a file
+// are skipped.
+func PrecompressDir(dir string, minSize int) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == "_build" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !PrecompressExts[filepath.Ext(path)] || info.Size() < int64(minSize) {
+			return nil
+		}
+		return precompressFile(path, info)
+	})
+}
+
+func precompressFile(path string, info os.FileInfo) error {
+	var data []byte
+	for encoding, ext := range Extensions {
+		target := path + ext
+		if sibling, err := os.Stat(target); err == nil && !sibling.ModTime().Before(info.ModTime()) {
+			continue
+		}
+
+		if data == nil {
+			var err error
+			if data, err = os.ReadFile(path); err != nil {
+				return err
+			}
+		}
+
+		var buf bytes.Buffer
+		if err := encode(&buf, encoding, data); err != nil {
+			return err
+		}
+		if buf.Len() >= len(data) {
+			os.Remove(target)
+			continue
+		}
+		if err := os.WriteFile(target, buf.Bytes(), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func encode(w io.Writer, encoding string, data []byte) error {
+	var enc io.WriteCloser
+	if encoding == Brotli {
+		enc = brotli.NewWriterLevel(w, brotli.BestCompression)
+	} else {
+		gw, err := gzip.NewWriterLevel(w, gzip.BestCompression)
+		if err != nil {
+			return err
+		}
+		enc = gw
+	}
+	if _, err := enc.Write(data); err != nil {
+		enc.Close()
+		return err
+	}
+	return enc.Close()
+}
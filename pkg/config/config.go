@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 )
@@ -99,6 +101,92 @@ Cloudflare Pages does not support Go-based serverless functions. To fix this:
 For SSR deployments, use the standalone adapter with platforms like Docker, Railway, or Fly.io`, c.Output.Type)
 	}
 
+	switch c.Session.Store {
+	case SessionStoreCookie, SessionStoreMemory, SessionStoreFile:
+	case "":
+		c.Session.Store = SessionStoreCookie
+	default:
+		return fmt.Errorf("invalid session store: %s (must be cookie, memory, or file)", c.Session.Store)
+	}
+
+	switch strings.ToLower(c.Session.SameSite) {
+	case "", "lax", "strict", "none":
+	default:
+		return fmt.Errorf("invalid session sameSite: %s (must be lax, strict, or none)", c.Session.SameSite)
+	}
+
+	if c.Session.CookieName == "" {
+		c.Session.CookieName = "galaxy_session"
+	}
+
+	if c.Session.Path == "" {
+		c.Session.Path = "/"
+	}
+
+	switch c.Security.CSRF.Mode {
+	case CSRFModeSynchronizer, CSRFModeDoubleSubmit:
+	case "":
+		c.Security.CSRF.Mode = CSRFModeDoubleSubmit
+	default:
+		return fmt.Errorf("invalid csrf mode: %s (must be synchronizer or double-submit)", c.Security.CSRF.Mode)
+	}
+
+	if c.Security.CSRF.Enabled && c.Security.CSRF.Mode == CSRFModeSynchronizer && !c.Session.Enabled {
+		return fmt.Errorf("security.csrf mode \"synchronizer\" stores tokens in the session; enable [session] or use mode = \"double-submit\"")
+	}
+
+	for i := range c.Security.RateLimit.Rules {
+		rule := &c.Security.RateLimit.Rules[i]
+		if rule.Path == "" {
+			rule.Path = "/*"
+		}
+		if rule.Limit <= 0 {
+			return fmt.Errorf("security.rateLimit rule %q: limit must be positive", rule.Path)
+		}
+		if rule.Window <= 0 {
+			rule.Window = 60
+		}
+		switch rule.Algorithm {
+		case RateLimitTokenBucket, RateLimitSlidingWindow:
+		case "":
+			rule.Algorithm = RateLimitTokenBucket
+		default:
+			return fmt.Errorf("security.rateLimit rule %q: invalid algorithm %s (must be token-bucket or sliding-window)", rule.Path, rule.Algorithm)
+		}
+		switch {
+		case rule.Key == "":
+			rule.Key = "ip"
+		case rule.Key == "ip", rule.Key == "session":
+		case strings.HasPrefix(rule.Key, "locals:") && len(rule.Key) > len("locals:"):
+		default:
+			return fmt.Errorf("security.rateLimit rule %q: invalid key %s (must be ip, session, or locals:<name>)", rule.Path, rule.Key)
+		}
+	}
+
+	for i := range c.Security.CORS.Rules {
+		rule := &c.Security.CORS.Rules[i]
+		if rule.Path == "" {
+			rule.Path = "/*"
+		}
+		if len(rule.AllowOrigins) == 0 {
+			return fmt.Errorf("security.cors rule %q: allowOrigins is required", rule.Path)
+		}
+		for _, origin := range rule.AllowOrigins {
+			if origin == "*" && rule.AllowCredentials {
+				return fmt.Errorf("security.cors rule %q: allowCredentials cannot be used with origin \"*\"", rule.Path)
+			}
+		}
+		if len(rule.AllowMethods) == 0 {
+			rule.AllowMethods = []string{"GET", "HEAD", "POST"}
+		}
+		for j, m := range rule.AllowMethods {
+			rule.AllowMethods[j] = strings.ToUpper(m)
+		}
+		if rule.MaxAge < 0 {
+			return fmt.Errorf("security.cors rule %q: maxAge must not be negative", rule.Path)
+		}
+	}
+
 	if c.Server.Port == 0 {
 		c.Server.Port = 4322
 	}
@@ -107,6 +195,45 @@ For SSR deployments, use the standalone adapter with platforms like Docker, Rail
 		c.Server.Host = "localhost"
 	}
 
+	if c.Server.LivenessPath == "" {
+		c.Server.LivenessPath = "/healthz"
+	}
+
+	if c.Server.ReadinessPath == "" {
+		c.Server.ReadinessPath = "/readyz"
+	}
+
+	if (c.Server.TLS.CertFile == "") != (c.Server.TLS.KeyFile == "") {
+		return fmt.Errorf("server.tls: certFile and keyFile must be set together")
+	}
+
+	if c.ISR.Path == "" {
+		c.ISR.Path = "/_galaxy/revalidate"
+	}
+	if !strings.HasPrefix(c.ISR.Path, "/") {
+		return fmt.Errorf("isr.path must start with /: %q", c.ISR.Path)
+	}
+
+	switch c.Prefetch.Strategy {
+	case "":
+		c.Prefetch.Strategy = PrefetchHover
+	case PrefetchHover, PrefetchViewport, PrefetchEager:
+	default:
+		return fmt.Errorf("prefetch.strategy must be hover, viewport or eager: %q", c.Prefetch.Strategy)
+	}
+
+	for i, route := range c.Cache.Routes {
+		if !strings.HasPrefix(route.Pattern, "/") {
+			return fmt.Errorf("cache.routes[%d]: pattern must start with /: %q", i, route.Pattern)
+		}
+		if _, err := path.Match(strings.TrimSuffix(route.Pattern, "/**"), "/"); err != nil {
+			return fmt.Errorf("cache.routes[%d]: invalid pattern %q: %w", i, route.Pattern, err)
+		}
+		if route.MaxAge < 0 || route.StaleWhileRevalidate < 0 {
+			return fmt.Errorf("cache.routes[%d]: maxAge and staleWhileRevalidate must not be negative", i)
+		}
+	}
+
 	if c.OutDir == "" {
 		c.OutDir = "./dist"
 	}
@@ -137,3 +264,10 @@ func (c *Config) IsStatic() bool {
 func (c *Config) IsHybrid() bool {
 	return c.Output.Type == OutputHybrid
 }
+
+// Embed reports whether the server should embed its pages and assets, set
+// with embed = true under [adapter.config].
+func (c *Config) Embed() bool {
+	embed, _ := c.Adapter.Config["embed"].(bool)
+	return embed
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Secrets are read by generated servers when they start, so they are never
+// compiled into the binary. Empty values fall back to the environment
+// variables of the packages using them: GALAXY_SESSION_SECRET,
+// GALAXY_SESSION_PREVIOUS_SECRETS, GALAXY_CSRF_SECRET and
+// GALAXY_REVALIDATE_SECRET.
+type Secrets struct {
+	Session         string
+	PreviousSession []string
+	CSRF            string
+	Revalidate      string
+}
+
+// LoadSecrets reads the secrets of the galaxy.config.toml in dir, if there
+// is one.
+func LoadSecrets(dir string) Secrets {
+	var cfg struct {
+		Session struct {
+			Secret          string   `toml:"secret"`
+			PreviousSecrets []string `toml:"previousSecrets"`
+		} `toml:"session"`
+		Security struct {
+			CSRF struct {
+				Secret string `toml:"secret"`
+			} `toml:"csrf"`
+		} `toml:"security"`
+		ISR struct {
+			Secret string `toml:"secret"`
+		} `toml:"isr"`
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "galaxy.config.toml")); err == nil {
+		toml.Unmarshal(data, &cfg)
+	}
+	return Secrets{
+		Session:         cfg.Session.Secret,
+		PreviousSession: cfg.Session.PreviousSecrets,
+		CSRF:            cfg.Security.CSRF.Secret,
+		Revalidate:      cfg.ISR.Secret,
+	}
+}
+
+// WithoutSecrets returns a copy of c with its secrets cleared, for code
+// generated from it.
+func (c *Config) WithoutSecrets() *Config {
+	cp := *c
+	cp.Session.Secret = ""
+	cp.Session.PreviousSecrets = nil
+	cp.Security.CSRF.Secret = ""
+	cp.ISR.Secret = ""
+	return &cp
+}
@@ -8,6 +8,14 @@ const (
 	OutputHybrid OutputType = "hybrid"
 )
 
+type SessionStore string
+
+const (
+	SessionStoreCookie SessionStore = "cookie"
+	SessionStoreMemory SessionStore = "memory"
+	SessionStoreFile   SessionStore = "file"
+)
+
 type AdapterName string
 
 const (
@@ -18,19 +26,24 @@ const (
 )
 
 type Config struct {
-	Site           string          `toml:"site"`
-	Base           string          `toml:"base"`
-	OutDir         string          `toml:"outDir"`
-	SrcDir         string          `toml:"srcDir"`
-	PackageManager string          `toml:"packageManager"`
-	Output         OutputConfig    `toml:"output"`
-	Server         ServerConfig    `toml:"server"`
-	Adapter        AdapterConfig   `toml:"adapter"`
-	Security       SecurityConfig  `toml:"security"`
-	Lifecycle      LifecycleConfig `toml:"lifecycle"`
-	Plugins        []PluginConfig  `toml:"plugins"`
-	Markdown       MarkdownConfig  `toml:"markdown"`
-	Content        ContentConfig   `toml:"content"`
+	Site           string            `toml:"site"`
+	Base           string            `toml:"base"`
+	OutDir         string            `toml:"outDir"`
+	SrcDir         string            `toml:"srcDir"`
+	PackageManager string            `toml:"packageManager"`
+	Output         OutputConfig      `toml:"output"`
+	Server         ServerConfig      `toml:"server"`
+	Adapter        AdapterConfig     `toml:"adapter"`
+	Security       SecurityConfig    `toml:"security"`
+	Session        SessionConfig     `toml:"session"`
+	Lifecycle      LifecycleConfig   `toml:"lifecycle"`
+	Plugins        []PluginConfig    `toml:"plugins"`
+	Markdown       MarkdownConfig    `toml:"markdown"`
+	Content        ContentConfig     `toml:"content"`
+	Compression    CompressionConfig `toml:"compression"`
+	Cache          CacheConfig       `toml:"cache"`
+	ISR            ISRConfig         `toml:"isr"`
+	Prefetch       PrefetchConfig    `toml:"prefetch"`
 }
 
 type OutputConfig struct {
@@ -40,6 +53,88 @@ type OutputConfig struct {
 type ServerConfig struct {
 	Port int    `toml:"port"`
 	Host string `toml:"host"`
+	// Timeouts are in seconds; zero means no timeout.
+	ReadTimeout       int `toml:"readTimeout"`
+	ReadHeaderTimeout int `toml:"readHeaderTimeout"`
+	WriteTimeout      int `toml:"writeTimeout"`
+	IdleTimeout       int `toml:"idleTimeout"`
+	// ShutdownTimeout bounds how long in-flight requests may drain on
+	// SIGINT or SIGTERM before the server closes them.
+	ShutdownTimeout int       `toml:"shutdownTimeout"`
+	MaxHeaderBytes  int       `toml:"maxHeaderBytes"`
+	DisableHTTP2    bool      `toml:"disableHTTP2"`
+	LivenessPath    string    `toml:"livenessPath"`
+	ReadinessPath   string    `toml:"readinessPath"`
+	TLS             TLSConfig `toml:"tls"`
+}
+
+type CompressionConfig struct {
+	// Enabled compresses dynamic responses with gzip or brotli.
+	Enabled bool `toml:"enabled"`
+	// Precompress writes .gz and .br siblings of static assets at build time.
+	Precompress bool `toml:"precompress"`
+	// MinSize is the smallest body, in bytes, worth compressing.
+	MinSize int `toml:"minSize"`
+}
+
+type CacheConfig struct {
+	Routes []CacheRoute `toml:"routes"`
+}
+
+// CacheRoute sets the HTTP caching policy of server-rendered pages whose
+// path matches Pattern. Patterns use path.Match syntax; a trailing "/**"
+// matches every path below the prefix.
+type CacheRoute struct {
+	Pattern string `toml:"pattern"`
+	// MaxAge and StaleWhileRevalidate are in seconds.
+	MaxAge               int      `toml:"maxAge"`
+	StaleWhileRevalidate int      `toml:"staleWhileRevalidate"`
+	Private              bool     `toml:"private"`
+	Vary                 []string `toml:"vary"`
+}
+
+// ISRConfig configures incremental static regeneration of pages that
+// declare `// revalidate = <seconds>` in their frontmatter.
+type ISRConfig struct {
+	// Dir persists regenerated pages across restarts; empty keeps them in
+	// memory only.
+	Dir string `toml:"dir"`
+	// Path is the on-demand revalidation endpoint.
+	Path string `toml:"path"`
+	// Secret authorizes revalidation requests.
+	Secret string `toml:"secret"`
+}
+
+type PrefetchStrategy string
+
+const (
+	// PrefetchHover loads a page when the pointer rests on or focus moves to
+	// its link.
+	PrefetchHover PrefetchStrategy = "hover"
+	// PrefetchViewport loads a page once its link scrolls into view.
+	PrefetchViewport PrefetchStrategy = "viewport"
+	// PrefetchEager loads a page as soon as the browser is idle.
+	PrefetchEager PrefetchStrategy = "eager"
+)
+
+// PrefetchConfig loads pages, along with their CSS and WASM modules, before
+// their links are followed. Links opt in with data-galaxy-prefetch, which may
+// name a strategy, and out with data-galaxy-prefetch="false".
+type PrefetchConfig struct {
+	Enabled bool `toml:"enabled"`
+	// Strategy is used by links that don't name one; it defaults to hover.
+	Strategy PrefetchStrategy `toml:"strategy"`
+	// All prefetches every same-origin link, not only those marked.
+	All bool `toml:"all"`
+}
+
+type TLSConfig struct {
+	CertFile string `toml:"certFile"`
+	KeyFile  string `toml:"keyFile"`
+}
+
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" && t.KeyFile != ""
 }
 
 type AdapterConfig struct {
@@ -69,12 +164,101 @@ type ContentConfig struct {
 	ContentDir  string `toml:"contentDir"`
 }
 
+type SessionConfig struct {
+	Enabled         bool         `toml:"enabled"`
+	Store           SessionStore `toml:"store"`
+	Dir             string       `toml:"dir"`
+	CookieName      string       `toml:"cookieName"`
+	Secret          string       `toml:"secret"`
+	PreviousSecrets []string     `toml:"previousSecrets"`
+	Encrypt         bool         `toml:"encrypt"`
+	IdleTimeout     int          `toml:"idleTimeout"`
+	MaxAge          int          `toml:"maxAge"`
+	Path            string       `toml:"path"`
+	Domain          string       `toml:"domain"`
+	Secure          bool         `toml:"secure"`
+	HTTPOnly        bool         `toml:"httpOnly"`
+	SameSite        string       `toml:"sameSite"`
+}
+
 type SecurityConfig struct {
 	CheckOrigin    bool            `toml:"checkOrigin"`
 	AllowOrigins   []string        `toml:"allowOrigins"`
 	AllowedDomains []RemotePattern `toml:"allowedDomains"`
 	Headers        HeadersConfig   `toml:"headers"`
 	BodyLimit      BodyLimitConfig `toml:"bodyLimit"`
+	CSRF           CSRFConfig      `toml:"csrf"`
+	RateLimit      RateLimitConfig `toml:"rateLimit"`
+	CORS           CORSConfig      `toml:"cors"`
+}
+
+type CSRFMode string
+
+const (
+	CSRFModeSynchronizer CSRFMode = "synchronizer"
+	CSRFModeDoubleSubmit CSRFMode = "double-submit"
+)
+
+// CSRFConfig enables token-based CSRF protection. Tokens are checked in
+// addition to the Origin check; requests without an Origin header are then
+// accepted when they carry a valid token.
+type CSRFConfig struct {
+	Enabled bool     `toml:"enabled"`
+	Mode    CSRFMode `toml:"mode"`
+	Secret  string   `toml:"secret"`
+	Exempt  []string `toml:"exempt"`
+	Secure  bool     `toml:"secure"`
+}
+
+type RateLimitAlgorithm string
+
+const (
+	RateLimitTokenBucket   RateLimitAlgorithm = "token-bucket"
+	RateLimitSlidingWindow RateLimitAlgorithm = "sliding-window"
+)
+
+type RateLimitConfig struct {
+	Enabled bool `toml:"enabled"`
+	// TrustedProxies lists proxy IPs or CIDRs whose X-Forwarded-For header is
+	// trusted when resolving the client IP.
+	TrustedProxies []string `toml:"trustedProxies"`
+	// Page is an HTML file, relative to the project root, served with 429
+	// responses to browsers.
+	Page  string          `toml:"page"`
+	Rules []RateLimitRule `toml:"rules"`
+}
+
+// RateLimitRule limits requests matching Path (exact, path.Match glob, or
+// "/prefix/*") and Methods (all methods when empty). Key is "ip", "session"
+// or "locals:<name>".
+type RateLimitRule struct {
+	Path      string             `toml:"path"`
+	Methods   []string           `toml:"methods"`
+	Limit     int                `toml:"limit"`
+	Window    int                `toml:"window"`
+	Burst     int                `toml:"burst"`
+	Algorithm RateLimitAlgorithm `toml:"algorithm"`
+	Key       string             `toml:"key"`
+}
+
+type CORSConfig struct {
+	Enabled bool       `toml:"enabled"`
+	Rules   []CORSRule `toml:"rules"`
+}
+
+// CORSRule applies to requests whose path matches Path (exact, path.Match
+// glob, or "/prefix/*"); the first matching rule wins. AllowOrigins entries
+// may be "*" or contain a "*." subdomain wildcard such as
+// "https://*.example.com". An empty AllowHeaders echoes the headers the
+// browser asks for.
+type CORSRule struct {
+	Path             string   `toml:"path"`
+	AllowOrigins     []string `toml:"allowOrigins"`
+	AllowMethods     []string `toml:"allowMethods"`
+	AllowHeaders     []string `toml:"allowHeaders"`
+	ExposeHeaders    []string `toml:"exposeHeaders"`
+	AllowCredentials bool     `toml:"allowCredentials"`
+	MaxAge           int      `toml:"maxAge"`
 }
 
 type HeadersConfig struct {
@@ -86,6 +270,12 @@ type HeadersConfig struct {
 	StrictTransportSecurity string `toml:"strictTransportSecurity"`
 	ContentSecurityPolicy   string `toml:"contentSecurityPolicy"`
 	PermissionsPolicy       string `toml:"permissionsPolicy"`
+	// CSPNonce adds a per-request nonce to ContentSecurityPolicy and to every
+	// script and style tag Galaxy injects. Static pages get hashes instead.
+	CSPNonce bool `toml:"cspNonce"`
+	// Integrity adds subresource integrity hashes to bundled CSS, JS and WASM
+	// loader tags in builds.
+	Integrity bool `toml:"integrity"`
 }
 
 type BodyLimitConfig struct {
@@ -109,9 +299,23 @@ func DefaultConfig() *Config {
 		Output: OutputConfig{
 			Type: OutputStatic,
 		},
+		ISR: ISRConfig{
+			Path: "/_galaxy/revalidate",
+		},
+		Compression: CompressionConfig{
+			MinSize: 1024,
+		},
 		Server: ServerConfig{
-			Port: 4322,
-			Host: "localhost",
+			Port:              4322,
+			Host:              "localhost",
+			ReadTimeout:       30,
+			ReadHeaderTimeout: 10,
+			WriteTimeout:      60,
+			IdleTimeout:       120,
+			ShutdownTimeout:   30,
+			MaxHeaderBytes:    1 << 20,
+			LivenessPath:      "/healthz",
+			ReadinessPath:     "/readyz",
 		},
 		Adapter: AdapterConfig{
 			Name:   AdapterStandalone,
@@ -131,6 +335,17 @@ func DefaultConfig() *Config {
 			Collections: true,
 			ContentDir:  "./src/content",
 		},
+		Session: SessionConfig{
+			Enabled:     false,
+			Store:       SessionStoreCookie,
+			Dir:         "./.galaxy/sessions",
+			CookieName:  "galaxy_session",
+			IdleTimeout: 1800,
+			MaxAge:      604800,
+			Path:        "/",
+			HTTPOnly:    true,
+			SameSite:    "lax",
+		},
 		Security: SecurityConfig{
 			CheckOrigin:    true,
 			AllowOrigins:   []string{},
@@ -149,6 +364,20 @@ func DefaultConfig() *Config {
 				Enabled:  true,
 				MaxBytes: 10 << 20,
 			},
+			CSRF: CSRFConfig{
+				Enabled: false,
+				Mode:    CSRFModeDoubleSubmit,
+				Exempt:  []string{},
+			},
+			RateLimit: RateLimitConfig{
+				Enabled:        false,
+				TrustedProxies: []string{},
+				Rules:          []RateLimitRule{},
+			},
+			CORS: CORSConfig{
+				Enabled: false,
+				Rules:   []CORSRule{},
+			},
 		},
 	}
 }
@@ -5,6 +5,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+
+	"github.com/withgalaxy/galaxy/pkg/cache"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/session"
 )
 
 type HandlerFunc func(*Context) error
@@ -41,6 +45,19 @@ func (c *Context) Cookie(name string) (*http.Cookie, error) {
 	return c.Request.Cookie(name)
 }
 
+func (c *Context) Session() *session.Session {
+	return session.FromRequest(c.Request)
+}
+
+func (c *Context) CSRFToken() string {
+	return security.CSRFToken(c.Request)
+}
+
+// Cache returns the data cache shared with pages' Galaxy.Cache.
+func (c *Context) Cache() *cache.Cache {
+	return cache.Default
+}
+
 func (c *Context) JSON(status int, data any) error {
 	c.Response.Header().Set("Content-Type", "application/json")
 	c.Response.WriteHeader(status)
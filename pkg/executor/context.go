@@ -1,6 +1,7 @@
 package executor
 
 import (
+	"errors"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -9,6 +10,9 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"github.com/withgalaxy/galaxy/pkg/cache"
 )
 
 type PackageFunc func(args ...interface{}) (interface{}, error)
@@ -38,6 +42,15 @@ type Context struct {
 	RedirectStatus int
 	ShouldRedirect bool
 	PackageFuncs   map[string]PackageFunc
+
+	// Set by Galaxy.SetCache; seconds.
+	CacheMaxAge               int
+	CacheStaleWhileRevalidate int
+	ShouldCache               bool
+
+	// State is set by Galaxy.SetState and handed to store.NewAtomFromServer
+	// in the browser. Clones share it, so components can contribute.
+	State map[string]interface{}
 }
 
 type GalaxyAPI struct {
@@ -45,6 +58,10 @@ type GalaxyAPI struct {
 	Params  map[string]interface{}
 	Locals  map[string]interface{}
 	Content interface{} // ContentAPI wrapper
+	Session interface{} // *session.Session when sessions are enabled
+	Cache   CacheAPI
+
+	CSRFToken string
 }
 
 // contentAPIWrapper provides Galaxy.Content.Get() and Galaxy.Content.GetCollection()
@@ -79,6 +96,39 @@ func (g *GalaxyAPI) Redirect(url string, status int) {
 	g.ctx.ShouldRedirect = true
 }
 
+// SetCache lets shared caches keep the page for maxAge seconds and serve it
+// stale for staleWhileRevalidate more while it is re-rendered.
+func (g *GalaxyAPI) SetCache(maxAge, staleWhileRevalidate int) {
+	g.ctx.CacheMaxAge = maxAge
+	g.ctx.CacheStaleWhileRevalidate = staleWhileRevalidate
+	g.ctx.ShouldCache = true
+}
+
+// SetState serializes value into the page under key, for
+// store.NewAtomFromServer to start from.
+func (g *GalaxyAPI) SetState(key string, value interface{}) {
+	g.ctx.State[key] = value
+}
+
+// CacheAPI is Galaxy.Cache, backed by cache.Default. TTLs are in seconds,
+// like Galaxy.SetCache; zero keeps a value until it is invalidated.
+type CacheAPI struct{}
+
+// Cache is Galaxy.Cache in compiled pages.
+var Cache CacheAPI
+
+func (CacheAPI) GetOrSet(key string, ttl int, fn func() (interface{}, error), tags ...string) (interface{}, error) {
+	return cache.Default.GetOrSet(key, time.Duration(ttl)*time.Second, fn, tags...)
+}
+
+func (CacheAPI) Invalidate(keys ...string) {
+	cache.Default.Invalidate(keys...)
+}
+
+func (CacheAPI) InvalidateTags(tags ...string) int {
+	return cache.Default.InvalidateTags(tags...)
+}
+
 func NewContext() *Context {
 	ctx := &Context{
 		Variables:    make(map[string]interface{}),
@@ -87,6 +137,7 @@ func NewContext() *Context {
 		Request:      nil,
 		Locals:       make(map[string]any),
 		PackageFuncs: make(map[string]PackageFunc),
+		State:        make(map[string]interface{}),
 	}
 
 	globalFuncsMutex.RLock()
@@ -119,6 +170,12 @@ func (c *Context) Clone() *Context {
 		RedirectStatus: c.RedirectStatus,
 		ShouldRedirect: c.ShouldRedirect,
 		PackageFuncs:   make(map[string]PackageFunc),
+
+		CacheMaxAge:               c.CacheMaxAge,
+		CacheStaleWhileRevalidate: c.CacheStaleWhileRevalidate,
+		ShouldCache:               c.ShouldCache,
+
+		State: c.State,
 	}
 
 	for k, v := range c.Variables {
@@ -142,6 +199,10 @@ func (c *Context) Clone() *Context {
 		Params: make(map[string]interface{}),
 		Locals: clone.Locals,
 	}
+	if parent, ok := c.Variables["Galaxy"].(*GalaxyAPI); ok {
+		galaxyAPI.Session = parent.Session
+		galaxyAPI.CSRFToken = parent.CSRFToken
+	}
 	clone.Variables["Galaxy"] = galaxyAPI
 
 	return clone
@@ -215,7 +276,12 @@ func (c *Context) Execute(code string) error {
 		if funcDecl, ok := decl.(*ast.FuncDecl); ok {
 			if funcDecl.Name.Name == "init" && funcDecl.Body != nil {
 				for _, stmt := range funcDecl.Body.List {
-					if err := c.executeStmt(stmt); err != nil {
+					err := c.executeStmt(stmt)
+					var ret *returnValues
+					if errors.As(err, &ret) {
+						return nil
+					}
+					if err != nil {
 						return err
 					}
 					if c.ShouldRedirect {
@@ -240,6 +306,8 @@ func (c *Context) executeStmt(stmt ast.Stmt) error {
 		return err
 	case *ast.AssignStmt:
 		return c.executeAssignStmt(s)
+	case *ast.ReturnStmt:
+		return c.executeReturnStmt(s)
 	case *ast.DeclStmt:
 		if genDecl, ok := s.Decl.(*ast.GenDecl); ok {
 			if genDecl.Tok == token.VAR {
@@ -258,6 +326,44 @@ func (c *Context) executeStmt(stmt ast.Stmt) error {
 	}
 }
 
+// returnValues carries a return statement's results out of the statements
+// enclosing it, up to the function literal that called them.
+type returnValues struct {
+	values []interface{}
+}
+
+func (r *returnValues) Error() string {
+	return "return outside of a function"
+}
+
+func (r *returnValues) result() (interface{}, error) {
+	var value interface{}
+	var err error
+	if len(r.values) > 0 {
+		value = r.values[0]
+	}
+	if len(r.values) > 1 {
+		err, _ = r.values[len(r.values)-1].(error)
+	}
+	return value, err
+}
+
+func (c *Context) executeReturnStmt(stmt *ast.ReturnStmt) error {
+	ret := &returnValues{}
+	for _, expr := range stmt.Results {
+		val, err := c.evalExpr(expr)
+		if err != nil {
+			return err
+		}
+		if tuple, ok := val.(Tuple); ok && len(stmt.Results) == 1 {
+			ret.values = tuple.Values
+			return ret
+		}
+		ret.values = append(ret.values, val)
+	}
+	return ret
+}
+
 func (c *Context) executeRangeStmt(stmt *ast.RangeStmt) error {
 	rangeVal, err := c.evalExpr(stmt.X)
 	if err != nil {
@@ -457,6 +563,8 @@ func (c *Context) evalExpr(expr ast.Expr) (interface{}, error) {
 		return c.evalIndexExpr(e)
 	case *ast.TypeAssertExpr:
 		return c.evalTypeAssertExpr(e)
+	case *ast.FuncLit:
+		return c.evalFuncLit(e), nil
 	default:
 		return nil, fmt.Errorf("unsupported expression type: %T", expr)
 	}
@@ -637,6 +745,24 @@ func (c *Context) evalTypeAssertExpr(expr *ast.TypeAssertExpr) (interface{}, err
 	return x, nil
 }
 
+// evalFuncLit turns a function literal into a func() (interface{}, error),
+// the shape Galaxy.Cache.GetOrSet takes. Its body runs in the page's scope.
+func (c *Context) evalFuncLit(lit *ast.FuncLit) func() (interface{}, error) {
+	return func() (interface{}, error) {
+		for _, stmt := range lit.Body.List {
+			err := c.executeStmt(stmt)
+			var ret *returnValues
+			if errors.As(err, &ret) {
+				return ret.result()
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+}
+
 func (c *Context) evalCallExpr(expr *ast.CallExpr) (interface{}, error) {
 	if sel, ok := expr.Fun.(*ast.SelectorExpr); ok {
 		if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == "Galaxy" {
@@ -763,7 +889,15 @@ func (c *Context) convertArgsToTypes(args []interface{}, method reflect.Value) (
 	values := make([]reflect.Value, len(args))
 
 	for i, arg := range args {
-		paramType := methodType.In(i)
+		if i >= methodType.NumIn() && !methodType.IsVariadic() {
+			return nil, fmt.Errorf("too many arguments: want %d, got %d", methodType.NumIn(), len(args))
+		}
+		var paramType reflect.Type
+		if methodType.IsVariadic() && i >= methodType.NumIn()-1 {
+			paramType = methodType.In(methodType.NumIn() - 1).Elem()
+		} else {
+			paramType = methodType.In(i)
+		}
 		argValue := reflect.ValueOf(arg)
 
 		// Handle nil
@@ -818,7 +952,7 @@ func (c *Context) handleMethodReturns(results []reflect.Value) (interface{}, err
 
 		if len(results) == 2 {
 			// (value, error) pattern - return as tuple
-			return []interface{}{results[0].Interface(), err}, nil
+			return Tuple{Values: []interface{}{results[0].Interface(), err}}, nil
 		}
 
 		// Multiple values + error
@@ -1128,6 +1262,18 @@ func (c *Context) SetLocals(locals map[string]any) {
 	}
 }
 
+func (c *Context) SetSession(session interface{}) {
+	if galaxy, ok := c.Variables["Galaxy"].(*GalaxyAPI); ok {
+		galaxy.Session = session
+	}
+}
+
+func (c *Context) SetCSRFToken(token string) {
+	if galaxy, ok := c.Variables["Galaxy"].(*GalaxyAPI); ok {
+		galaxy.CSRFToken = token
+	}
+}
+
 func (c *Context) GetLocals() map[string]any {
 	return c.Locals
 }
@@ -446,6 +446,34 @@ func TestGalaxyRedirect(t *testing.T) {
 	}
 }
 
+func TestGalaxySetCache(t *testing.T) {
+	ctx := NewContext()
+
+	if err := ctx.Execute(`Galaxy.SetCache(60, 300)`); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if !ctx.ShouldCache || ctx.CacheMaxAge != 60 || ctx.CacheStaleWhileRevalidate != 300 {
+		t.Errorf("unexpected cache policy: %v %d %d", ctx.ShouldCache, ctx.CacheMaxAge, ctx.CacheStaleWhileRevalidate)
+	}
+}
+
+func TestGalaxySetState(t *testing.T) {
+	ctx := NewContext()
+
+	if err := ctx.Execute(`cart := 3
+Galaxy.SetState("cart", cart)`); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+	if err := ctx.Clone().Execute(`Galaxy.SetState("user", "ada")`); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if ctx.State["cart"] != int64(3) || ctx.State["user"] != "ada" {
+		t.Errorf("expected state from the page and its components, got %v", ctx.State)
+	}
+}
+
 func TestConditionalRedirect(t *testing.T) {
 	ctx := NewContext()
 
@@ -1292,3 +1320,37 @@ func TestMethodCallReturnsNilValue(t *testing.T) {
 		t.Errorf("Expected nil, got %v", result)
 	}
 }
+
+func TestGalaxyCacheGetOrSet(t *testing.T) {
+	calls := 0
+	RegisterGlobalFunc("svc", "Load", func(args ...interface{}) (interface{}, error) {
+		calls++
+		return fmt.Sprintf("profile %v", args[0]), nil
+	})
+	defer Cache.InvalidateTags("profiles")
+
+	code := `
+id := "42"
+profile, err := Galaxy.Cache.GetOrSet("profile:"+id, 60, func() (any, error) {
+	return svc.Load(id)
+}, "profiles")
+`
+	for i := 0; i < 2; i++ {
+		ctx := NewContext()
+		if err := ctx.Execute(code); err != nil {
+			t.Fatalf("Execute failed: %v", err)
+		}
+		profile, _ := ctx.Get("profile")
+		errVal, _ := ctx.Get("err")
+		if profile != "profile 42" || errVal != nil {
+			t.Fatalf("got %v, %v", profile, errVal)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("expected the second page view to be cached, fetched %d times", calls)
+	}
+
+	if n := Cache.InvalidateTags("profiles"); n != 1 {
+		t.Errorf("InvalidateTags removed %d entries", n)
+	}
+}
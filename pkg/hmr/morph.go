@@ -0,0 +1,105 @@
+package hmr
+
+// MorphPath is where the dev server serves MorphRuntime.
+const MorphPath = "/__hmr/morph.js"
+
+// MorphRuntime defines morph(from, to), which updates the DOM node from in
+// place until it matches to, which may belong to another document. Nodes
+// that are still there are kept, along with their focus, scroll position
+// and listeners, and children with an id are matched by it wherever they
+// moved. Elements marked data-galaxy-persist in both trees are left alone.
+//
+// It is shared by hot reloads and the client router, and also exposed as
+// window.__galaxyMorph for scripts loading it on its own.
+const MorphRuntime = `function morph(from, to) {
+	if (from.nodeType !== to.nodeType || from.nodeName !== to.nodeName) {
+		const next = from.ownerDocument.importNode(to, true);
+		from.replaceWith(next);
+		return next;
+	}
+	if (from.nodeType === Node.TEXT_NODE || from.nodeType === Node.COMMENT_NODE) {
+		if (from.nodeValue !== to.nodeValue) {
+			from.nodeValue = to.nodeValue;
+		}
+		return from;
+	}
+	if (from.nodeType !== Node.ELEMENT_NODE) {
+		return from;
+	}
+	if (from.hasAttribute('data-galaxy-persist') && to.hasAttribute('data-galaxy-persist')) {
+		return from;
+	}
+	morphAttributes(from, to);
+	morphChildren(from, to);
+	return from;
+}
+
+function morphAttributes(from, to) {
+	for (const attr of Array.from(from.attributes)) {
+		if (!to.hasAttribute(attr.name)) {
+			from.removeAttribute(attr.name);
+		}
+	}
+	for (const attr of Array.from(to.attributes)) {
+		if (from.getAttribute(attr.name) !== attr.value) {
+			from.setAttribute(attr.name, attr.value);
+		}
+	}
+}
+
+function sameNode(a, b) {
+	if (a.nodeType !== b.nodeType || a.nodeName !== b.nodeName) {
+		return false;
+	}
+	return a.nodeType !== Node.ELEMENT_NODE || a.id === b.id;
+}
+
+function morphChildren(from, to) {
+	const byId = new Map();
+	for (const child of Array.from(from.children)) {
+		if (child.id) {
+			byId.set(child.id, child);
+		}
+	}
+
+	let current = from.firstChild;
+	for (const next of Array.from(to.childNodes)) {
+		let match = null;
+		if (next.nodeType === Node.ELEMENT_NODE && next.id && byId.has(next.id)) {
+			match = byId.get(next.id);
+		} else if (current && sameNode(current, next)) {
+			match = current;
+		} else if (next.nodeType === Node.ELEMENT_NODE) {
+			// Look past nodes that were removed or replaced for the same element.
+			for (let sibling = current; sibling; sibling = sibling.nextSibling) {
+				if (sibling.nodeType === Node.ELEMENT_NODE && sameNode(sibling, next)) {
+					match = sibling;
+					break;
+				}
+			}
+		}
+
+		if (!match) {
+			from.insertBefore(from.ownerDocument.importNode(next, true), current);
+			continue;
+		}
+		if (match.id) {
+			byId.delete(match.id);
+		}
+		if (match === current) {
+			current = current.nextSibling;
+		} else {
+			from.insertBefore(match, current);
+		}
+		morph(match, next);
+	}
+
+	while (current) {
+		const following = current.nextSibling;
+		current.remove();
+		current = following;
+	}
+}
+
+window.__galaxyMorph = morph;
+`
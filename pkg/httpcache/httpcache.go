@@ -0,0 +1,207 @@
+// Package httpcache sets Cache-Control, ETag and Vary on rendered pages and
+// answers conditional requests with 304 Not Modified.
+package httpcache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"html"
+	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/executor"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/session"
+)
+
+// Immutable is the Cache-Control of content-hashed assets, whose URL changes
+// whenever their content does.
+const Immutable = "public, max-age=31536000, immutable"
+
+var hashedName = regexp.MustCompile(`-[0-9a-f]{8,}\.[A-Za-z0-9]+$`)
+
+// Hashed reports whether name carries a content hash, like the
+// styles-1a2b3c4d.css files written by the bundler.
+func Hashed(name string) bool {
+	return hashedName.MatchString(name)
+}
+
+// Policy is how long shared caches and browsers may keep a page.
+type Policy struct {
+	MaxAge               int
+	StaleWhileRevalidate int
+	Private              bool
+	Vary                 []string
+}
+
+// CacheControl renders p as a Cache-Control header value.
+func (p Policy) CacheControl() string {
+	if p.MaxAge <= 0 && p.StaleWhileRevalidate <= 0 {
+		if p.Private {
+			return "private, no-cache"
+		}
+		return "no-cache"
+	}
+	scope := "public"
+	if p.Private {
+		scope = "private"
+	}
+	value := scope + ", max-age=" + strconv.Itoa(p.MaxAge)
+	if p.StaleWhileRevalidate > 0 {
+		value += ", stale-while-revalidate=" + strconv.Itoa(p.StaleWhileRevalidate)
+	}
+	return value
+}
+
+// FromConfig converts a [[cache.routes]] entry to a Policy.
+func FromConfig(route config.CacheRoute) Policy {
+	return Policy{
+		MaxAge:               route.MaxAge,
+		StaleWhileRevalidate: route.StaleWhileRevalidate,
+		Private:              route.Private,
+		Vary:                 route.Vary,
+	}
+}
+
+// Match returns the policy of the first route whose pattern matches
+// urlPath.
+func Match(routes []config.CacheRoute, urlPath string) (Policy, bool) {
+	for _, route := range routes {
+		if matchPattern(route.Pattern, urlPath) {
+			return FromConfig(route), true
+		}
+	}
+	return Policy{}, false
+}
+
+// For returns the policy of the page at urlPath: its [[cache.routes]] entry,
+// with the durations replaced by the page's own Galaxy.SetCache call if it
+// made one. It returns nil when neither applies.
+func For(routes []config.CacheRoute, urlPath string, page *Policy) *Policy {
+	policy, ok := Match(routes, urlPath)
+	if page == nil {
+		if !ok {
+			return nil
+		}
+		return &policy
+	}
+	policy.MaxAge = page.MaxAge
+	policy.StaleWhileRevalidate = page.StaleWhileRevalidate
+	return &policy
+}
+
+// PagePolicy returns the policy a page's frontmatter set with
+// Galaxy.SetCache, or nil.
+func PagePolicy(ctx *executor.Context) *Policy {
+	if !ctx.ShouldCache {
+		return nil
+	}
+	return &Policy{MaxAge: ctx.CacheMaxAge, StaleWhileRevalidate: ctx.CacheStaleWhileRevalidate}
+}
+
+func matchPattern(pattern, urlPath string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		return urlPath == prefix || strings.HasPrefix(urlPath, prefix+"/")
+	}
+	matched, _ := path.Match(pattern, urlPath)
+	return matched
+}
+
+// ETag returns a weak entity tag for body. It is weak because compression
+// may change the bytes on the wire.
+func ETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// NotModified reports whether r's If-None-Match already names etag, using
+// the weak comparison RFC 9110 requires for If-None-Match.
+func NotModified(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Write sends a rendered page with an ETag, plus Cache-Control and Vary when
+// policy is non-nil, or 304 Not Modified if the client's copy is current.
+// Only GET and HEAD requests are answered with 304.
+//
+// Responses that set a cookie, or whose render used the session or a CSRF
+// token, are marked private and vary on Cookie so shared caches never hand
+// one visitor's page to another. Pages carrying the request's CSP nonce or
+// CSRF token get no ETag: a client revalidating its copy would keep values
+// that no longer match the new response.
+func Write(w http.ResponseWriter, r *http.Request, body []byte, policy *Policy) {
+	h := w.Header()
+	personal := Personal(w, r)
+	if personal {
+		private := Policy{Private: true}
+		if policy != nil {
+			private = *policy
+			private.Private = true
+		}
+		policy = &private
+	}
+	if policy != nil {
+		if h.Get("Cache-Control") == "" {
+			h.Set("Cache-Control", policy.CacheControl())
+		}
+		for _, field := range policy.Vary {
+			h.Add("Vary", field)
+		}
+	}
+	if personal {
+		h.Add("Vary", "Cookie")
+	}
+
+	if perRequest(body, r) {
+		w.Write(body)
+		return
+	}
+
+	etag := ETag(body)
+	h.Set("ETag", etag)
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && NotModified(r, etag) {
+		h.Del("Content-Type")
+		h.Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Write(body)
+}
+
+// Personal reports whether the response to r belongs to one visitor: it
+// sets a cookie, or rendering it read or changed the session or issued a
+// CSRF token.
+func Personal(w http.ResponseWriter, r *http.Request) bool {
+	if len(w.Header().Values("Set-Cookie")) > 0 {
+		return true
+	}
+	if sess := session.FromRequest(r); sess.Used() || sess.Modified() {
+		return true
+	}
+	return security.IssuedCSRFToken(r) != ""
+}
+
+// perRequest reports whether body contains r's CSP nonce or CSRF token.
+func perRequest(body []byte, r *http.Request) bool {
+	for _, value := range []string{security.CSPNonce(r), security.IssuedCSRFToken(r)} {
+		if value != "" && bytes.Contains(body, []byte(html.EscapeString(value))) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package httpcache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/session"
+)
+
+func TestFor(t *testing.T) {
+	routes := []config.CacheRoute{
+		{Pattern: "/blog/**", MaxAge: 60, StaleWhileRevalidate: 600, Vary: []string{"Cookie"}},
+		{Pattern: "/products/*", MaxAge: 30, Private: true},
+	}
+
+	tests := []struct {
+		path string
+		page *Policy
+		want string
+	}{
+		{"/blog", nil, "public, max-age=60, stale-while-revalidate=600"},
+		{"/blog/2024/hello", nil, "public, max-age=60, stale-while-revalidate=600"},
+		{"/blog/hello", &Policy{MaxAge: 5}, "public, max-age=5"},
+		{"/products/shoe", nil, "private, max-age=30"},
+		{"/products/shoe/reviews", nil, ""},
+		{"/about", &Policy{MaxAge: 10, StaleWhileRevalidate: 20}, "public, max-age=10, stale-while-revalidate=20"},
+		{"/blogroll", nil, ""},
+	}
+	for _, tt := range tests {
+		policy := For(routes, tt.path, tt.page)
+		got := ""
+		if policy != nil {
+			got = policy.CacheControl()
+		}
+		if got != tt.want {
+			t.Errorf("For(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	body := []byte("<h1>Hello</h1>")
+	policy := &Policy{MaxAge: 60, Vary: []string{"Cookie"}}
+
+	w := httptest.NewRecorder()
+	Write(w, httptest.NewRequest("GET", "/", nil), body, policy)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != string(body) {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+	if etag == "" || etag[:2] != "W/" {
+		t.Errorf("expected a weak ETag, got %q", etag)
+	}
+	if w.Header().Get("Cache-Control") != "public, max-age=60" || w.Header().Get("Vary") != "Cookie" {
+		t.Errorf("unexpected headers %v", w.Header())
+	}
+
+	for _, inm := range []string{etag, `"other", ` + etag[2:], "*"} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("If-None-Match", inm)
+		w = httptest.NewRecorder()
+		Write(w, req, body, policy)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: got %d", inm, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("If-None-Match", `W/"stale"`)
+	w = httptest.NewRecorder()
+	Write(w, req, body, nil)
+	if w.Code != http.StatusOK || w.Header().Get("Cache-Control") != "" {
+		t.Errorf("stale ETag: got %d, Cache-Control %q", w.Code, w.Header().Get("Cache-Control"))
+	}
+}
+
+func TestWritePersonal(t *testing.T) {
+	manager, err := session.NewManagerWithStore(config.SessionConfig{Secret: "test"}, session.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	policy := &Policy{MaxAge: 60}
+
+	tests := []struct {
+		name   string
+		policy *Policy
+		render func(w http.ResponseWriter, r *http.Request)
+		want   string
+	}{
+		{"anonymous", policy, func(http.ResponseWriter, *http.Request) {}, "public, max-age=60"},
+		{"set-cookie", policy, func(w http.ResponseWriter, r *http.Request) {
+			http.SetCookie(w, &http.Cookie{Name: "theme", Value: "dark"})
+		}, "private, max-age=60"},
+		{"session read", policy, func(w http.ResponseWriter, r *http.Request) {
+			session.FromRequest(r).GetString("user")
+		}, "private, max-age=60"},
+		{"session read without policy", nil, func(w http.ResponseWriter, r *http.Request) {
+			session.FromRequest(r).GetString("user")
+		}, "private, no-cache"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			manager.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.render(w, r)
+				Write(w, r, []byte("<h1>Hello</h1>"), tt.policy)
+			})).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+			if got := w.Header().Get("Cache-Control"); got != tt.want {
+				t.Errorf("Cache-Control = %q, want %q", got, tt.want)
+			}
+			varyCookie := strings.Contains(strings.Join(w.Header().Values("Vary"), ","), "Cookie")
+			if private := strings.HasPrefix(tt.want, "private"); varyCookie != private {
+				t.Errorf("Vary = %q", w.Header().Values("Vary"))
+			}
+		})
+	}
+}
+
+func TestWritePerRequestValues(t *testing.T) {
+	req, nonce := security.WithCSPNonce(httptest.NewRequest("GET", "/", nil))
+	req.Header.Set("If-None-Match", "*")
+	body := []byte(`<script nonce="` + nonce + `">run()</script>`)
+
+	w := httptest.NewRecorder()
+	Write(w, req, body, &Policy{MaxAge: 60})
+	if w.Code != http.StatusOK || w.Body.String() != string(body) {
+		t.Fatalf("got %d %q", w.Code, w.Body.String())
+	}
+	if etag := w.Header().Get("ETag"); etag != "" {
+		t.Errorf("page with a CSP nonce got ETag %q", etag)
+	}
+
+	w = httptest.NewRecorder()
+	Write(w, req, []byte("<h1>Hello</h1>"), nil)
+	if w.Code != http.StatusNotModified {
+		t.Errorf("page without the nonce: got %d, want 304", w.Code)
+	}
+}
+
+func TestHashed(t *testing.T) {
+	for name, want := range map[string]bool{
+		"_assets/styles-1a2b3c4d.css":         true,
+		"_assets/wasm/script-0123456789.wasm": true,
+		"_assets/wasm-manifest.json":          false,
+		"_assets/app.css":                     false,
+	} {
+		if Hashed(name) != want {
+			t.Errorf("Hashed(%q) = %v", name, !want)
+		}
+	}
+}
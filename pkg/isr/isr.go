@@ -0,0 +1,418 @@
+// Package isr implements incremental static regeneration: pages are rendered
+// on their first request, served from a cache, and regenerated in the
+// background once they are older than their route's revalidate interval.
+package isr
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"html"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/withgalaxy/galaxy/pkg/httpcache"
+	"github.com/withgalaxy/galaxy/pkg/security"
+)
+
+// Route holds a page's regeneration settings, declared as comments in its
+// frontmatter like `// prerender = false`:
+//
+//	// revalidate = 60
+//	// tags = blog, posts
+type Route struct {
+	// Revalidate is how many seconds a rendered page is served before it is
+	// regenerated. Zero keeps it until it is purged.
+	Revalidate int
+	Tags       []string
+}
+
+var (
+	revalidateComment = regexp.MustCompile(`(?m)^\s*//\s*revalidate\s*=\s*(\d+)\s*$`)
+	tagsComment       = regexp.MustCompile(`(?m)^\s*//\s*tags\s*=\s*(.+?)\s*$`)
+)
+
+// ParseRoute reads the regeneration settings from a page's frontmatter. ok
+// is false for pages without a revalidate comment.
+func ParseRoute(frontmatter string) (route Route, ok bool) {
+	m := revalidateComment.FindStringSubmatch(frontmatter)
+	if m == nil {
+		return Route{}, false
+	}
+	route.Revalidate, _ = strconv.Atoi(m[1])
+	if m := tagsComment.FindStringSubmatch(frontmatter); m != nil {
+		for _, tag := range strings.Split(m[1], ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				route.Tags = append(route.Tags, tag)
+			}
+		}
+	}
+	return route, true
+}
+
+// Page is a rendered response held by the cache.
+type Page struct {
+	// Key is the path and query the page was rendered for, see cacheKey.
+	Key        string      `json:"key,omitempty"`
+	Path       string      `json:"path"`
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	Rendered   time.Time   `json:"rendered"`
+	Revalidate int         `json:"revalidate"`
+	Tags       []string    `json:"tags,omitempty"`
+}
+
+func (p *Page) stale(now time.Time) bool {
+	return p.Revalidate > 0 && now.Sub(p.Rendered) >= time.Duration(p.Revalidate)*time.Second
+}
+
+// DefaultMaxPages is how many pages a Cache keeps unless told otherwise, so
+// requests with ever new query strings can't grow it without bound.
+const DefaultMaxPages = 10000
+
+// Cache keeps regenerated pages in memory and, when it has a directory, on
+// disk so they survive restarts.
+type Cache struct {
+	// MaxPages is how many pages are kept before the least recently served
+	// one is dropped, or any number when it is zero.
+	MaxPages int
+
+	dir string
+
+	mu    sync.Mutex
+	pages map[string]*Page
+	// order holds the keys of pages, most recently served first.
+	order      *list.List
+	elems      map[string]*list.Element
+	refreshing map[string]bool
+	// purges counts Purge calls so renders that started before one don't
+	// store what it dropped.
+	purges int
+}
+
+// New returns a cache persisting pages to dir, or memory only when dir is
+// empty. Pages already in dir are loaded.
+func New(dir string) *Cache {
+	c := &Cache{
+		MaxPages:   DefaultMaxPages,
+		dir:        dir,
+		pages:      map[string]*Page{},
+		order:      list.New(),
+		elems:      map[string]*list.Element{},
+		refreshing: map[string]bool{},
+	}
+	if dir != "" {
+		c.load()
+	}
+	return c
+}
+
+// cacheKey is the path and query of u, its parameters sorted so their order
+// doesn't split the cache.
+func cacheKey(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.Path
+	}
+	return u.Path + "?" + u.Query().Encode()
+}
+
+// Serve answers a GET or HEAD request for a regenerated page: from the cache
+// when possible, otherwise by calling render. A stale page is served as is
+// while render refreshes it in the background. Other methods always render.
+func (c *Cache) Serve(w http.ResponseWriter, r *http.Request, route Route, render func(http.ResponseWriter, *http.Request)) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		render(w, r)
+		return
+	}
+
+	key := cacheKey(r.URL)
+	c.mu.Lock()
+	page := c.pages[key]
+	if page != nil {
+		c.order.MoveToFront(c.elems[key])
+	}
+	refresh := page != nil && page.stale(time.Now()) && !c.refreshing[key]
+	if refresh {
+		c.refreshing[key] = true
+	}
+	c.mu.Unlock()
+
+	switch {
+	case page == nil:
+		c.write(w, r, c.render(r, route, render), "MISS")
+	case refresh:
+		c.write(w, r, page, "STALE")
+		// The visitor's request is done; regenerate with a copy that
+		// outlives it.
+		bg := r.Clone(context.WithoutCancel(r.Context()))
+		go func() {
+			defer func() {
+				c.mu.Lock()
+				delete(c.refreshing, key)
+				c.mu.Unlock()
+			}()
+			c.render(bg, route, render)
+		}()
+	case page.stale(time.Now()):
+		c.write(w, r, page, "STALE")
+	default:
+		c.write(w, r, page, "HIT")
+	}
+}
+
+// render renders a fresh copy of the page and stores it if it can be shared
+// between visitors: a 200 that sets no cookies, is not private or no-store,
+// and used neither the visitor's session nor a CSRF token.
+func (c *Cache) render(r *http.Request, route Route, render func(http.ResponseWriter, *http.Request)) *Page {
+	req := r.Clone(r.Context())
+	req.Header.Del("If-None-Match")
+	req.Header.Del("If-Modified-Since")
+
+	c.mu.Lock()
+	purges := c.purges
+	c.mu.Unlock()
+
+	rec := &recorder{header: http.Header{}, status: http.StatusOK}
+	render(rec, req)
+
+	page := &Page{
+		Key:        cacheKey(r.URL),
+		Path:       r.URL.Path,
+		Status:     rec.status,
+		Header:     rec.header,
+		Body:       []byte(shareable(rec.body.String(), req)),
+		Rendered:   time.Now(),
+		Revalidate: route.Revalidate,
+		Tags:       route.Tags,
+	}
+	page.Header.Del("Etag")
+	page.Header.Del("Content-Length")
+
+	if page.Status == http.StatusOK && shared(rec, req) {
+		c.store(page, purges)
+	}
+	return page
+}
+
+func shared(rec *recorder, r *http.Request) bool {
+	cacheControl := strings.ToLower(rec.header.Get("Cache-Control"))
+	if strings.Contains(cacheControl, "private") || strings.Contains(cacheControl, "no-store") {
+		return false
+	}
+	return !httpcache.Personal(rec, r)
+}
+
+// shareable replaces the rendering request's CSP nonce in body with the
+// placeholder filled in again for each visitor.
+func shareable(body string, r *http.Request) string {
+	if nonce := security.CSPNonce(r); nonce != "" {
+		body = strings.ReplaceAll(body, html.EscapeString(nonce), security.CSPNoncePlaceholder)
+	}
+	return body
+}
+
+func (c *Cache) write(w http.ResponseWriter, r *http.Request, page *Page, state string) {
+	h := w.Header()
+	for k, v := range page.Header {
+		h[k] = append([]string(nil), v...)
+	}
+	h.Set("X-Galaxy-Cache", state)
+
+	body := security.InjectCSPNonce(string(page.Body), r)
+	if page.Status != http.StatusOK {
+		w.WriteHeader(page.Status)
+		w.Write([]byte(body))
+		return
+	}
+	httpcache.Write(w, r, []byte(body), nil)
+}
+
+func (c *Cache) store(page *Page, purges int) {
+	c.mu.Lock()
+	if c.purges != purges {
+		c.mu.Unlock()
+		return
+	}
+	c.add(page)
+	evicted := c.evict()
+	c.mu.Unlock()
+
+	if c.dir == "" {
+		return
+	}
+	c.remove(evicted)
+	data, err := json.Marshal(page)
+	if err == nil {
+		err = writeFile(c.file(page.Key), data)
+	}
+	if err != nil {
+		log.Printf("⚠️  isr: persist %s: %v", page.Key, err)
+	}
+}
+
+// add holds page under its key as the most recently served. c.mu must be
+// held.
+func (c *Cache) add(page *Page) {
+	if el, ok := c.elems[page.Key]; ok {
+		c.order.MoveToFront(el)
+	} else {
+		c.elems[page.Key] = c.order.PushFront(page.Key)
+	}
+	c.pages[page.Key] = page
+}
+
+// drop forgets the page at key. c.mu must be held.
+func (c *Cache) drop(key string) {
+	if el, ok := c.elems[key]; ok {
+		c.order.Remove(el)
+		delete(c.elems, key)
+	}
+	delete(c.pages, key)
+}
+
+// evict drops the least recently served pages over MaxPages and returns
+// their keys. c.mu must be held.
+func (c *Cache) evict() []string {
+	var evicted []string
+	for c.MaxPages > 0 && c.order.Len() > c.MaxPages {
+		key := c.order.Back().Value.(string)
+		c.drop(key)
+		evicted = append(evicted, key)
+	}
+	return evicted
+}
+
+// remove deletes the files of the pages at keys.
+func (c *Cache) remove(keys []string) {
+	for _, key := range keys {
+		if err := os.Remove(c.file(key)); err != nil && !os.IsNotExist(err) {
+			log.Printf("⚠️  isr: remove %s: %v", key, err)
+		}
+	}
+}
+
+// Purge drops the pages at paths, whatever their query, and those tagged
+// with any of tags, so they are rendered afresh on their next request. It
+// returns how many pages were dropped.
+func (c *Cache) Purge(paths, tags []string) int {
+	drop := map[string]bool{}
+	for _, p := range paths {
+		if !strings.HasPrefix(p, "/") {
+			p = "/" + p
+		}
+		drop[p] = true
+	}
+	tagged := map[string]bool{}
+	for _, tag := range tags {
+		tagged[tag] = true
+	}
+
+	c.mu.Lock()
+	c.purges++
+	var purged []string
+	for key, page := range c.pages {
+		match := drop[page.Path]
+		for _, tag := range page.Tags {
+			match = match || tagged[tag]
+		}
+		if match {
+			c.drop(key)
+			purged = append(purged, key)
+		}
+	}
+	c.mu.Unlock()
+
+	if c.dir != "" {
+		c.remove(purged)
+	}
+	return len(purged)
+}
+
+func (c *Cache) file(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+func (c *Cache) load() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("⚠️  isr: load %s: %v", c.dir, err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(c.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var page Page
+		if json.Unmarshal(data, &page) != nil || page.Path == "" {
+			continue
+		}
+		if page.Key == "" {
+			page.Key = page.Path
+		}
+		c.add(&page)
+	}
+	c.remove(c.evict())
+}
+
+// writeFile replaces name atomically so a crash never leaves a torn page.
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".page-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+// recorder captures a rendered response.
+type recorder struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        strings.Builder
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	return r.body.Write(p)
+}
@@ -0,0 +1,227 @@
+package isr
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/session"
+)
+
+func TestParseRoute(t *testing.T) {
+	route, ok := ParseRoute("// revalidate = 60\n// tags = blog, posts\nposts := load()")
+	if !ok || route.Revalidate != 60 || strings.Join(route.Tags, ",") != "blog,posts" {
+		t.Errorf("got %+v %v", route, ok)
+	}
+	if _, ok := ParseRoute("// prerender = false"); ok {
+		t.Error("pages without a revalidate comment are not regenerated")
+	}
+}
+
+func TestCache_Serve(t *testing.T) {
+	var renders atomic.Int32
+	render := func(w http.ResponseWriter, r *http.Request) {
+		n := renders.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<p>version " + string(rune('0'+n)) + "</p>"))
+	}
+	c := New(t.TempDir())
+	route := Route{Revalidate: 60, Tags: []string{"blog"}}
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c.Serve(w, httptest.NewRequest("GET", path, nil), route, render)
+		return w
+	}
+
+	if w := get("/post"); w.Header().Get("X-Galaxy-Cache") != "MISS" || w.Body.String() != "<p>version 1</p>" {
+		t.Fatalf("first request: %s %q", w.Header().Get("X-Galaxy-Cache"), w.Body.String())
+	}
+	w := get("/post")
+	if w.Header().Get("X-Galaxy-Cache") != "HIT" || w.Body.String() != "<p>version 1</p>" || renders.Load() != 1 {
+		t.Fatalf("second request should be cached: %s %q", w.Header().Get("X-Galaxy-Cache"), w.Body.String())
+	}
+	if w.Header().Get("ETag") == "" || w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("cached headers: %v", w.Header())
+	}
+
+	// Age the page past its revalidate interval.
+	c.mu.Lock()
+	c.pages["/post"].Rendered = time.Now().Add(-2 * time.Minute)
+	c.mu.Unlock()
+
+	if w := get("/post"); w.Header().Get("X-Galaxy-Cache") != "STALE" || w.Body.String() != "<p>version 1</p>" {
+		t.Fatalf("stale request: %s %q", w.Header().Get("X-Galaxy-Cache"), w.Body.String())
+	}
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		c.mu.Lock()
+		refreshing := c.refreshing["/post"]
+		c.mu.Unlock()
+		if !refreshing {
+			break
+		}
+	}
+	if w := get("/post"); w.Body.String() != "<p>version 2</p>" {
+		t.Fatalf("expected the regenerated page, got %q", w.Body.String())
+	}
+
+	// A restart loads the pages persisted to disk.
+	restarted := New(c.dir)
+	w = httptest.NewRecorder()
+	restarted.Serve(w, httptest.NewRequest("GET", "/post", nil), route, render)
+	if w.Header().Get("X-Galaxy-Cache") != "HIT" {
+		t.Errorf("expected the persisted page after restart, got %s", w.Header().Get("X-Galaxy-Cache"))
+	}
+
+	if n := c.Purge(nil, []string{"blog"}); n != 1 {
+		t.Errorf("Purge by tag dropped %d pages", n)
+	}
+	if w := get("/post"); w.Header().Get("X-Galaxy-Cache") != "MISS" {
+		t.Errorf("purged page should be rendered again, got %s", w.Header().Get("X-Galaxy-Cache"))
+	}
+}
+
+func TestCache_SkipsPrivateResponses(t *testing.T) {
+	c := New("")
+	render := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "visitor", Value: "1"})
+		w.Write([]byte("hello"))
+	}
+	for _, path := range []string{"/missing", "/cookie"} {
+		for i := 0; i < 2; i++ {
+			w := httptest.NewRecorder()
+			c.Serve(w, httptest.NewRequest("GET", path, nil), Route{Revalidate: 60}, render)
+			if w.Header().Get("X-Galaxy-Cache") != "MISS" {
+				t.Errorf("%s should not be cached", path)
+			}
+		}
+	}
+	w := httptest.NewRecorder()
+	c.Serve(w, httptest.NewRequest("GET", "/missing", nil), Route{Revalidate: 60}, render)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("status was not replayed: %d", w.Code)
+	}
+}
+
+func TestCache_SkipsSessionPages(t *testing.T) {
+	manager, err := session.NewManagerWithStore(config.SessionConfig{Secret: "test"}, session.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	login := func(user string) *http.Cookie {
+		w := httptest.NewRecorder()
+		manager.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			session.FromRequest(r).Set("user", user)
+		})).ServeHTTP(w, httptest.NewRequest("GET", "/login", nil))
+		return w.Result().Cookies()[0]
+	}
+
+	c := New("")
+	account := manager.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Serve(w, r, Route{Revalidate: 60}, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hello " + session.FromRequest(r).GetString("user")))
+		})
+	}))
+	for _, user := range []string{"alice", "bob"} {
+		req := httptest.NewRequest("GET", "/account", nil)
+		req.AddCookie(login(user))
+		w := httptest.NewRecorder()
+		account.ServeHTTP(w, req)
+		if w.Header().Get("X-Galaxy-Cache") != "MISS" || w.Body.String() != "hello "+user {
+			t.Errorf("%s got %s %q", user, w.Header().Get("X-Galaxy-Cache"), w.Body.String())
+		}
+	}
+}
+
+func TestCache_KeysOnQuery(t *testing.T) {
+	c := New("")
+	render := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("page " + r.URL.Query().Get("page")))
+	}
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c.Serve(w, httptest.NewRequest("GET", target, nil), Route{Revalidate: 60}, render)
+		return w
+	}
+
+	get("/posts?page=1&sort=new")
+	if w := get("/posts?page=2&sort=new"); w.Header().Get("X-Galaxy-Cache") != "MISS" || w.Body.String() != "page 2" {
+		t.Errorf("another query should render its own page, got %s %q", w.Header().Get("X-Galaxy-Cache"), w.Body.String())
+	}
+	if w := get("/posts?sort=new&page=1"); w.Header().Get("X-Galaxy-Cache") != "HIT" || w.Body.String() != "page 1" {
+		t.Errorf("reordered parameters should share the page, got %s %q", w.Header().Get("X-Galaxy-Cache"), w.Body.String())
+	}
+
+	if n := c.Purge([]string{"/posts"}, nil); n != 2 {
+		t.Errorf("Purge by path dropped %d pages, want every query", n)
+	}
+}
+
+func TestCache_MaxPages(t *testing.T) {
+	c := New(t.TempDir())
+	c.MaxPages = 2
+	render := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path))
+	}
+	get := func(path string) string {
+		w := httptest.NewRecorder()
+		c.Serve(w, httptest.NewRequest("GET", path, nil), Route{Revalidate: 60}, render)
+		return w.Header().Get("X-Galaxy-Cache")
+	}
+
+	get("/a")
+	get("/b")
+	get("/a")
+	get("/c")
+	if state := get("/a"); state != "HIT" {
+		t.Errorf("recently served page was evicted: %s", state)
+	}
+	if state := get("/b"); state != "MISS" {
+		t.Errorf("least recently served page should be evicted, got %s", state)
+	}
+	if len(c.pages) != 2 {
+		t.Errorf("cache holds %d pages, want 2", len(c.pages))
+	}
+
+	restarted := New(c.dir)
+	if len(restarted.pages) != 2 {
+		t.Errorf("evicted pages were left on disk: %d loaded", len(restarted.pages))
+	}
+}
+
+func TestRevalidateHandler(t *testing.T) {
+	c := New("")
+	c.Serve(httptest.NewRecorder(), httptest.NewRequest("GET", "/pricing", nil), Route{}, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("$9"))
+	})
+	h := c.RevalidateHandler("s3cret")
+
+	req := httptest.NewRequest("POST", "/_galaxy/revalidate?path=/pricing", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("missing secret: got %d", w.Code)
+	}
+
+	req.Header.Set("Authorization", "Bearer s3cret")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || strings.TrimSpace(w.Body.String()) != `{"purged":1}` {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+
+	t.Setenv(SecretEnv, "")
+	w = httptest.NewRecorder()
+	c.RevalidateHandler("").ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("endpoint without a secret should be disabled, got %d", w.Code)
+	}
+}
@@ -0,0 +1,59 @@
+package isr
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// SecretEnv is consulted when [isr].secret is empty so the secret can stay
+// out of galaxy.config.toml.
+const SecretEnv = "GALAXY_REVALIDATE_SECRET"
+
+// RevalidateHandler purges pages on demand. Requests are POSTs carrying
+// `Authorization: Bearer <secret>` and one or more path or tag parameters:
+//
+//	curl -X POST -H "Authorization: Bearer $SECRET" \
+//	    "https://example.com/_galaxy/revalidate?tag=blog&path=/pricing"
+//
+// Without a secret the endpoint answers 404.
+func (c *Cache) RevalidateHandler(secret string) http.Handler {
+	if secret == "" {
+		secret = os.Getenv(SecretEnv)
+	}
+	if secret == "" {
+		log.Printf("⚠️  isr: no revalidation secret configured (set [isr].secret or %s); on-demand revalidation is disabled", SecretEnv)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if secret == "" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		paths, tags := r.Form["path"], r.Form["tag"]
+		if len(paths) == 0 && len(tags) == 0 {
+			http.Error(w, "path or tag required", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"purged": c.Purge(paths, tags)})
+	})
+}
@@ -0,0 +1,145 @@
+package lifecycle
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+)
+
+// Server runs a generated server's http.Server. It applies the [server]
+// timeouts, answers liveness and readiness probes, and on SIGINT or SIGTERM
+// drains in-flight requests before running the shutdown hooks.
+type Server struct {
+	HTTP *http.Server
+	// Lifecycle, if set, runs its startup hooks once the listener is open
+	// and its shutdown hooks after the server has drained.
+	Lifecycle *Lifecycle
+
+	config config.ServerConfig
+	ready  atomic.Bool
+}
+
+func NewServer(cfg config.ServerConfig, handler http.Handler) *Server {
+	s := &Server{config: cfg}
+	s.HTTP = &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.Port),
+		Handler:           s.probes(handler),
+		ReadTimeout:       seconds(cfg.ReadTimeout),
+		ReadHeaderTimeout: seconds(cfg.ReadHeaderTimeout),
+		WriteTimeout:      seconds(cfg.WriteTimeout),
+		IdleTimeout:       seconds(cfg.IdleTimeout),
+		MaxHeaderBytes:    cfg.MaxHeaderBytes,
+	}
+	if cfg.DisableHTTP2 {
+		s.HTTP.TLSNextProto = make(map[string]func(*http.Server, *tls.Conn, http.Handler))
+	}
+	return s
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
+}
+
+// probes serves the liveness path, which succeeds while the process is up,
+// and the readiness path, which succeeds only between startup and shutdown.
+func (s *Server) probes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case s.config.LivenessPath != "" && r.URL.Path == s.config.LivenessPath:
+			w.Write([]byte("ok"))
+		case s.config.ReadinessPath != "" && r.URL.Path == s.config.ReadinessPath:
+			if !s.ready.Load() {
+				http.Error(w, "not ready", http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("ok"))
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// Ready reports whether the server is accepting traffic.
+func (s *Server) Ready() bool {
+	return s.ready.Load()
+}
+
+// ListenAndServe listens on s.HTTP.Addr and serves until SIGINT or SIGTERM.
+func (s *Server) ListenAndServe() error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := net.Listen("tcp", s.HTTP.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves on ln until ctx is done, then shuts down gracefully.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if s.config.TLS.Enabled() {
+			errCh <- s.HTTP.ServeTLS(ln, s.config.TLS.CertFile, s.config.TLS.KeyFile)
+		} else {
+			errCh <- s.HTTP.Serve(ln)
+		}
+	}()
+
+	if s.Lifecycle != nil {
+		if err := s.Lifecycle.ExecuteStartup(); err != nil {
+			s.HTTP.Close()
+			return err
+		}
+	}
+	s.ready.Store(true)
+
+	select {
+	case err := <-errCh:
+		s.ready.Store(false)
+		if s.Lifecycle != nil {
+			s.Lifecycle.ExecuteShutdown()
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	return s.Shutdown()
+}
+
+// Shutdown stops accepting connections, waits up to the configured
+// shutdown timeout for in-flight requests, then runs the shutdown hooks.
+func (s *Server) Shutdown() error {
+	s.ready.Store(false)
+
+	ctx := context.Background()
+	if s.config.ShutdownTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, seconds(s.config.ShutdownTimeout))
+		defer cancel()
+	}
+
+	err := s.HTTP.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		s.HTTP.Close()
+		err = fmt.Errorf("shutdown timeout after %ds: %w", s.config.ShutdownTimeout, err)
+	}
+
+	if s.Lifecycle != nil {
+		if hookErr := s.Lifecycle.ExecuteShutdown(); hookErr != nil && err == nil {
+			err = hookErr
+		}
+	}
+	return err
+}
@@ -0,0 +1,110 @@
+package lifecycle
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+)
+
+type recordingHook struct {
+	events chan string
+}
+
+func (h *recordingHook) OnStartup() error {
+	h.events <- "startup"
+	return nil
+}
+
+func (h *recordingHook) OnShutdown() error {
+	h.events <- "shutdown"
+	return nil
+}
+
+func TestServer_DrainsBeforeShutdownHooks(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.Write([]byte("done"))
+	})
+
+	srv := NewServer(config.DefaultConfig().Server, handler)
+	hook := &recordingHook{events: make(chan string, 4)}
+	srv.Lifecycle = NewLifecycle().Register(hook)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := "http://" + ln.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve(ctx, ln) }()
+
+	if got := <-hook.events; got != "startup" {
+		t.Fatalf("first event = %q", got)
+	}
+	waitFor(t, srv.Ready)
+
+	for path, want := range map[string]int{"/healthz": 200, "/readyz": 200} {
+		resp, err := http.Get(base + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s = %d, want %d", path, resp.StatusCode, want)
+		}
+	}
+
+	body := make(chan string, 1)
+	go func() {
+		resp, err := http.Get(base + "/slow")
+		if err != nil {
+			body <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		body <- string(data)
+	}()
+	<-started
+
+	cancel()
+	waitFor(t, func() bool { return !srv.Ready() })
+
+	select {
+	case ev := <-hook.events:
+		t.Fatalf("%s hook ran before in-flight request finished", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if got := <-body; got != "done" {
+		t.Errorf("in-flight request got %q", got)
+	}
+	if err := <-served; err != nil {
+		t.Errorf("Serve returned %v", err)
+	}
+	if got := <-hook.events; got != "shutdown" {
+		t.Errorf("last event = %q", got)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
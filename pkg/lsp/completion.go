@@ -147,6 +147,16 @@ func (s *Server) getGalaxyCompletions() []protocol.CompletionItem {
 			Kind:   protocol.CompletionItemKindMethod,
 			Detail: "func(url string, status int)",
 		},
+		{
+			Label:  "Galaxy.SetCache",
+			Kind:   protocol.CompletionItemKindMethod,
+			Detail: "func(maxAge, staleWhileRevalidate int) - Cache-Control in seconds",
+		},
+		{
+			Label:  "Galaxy.Cache.GetOrSet",
+			Kind:   protocol.CompletionItemKindMethod,
+			Detail: "func(key string, ttl int, fn func() (any, error), tags ...string) (any, error) - data cache, ttl in seconds",
+		},
 		{
 			Label:  "Galaxy.Locals",
 			Kind:   protocol.CompletionItemKindField,
@@ -157,6 +167,16 @@ func (s *Server) getGalaxyCompletions() []protocol.CompletionItem {
 			Kind:   protocol.CompletionItemKindField,
 			Detail: "map[string]interface{} - route params",
 		},
+		{
+			Label:  "Galaxy.Session",
+			Kind:   protocol.CompletionItemKindField,
+			Detail: "*session.Session - visitor session",
+		},
+		{
+			Label:  "Galaxy.CSRFToken",
+			Kind:   protocol.CompletionItemKindField,
+			Detail: "string - CSRF token for forms and fetch headers",
+		},
 	}
 }
 
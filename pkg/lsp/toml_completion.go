@@ -31,7 +31,7 @@ func (s *Server) getTOMLCompletions(content string, pos protocol.Position) (*pro
 func getTableCompletions(schema *TOMLSchema) []protocol.CompletionItem {
 	items := make([]protocol.CompletionItem, 0)
 
-	tables := []string{"output", "server", "adapter", "security", "lifecycle", "markdown", "content"}
+	tables := []string{"output", "server", "adapter", "security", "lifecycle", "markdown", "content", "compression", "cache", "isr"}
 
 	for _, table := range tables {
 		if tableSchema, ok := schema.Tables[table]; ok {
@@ -73,6 +73,11 @@ func BuildTOMLSchema() *TOMLSchema {
 						Description: "Security configuration",
 						IsTable:     true,
 					},
+					"session": {
+						Type:        "table",
+						Description: "Cookie session configuration",
+						IsTable:     true,
+					},
 					"lifecycle": {
 						Type:        "table",
 						Description: "Lifecycle hooks configuration",
@@ -119,6 +124,144 @@ func BuildTOMLSchema() *TOMLSchema {
 						Description: "Host address for dev server",
 						Default:     "localhost",
 					},
+					"readTimeout": {
+						Type:        "int",
+						Description: "Seconds to read a request, including the body (0 disables)",
+						Default:     "30",
+					},
+					"readHeaderTimeout": {
+						Type:        "int",
+						Description: "Seconds to read request headers (0 disables)",
+						Default:     "10",
+					},
+					"writeTimeout": {
+						Type:        "int",
+						Description: "Seconds to write a response (0 disables)",
+						Default:     "60",
+					},
+					"idleTimeout": {
+						Type:        "int",
+						Description: "Seconds to keep idle keep-alive connections open (0 disables)",
+						Default:     "120",
+					},
+					"shutdownTimeout": {
+						Type:        "int",
+						Description: "Seconds to drain in-flight requests on SIGTERM (0 waits indefinitely)",
+						Default:     "30",
+					},
+					"maxHeaderBytes": {
+						Type:        "int",
+						Description: "Maximum size of request headers in bytes",
+						Default:     "1048576",
+					},
+					"disableHTTP2": {
+						Type:        "bool",
+						Description: "Serve HTTP/1.1 only over TLS",
+						Default:     "false",
+					},
+					"livenessPath": {
+						Type:        "string",
+						Description: "Probe that succeeds while the process is running",
+						Default:     "/healthz",
+					},
+					"readinessPath": {
+						Type:        "string",
+						Description: "Probe that succeeds once startup hooks finish, until shutdown begins",
+						Default:     "/readyz",
+					},
+					"tls": {
+						Type:        "table",
+						Description: "Serve HTTPS from certificate files",
+						IsTable:     true,
+					},
+				},
+			},
+			"server.tls": {
+				Description: "TLS certificate for production servers",
+				Fields: map[string]FieldSchema{
+					"certFile": {
+						Type:        "string",
+						Description: "PEM certificate file (with chain)",
+					},
+					"keyFile": {
+						Type:        "string",
+						Description: "PEM private key file",
+					},
+				},
+			},
+			"compression": {
+				Description: "Gzip/brotli response compression",
+				Fields: map[string]FieldSchema{
+					"enabled": {
+						Type:        "bool",
+						Description: "Compress HTML, JSON, CSS and JS responses",
+						Default:     "true",
+					},
+					"precompress": {
+						Type:        "bool",
+						Description: "Write .br and .gz siblings of static files at build time",
+						Default:     "true",
+					},
+					"minSize": {
+						Type:        "int",
+						Description: "Smallest response in bytes worth compressing",
+						Default:     "1024",
+					},
+				},
+			},
+			"cache": {
+				Description: "HTTP caching of server-rendered pages",
+				Fields: map[string]FieldSchema{
+					"routes": {
+						Type:        "table",
+						Description: "Caching policies, first match wins (use [[cache.routes]] for array)",
+						IsTable:     true,
+					},
+				},
+			},
+			"cache.routes": {
+				Description: "Cache-Control for pages matching a pattern",
+				Fields: map[string]FieldSchema{
+					"pattern": {
+						Type:        "string",
+						Description: "Path to match (path.Match glob, or /prefix/** for a subtree)",
+						Required:    true,
+					},
+					"maxAge": {
+						Type:        "int",
+						Description: "Seconds caches may serve the page without revalidating",
+					},
+					"staleWhileRevalidate": {
+						Type:        "int",
+						Description: "Seconds a stale page may be served while it is refreshed",
+					},
+					"private": {
+						Type:        "bool",
+						Description: "Only let browsers, not shared caches, store the page",
+						Default:     "false",
+					},
+					"vary": {
+						Type:        "array",
+						Description: "Request headers the page varies by, e.g. [\"Cookie\"]",
+					},
+				},
+			},
+			"isr": {
+				Description: "Incremental static regeneration of pages with a revalidate comment",
+				Fields: map[string]FieldSchema{
+					"dir": {
+						Type:        "string",
+						Description: "Directory persisting regenerated pages across restarts",
+					},
+					"path": {
+						Type:        "string",
+						Description: "On-demand revalidation endpoint",
+						Default:     "/_galaxy/revalidate",
+					},
+					"secret": {
+						Type:        "string",
+						Description: "Bearer token for the revalidation endpoint (falls back to GALAXY_REVALIDATE_SECRET)",
+					},
 				},
 			},
 			"adapter": {
@@ -142,6 +285,16 @@ func BuildTOMLSchema() *TOMLSchema {
 					},
 				},
 			},
+			"adapter.config": {
+				Description: "Adapter-specific configuration",
+				Fields: map[string]FieldSchema{
+					"embed": {
+						Type:        "bool",
+						Description: "Embed pages and assets into a single server binary (standalone)",
+						Default:     "false",
+					},
+				},
+			},
 			"lifecycle": {
 				Description: "Lifecycle hooks configuration",
 				Fields: map[string]FieldSchema{
@@ -217,6 +370,21 @@ func BuildTOMLSchema() *TOMLSchema {
 						Description: "Request body size limits",
 						IsTable:     true,
 					},
+					"csrf": {
+						Type:        "table",
+						Description: "Token-based CSRF protection",
+						IsTable:     true,
+					},
+					"rateLimit": {
+						Type:        "table",
+						Description: "Request rate limiting",
+						IsTable:     true,
+					},
+					"cors": {
+						Type:        "table",
+						Description: "Cross-origin resource sharing",
+						IsTable:     true,
+					},
 				},
 			},
 			"security.headers": {
@@ -261,6 +429,16 @@ func BuildTOMLSchema() *TOMLSchema {
 						Type:        "string",
 						Description: "Permissions-Policy header",
 					},
+					"cspNonce": {
+						Type:        "bool",
+						Description: "Add a per-request nonce to the Content-Security-Policy and framework-injected tags",
+						Default:     "false",
+					},
+					"integrity": {
+						Type:        "bool",
+						Description: "Add subresource integrity hashes to local scripts and stylesheets",
+						Default:     "false",
+					},
 				},
 			},
 			"security.bodyLimit": {
@@ -278,6 +456,223 @@ func BuildTOMLSchema() *TOMLSchema {
 					},
 				},
 			},
+			"security.csrf": {
+				Description: "Token-based CSRF protection (Galaxy.CSRFToken, <CSRFInput/>)",
+				Fields: map[string]FieldSchema{
+					"enabled": {
+						Type:        "bool",
+						Description: "Require a CSRF token on unsafe requests",
+						Default:     "false",
+					},
+					"mode": {
+						Type:        "string",
+						EnumValues:  []string{string(config.CSRFModeDoubleSubmit), string(config.CSRFModeSynchronizer)},
+						Description: "double-submit uses a signed cookie; synchronizer stores the token in the session",
+						Default:     string(config.CSRFModeDoubleSubmit),
+					},
+					"secret": {
+						Type:        "string",
+						Description: "Signing secret for double-submit cookies (falls back to GALAXY_CSRF_SECRET)",
+					},
+					"exempt": {
+						Type:        "array",
+						Description: "Paths skipped by CSRF checks (supports /prefix/* and path.Match globs)",
+					},
+					"secure": {
+						Type:        "bool",
+						Description: "Only send the CSRF cookie over HTTPS",
+						Default:     "false",
+					},
+				},
+			},
+			"security.rateLimit": {
+				Description: "Request rate limiting (SSR and hybrid only)",
+				Fields: map[string]FieldSchema{
+					"enabled": {
+						Type:        "bool",
+						Description: "Enforce the rules in [[security.rateLimit.rules]]",
+						Default:     "false",
+					},
+					"trustedProxies": {
+						Type:        "array",
+						Description: "Proxy IPs or CIDRs whose X-Forwarded-For header is trusted",
+					},
+					"page": {
+						Type:        "string",
+						Description: "HTML file served with 429 responses to browsers",
+					},
+					"rules": {
+						Type:        "table",
+						Description: "Rate limit rules (use [[security.rateLimit.rules]] for array)",
+						IsTable:     true,
+					},
+				},
+			},
+			"security.rateLimit.rules": {
+				Description: "A rate limit rule",
+				Fields: map[string]FieldSchema{
+					"path": {
+						Type:        "string",
+						Description: "Path to limit (exact, /prefix/*, or path.Match glob)",
+						Default:     "/*",
+					},
+					"methods": {
+						Type:        "array",
+						Description: "HTTP methods to limit (all when empty)",
+					},
+					"limit": {
+						Type:        "int",
+						Description: "Requests allowed per window",
+						Required:    true,
+					},
+					"window": {
+						Type:        "int",
+						Description: "Window length in seconds",
+						Default:     "60",
+					},
+					"burst": {
+						Type:        "int",
+						Description: "Token bucket capacity (defaults to limit)",
+					},
+					"algorithm": {
+						Type:        "string",
+						EnumValues:  []string{string(config.RateLimitTokenBucket), string(config.RateLimitSlidingWindow)},
+						Description: "Limiting algorithm",
+						Default:     string(config.RateLimitTokenBucket),
+					},
+					"key": {
+						Type:        "string",
+						Description: "What to count requests by: ip, session, or locals:<name>",
+						Default:     "ip",
+					},
+				},
+			},
+			"security.cors": {
+				Description: "Cross-origin resource sharing (SSR and hybrid only)",
+				Fields: map[string]FieldSchema{
+					"enabled": {
+						Type:        "bool",
+						Description: "Apply the rules in [[security.cors.rules]] and answer preflight requests",
+						Default:     "false",
+					},
+					"rules": {
+						Type:        "table",
+						Description: "CORS rules, first match wins (use [[security.cors.rules]] for array)",
+						IsTable:     true,
+					},
+				},
+			},
+			"security.cors.rules": {
+				Description: "A CORS rule",
+				Fields: map[string]FieldSchema{
+					"path": {
+						Type:        "string",
+						Description: "Path the rule applies to (exact, /prefix/*, or path.Match glob)",
+						Default:     "/*",
+					},
+					"allowOrigins": {
+						Type:        "array",
+						Description: "Allowed origins; supports \"*\" and wildcards like https://*.example.com",
+						Required:    true,
+					},
+					"allowMethods": {
+						Type:        "array",
+						Description: "Methods allowed in preflight responses",
+						Default:     `["GET", "HEAD", "POST"]`,
+					},
+					"allowHeaders": {
+						Type:        "array",
+						Description: "Request headers allowed (echoes requested headers when empty)",
+					},
+					"exposeHeaders": {
+						Type:        "array",
+						Description: "Response headers readable by the browser",
+					},
+					"allowCredentials": {
+						Type:        "bool",
+						Description: "Allow cookies and credentials (not allowed with origin \"*\")",
+						Default:     "false",
+					},
+					"maxAge": {
+						Type:        "int",
+						Description: "Seconds browsers may cache preflight responses",
+					},
+				},
+			},
+			"session": {
+				Description: "Cookie sessions exposed as Galaxy.Session",
+				Fields: map[string]FieldSchema{
+					"enabled": {
+						Type:        "bool",
+						Description: "Enable sessions (SSR and hybrid only)",
+						Default:     "false",
+					},
+					"store": {
+						Type:        "string",
+						EnumValues:  []string{string(config.SessionStoreCookie), string(config.SessionStoreMemory), string(config.SessionStoreFile)},
+						Description: "Where session data is kept",
+						Default:     "cookie",
+					},
+					"dir": {
+						Type:        "string",
+						Description: "Directory for the file store",
+						Default:     "./.galaxy/sessions",
+					},
+					"cookieName": {
+						Type:        "string",
+						Description: "Session cookie name",
+						Default:     "galaxy_session",
+					},
+					"secret": {
+						Type:        "string",
+						Description: "Signing secret (falls back to GALAXY_SESSION_SECRET)",
+					},
+					"previousSecrets": {
+						Type:        "array",
+						Description: "Old secrets still accepted while rotating keys",
+					},
+					"encrypt": {
+						Type:        "bool",
+						Description: "Encrypt the cookie value with AES-GCM",
+						Default:     "false",
+					},
+					"idleTimeout": {
+						Type:        "int",
+						Description: "Seconds of inactivity before a session expires",
+						Default:     "1800",
+					},
+					"maxAge": {
+						Type:        "int",
+						Description: "Absolute session lifetime in seconds",
+						Default:     "604800",
+					},
+					"path": {
+						Type:        "string",
+						Description: "Cookie path",
+						Default:     "/",
+					},
+					"domain": {
+						Type:        "string",
+						Description: "Cookie domain",
+					},
+					"secure": {
+						Type:        "bool",
+						Description: "Only send the cookie over HTTPS",
+						Default:     "false",
+					},
+					"httpOnly": {
+						Type:        "bool",
+						Description: "Hide the cookie from JavaScript",
+						Default:     "true",
+					},
+					"sameSite": {
+						Type:        "string",
+						EnumValues:  []string{"lax", "strict", "none"},
+						Description: "Cookie SameSite attribute",
+						Default:     "lax",
+					},
+				},
+			},
 		},
 	}
 }
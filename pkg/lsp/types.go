@@ -87,6 +87,14 @@ func (ti *TypeInferencer) addGalaxyTypes() {
 				IsMap:    true,
 				Fields:   make(map[string]TypeInfo),
 			},
+			"Session": {
+				Name:     "Session",
+				TypeName: "*session.Session",
+			},
+			"CSRFToken": {
+				Name:     "CSRFToken",
+				TypeName: "string",
+			},
 		},
 	}
 }
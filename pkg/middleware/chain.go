@@ -23,7 +23,9 @@ func (c *Chain) Execute(ctx *Context, final HandlerFunc) error {
 		return final(ctx)
 	}
 
-	allMiddleware := append(c.middleware, finalMiddleware)
+	// Cap the slice so append copies rather than writing into the backing
+	// array shared by concurrent executions.
+	allMiddleware := append(c.middleware[:len(c.middleware):len(c.middleware)], finalMiddleware)
 	ctx.middleware = allMiddleware
 
 	return ctx.Next()
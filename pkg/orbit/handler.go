@@ -4,12 +4,16 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/withgalaxy/galaxy/pkg/endpoints"
 	"github.com/withgalaxy/galaxy/pkg/executor"
 	"github.com/withgalaxy/galaxy/pkg/parser"
 	"github.com/withgalaxy/galaxy/pkg/router"
+	"github.com/withgalaxy/galaxy/pkg/security"
 	"github.com/withgalaxy/galaxy/pkg/server"
+	"github.com/withgalaxy/galaxy/pkg/session"
+	"github.com/withgalaxy/galaxy/pkg/ssr"
 )
 
 func (p *GalaxyPlugin) handleRoute(w http.ResponseWriter, r *http.Request, route *router.Route, params map[string]string) {
@@ -34,27 +38,43 @@ func (p *GalaxyPlugin) handlePage(w http.ResponseWriter, r *http.Request, route
 		cacheKey = fmt.Sprintf("%s?%v", route.FilePath, params)
 	}
 
-	if cached, ok := p.Cache.Get(cacheKey); ok {
-		w.Header().Set("Content-Type", "text/html")
-		w.Write([]byte(cached.Template))
-		return
+	// Pages that read the session or the CSRF token render differently per
+	// visitor, so they bypass the page cache. <CSRFInput/> is safe to cache
+	// because the token is injected after rendering.
+	sess := session.FromRequest(r)
+	source, _ := os.ReadFile(route.FilePath)
+	usesCSRFToken := strings.Contains(string(source), "CSRFToken")
+	cacheable := sess == nil && !usesCSRFToken
+
+	if cacheable {
+		if cached, ok := p.Cache.Get(cacheKey); ok {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(security.InjectCSPNonce(security.InjectCSRFToken(cached.Template, r), r)))
+			return
+		}
 	}
 
 	p.Compiler.ClearCache()
-	p.Compiler.ResetComponentTracking()
+	render := p.Compiler.NewSession(route.FilePath, nil)
 
 	ctx := executor.NewContext()
 	for k, v := range params {
 		ctx.Set(k, v)
 	}
+	if sess != nil {
+		ctx.SetSession(sess)
+	}
+	if usesCSRFToken {
+		ctx.SetCSRFToken(security.CSRFToken(r))
+	}
 
-	html, err := p.Compiler.CompileWithContext(route.FilePath, nil, nil, ctx)
+	html, err := render.CompileWithContext(route.FilePath, nil, nil, ctx)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	p.ComponentTracker.TrackPageComponents(route.FilePath, p.Compiler.UsedComponents)
+	p.ComponentTracker.TrackPageComponents(route.FilePath, render.UsedComponents)
 
 	content, err := os.ReadFile(route.FilePath)
 	if err == nil {
@@ -62,7 +82,7 @@ func (p *GalaxyPlugin) handlePage(w http.ResponseWriter, r *http.Request, route
 		if parseErr == nil {
 			cssPath, _ := p.Bundler.BundleStyles(comp, route.FilePath)
 			jsPath, _ := p.Bundler.BundleScripts(comp, route.FilePath)
-			wasmAssets, _ := p.Bundler.BundleWasmScripts(comp, route.FilePath)
+			wasmAssets, _ := p.Bundler.BundleWasmScripts(comp, route.FilePath, render.WasmSources()...)
 
 			scopeID := ""
 			if cssPath != "" {
@@ -73,12 +93,16 @@ func (p *GalaxyPlugin) handlePage(w http.ResponseWriter, r *http.Request, route
 		}
 	}
 
-	p.Cache.Set(cacheKey, &server.PagePlugin{
-		Template: html,
-	})
+	html = ssr.InjectState(html, ctx.State)
+
+	if cacheable {
+		p.Cache.Set(cacheKey, &server.PagePlugin{
+			Template: html,
+		})
+	}
 
 	w.Header().Set("Content-Type", "text/html")
-	w.Write([]byte(html))
+	w.Write([]byte(security.InjectCSPNonce(security.InjectCSRFToken(html, r), r)))
 }
 
 func (p *GalaxyPlugin) handleEndpoint(w http.ResponseWriter, r *http.Request, route *router.Route, params map[string]string) {
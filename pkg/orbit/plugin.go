@@ -12,12 +12,15 @@ import (
 	"github.com/withgalaxy/galaxy/pkg/assets"
 	"github.com/withgalaxy/galaxy/pkg/codegen"
 	"github.com/withgalaxy/galaxy/pkg/compiler"
+	"github.com/withgalaxy/galaxy/pkg/config"
 	"github.com/withgalaxy/galaxy/pkg/endpoints"
 	"github.com/withgalaxy/galaxy/pkg/hmr"
 	"github.com/withgalaxy/galaxy/pkg/lifecycle"
 	"github.com/withgalaxy/galaxy/pkg/middleware"
 	"github.com/withgalaxy/galaxy/pkg/router"
+	"github.com/withgalaxy/galaxy/pkg/security"
 	"github.com/withgalaxy/galaxy/pkg/server"
+	"github.com/withgalaxy/galaxy/pkg/session"
 	orbit "github.com/withgalaxy/orbit/plugin"
 )
 
@@ -35,6 +38,12 @@ type GalaxyPlugin struct {
 	MiddlewareChain    *middleware.Chain
 	LoadedMiddleware   *middleware.LoadedMiddleware
 	Lifecycle          *lifecycle.Lifecycle
+	Config             *config.Config
+	SessionManager     *session.Manager
+	CSRFMiddleware     *security.CSRFMiddleware
+	RateLimiter        *security.RateLimiter
+	CORSMiddleware     *security.CORSMiddleware
+	HeadersMiddleware  *security.HeadersMiddleware
 	UseCodegen         bool
 	CodegenPort        int
 	codegenCmd         *exec.Cmd
@@ -135,6 +144,7 @@ func (p *GalaxyPlugin) ConfigResolved(config any) error {
 func (p *GalaxyPlugin) buildCodegenServer() error {
 	builder := codegen.NewCodegenBuilder(p.Router.Routes, p.PagesDir, ".galaxy", "dev-server", p.PublicDir)
 	builder.Bundler = p.Bundler
+	builder.Config = p.Config
 	if err := builder.Build(); err != nil {
 		return fmt.Errorf("build failed: %w", err)
 	}
@@ -216,6 +226,67 @@ func (p *GalaxyPlugin) HandleHotUpdate(file string) ([]string, error) {
 	return []string{file}, nil
 }
 
+// SetConfig applies galaxy.config.toml settings that affect request handling.
+func (p *GalaxyPlugin) SetConfig(cfg *config.Config) error {
+	p.Config = cfg
+	if cfg == nil {
+		return nil
+	}
+
+	if cfg.Session.Enabled {
+		manager, err := session.NewManager(cfg.Session)
+		if err != nil {
+			return fmt.Errorf("sessions: %w", err)
+		}
+		p.SessionManager = manager
+	}
+
+	if security.CSRFEnabled(cfg) {
+		p.CSRFMiddleware = security.NewCSRFMiddleware(security.NewCSRFConfig(cfg))
+	}
+
+	if cfg.Security.Headers.Enabled {
+		p.HeadersMiddleware = security.NewHeadersMiddleware(cfg.Security.Headers)
+	}
+	p.Bundler.CSPNonce = security.CSPNonceEnabled(cfg)
+
+	if security.CORSEnabled(cfg) {
+		p.CORSMiddleware = security.NewCORSMiddleware(cfg.Security.CORS)
+	}
+
+	limiter, err := security.NewRateLimiterFromConfig(cfg, p.RootDir)
+	if err != nil {
+		return fmt.Errorf("rate limiting: %w", err)
+	}
+	p.RateLimiter = limiter
+
+	return nil
+}
+
+func (p *GalaxyPlugin) serveRoute(w http.ResponseWriter, r *http.Request, route *router.Route, params map[string]string) {
+	if p.MiddlewareChain != nil {
+		mwCtx := middleware.NewContext(w, r)
+		mwCtx.Params = params
+
+		err := p.MiddlewareChain.Execute(mwCtx, func(ctx *middleware.Context) error {
+			if p.RateLimiter != nil && !p.RateLimiter.Allow(ctx.Response, ctx.Request, ctx.Locals) {
+				return nil
+			}
+			p.handleRoute(ctx.Response, ctx.Request, route, params)
+			return nil
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if p.RateLimiter != nil && !p.RateLimiter.Allow(w, r, nil) {
+		return
+	}
+	p.handleRoute(w, r, route, params)
+}
+
 func (p *GalaxyPlugin) Middleware() orbit.Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -238,22 +309,22 @@ func (p *GalaxyPlugin) Middleware() orbit.Middleware {
 				return
 			}
 
-			if p.MiddlewareChain != nil {
-				mwCtx := middleware.NewContext(rw, r)
-				mwCtx.Params = params
-
-				err := p.MiddlewareChain.Execute(mwCtx, func(ctx *middleware.Context) error {
-					p.handleRoute(ctx.Response, ctx.Request, route, params)
-					return nil
-				})
-				if err != nil {
-					http.Error(rw, err.Error(), http.StatusInternalServerError)
-				}
-				p.logRequest(r, rw.statusCode, time.Since(start))
-				return
+			var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				p.serveRoute(w, r, route, params)
+			})
+			if p.HeadersMiddleware != nil {
+				handler = p.HeadersMiddleware.Handler(handler)
 			}
-
-			p.handleRoute(rw, r, route, params)
+			if p.CSRFMiddleware != nil {
+				handler = p.CSRFMiddleware.Handler(handler)
+			}
+			if p.SessionManager != nil {
+				handler = p.SessionManager.Handler(handler)
+			}
+			if p.CORSMiddleware != nil {
+				handler = p.CORSMiddleware.Handler(handler)
+			}
+			handler.ServeHTTP(rw, r)
 			p.logRequest(r, rw.statusCode, time.Since(start))
 		})
 	}
@@ -297,12 +368,19 @@ func getStatusColor(status int) string {
 }
 
 func (p *GalaxyPlugin) proxyToCodegen(w http.ResponseWriter, r *http.Request) {
-	target := fmt.Sprintf("http://localhost:%d%s", p.CodegenPort, r.URL.Path)
+	target := fmt.Sprintf("http://localhost:%d%s", p.CodegenPort, r.URL.RequestURI())
 
 	proxyReq, _ := http.NewRequest(r.Method, target, r.Body)
 	proxyReq.Header = r.Header
-
-	client := &http.Client{}
+	proxyReq.Host = r.Host
+
+	// Redirects (e.g. POST-redirect-GET after a form submit) must reach the
+	// browser together with their Set-Cookie headers.
+	client := &http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
 	resp, err := client.Do(proxyReq)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadGateway)
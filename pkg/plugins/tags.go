@@ -0,0 +1,81 @@
+package plugins
+
+import (
+	"html"
+	"sort"
+	"strings"
+)
+
+var voidTags = map[string]bool{
+	"base": true, "link": true, "meta": true,
+}
+
+// Render returns the tag as HTML. nonceAttr is appended to script, style
+// and stylesheet link tags that don't set their own nonce.
+func (t HTMLTag) Render(nonceAttr string) string {
+	name := strings.ToLower(t.Tag)
+
+	keys := make([]string, 0, len(t.Attributes))
+	for k := range t.Attributes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("<" + name)
+	for _, k := range keys {
+		b.WriteString(" " + k + `="` + html.EscapeString(t.Attributes[k]) + `"`)
+	}
+	if _, ok := t.Attributes["nonce"]; !ok && t.acceptsNonce(name) {
+		b.WriteString(nonceAttr)
+	}
+	b.WriteString(">")
+
+	if voidTags[name] {
+		return b.String()
+	}
+	b.WriteString(t.Content)
+	b.WriteString("</" + name + ">")
+	return b.String()
+}
+
+func (t HTMLTag) acceptsNonce(name string) bool {
+	switch name {
+	case "script", "style":
+		return true
+	case "link":
+		return strings.EqualFold(t.Attributes["rel"], "stylesheet")
+	}
+	return false
+}
+
+// ApplyTags inserts tags into page at their positions.
+func ApplyTags(page string, tags []HTMLTag, nonceAttr string) string {
+	for _, tag := range tags {
+		rendered := tag.Render(nonceAttr)
+		switch tag.Position {
+		case HeadStart:
+			page = insertAfterOpenTag(page, "<head", rendered)
+		case HeadEnd:
+			page = strings.Replace(page, "</head>", rendered+"\n</head>", 1)
+		case BodyStart:
+			page = insertAfterOpenTag(page, "<body", rendered)
+		case BodyEnd:
+			page = strings.Replace(page, "</body>", rendered+"\n</body>", 1)
+		}
+	}
+	return page
+}
+
+func insertAfterOpenTag(page, open, content string) string {
+	i := strings.Index(page, open)
+	if i < 0 {
+		return page
+	}
+	end := strings.Index(page[i:], ">")
+	if end < 0 {
+		return page
+	}
+	at := i + end + 1
+	return page[:at] + "\n" + content + page[at:]
+}
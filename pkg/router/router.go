@@ -2,6 +2,7 @@ package router
 
 import (
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -33,7 +34,10 @@ type Route struct {
 type Router struct {
 	Routes   []*Route
 	PagesDir string
-	mu       sync.RWMutex
+	// FS, if set, is searched instead of the OS filesystem; PagesDir and
+	// route file paths are then relative to its root.
+	FS fs.FS
+	mu sync.RWMutex
 }
 
 func NewRouter(pagesDir string) *Router {
@@ -43,6 +47,13 @@ func NewRouter(pagesDir string) *Router {
 	}
 }
 
+// NewRouterFS discovers routes in fsys, with pagesDir relative to its root.
+func NewRouterFS(fsys fs.FS, pagesDir string) *Router {
+	r := NewRouter(pagesDir)
+	r.FS = fsys
+	return r
+}
+
 func (r *Router) Discover() error {
 	return r.discover()
 }
@@ -176,7 +187,7 @@ func (r *Router) Reload() error {
 }
 
 func (r *Router) discover() error {
-	return filepath.Walk(r.PagesDir, func(path string, info os.FileInfo, err error) error {
+	return r.walk(func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -211,6 +222,19 @@ func (r *Router) discover() error {
 	})
 }
 
+func (r *Router) walk(fn filepath.WalkFunc) error {
+	if r.FS == nil {
+		return filepath.Walk(r.PagesDir, fn)
+	}
+	return fs.WalkDir(r.FS, filepath.ToSlash(filepath.Clean(r.PagesDir)), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fn(filepath.FromSlash(path), nil, err)
+		}
+		info, err := d.Info()
+		return fn(filepath.FromSlash(path), info, err)
+	})
+}
+
 func (r *Router) sort() {
 	sort.Slice(r.Routes, func(i, j int) bool {
 		if r.Routes[i].Priority != r.Routes[j].Priority {
@@ -0,0 +1,197 @@
+package security
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/middleware"
+)
+
+// ErrPreflightHandled is returned by CORSMiddleware.Middleware once it has
+// answered a preflight request; callers should stop processing.
+var ErrPreflightHandled = errors.New("cors: preflight handled")
+
+var wildcardOriginPart = regexp.MustCompile(`^[a-z0-9.-]+$`)
+
+type corsRule struct {
+	config.CORSRule
+	anyOrigin bool
+	origins   map[string]bool
+	wildcards [][2]string
+	headers   map[string]bool
+	anyHeader bool
+}
+
+type CORSMiddleware struct {
+	rules []*corsRule
+}
+
+func NewCORSMiddleware(cfg config.CORSConfig) *CORSMiddleware {
+	m := &CORSMiddleware{}
+	for _, r := range cfg.Rules {
+		rule := &corsRule{
+			CORSRule: r,
+			origins:  make(map[string]bool),
+			headers:  make(map[string]bool),
+		}
+		for _, origin := range r.AllowOrigins {
+			origin = NormalizeOrigin(origin)
+			switch {
+			case origin == "*":
+				rule.anyOrigin = true
+			case strings.Count(origin, "*") == 1:
+				prefix, suffix, _ := strings.Cut(origin, "*")
+				rule.wildcards = append(rule.wildcards, [2]string{prefix, suffix})
+			default:
+				rule.origins[origin] = true
+			}
+		}
+		for _, h := range r.AllowHeaders {
+			if h == "*" {
+				rule.anyHeader = true
+			}
+			rule.headers[http.CanonicalHeaderKey(h)] = true
+		}
+		m.rules = append(m.rules, rule)
+	}
+	return m
+}
+
+// CORSEnabled reports whether cfg requires the CORS middleware.
+func CORSEnabled(cfg *config.Config) bool {
+	return cfg.IsSSR() && cfg.Security.CORS.Enabled && len(cfg.Security.CORS.Rules) > 0
+}
+
+func (m *CORSMiddleware) Middleware(ctx *middleware.Context, next func() error) error {
+	if m.handle(ctx.Response, ctx.Request) {
+		return ErrPreflightHandled
+	}
+	return next()
+}
+
+func (m *CORSMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if m.handle(w, r) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// handle sets CORS headers for r and reports whether it answered a preflight.
+func (m *CORSMiddleware) handle(w http.ResponseWriter, r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+
+	rule := m.match(r.URL.Path)
+	if rule == nil {
+		return false
+	}
+
+	h := w.Header()
+	preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+	if preflight {
+		h.Add("Vary", "Origin")
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+
+		allowedHeaders, ok := rule.allowHeaders(r.Header.Get("Access-Control-Request-Headers"))
+		if !rule.allowOrigin(origin) || !rule.allowMethod(r.Header.Get("Access-Control-Request-Method")) || !ok {
+			http.Error(w, "Forbidden: CORS preflight rejected", http.StatusForbidden)
+			return true
+		}
+
+		rule.setOrigin(h, origin)
+		h.Set("Access-Control-Allow-Methods", strings.Join(rule.AllowMethods, ", "))
+		if allowedHeaders != "" {
+			h.Set("Access-Control-Allow-Headers", allowedHeaders)
+		}
+		if rule.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", strconv.Itoa(rule.MaxAge))
+		}
+		w.WriteHeader(http.StatusNoContent)
+		return true
+	}
+
+	if !rule.anyOrigin || rule.AllowCredentials {
+		h.Add("Vary", "Origin")
+	}
+	if !rule.allowOrigin(origin) {
+		return false
+	}
+	rule.setOrigin(h, origin)
+	if len(rule.ExposeHeaders) > 0 {
+		h.Set("Access-Control-Expose-Headers", strings.Join(rule.ExposeHeaders, ", "))
+	}
+	return false
+}
+
+func (m *CORSMiddleware) match(p string) *corsRule {
+	for _, rule := range m.rules {
+		if matchPath(rule.Path, p) {
+			return rule
+		}
+	}
+	return nil
+}
+
+func (r *corsRule) allowOrigin(origin string) bool {
+	if r.anyOrigin {
+		return true
+	}
+	origin = NormalizeOrigin(origin)
+	if r.origins[origin] {
+		return true
+	}
+	for _, w := range r.wildcards {
+		prefix, suffix := w[0], w[1]
+		if len(origin) <= len(prefix)+len(suffix) || !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+			continue
+		}
+		if wildcardOriginPart.MatchString(origin[len(prefix) : len(origin)-len(suffix)]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *corsRule) allowMethod(method string) bool {
+	for _, m := range r.AllowMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// allowHeaders returns the Access-Control-Allow-Headers value for a preflight
+// asking for requested, and whether every requested header is allowed.
+func (r *corsRule) allowHeaders(requested string) (string, bool) {
+	if len(r.AllowHeaders) == 0 || r.anyHeader {
+		return requested, true
+	}
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.TrimSpace(h)
+		if h != "" && !r.headers[http.CanonicalHeaderKey(h)] {
+			return "", false
+		}
+	}
+	return strings.Join(r.AllowHeaders, ", "), true
+}
+
+func (r *corsRule) setOrigin(h http.Header, origin string) {
+	if r.anyOrigin && !r.AllowCredentials {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if r.AllowCredentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
@@ -0,0 +1,151 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/middleware"
+)
+
+func newTestCORS() *CORSMiddleware {
+	return NewCORSMiddleware(config.CORSConfig{
+		Enabled: true,
+		Rules: []config.CORSRule{
+			{
+				Path:             "/api/private/*",
+				AllowOrigins:     []string{"https://app.example.com", "https://*.example.org"},
+				AllowMethods:     []string{"GET", "POST", "DELETE"},
+				AllowHeaders:     []string{"Content-Type", "X-CSRF-Token"},
+				ExposeHeaders:    []string{"X-Total-Count"},
+				AllowCredentials: true,
+				MaxAge:           600,
+			},
+			{
+				Path:         "/api/*",
+				AllowOrigins: []string{"*"},
+				AllowMethods: []string{"GET"},
+			},
+		},
+	})
+}
+
+func corsRequest(m *CORSMiddleware, method, path, origin string, headers map[string]string) (*httptest.ResponseRecorder, bool) {
+	req := httptest.NewRequest(method, path, nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	called := false
+	m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(w, req)
+	return w, called
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	m := newTestCORS()
+
+	tests := []struct {
+		name        string
+		path        string
+		origin      string
+		method      string
+		headers     string
+		wantStatus  int
+		wantOrigin  string
+		wantHeaders string
+	}{
+		{"exact origin", "/api/private/items", "https://app.example.com", "DELETE", "content-type", http.StatusNoContent, "https://app.example.com", "Content-Type, X-CSRF-Token"},
+		{"wildcard subdomain", "/api/private/items", "https://eu.example.org", "POST", "", http.StatusNoContent, "https://eu.example.org", "Content-Type, X-CSRF-Token"},
+		{"wildcard does not match apex", "/api/private/items", "https://example.org", "POST", "", http.StatusForbidden, "", ""},
+		{"wildcard rejects other hosts", "/api/private/items", "https://evil.com/.example.org", "POST", "", http.StatusForbidden, "", ""},
+		{"disallowed origin", "/api/private/items", "https://evil.com", "GET", "", http.StatusForbidden, "", ""},
+		{"disallowed method", "/api/private/items", "https://app.example.com", "PUT", "", http.StatusForbidden, "", ""},
+		{"disallowed header", "/api/private/items", "https://app.example.com", "POST", "X-Secret", http.StatusForbidden, "", ""},
+		{"any origin echoes headers", "/api/public", "https://anywhere.dev", "GET", "X-Custom", http.StatusNoContent, "*", "X-Custom"},
+		{"no rule", "/about", "https://app.example.com", "GET", "", http.StatusOK, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{"Access-Control-Request-Method": tt.method}
+			if tt.headers != "" {
+				headers["Access-Control-Request-Headers"] = tt.headers
+			}
+			w, called := corsRequest(m, "OPTIONS", tt.path, tt.origin, headers)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if called != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("next handler called = %v", called)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Headers"); got != tt.wantHeaders {
+				t.Errorf("Allow-Headers = %q, want %q", got, tt.wantHeaders)
+			}
+		})
+	}
+}
+
+func TestCORS_PreflightHeaders(t *testing.T) {
+	w, _ := corsRequest(newTestCORS(), "OPTIONS", "/api/private/x", "https://app.example.com",
+		map[string]string{"Access-Control-Request-Method": "POST"})
+
+	if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, DELETE" {
+		t.Errorf("Allow-Methods = %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Allow-Credentials = %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("Max-Age = %q", got)
+	}
+}
+
+func TestCORS_ActualRequest(t *testing.T) {
+	m := newTestCORS()
+
+	w, called := corsRequest(m, "GET", "/api/private/items", "HTTPS://APP.EXAMPLE.COM/", nil)
+	if !called {
+		t.Fatal("expected request to reach handler")
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "HTTPS://APP.EXAMPLE.COM/" {
+		t.Errorf("Allow-Origin = %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "X-Total-Count" {
+		t.Errorf("Expose-Headers = %q", got)
+	}
+	if got := w.Header().Get("Vary"); got != "Origin" {
+		t.Errorf("Vary = %q", got)
+	}
+
+	w, called = corsRequest(m, "GET", "/api/private/items", "https://evil.com", nil)
+	if !called || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Error("disallowed origin should get no CORS headers")
+	}
+
+	w, _ = corsRequest(m, "GET", "/api/private/items", "", nil)
+	if len(w.Header()) != 0 {
+		t.Errorf("same-origin request should be untouched, got %v", w.Header())
+	}
+}
+
+func TestCORS_MiddlewareStopsOnPreflight(t *testing.T) {
+	req := httptest.NewRequest("OPTIONS", "/api/public", nil)
+	req.Header.Set("Origin", "https://anywhere.dev")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	ctx := middleware.NewContext(httptest.NewRecorder(), req)
+
+	if err := newTestCORS().Middleware(ctx, func() error { return nil }); err != ErrPreflightHandled {
+		t.Errorf("expected ErrPreflightHandled, got %v", err)
+	}
+}
@@ -0,0 +1,331 @@
+package security
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"html"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+)
+
+const (
+	// CSPNoncePlaceholder marks framework-injected tags at render time and is
+	// replaced with the request's nonce by InjectCSPNonce.
+	CSPNoncePlaceholder = "__GALAXY_CSP_NONCE__"
+
+	// CSPNonceAttr is appended to framework-injected <script>, <style> and
+	// <link rel="stylesheet"> tags when [security.headers].cspNonce is on.
+	CSPNonceAttr = ` nonce="` + CSPNoncePlaceholder + `"`
+)
+
+type cspNonceKey struct{}
+
+// WithCSPNonce returns r carrying a fresh nonce, and the nonce.
+func WithCSPNonce(r *http.Request) (*http.Request, string) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("security: generate csp nonce: " + err.Error())
+	}
+	nonce := base64.StdEncoding.EncodeToString(b)
+	return r.WithContext(context.WithValue(r.Context(), cspNonceKey{}, nonce)), nonce
+}
+
+// CSPNonce returns the nonce for r, or "" when nonces are disabled.
+func CSPNonce(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	nonce, _ := r.Context().Value(cspNonceKey{}).(string)
+	return nonce
+}
+
+// InjectCSPNonce fills nonce placeholders in page with the nonce for r. When
+// r has no nonce the attributes are removed instead.
+func InjectCSPNonce(page string, r *http.Request) string {
+	if !strings.Contains(page, CSPNoncePlaceholder) {
+		return page
+	}
+	if nonce := CSPNonce(r); nonce != "" {
+		return strings.ReplaceAll(page, CSPNoncePlaceholder, html.EscapeString(nonce))
+	}
+	page = strings.ReplaceAll(page, CSPNonceAttr, "")
+	return strings.ReplaceAll(page, CSPNoncePlaceholder, "")
+}
+
+// AddCSPSources appends sources to directive in policy. A missing directive
+// is created from default-src (or 'self') so adding a nonce or hash never
+// loosens the policy.
+func AddCSPSources(policy, directive string, sources ...string) string {
+	if len(sources) == 0 {
+		return policy
+	}
+
+	var parts []string
+	var fallback []string
+	found := false
+	for _, part := range strings.Split(policy, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.Fields(part)
+		switch strings.ToLower(fields[0]) {
+		case directive:
+			part += " " + strings.Join(sources, " ")
+			found = true
+		case "default-src":
+			for _, src := range fields[1:] {
+				if src != "'none'" {
+					fallback = append(fallback, src)
+				}
+			}
+		}
+		parts = append(parts, part)
+	}
+
+	if !found {
+		if len(fallback) == 0 {
+			fallback = []string{"'self'"}
+		}
+		parts = append(parts, directive+" "+strings.Join(append(fallback, sources...), " "))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// NonceCSP adds nonce to the script-src and style-src directives of policy.
+func NonceCSP(policy, nonce string) string {
+	source := "'nonce-" + nonce + "'"
+	policy = AddCSPSources(policy, "script-src", source)
+	return AddCSPSources(policy, "style-src", source)
+}
+
+var (
+	inlineScriptPattern = regexp.MustCompile(`(?is)<script\b([^>]*)>(.*?)</script>`)
+	inlineStylePattern  = regexp.MustCompile(`(?is)<style\b[^>]*>(.*?)</style>`)
+	srcAttrPattern      = regexp.MustCompile(`(?i)\ssrc\s*=`)
+)
+
+// HashCSP adds sha256 hashes of the inline scripts and styles in page to
+// policy, for static pages where no per-request nonce is possible.
+func HashCSP(policy, page string) string {
+	var scripts, styles []string
+	for _, m := range inlineScriptPattern.FindAllStringSubmatch(page, -1) {
+		if srcAttrPattern.MatchString(m[1]) || strings.TrimSpace(m[2]) == "" {
+			continue
+		}
+		scripts = append(scripts, cspHash(m[2]))
+	}
+	for _, m := range inlineStylePattern.FindAllStringSubmatch(page, -1) {
+		styles = append(styles, cspHash(m[1]))
+	}
+	policy = AddCSPSources(policy, "script-src", scripts...)
+	return AddCSPSources(policy, "style-src", styles...)
+}
+
+func cspHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return "'sha256-" + base64.StdEncoding.EncodeToString(sum[:]) + "'"
+}
+
+// StaticCSPEnabled reports whether prerendered pages get a hash-based
+// policy, since the nonce policy in cfg can't apply to them.
+func StaticCSPEnabled(cfg config.HeadersConfig) bool {
+	return cfg.Enabled && cfg.CSPNonce && cfg.ContentSecurityPolicy != ""
+}
+
+var staticCSPPattern = regexp.MustCompile(`\n<meta http-equiv="Content-Security-Policy" content="([^"]*)">|<meta http-equiv="Content-Security-Policy" content="([^"]*)">\n`)
+
+// InjectStaticCSP strips nonce placeholders from page and adds a
+// Content-Security-Policy meta tag built from policy and the page's inline
+// hashes.
+func InjectStaticCSP(page, policy string) string {
+	page = InjectCSPNonce(page, nil)
+	meta := `<meta http-equiv="Content-Security-Policy" content="` + html.EscapeString(HashCSP(policy, page)) + `">`
+	if i := strings.Index(strings.ToLower(page), "<head>"); i >= 0 {
+		return page[:i+len("<head>")] + "\n" + meta + page[i+len("<head>"):]
+	}
+	return meta + "\n" + page
+}
+
+// ExtractStaticCSP removes the meta tag added by InjectStaticCSP from page
+// and returns its policy, for servers to send as a header instead. A page
+// under both would only run what each allows.
+func ExtractStaticCSP(page string) (string, string, bool) {
+	m := staticCSPPattern.FindStringSubmatchIndex(page)
+	if m == nil {
+		return page, "", false
+	}
+	// InjectStaticCSP puts a newline before the tag in <head>, and after it
+	// at the start of a page.
+	content := m[4:6]
+	if m[2] >= 0 {
+		content = m[2:4]
+	}
+	return page[:m[0]] + page[m[1]:], html.UnescapeString(page[content[0]:content[1]]), true
+}
+
+var (
+	assetTagPattern = regexp.MustCompile(`(?i)<(script|link)\b[^>]*>`)
+	attrPattern     = regexp.MustCompile(`(?i)\s(src|href|rel|integrity)\s*=\s*"([^"]*)"`)
+	integrityCache  sync.Map
+)
+
+type integrityEntry struct {
+	modTime time.Time
+	size    int64
+	value   string
+}
+
+// AddIntegrity adds sha384 integrity attributes to <script src> and
+// <link rel="stylesheet"> tags that reference local files. Absolute URLs are
+// resolved against root and relative ones against pageDir; tags whose file
+// cannot be read are left unchanged.
+func AddIntegrity(page, root, pageDir string) string {
+	return addIntegrity(page, func(ref string) string {
+		file := resolveAsset(ref, root, pageDir)
+		if file == "" {
+			return ""
+		}
+		return fileIntegrity(file)
+	})
+}
+
+// AddIntegrityFS is AddIntegrity for servers reading their assets from fsys,
+// such as an embedded file system. Only absolute URLs are hashed.
+func AddIntegrityFS(page string, fsys fs.FS) string {
+	return addIntegrity(page, func(ref string) string {
+		if !strings.HasPrefix(ref, "/") || strings.HasPrefix(ref, "//") {
+			return ""
+		}
+		ref, _, _ = strings.Cut(ref, "?")
+		ref, _, _ = strings.Cut(ref, "#")
+		return fsIntegrity(fsys, path.Clean(strings.TrimPrefix(ref, "/")))
+	})
+}
+
+func addIntegrity(page string, integrity func(ref string) string) string {
+	return assetTagPattern.ReplaceAllStringFunc(page, func(tag string) string {
+		attrs := map[string]string{}
+		for _, m := range attrPattern.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(m[1])] = m[2]
+		}
+		if _, ok := attrs["integrity"]; ok {
+			return tag
+		}
+
+		ref := attrs["src"]
+		if strings.HasPrefix(strings.ToLower(tag), "<link") {
+			if !strings.EqualFold(attrs["rel"], "stylesheet") {
+				return tag
+			}
+			ref = attrs["href"]
+		}
+
+		sum := integrity(ref)
+		if sum == "" {
+			return tag
+		}
+
+		end := len(tag) - 1
+		if strings.HasSuffix(tag, "/>") {
+			end--
+		}
+		return strings.TrimRight(tag[:end], " ") + ` integrity="` + sum + `"` + tag[end:]
+	})
+}
+
+func resolveAsset(ref, root, pageDir string) string {
+	if ref == "" || strings.HasPrefix(ref, "//") || strings.Contains(ref, "://") || strings.HasPrefix(ref, "data:") {
+		return ""
+	}
+	ref, _, _ = strings.Cut(ref, "?")
+	ref, _, _ = strings.Cut(ref, "#")
+	if strings.HasPrefix(ref, "/") {
+		if root == "" {
+			return ""
+		}
+		return filepath.Join(root, filepath.FromSlash(ref))
+	}
+	if pageDir == "" {
+		return ""
+	}
+	return filepath.Join(pageDir, filepath.FromSlash(ref))
+}
+
+type fsIntegrityKey struct {
+	fsys fs.FS
+	name string
+}
+
+func fsIntegrity(fsys fs.FS, name string) string {
+	info, err := fs.Stat(fsys, name)
+	if err != nil || info.IsDir() {
+		return ""
+	}
+	// Map-based file systems such as fstest.MapFS can't be cache keys.
+	cacheable := reflect.TypeOf(fsys).Comparable()
+	key := fsIntegrityKey{fsys, name}
+	if cacheable {
+		if cached, ok := integrityCache.Load(key); ok {
+			e := cached.(integrityEntry)
+			if e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+				return e.value
+			}
+		}
+	}
+
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return ""
+	}
+	value := integrityValue(data)
+	if cacheable {
+		integrityCache.Store(key, integrityEntry{modTime: info.ModTime(), size: info.Size(), value: value})
+	}
+	return value
+}
+
+func integrityValue(data []byte) string {
+	sum := sha512.Sum384(data)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func fileIntegrity(file string) string {
+	info, err := os.Stat(file)
+	if err != nil || info.IsDir() {
+		return ""
+	}
+	if cached, ok := integrityCache.Load(file); ok {
+		e := cached.(integrityEntry)
+		if e.modTime.Equal(info.ModTime()) && e.size == info.Size() {
+			return e.value
+		}
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	value := integrityValue(data)
+	integrityCache.Store(file, integrityEntry{modTime: info.ModTime(), size: info.Size(), value: value})
+	return value
+}
+
+// CSPNonceEnabled reports whether cfg asks for per-request CSP nonces.
+func CSPNonceEnabled(cfg *config.Config) bool {
+	h := cfg.Security.Headers
+	return h.Enabled && h.CSPNonce && h.ContentSecurityPolicy != ""
+}
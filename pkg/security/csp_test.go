@@ -0,0 +1,155 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/middleware"
+)
+
+func TestAddCSPSources(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		want   string
+	}{
+		{"existing directive", "default-src 'self'; script-src 'self'", "default-src 'self'; script-src 'self' 'nonce-x'"},
+		{"from default-src", "default-src 'self' https://cdn.example.com", "default-src 'self' https://cdn.example.com; script-src 'self' https://cdn.example.com 'nonce-x'"},
+		{"default-src none", "default-src 'none'", "default-src 'none'; script-src 'self' 'nonce-x'"},
+		{"empty policy", "", "script-src 'self' 'nonce-x'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := AddCSPSources(tt.policy, "script-src", "'nonce-x'"); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeadersMiddleware_Nonce(t *testing.T) {
+	cfg := config.DefaultConfig().Security.Headers
+	cfg.Enabled = true
+	cfg.ContentSecurityPolicy = "default-src 'self'"
+	cfg.CSPNonce = true
+	m := NewHeadersMiddleware(cfg)
+
+	page := `<script src="/app.js"` + CSPNonceAttr + `></script>`
+	var body string
+	w := httptest.NewRecorder()
+	m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = InjectCSPNonce(page, r)
+	})).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	policy := w.Header().Get("Content-Security-Policy")
+	start := strings.Index(body, `nonce="`)
+	if start < 0 {
+		t.Fatalf("expected nonce attribute, got %s", body)
+	}
+	nonce := body[start+len(`nonce="`):]
+	nonce = nonce[:strings.Index(nonce, `"`)]
+
+	for _, want := range []string{"script-src 'self' 'nonce-" + nonce + "'", "style-src 'self' 'nonce-" + nonce + "'"} {
+		if !strings.Contains(policy, want) {
+			t.Errorf("policy %q missing %q", policy, want)
+		}
+	}
+
+	w2 := httptest.NewRecorder()
+	ctx := middleware.NewContext(w2, httptest.NewRequest("GET", "/", nil))
+	m.Middleware(ctx, func() error { return nil })
+	if CSPNonce(ctx.Request) == nonce {
+		t.Error("expected a fresh nonce per request")
+	}
+}
+
+func TestInjectCSPNonce_StripsWithoutNonce(t *testing.T) {
+	page := `<link rel="stylesheet" href="/a.css"` + CSPNonceAttr + `>`
+	if got := InjectCSPNonce(page, httptest.NewRequest("GET", "/", nil)); got != `<link rel="stylesheet" href="/a.css">` {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestInjectStaticCSP(t *testing.T) {
+	page := "<html><head><style>body{}</style></head><body><script>run()</script><script src=\"/a.js\"" + CSPNonceAttr + "></script></body></html>"
+	got := InjectStaticCSP(page, "default-src 'self'")
+
+	if strings.Contains(got, CSPNoncePlaceholder) {
+		t.Error("expected nonce placeholders to be removed")
+	}
+	if !strings.HasPrefix(got, "<html><head>\n<meta http-equiv=\"Content-Security-Policy\"") {
+		t.Errorf("expected CSP meta tag at the start of head, got %s", got)
+	}
+	for _, want := range []string{
+		"script-src &#39;self&#39; &#39;" + strings.Trim(cspHash("run()"), "'") + "&#39;",
+		"style-src &#39;self&#39; &#39;" + strings.Trim(cspHash("body{}"), "'") + "&#39;",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("meta tag missing %q in %s", want, got)
+		}
+	}
+}
+
+func TestExtractStaticCSP(t *testing.T) {
+	for _, page := range []string{
+		"<html><head><script>run()</script></head></html>",
+		"<p>fragment</p><script>run()</script>",
+	} {
+		injected := InjectStaticCSP(page, "default-src 'self'")
+		got, policy, ok := ExtractStaticCSP(injected)
+		if !ok || got != page {
+			t.Errorf("ExtractStaticCSP() = %q, %v, want the page without its meta tag", got, ok)
+		}
+		if want := "default-src 'self'; script-src 'self' " + cspHash("run()"); policy != want {
+			t.Errorf("policy = %q, want %q", policy, want)
+		}
+	}
+
+	if _, _, ok := ExtractStaticCSP("<html><head></head></html>"); ok {
+		t.Error("pages without a policy should report none")
+	}
+}
+
+func TestAddIntegrity(t *testing.T) {
+	root := t.TempDir()
+	os.MkdirAll(filepath.Join(root, "_assets"), 0755)
+	os.WriteFile(filepath.Join(root, "_assets", "app.js"), []byte("console.log(1)"), 0644)
+	os.WriteFile(filepath.Join(root, "_assets", "app.css"), []byte("body{}"), 0644)
+
+	page := `<link rel="stylesheet" href="/_assets/app.css">` +
+		`<script src="_assets/app.js"></script>` +
+		`<script src="https://cdn.example.com/x.js"></script>` +
+		`<script src="/missing.js"></script>` +
+		`<link rel="icon" href="/_assets/app.css">`
+	got := AddIntegrity(page, root, root)
+
+	if strings.Count(got, `integrity="sha384-`) != 2 {
+		t.Fatalf("expected integrity on the two local assets, got %s", got)
+	}
+	if !strings.Contains(got, `<script src="_assets/app.js" integrity="sha384-`) {
+		t.Errorf("expected relative script to be hashed, got %s", got)
+	}
+	if !strings.Contains(got, `<link rel="icon" href="/_assets/app.css">`) {
+		t.Error("non-stylesheet links should be left alone")
+	}
+	if AddIntegrity(got, root, root) != got {
+		t.Error("existing integrity attributes should be kept")
+	}
+}
+
+func TestAddIntegrityFS(t *testing.T) {
+	fsys := fstest.MapFS{"_assets/app.js": {Data: []byte("console.log(1)")}}
+	page := `<script src="/_assets/app.js?v=1"></script><script src="_assets/app.js"></script><script src="//cdn.example.com/app.js"></script>`
+
+	got := AddIntegrityFS(page, fsys)
+	if strings.Count(got, `integrity="sha384-`) != 1 || !strings.HasPrefix(got, `<script src="/_assets/app.js?v=1" integrity="sha384-`) {
+		t.Errorf("expected only the absolute local script to be hashed, got %s", got)
+	}
+}
@@ -5,45 +5,111 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/withgalaxy/galaxy/pkg/config"
 	"github.com/withgalaxy/galaxy/pkg/middleware"
 )
 
 type CSRFMiddleware struct {
 	config         *CSRFConfig
 	allowedOrigins map[string]bool
+	secret         []byte
+}
+
+// NewCSRFConfig maps galaxy.config.toml security settings onto CSRFConfig.
+func NewCSRFConfig(cfg *config.Config) *CSRFConfig {
+	return &CSRFConfig{
+		CheckOrigin:  cfg.Security.CheckOrigin,
+		AllowOrigins: cfg.Security.AllowOrigins,
+		SiteURL:      cfg.Site,
+		Token:        cfg.Security.CSRF.Enabled,
+		TokenMode:    string(cfg.Security.CSRF.Mode),
+		Secret:       cfg.Security.CSRF.Secret,
+		Exempt:       cfg.Security.CSRF.Exempt,
+		Secure:       cfg.Security.CSRF.Secure,
+	}
+}
+
+// CSRFEnabled reports whether cfg requires the CSRF middleware.
+func CSRFEnabled(cfg *config.Config) bool {
+	return cfg.IsSSR() && (cfg.Security.CheckOrigin || cfg.Security.CSRF.Enabled)
 }
 
 func NewCSRFMiddleware(cfg *CSRFConfig) *CSRFMiddleware {
-	return &CSRFMiddleware{
+	m := &CSRFMiddleware{
 		config:         cfg,
 		allowedOrigins: GetAllowedOrigins(cfg.SiteURL, cfg.AllowOrigins),
 	}
+	if cfg.Token && cfg.TokenMode != csrfModeSynchronizer {
+		m.secret = csrfSecret(cfg.Secret)
+	}
+	return m
 }
 
 func (m *CSRFMiddleware) Middleware(ctx *middleware.Context, next func() error) error {
-	if !m.config.CheckOrigin {
+	if m.config.Token {
+		ctx.Request = withCSRFState(ctx.Request, &csrfState{m: m, w: ctx.Response})
+	}
+
+	if !m.config.CheckOrigin && !m.config.Token {
 		return next()
 	}
 
-	if !shouldCheckCSRF(ctx.Request) {
+	if m.isExempt(ctx.Request.URL.Path) {
 		return next()
 	}
 
-	origin := GetOriginFromRequest(ctx.Request)
+	if !m.config.Token {
+		if !shouldCheckCSRF(ctx.Request) {
+			return next()
+		}
+
+		origin := GetOriginFromRequest(ctx.Request)
+
+		if origin == "" {
+			http.Error(ctx.Response, "Forbidden: Origin header required", http.StatusForbidden)
+			return fmt.Errorf("origin header missing")
+		}
 
-	if origin == "" {
-		http.Error(ctx.Response, "Forbidden: Origin header required", http.StatusForbidden)
-		return fmt.Errorf("origin header missing")
+		if !m.isOriginAllowed(origin) {
+			http.Error(ctx.Response, "Forbidden: Origin not allowed", http.StatusForbidden)
+			return fmt.Errorf("origin not allowed: %s", origin)
+		}
+
+		return next()
+	}
+
+	// With tokens every unsafe request is checked regardless of content
+	// type; a missing Origin is tolerated because the token proves intent.
+	if isSafeMethod(ctx.Request.Method) {
+		return next()
 	}
 
-	if !m.isOriginAllowed(origin) {
-		http.Error(ctx.Response, "Forbidden: Origin not allowed", http.StatusForbidden)
-		return fmt.Errorf("origin not allowed: %s", origin)
+	if m.config.CheckOrigin {
+		if origin := GetOriginFromRequest(ctx.Request); origin != "" && !m.isOriginAllowed(origin) {
+			http.Error(ctx.Response, "Forbidden: Origin not allowed", http.StatusForbidden)
+			return fmt.Errorf("origin not allowed: %s", origin)
+		}
+	}
+
+	if !m.validToken(ctx.Request) {
+		http.Error(ctx.Response, "Forbidden: invalid CSRF token", http.StatusForbidden)
+		return fmt.Errorf("invalid csrf token")
 	}
 
 	return next()
 }
 
+// Handler applies the middleware to a net/http handler.
+func (m *CSRFMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := middleware.NewContext(w, r)
+		m.Middleware(ctx, func() error {
+			next.ServeHTTP(ctx.Response, ctx.Request)
+			return nil
+		})
+	})
+}
+
 func (m *CSRFMiddleware) isOriginAllowed(origin string) bool {
 	if IsLocalhost(origin) {
 		return true
@@ -0,0 +1,232 @@
+package security
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"html"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/withgalaxy/galaxy/pkg/session"
+)
+
+const (
+	CSRFFieldName  = "_csrf"
+	CSRFHeaderName = "X-CSRF-Token"
+	CSRFCookieName = "galaxy_csrf"
+
+	// CSRFPlaceholder is emitted by <CSRFInput/> at compile time and replaced
+	// with the request's token by InjectCSRFToken before the page is written.
+	CSRFPlaceholder = "__GALAXY_CSRF_TOKEN__"
+
+	// CSRFSecretEnv is consulted when [security.csrf].secret is empty.
+	CSRFSecretEnv = "GALAXY_CSRF_SECRET"
+
+	csrfModeSynchronizer = "synchronizer"
+	csrfSessionKey       = "_csrf"
+)
+
+type csrfContextKey struct{}
+
+// csrfState lazily issues the token for one request so pages that never
+// render a form don't create sessions or cookies.
+type csrfState struct {
+	mu    sync.Mutex
+	m     *CSRFMiddleware
+	w     http.ResponseWriter
+	r     *http.Request
+	token string
+}
+
+func (s *csrfState) Token() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" {
+		return s.token
+	}
+
+	if s.m.config.TokenMode == csrfModeSynchronizer {
+		sess := session.FromRequest(s.r)
+		if sess == nil {
+			return ""
+		}
+		token := sess.GetString(csrfSessionKey)
+		if token == "" {
+			token = newCSRFToken()
+			sess.Set(csrfSessionKey, token)
+		}
+		s.token = token
+		return token
+	}
+
+	if token := s.m.cookieToken(s.r); token != "" {
+		s.token = token
+		return token
+	}
+
+	s.token = newCSRFToken()
+	http.SetCookie(s.w, &http.Cookie{
+		Name:     CSRFCookieName,
+		Value:    s.token + "." + s.m.sign(s.token),
+		Path:     "/",
+		Secure:   s.m.config.Secure,
+		HttpOnly: false,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return s.token
+}
+
+// CSRFToken returns the CSRF token for r, issuing one if needed. It returns
+// "" when token protection is disabled.
+func CSRFToken(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	state, ok := r.Context().Value(csrfContextKey{}).(*csrfState)
+	if !ok {
+		return ""
+	}
+	return state.Token()
+}
+
+// IssuedCSRFToken returns the token CSRFToken already returned for r, without
+// issuing one.
+func IssuedCSRFToken(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	state, ok := r.Context().Value(csrfContextKey{}).(*csrfState)
+	if !ok {
+		return ""
+	}
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	return state.token
+}
+
+// CSRFInput returns the hidden form field rendered by <CSRFInput/>.
+func CSRFInput(token string) string {
+	return `<input type="hidden" name="` + CSRFFieldName + `" value="` + html.EscapeString(token) + `">`
+}
+
+// InjectCSRFToken replaces CSRFPlaceholder in rendered HTML with the token
+// for r.
+func InjectCSRFToken(page string, r *http.Request) string {
+	if !strings.Contains(page, CSRFPlaceholder) {
+		return page
+	}
+	return strings.ReplaceAll(page, CSRFPlaceholder, html.EscapeString(CSRFToken(r)))
+}
+
+func newCSRFToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic("security: generate csrf token: " + err.Error())
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func csrfSecret(configured string) []byte {
+	secret := configured
+	if secret == "" {
+		secret = os.Getenv(CSRFSecretEnv)
+	}
+	if secret == "" {
+		secret = os.Getenv(session.SecretEnv)
+	}
+	if secret == "" {
+		log.Printf("⚠️  csrf: no secret configured (set [security.csrf].secret or %s); tokens will not survive restarts", CSRFSecretEnv)
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			panic("security: generate csrf secret: " + err.Error())
+		}
+		secret = hex.EncodeToString(b)
+	}
+	sum := sha256.Sum256([]byte("galaxy-csrf:" + secret))
+	return sum[:]
+}
+
+func (m *CSRFMiddleware) sign(token string) string {
+	h := hmac.New(sha256.New, m.secret)
+	h.Write([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// cookieToken returns the token from a valid double-submit cookie.
+func (m *CSRFMiddleware) cookieToken(r *http.Request) string {
+	cookie, err := r.Cookie(CSRFCookieName)
+	if err != nil {
+		return ""
+	}
+	token, mac, ok := strings.Cut(cookie.Value, ".")
+	if !ok || token == "" {
+		return ""
+	}
+	if !hmac.Equal([]byte(mac), []byte(m.sign(token))) {
+		return ""
+	}
+	return token
+}
+
+func (m *CSRFMiddleware) expectedToken(r *http.Request) string {
+	if m.config.TokenMode == csrfModeSynchronizer {
+		return session.FromRequest(r).GetString(csrfSessionKey)
+	}
+	return m.cookieToken(r)
+}
+
+func submittedToken(r *http.Request) string {
+	if token := r.Header.Get(CSRFHeaderName); token != "" {
+		return token
+	}
+
+	ct := strings.TrimSpace(strings.Split(r.Header.Get("Content-Type"), ";")[0])
+	if ct == "application/x-www-form-urlencoded" || ct == "multipart/form-data" {
+		return r.PostFormValue(CSRFFieldName)
+	}
+	return ""
+}
+
+func (m *CSRFMiddleware) validToken(r *http.Request) bool {
+	expected := m.expectedToken(r)
+	if expected == "" {
+		return false
+	}
+	submitted := submittedToken(r)
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(submitted)) == 1
+}
+
+func (m *CSRFMiddleware) isExempt(p string) bool {
+	for _, pattern := range m.config.Exempt {
+		if matchPath(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath matches p against an exact path, a path.Match glob, or a
+// "/prefix/*" pattern covering everything below prefix.
+func matchPath(pattern, p string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return p == prefix || strings.HasPrefix(p, prefix+"/")
+	}
+	matched, _ := path.Match(pattern, p)
+	return matched
+}
+
+func withCSRFState(r *http.Request, state *csrfState) *http.Request {
+	r = r.WithContext(context.WithValue(r.Context(), csrfContextKey{}, state))
+	state.r = r
+	return r
+}
@@ -0,0 +1,226 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/middleware"
+	"github.com/withgalaxy/galaxy/pkg/session"
+)
+
+func newTokenMiddleware(mode string) *CSRFMiddleware {
+	return NewCSRFMiddleware(&CSRFConfig{
+		CheckOrigin: true,
+		SiteURL:     "https://example.com",
+		Token:       true,
+		TokenMode:   mode,
+		Secret:      "test-secret",
+		Exempt:      []string{"/webhooks/*", "/health"},
+	})
+}
+
+// issueToken renders a page through m and returns the token together with
+// the cookies the browser would store.
+func issueToken(t *testing.T, m *CSRFMiddleware, wrap func(http.Handler) http.Handler, cookies []*http.Cookie) (string, []*http.Cookie) {
+	t.Helper()
+	var token string
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = CSRFToken(r)
+		w.Write([]byte(InjectCSRFToken(CSRFInput(CSRFPlaceholder), r)))
+	}))
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+
+	req := httptest.NewRequest("GET", "/form", nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if !strings.Contains(w.Body.String(), `value="`+token+`"`) {
+		t.Fatalf("expected token in rendered input, got %s", w.Body.String())
+	}
+	return token, append(cookies, w.Result().Cookies()...)
+}
+
+func submit(m *CSRFMiddleware, wrap func(http.Handler) http.Handler, req *http.Request, cookies []*http.Cookie) int {
+	var handler http.Handler = m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	if wrap != nil {
+		handler = wrap(handler)
+	}
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w.Code
+}
+
+func formRequest(path, token string) *http.Request {
+	form := url.Values{}
+	if token != "" {
+		form.Set(CSRFFieldName, token)
+	}
+	req := httptest.NewRequest("POST", path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req
+}
+
+func TestCSRFToken_DoubleSubmit(t *testing.T) {
+	m := newTokenMiddleware("double-submit")
+	token, cookies := issueToken(t, m, nil, nil)
+
+	tests := []struct {
+		name    string
+		req     func() *http.Request
+		cookies []*http.Cookie
+		want    int
+	}{
+		{
+			name:    "form field without origin",
+			req:     func() *http.Request { return formRequest("/form", token) },
+			cookies: cookies,
+			want:    http.StatusNoContent,
+		},
+		{
+			name: "json with header",
+			req: func() *http.Request {
+				req := httptest.NewRequest("POST", "/api/items", strings.NewReader(`{}`))
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set(CSRFHeaderName, token)
+				return req
+			},
+			cookies: cookies,
+			want:    http.StatusNoContent,
+		},
+		{
+			name: "json without header",
+			req: func() *http.Request {
+				req := httptest.NewRequest("POST", "/api/items", strings.NewReader(`{}`))
+				req.Header.Set("Content-Type", "application/json")
+				return req
+			},
+			cookies: cookies,
+			want:    http.StatusForbidden,
+		},
+		{
+			name:    "missing cookie",
+			req:     func() *http.Request { return formRequest("/form", token) },
+			cookies: nil,
+			want:    http.StatusForbidden,
+		},
+		{
+			name:    "wrong token",
+			req:     func() *http.Request { return formRequest("/form", "forged") },
+			cookies: cookies,
+			want:    http.StatusForbidden,
+		},
+		{
+			name: "forged cookie",
+			req:  func() *http.Request { return formRequest("/form", "forged") },
+			cookies: []*http.Cookie{
+				{Name: CSRFCookieName, Value: "forged.bad-signature"},
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name: "disallowed origin with valid token",
+			req: func() *http.Request {
+				req := formRequest("/form", token)
+				req.Header.Set("Origin", "https://evil.com")
+				return req
+			},
+			cookies: cookies,
+			want:    http.StatusForbidden,
+		},
+		{
+			name:    "exempt prefix",
+			req:     func() *http.Request { return formRequest("/webhooks/stripe", "") },
+			cookies: nil,
+			want:    http.StatusNoContent,
+		},
+		{
+			name:    "exempt exact path",
+			req:     func() *http.Request { return formRequest("/health", "") },
+			cookies: nil,
+			want:    http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := submit(m, nil, tt.req(), tt.cookies); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSRFToken_ReusesCookieToken(t *testing.T) {
+	m := newTokenMiddleware("double-submit")
+	first, cookies := issueToken(t, m, nil, nil)
+	second, _ := issueToken(t, m, nil, cookies)
+
+	if first != second {
+		t.Error("expected token from existing cookie to be reused")
+	}
+}
+
+func TestCSRFToken_Synchronizer(t *testing.T) {
+	cfg := config.DefaultConfig().Session
+	cfg.Secret = "session-secret"
+	manager, err := session.NewManagerWithStore(cfg, session.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := newTokenMiddleware("synchronizer")
+	token, cookies := issueToken(t, m, manager.Handler, nil)
+
+	if len(cookies) != 1 || cookies[0].Name != cfg.CookieName {
+		t.Fatalf("expected only the session cookie, got %v", cookies)
+	}
+
+	if got := submit(m, manager.Handler, formRequest("/form", token), cookies); got != http.StatusNoContent {
+		t.Errorf("valid token: status = %d", got)
+	}
+	if got := submit(m, manager.Handler, formRequest("/form", token), nil); got != http.StatusForbidden {
+		t.Errorf("token without session: status = %d", got)
+	}
+}
+
+func TestCSRFToken_NotIssuedUntilUsed(t *testing.T) {
+	m := newTokenMiddleware("double-submit")
+	handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<p>no form</p>"))
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("expected no CSRF cookie for pages without forms")
+	}
+}
+
+func TestCSRFToken_OriginOnlyModeUnchanged(t *testing.T) {
+	m := NewCSRFMiddleware(&CSRFConfig{CheckOrigin: true, SiteURL: "https://example.com"})
+
+	req := httptest.NewRequest("POST", "/", nil)
+	ctx := middleware.NewContext(httptest.NewRecorder(), req)
+	if err := m.Middleware(ctx, func() error { return nil }); err == nil {
+		t.Error("expected missing Origin to be rejected without token mode")
+	}
+
+	if CSRFToken(ctx.Request) != "" {
+		t.Error("expected no token when token mode is disabled")
+	}
+}
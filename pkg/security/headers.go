@@ -1,6 +1,8 @@
 package security
 
 import (
+	"net/http"
+
 	"github.com/withgalaxy/galaxy/pkg/config"
 	"github.com/withgalaxy/galaxy/pkg/middleware"
 )
@@ -34,7 +36,13 @@ func (m *HeadersMiddleware) Middleware(ctx *middleware.Context, next func() erro
 		ctx.Response.Header().Set("Strict-Transport-Security", m.config.StrictTransportSecurity)
 	}
 	if m.config.ContentSecurityPolicy != "" {
-		ctx.Response.Header().Set("Content-Security-Policy", m.config.ContentSecurityPolicy)
+		policy := m.config.ContentSecurityPolicy
+		if m.config.CSPNonce {
+			var nonce string
+			ctx.Request, nonce = WithCSPNonce(ctx.Request)
+			policy = NonceCSP(policy, nonce)
+		}
+		ctx.Response.Header().Set("Content-Security-Policy", policy)
 	}
 	if m.config.PermissionsPolicy != "" {
 		ctx.Response.Header().Set("Permissions-Policy", m.config.PermissionsPolicy)
@@ -42,3 +50,13 @@ func (m *HeadersMiddleware) Middleware(ctx *middleware.Context, next func() erro
 
 	return next()
 }
+
+func (m *HeadersMiddleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := middleware.NewContext(w, r)
+		if err := m.Middleware(ctx, func() error { return nil }); err != nil {
+			return
+		}
+		next.ServeHTTP(w, ctx.Request)
+	})
+}
@@ -0,0 +1,412 @@
+package security
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/middleware"
+	"github.com/withgalaxy/galaxy/pkg/session"
+)
+
+// RateLimitState is the per-key bookkeeping shared by both algorithms.
+type RateLimitState struct {
+	// Token bucket.
+	Tokens float64
+	Last   time.Time
+
+	// Sliding window.
+	WindowStart time.Time
+	Count       int
+	PrevCount   int
+}
+
+// RateLimitStore persists RateLimitState. Update must apply fn atomically
+// for key; ttl is how long an idle entry needs to be kept.
+type RateLimitStore interface {
+	Update(key string, ttl time.Duration, fn func(state *RateLimitState)) error
+}
+
+// MemoryRateLimitStore keeps state in process memory.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryRateLimitEntry
+	sweep   time.Time
+}
+
+type memoryRateLimitEntry struct {
+	state   RateLimitState
+	expires time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{entries: make(map[string]*memoryRateLimitEntry)}
+}
+
+func (s *MemoryRateLimitStore) Update(key string, ttl time.Duration, fn func(state *RateLimitState)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.After(s.sweep) {
+		for k, e := range s.entries {
+			if now.After(e.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.sweep = now.Add(time.Minute)
+	}
+
+	e, ok := s.entries[key]
+	if !ok || now.After(e.expires) {
+		e = &memoryRateLimitEntry{}
+		s.entries[key] = e
+	}
+	fn(&e.state)
+	e.expires = now.Add(ttl)
+	return nil
+}
+
+// Len returns the number of tracked keys.
+func (s *MemoryRateLimitStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+type rateLimitResult struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+	window     int
+}
+
+// RateLimiter enforces [security.rateLimit] rules. It runs after the user
+// middleware chain so rules keyed on Locals see values set there.
+type RateLimiter struct {
+	rules   []config.RateLimitRule
+	store   RateLimitStore
+	proxies []*net.IPNet
+
+	// Page is served to browsers with 429 responses when set.
+	Page string
+
+	now func() time.Time
+}
+
+// NewRateLimiter builds a limiter for cfg. A nil store uses memory.
+func NewRateLimiter(cfg config.RateLimitConfig, store RateLimitStore) (*RateLimiter, error) {
+	proxies, err := parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+	return &RateLimiter{
+		rules:   cfg.Rules,
+		store:   store,
+		proxies: proxies,
+		now:     time.Now,
+	}, nil
+}
+
+// NewRateLimiterFromConfig returns the limiter configured in cfg, loading
+// the custom 429 page relative to rootDir, or nil when rate limiting is off.
+func NewRateLimiterFromConfig(cfg *config.Config, rootDir string) (*RateLimiter, error) {
+	rl := cfg.Security.RateLimit
+	if !rl.Enabled || len(rl.Rules) == 0 {
+		return nil, nil
+	}
+	limiter, err := NewRateLimiter(rl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if rl.Page != "" {
+		page, err := os.ReadFile(filepath.Join(rootDir, rl.Page))
+		if err != nil {
+			return nil, fmt.Errorf("read rate limit page: %w", err)
+		}
+		limiter.Page = string(page)
+	}
+	return limiter, nil
+}
+
+func parseTrustedProxies(entries []string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func (l *RateLimiter) trusted(ip net.IP) bool {
+	for _, n := range l.proxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the client address for r. X-Forwarded-For is only
+// honoured when the connecting peer is a trusted proxy, and is walked from
+// the right so clients cannot spoof entries added by the proxies.
+func (l *RateLimiter) ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	peer := net.ParseIP(host)
+	if peer == nil || !l.trusted(peer) {
+		return host
+	}
+
+	var hops []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		for _, hop := range strings.Split(h, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(hops[i])
+		if ip == nil {
+			break
+		}
+		if !l.trusted(ip) || i == 0 {
+			return ip.String()
+		}
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return host
+}
+
+func (l *RateLimiter) key(rule config.RateLimitRule, r *http.Request, locals map[string]any) string {
+	switch {
+	case rule.Key == "session":
+		sess := session.FromRequest(r)
+		if sess == nil {
+			sess = session.FromLocals(locals)
+		}
+		if sess != nil && !sess.IsNew() && sess.ID() != "" {
+			return "session:" + sess.ID()
+		}
+	case strings.HasPrefix(rule.Key, "locals:"):
+		name := strings.TrimPrefix(rule.Key, "locals:")
+		if v, ok := locals[name]; ok && v != nil {
+			if s := fmt.Sprint(v); s != "" {
+				return rule.Key + ":" + s
+			}
+		}
+	}
+	return "ip:" + l.ClientIP(r)
+}
+
+func ruleMatches(rule config.RateLimitRule, r *http.Request) bool {
+	if !matchPath(rule.Path, r.URL.Path) {
+		return false
+	}
+	if len(rule.Methods) == 0 {
+		return true
+	}
+	for _, m := range rule.Methods {
+		if strings.EqualFold(m, r.Method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Allow counts r against every matching rule. When a limit is exceeded it
+// writes the 429 response and returns false.
+func (l *RateLimiter) Allow(w http.ResponseWriter, r *http.Request, locals map[string]any) bool {
+	var current *rateLimitResult
+	for i, rule := range l.rules {
+		if !ruleMatches(rule, r) {
+			continue
+		}
+		res, err := l.take(fmt.Sprintf("%d|%s", i, l.key(rule, r, locals)), rule)
+		if err != nil {
+			continue
+		}
+		if current == nil || !res.allowed || (current.allowed && res.remaining < current.remaining) {
+			current = &res
+		}
+		if !res.allowed {
+			break
+		}
+	}
+	if current == nil {
+		return true
+	}
+
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(current.limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(current.remaining))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(current.reset)))
+	h.Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", current.limit, current.window))
+	if current.allowed {
+		return true
+	}
+
+	h.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(current.retryAfter))))
+	l.reject(w, r)
+	return false
+}
+
+func (l *RateLimiter) reject(w http.ResponseWriter, r *http.Request) {
+	if l.Page != "" && strings.Contains(r.Header.Get("Accept"), "text/html") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(l.Page))
+		return
+	}
+	http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+}
+
+func (l *RateLimiter) Middleware(ctx *middleware.Context, next func() error) error {
+	if !l.Allow(ctx.Response, ctx.Request, ctx.Locals) {
+		return fmt.Errorf("rate limit exceeded")
+	}
+	return next()
+}
+
+// Handler wraps a net/http handler. Locals-keyed rules fall back to the
+// client IP since no middleware chain has run.
+func (l *RateLimiter) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l.Allow(w, r, nil) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+func (l *RateLimiter) take(key string, rule config.RateLimitRule) (rateLimitResult, error) {
+	window := time.Duration(rule.Window) * time.Second
+	now := l.now()
+	var res rateLimitResult
+	var err error
+	if rule.Algorithm == config.RateLimitSlidingWindow {
+		err = l.store.Update(key, 2*window, func(s *RateLimitState) {
+			res = slidingWindow(s, rule.Limit, window, now)
+		})
+	} else {
+		err = l.store.Update(key, refillTime(rule.Limit, rule.Burst, window), func(s *RateLimitState) {
+			res = tokenBucket(s, rule.Limit, rule.Burst, window, now)
+		})
+	}
+	res.window = rule.Window
+	return res, err
+}
+
+// tokenBucket refills limit tokens per window up to burst (default limit).
+func tokenBucket(s *RateLimitState, limit, burst int, window time.Duration, now time.Time) rateLimitResult {
+	capacity := float64(limit)
+	if burst > 0 {
+		capacity = float64(burst)
+	}
+	rate := float64(limit) / window.Seconds()
+
+	if s.Last.IsZero() {
+		s.Tokens = capacity
+	} else if elapsed := now.Sub(s.Last).Seconds(); elapsed > 0 {
+		s.Tokens = math.Min(capacity, s.Tokens+elapsed*rate)
+	}
+	s.Last = now
+
+	res := rateLimitResult{limit: int(capacity)}
+	if s.Tokens >= 1 {
+		s.Tokens--
+		res.allowed = true
+	} else {
+		res.retryAfter = seconds((1 - s.Tokens) / rate)
+	}
+	res.remaining = int(s.Tokens)
+	res.reset = seconds((capacity - s.Tokens) / rate)
+	return res
+}
+
+// refillTime is how long an empty bucket takes to fill up again, after
+// which its entry holds nothing a fresh one wouldn't.
+func refillTime(limit, burst int, window time.Duration) time.Duration {
+	if burst <= 0 {
+		return window
+	}
+	return window * time.Duration(burst) / time.Duration(limit)
+}
+
+// slidingWindow approximates a rolling window by weighting the previous
+// fixed window's count by how much of it still overlaps.
+func slidingWindow(s *RateLimitState, limit int, window time.Duration, now time.Time) rateLimitResult {
+	start := now.Truncate(window)
+	if !s.WindowStart.Equal(start) {
+		if s.WindowStart.Equal(start.Add(-window)) {
+			s.PrevCount = s.Count
+		} else {
+			s.PrevCount = 0
+		}
+		s.Count = 0
+		s.WindowStart = start
+	}
+
+	elapsed := now.Sub(start)
+	weight := 1 - elapsed.Seconds()/window.Seconds()
+	used := float64(s.PrevCount)*weight + float64(s.Count)
+
+	res := rateLimitResult{limit: limit, reset: window - elapsed}
+	if used+1 <= float64(limit) {
+		s.Count++
+		used++
+		res.allowed = true
+	} else if s.Count+1 > limit || s.PrevCount == 0 {
+		res.retryAfter = window - elapsed
+	} else {
+		// Wait until enough of the previous window has slid out.
+		need := 1 - float64(limit-s.Count-1)/float64(s.PrevCount)
+		res.retryAfter = seconds(need*window.Seconds()) - elapsed
+	}
+	res.remaining = max(0, limit-int(math.Ceil(used)))
+	return res
+}
+
+func seconds(f float64) time.Duration {
+	return time.Duration(f * float64(time.Second))
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
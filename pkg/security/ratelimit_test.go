@@ -0,0 +1,218 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/middleware"
+)
+
+func newTestLimiter(t *testing.T, cfg config.RateLimitConfig) (*RateLimiter, *time.Time) {
+	t.Helper()
+	l, err := NewRateLimiter(cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Unix(1_700_000_000, 0)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func hit(l *RateLimiter, method, path string, locals map[string]any) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	if l.Allow(w, req, locals) {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w
+}
+
+func TestRateLimiter_TokenBucket(t *testing.T) {
+	l, now := newTestLimiter(t, config.RateLimitConfig{Rules: []config.RateLimitRule{
+		{Path: "/api/*", Limit: 2, Window: 10, Algorithm: config.RateLimitTokenBucket, Key: "ip"},
+	}})
+
+	for i := 0; i < 2; i++ {
+		if w := hit(l, "GET", "/api/items", nil); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, w.Code)
+		}
+	}
+
+	w := hit(l, "GET", "/api/items", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "5" {
+		t.Errorf("Retry-After = %q, want 5", got)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", got)
+	}
+
+	if w := hit(l, "GET", "/other", nil); w.Code != http.StatusOK {
+		t.Error("unmatched path should not be limited")
+	}
+
+	*now = now.Add(5 * time.Second)
+	if w := hit(l, "GET", "/api/items", nil); w.Code != http.StatusOK {
+		t.Errorf("expected refilled token after 5s, got %d", w.Code)
+	}
+}
+
+func TestRateLimiter_Burst(t *testing.T) {
+	l, _ := newTestLimiter(t, config.RateLimitConfig{Rules: []config.RateLimitRule{
+		{Path: "/*", Limit: 1, Window: 60, Burst: 3, Algorithm: config.RateLimitTokenBucket, Key: "ip"},
+	}})
+
+	for i := 0; i < 3; i++ {
+		if w := hit(l, "GET", "/", nil); w.Code != http.StatusOK {
+			t.Fatalf("burst request %d: status %d", i, w.Code)
+		}
+	}
+	if w := hit(l, "GET", "/", nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 after burst, got %d", w.Code)
+	}
+}
+
+// ttlStore records the TTLs the limiter asks for.
+type ttlStore struct {
+	*MemoryRateLimitStore
+	ttls []time.Duration
+}
+
+func (s *ttlStore) Update(key string, ttl time.Duration, fn func(state *RateLimitState)) error {
+	s.ttls = append(s.ttls, ttl)
+	return s.MemoryRateLimitStore.Update(key, ttl, fn)
+}
+
+func TestRateLimiter_TokenBucketTTL(t *testing.T) {
+	store := &ttlStore{MemoryRateLimitStore: NewMemoryRateLimitStore()}
+	l, err := NewRateLimiter(config.RateLimitConfig{Rules: []config.RateLimitRule{
+		{Path: "/burst", Limit: 2, Window: 60, Burst: 10, Algorithm: config.RateLimitTokenBucket, Key: "ip"},
+		{Path: "/plain", Limit: 2, Window: 60, Algorithm: config.RateLimitTokenBucket, Key: "ip"},
+	}}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hit(l, "GET", "/burst", nil)
+	hit(l, "GET", "/plain", nil)
+	// An emptied bucket of 10 refilling 2 a minute needs 5 minutes to be
+	// full again; forgetting it after one would hand out a fresh burst.
+	if len(store.ttls) != 2 || store.ttls[0] != 5*time.Minute || store.ttls[1] != time.Minute {
+		t.Errorf("TTLs = %v, want [5m0s 1m0s]", store.ttls)
+	}
+}
+
+func TestRateLimiter_SlidingWindow(t *testing.T) {
+	l, now := newTestLimiter(t, config.RateLimitConfig{Rules: []config.RateLimitRule{
+		{Path: "/login", Methods: []string{"POST"}, Limit: 4, Window: 60, Algorithm: config.RateLimitSlidingWindow, Key: "ip"},
+	}})
+
+	for i := 0; i < 4; i++ {
+		if w := hit(l, "POST", "/login", nil); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i, w.Code)
+		}
+	}
+	if w := hit(l, "POST", "/login", nil); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", w.Code)
+	}
+	if w := hit(l, "GET", "/login", nil); w.Code != http.StatusOK {
+		t.Error("GET should not match a POST-only rule")
+	}
+
+	// Halfway into the next window half the previous count still applies.
+	*now = now.Truncate(time.Minute).Add(90 * time.Second)
+	allowed := 0
+	for i := 0; i < 4; i++ {
+		if hit(l, "POST", "/login", nil).Code == http.StatusOK {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("expected 2 requests allowed mid-window, got %d", allowed)
+	}
+}
+
+func TestRateLimiter_LocalsKey(t *testing.T) {
+	l, _ := newTestLimiter(t, config.RateLimitConfig{Rules: []config.RateLimitRule{
+		{Path: "/*", Limit: 1, Window: 60, Algorithm: config.RateLimitTokenBucket, Key: "locals:userID"},
+	}})
+
+	alice := map[string]any{"userID": "alice"}
+	bob := map[string]any{"userID": "bob"}
+
+	if hit(l, "GET", "/", alice).Code != http.StatusOK || hit(l, "GET", "/", bob).Code != http.StatusOK {
+		t.Fatal("first request per user should pass")
+	}
+	if hit(l, "GET", "/", alice).Code != http.StatusTooManyRequests {
+		t.Error("expected alice to be limited")
+	}
+	if hit(l, "GET", "/", nil).Code != http.StatusOK {
+		t.Error("missing locals key should fall back to the client IP")
+	}
+}
+
+func TestRateLimiter_ClientIP(t *testing.T) {
+	l, err := NewRateLimiter(config.RateLimitConfig{TrustedProxies: []string{"10.0.0.0/8", "192.168.1.1"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		remote string
+		xff    string
+		want   string
+	}{
+		{"direct", "203.0.113.9:1234", "", "203.0.113.9"},
+		{"untrusted peer ignores header", "203.0.113.9:1234", "1.2.3.4", "203.0.113.9"},
+		{"trusted proxy", "10.1.2.3:80", "198.51.100.7", "198.51.100.7"},
+		{"spoofed leftmost entry", "10.1.2.3:80", "1.1.1.1, 198.51.100.7, 10.0.0.5", "198.51.100.7"},
+		{"single trusted ip", "192.168.1.1:80", "198.51.100.7", "198.51.100.7"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remote
+			if tt.xff != "" {
+				req.Header.Set("X-Forwarded-For", tt.xff)
+			}
+			if got := l.ClientIP(req); got != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewRateLimiter(config.RateLimitConfig{TrustedProxies: []string{"not-an-ip"}}, nil); err == nil {
+		t.Error("expected invalid trusted proxy to be rejected")
+	}
+}
+
+func TestRateLimiter_CustomPage(t *testing.T) {
+	l, _ := newTestLimiter(t, config.RateLimitConfig{Rules: []config.RateLimitRule{
+		{Path: "/*", Limit: 1, Window: 60, Algorithm: config.RateLimitTokenBucket, Key: "ip"},
+	}})
+	l.Page = "<h1>Slow down</h1>"
+
+	ctx := middleware.NewContext(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if err := l.Middleware(ctx, func() error { return nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	ctx = middleware.NewContext(w, req)
+	if err := l.Middleware(ctx, func() error { return nil }); err == nil {
+		t.Fatal("expected middleware to stop the request")
+	}
+	if w.Code != http.StatusTooManyRequests || !strings.Contains(w.Body.String(), "Slow down") {
+		t.Errorf("expected custom 429 page, got %d %q", w.Code, w.Body.String())
+	}
+}
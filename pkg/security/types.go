@@ -4,4 +4,12 @@ type CSRFConfig struct {
 	CheckOrigin  bool
 	AllowOrigins []string
 	SiteURL      string
+
+	// Token enables synchronizer or double-submit tokens in addition to the
+	// Origin check.
+	Token     bool
+	TokenMode string
+	Secret    string
+	Exempt    []string
+	Secure    bool
 }
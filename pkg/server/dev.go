@@ -19,6 +19,7 @@ import (
 	"github.com/withgalaxy/galaxy/pkg/assets"
 	"github.com/withgalaxy/galaxy/pkg/codegen"
 	"github.com/withgalaxy/galaxy/pkg/compiler"
+	"github.com/withgalaxy/galaxy/pkg/compress"
 	"github.com/withgalaxy/galaxy/pkg/config"
 	"github.com/withgalaxy/galaxy/pkg/endpoints"
 	"github.com/withgalaxy/galaxy/pkg/executor"
@@ -30,6 +31,7 @@ import (
 	"github.com/withgalaxy/galaxy/pkg/plugins/tailwind"
 	"github.com/withgalaxy/galaxy/pkg/router"
 	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/session"
 	"github.com/withgalaxy/galaxy/pkg/ssr"
 	"github.com/withgalaxy/galaxy/pkg/template"
 )
@@ -57,6 +59,10 @@ type DevServer struct {
 	BodyLimitMiddleware    *security.BodyLimitMiddleware
 	ForwardedHostValidator *security.ForwardedHostValidator
 	HeadersMiddleware      *security.HeadersMiddleware
+	SessionManager         *session.Manager
+	RateLimiter            *security.RateLimiter
+	CORSMiddleware         *security.CORSMiddleware
+	Config                 *config.Config
 	compileMu              sync.Mutex
 	codegenServerCmd       *exec.Cmd
 	HMRServer              *hmr.Server
@@ -105,6 +111,7 @@ func NewDevServer(cfg *config.Config, rootDir, pagesDir, publicDir string, port
 		EndpointCompiler:   endpoints.NewCompiler(rootDir, ".galaxy/endpoints"),
 		MiddlewareCompiler: middleware.NewCompiler(rootDir, ".galaxy/middleware"),
 		Verbose:            verbose,
+		Config:             cfg,
 
 		UseCodegen:         useCodegen,
 		PageCache:          NewPageCache(),
@@ -119,6 +126,7 @@ func NewDevServer(cfg *config.Config, rootDir, pagesDir, publicDir string, port
 	srv.ComponentTracker = hmr.NewComponentTracker()
 
 	srv.Bundler.DevMode = true
+	srv.Bundler.CSPNonce = security.CSPNonceEnabled(cfg)
 
 	if cfg.Security.BodyLimit.Enabled {
 		maxBytes := cfg.Security.BodyLimit.MaxBytes
@@ -132,18 +140,36 @@ func NewDevServer(cfg *config.Config, rootDir, pagesDir, publicDir string, port
 		srv.ForwardedHostValidator = security.NewForwardedHostValidator(cfg.Security.AllowedDomains)
 	}
 
-	if cfg.Security.CheckOrigin && cfg.IsSSR() {
-		srv.CSRFMiddleware = security.NewCSRFMiddleware(&security.CSRFConfig{
-			CheckOrigin:  cfg.Security.CheckOrigin,
-			AllowOrigins: cfg.Security.AllowOrigins,
-			SiteURL:      cfg.Site,
-		})
+	if security.CSRFEnabled(cfg) {
+		srv.CSRFMiddleware = security.NewCSRFMiddleware(security.NewCSRFConfig(cfg))
+	}
+
+	if security.CORSEnabled(cfg) {
+		srv.CORSMiddleware = security.NewCORSMiddleware(cfg.Security.CORS)
 	}
 
 	if cfg.Security.Headers.Enabled {
 		srv.HeadersMiddleware = security.NewHeadersMiddleware(cfg.Security.Headers)
 	}
 
+	if cfg.Session.Enabled && cfg.IsSSR() {
+		manager, err := session.NewManager(cfg.Session)
+		if err != nil {
+			log.Printf("⚠️  Sessions disabled: %v", err)
+		} else {
+			srv.SessionManager = manager
+		}
+	}
+
+	if cfg.IsSSR() {
+		limiter, err := security.NewRateLimiterFromConfig(cfg, rootDir)
+		if err != nil {
+			log.Printf("⚠️  Rate limiting disabled: %v", err)
+		} else {
+			srv.RateLimiter = limiter
+		}
+	}
+
 	return srv
 }
 
@@ -174,11 +200,15 @@ func (s *DevServer) Start() error {
 	s.HMRServer.Start()
 	http.HandleFunc("/__hmr", s.HMRServer.HandleWebSocket)
 	http.HandleFunc("/__hmr/client.js", s.serveHMRClient)
-	http.HandleFunc("/__hmr/morph.js", s.serveHMRMorph)
+	http.HandleFunc(hmr.MorphPath, s.serveHMRMorph)
 	http.HandleFunc("/__hmr/overlay.js", s.serveHMROverlay)
 	http.HandleFunc("/__hmr/render", s.handleHMRRender)
 
-	http.HandleFunc("/", s.logRequest(s.handleRequest))
+	var handler http.Handler = s.logRequest(s.handleRequest)
+	if s.Config.Compression.Enabled {
+		handler = compress.Handler(s.Config.Compression, handler)
+	}
+	http.Handle("/", handler)
 
 	addr := fmt.Sprintf(":%d", s.Port)
 	fmt.Printf("🚀 Dev server running at http://localhost%s\n", addr)
@@ -225,6 +255,14 @@ func (s *DevServer) ReloadMiddleware() error {
 	return nil
 }
 
+// readinessPath is the codegen server's readiness probe.
+func (s *DevServer) readinessPath() string {
+	if s.Config != nil && s.Config.Server.ReadinessPath != "" {
+		return s.Config.Server.ReadinessPath
+	}
+	return config.DefaultConfig().Server.ReadinessPath
+}
+
 func (s *DevServer) printRoutes() {
 	for _, route := range s.Router.Routes {
 		fmt.Printf("  %s\n", route.Pattern)
@@ -285,6 +323,21 @@ func (s *DevServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if r.URL.Path == ssr.HydrationPath {
+		ssr.ServeHydration(w, r)
+		return
+	}
+
+	if r.URL.Path == ssr.RouterPath {
+		ssr.ServeRouter(w, r)
+		return
+	}
+
+	if r.URL.Path == ssr.PrefetchPath {
+		ssr.ServePrefetch(w, r)
+		return
+	}
+
 	if filepath.Ext(r.URL.Path) != "" {
 		s.serveStatic(w, r)
 		return
@@ -331,6 +384,26 @@ func (s *DevServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 		mwCtx.Request.URL = validatedURL
 	}
 
+	if s.CORSMiddleware != nil {
+		if err := s.CORSMiddleware.Middleware(mwCtx, func() error { return nil }); err != nil {
+			return
+		}
+	}
+
+	if s.SessionManager != nil {
+		// The session is saved once the route is served, even if it wrote
+		// nothing.
+		s.SessionManager.Middleware(mwCtx, func() error {
+			s.serveRoute(w, route, mwCtx, params)
+			return nil
+		})
+		return
+	}
+	s.serveRoute(w, route, mwCtx, params)
+}
+
+// serveRoute runs the remaining middleware and the route's handler.
+func (s *DevServer) serveRoute(w http.ResponseWriter, route *router.Route, mwCtx *middleware.Context, params map[string]string) {
 	if s.CSRFMiddleware != nil {
 		if err := s.CSRFMiddleware.Middleware(mwCtx, func() error { return nil }); err != nil {
 			return
@@ -345,6 +418,9 @@ func (s *DevServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 
 	if s.HasMiddleware && s.MiddlewareChain != nil {
 		err := s.MiddlewareChain.Execute(mwCtx, func(ctx *middleware.Context) error {
+			if s.RateLimiter != nil && !s.RateLimiter.Allow(ctx.Response, ctx.Request, ctx.Locals) {
+				return nil
+			}
 			if route.IsEndpoint {
 				s.handleEndpoint(route, ctx, params)
 			} else if route.Type == router.RouteMarkdown {
@@ -360,6 +436,10 @@ func (s *DevServer) handleRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if s.RateLimiter != nil && !s.RateLimiter.Allow(mwCtx.Response, mwCtx.Request, mwCtx.Locals) {
+		return
+	}
+
 	if route.Type == router.RouteMarkdown {
 		s.handleMarkdownPage(route, mwCtx, params)
 	} else {
@@ -397,18 +477,7 @@ func (s *DevServer) handlePage(route *router.Route, mwCtx *middleware.Context, p
 		return
 	}
 
-	resolver := s.Compiler.Resolver
-	resolver.SetCurrentFile(route.FilePath)
-
-	imports := make([]compiler.Import, len(comp.Imports))
-	for i, imp := range comp.Imports {
-		imports[i] = compiler.Import{
-			Path:        imp.Path,
-			Alias:       imp.Alias,
-			IsComponent: imp.IsComponent,
-		}
-	}
-	resolver.ParseImports(imports)
+	render := s.Compiler.NewSession(route.FilePath, compiler.PageImports(comp))
 
 	ctx := executor.NewContext()
 
@@ -443,6 +512,12 @@ func (s *DevServer) handlePage(route *router.Route, mwCtx *middleware.Context, p
 	reqCtx := ssr.NewRequestContext(mwCtx.Request, params)
 	ctx.SetRequest(reqCtx)
 	ctx.SetLocals(mwCtx.Locals)
+	if sess := session.FromRequest(mwCtx.Request); sess != nil {
+		ctx.SetSession(sess)
+	}
+	if strings.Contains(comp.Frontmatter+comp.Template, "CSRFToken") {
+		ctx.SetCSRFToken(security.CSRFToken(mwCtx.Request))
+	}
 
 	ctx.SetParams(params)
 
@@ -462,12 +537,10 @@ func (s *DevServer) handlePage(route *router.Route, mwCtx *middleware.Context, p
 		return
 	}
 
-	s.Compiler.CollectedStyles = nil
-	s.Compiler.ResetComponentTracking()
-	processedTemplate := s.Compiler.ProcessComponentTags(comp.Template, ctx)
+	processedTemplate := render.ProcessComponentTags(comp.Template, ctx)
 
-	if s.ComponentTracker != nil && len(s.Compiler.UsedComponents) > 0 {
-		s.ComponentTracker.TrackPageComponents(route.FilePath, s.Compiler.UsedComponents)
+	if s.ComponentTracker != nil && len(render.UsedComponents) > 0 {
+		s.ComponentTracker.TrackPageComponents(route.FilePath, render.UsedComponents)
 	}
 
 	engine := template.NewEngine(ctx)
@@ -477,7 +550,7 @@ func (s *DevServer) handlePage(route *router.Route, mwCtx *middleware.Context, p
 		return
 	}
 
-	allStyles := append(comp.Styles, s.Compiler.CollectedStyles...)
+	allStyles := append(comp.Styles, render.CollectedStyles...)
 	compWithStyles := &parser.Component{
 		Frontmatter: comp.Frontmatter,
 		Template:    comp.Template,
@@ -498,7 +571,7 @@ func (s *DevServer) handlePage(route *router.Route, mwCtx *middleware.Context, p
 		return
 	}
 
-	wasmAssets, err := s.Bundler.BundleWasmScripts(comp, route.FilePath)
+	wasmAssets, err := s.Bundler.BundleWasmScripts(comp, route.FilePath, render.WasmSources()...)
 	if err != nil {
 		http.Error(mwCtx.Response, fmt.Sprintf("WASM bundle error: %v", err), http.StatusInternalServerError)
 		return
@@ -513,6 +586,10 @@ func (s *DevServer) handlePage(route *router.Route, mwCtx *middleware.Context, p
 	}
 
 	rendered = s.Bundler.InjectAssetsWithWasm(rendered, cssPath, jsPath, scopeID, wasmAssets)
+	rendered = ssr.InjectState(rendered, ctx.State)
+	rendered = ssr.InjectPrefetch(rendered, s.Config.Prefetch)
+	rendered = security.InjectCSRFToken(rendered, mwCtx.Request)
+	rendered = security.InjectCSPNonce(rendered, mwCtx.Request)
 
 	mwCtx.Response.Header().Set("Content-Type", "text/html; charset=utf-8")
 	mwCtx.Response.Write([]byte(rendered))
@@ -549,8 +626,7 @@ func (s *DevServer) handleMarkdownPage(route *router.Route, mwCtx *middleware.Co
 			"default": doc.HTML,
 		}
 
-		s.Compiler.CollectedStyles = nil
-		rendered, err := s.Compiler.Compile(layoutPath, props, slots)
+		rendered, err := s.Compiler.NewSession(layoutPath, nil).Compile(layoutPath, props, slots)
 		if err != nil {
 			http.Error(mwCtx.Response, fmt.Sprintf("Layout compile error: %v", err), http.StatusInternalServerError)
 			return
@@ -596,6 +672,7 @@ func (s *DevServer) buildAndStartCodegenServer() error {
 
 	// Build the server using CodegenBuilder
 	builder := codegen.NewCodegenBuilder(s.Router.Routes, s.PagesDir, ".galaxy", "dev-server", s.PublicDir)
+	builder.Config = s.Config
 	builder.Bundler = s.Bundler
 	if err := builder.Build(); err != nil {
 		return fmt.Errorf("codegen build failed: %w", err)
@@ -642,7 +719,7 @@ func (s *DevServer) buildAndStartCodegenServer() error {
 	// Wait for server to be ready
 	serverURL := fmt.Sprintf("http://localhost:%d", s.codegenServerPort)
 	for i := 0; i < 50; i++ {
-		resp, err := http.Get(serverURL + "/health")
+		resp, err := http.Get(serverURL + s.readinessPath())
 		if err == nil {
 			resp.Body.Close()
 			if resp.StatusCode < 500 {
@@ -683,9 +760,9 @@ func (s *DevServer) broadcastWasmReloadFromManifest(filesToRebuild []string) {
 		manifestKey := "pages/" + relPath
 
 		if pageAssets, ok := manifest.Assets[manifestKey]; ok && len(pageAssets.WasmModules) > 0 {
-			// Get the first WASM module (most pages only have one)
+			// Pages link all their scripts into one module
 			wasmMod := pageAssets.WasmModules[0]
-			moduleId := filepath.Base(filePath)
+			moduleId := assets.WasmModuleID(filePath)
 
 			s.HMRServer.BroadcastWasmReload(wasmMod.WasmPath, wasmMod.Hash, moduleId)
 		}
@@ -816,6 +893,7 @@ func (s *DevServer) executeCodegenRebuild() {
 	s.codegenReady = false
 
 	builder := codegen.NewCodegenBuilder(s.Router.Routes, s.PagesDir, ".galaxy", "dev-server", s.PublicDir)
+	builder.Config = s.Config
 	builder.Bundler = s.Bundler
 
 	var rebuildErr error
@@ -873,7 +951,7 @@ func (s *DevServer) executeCodegenRebuild() {
 	// Wait for server to be ready
 	serverURL := fmt.Sprintf("http://localhost:%d", s.codegenServerPort)
 	for i := 0; i < 50; i++ {
-		resp, err := http.Get(serverURL + "/health")
+		resp, err := http.Get(serverURL + s.readinessPath())
 		if err == nil {
 			resp.Body.Close()
 			if resp.StatusCode < 500 {
@@ -916,27 +994,14 @@ func (s *DevServer) handlePageWithCodegen(route *router.Route, mwCtx *middleware
 
 	// Process component tags BEFORE compiling
 	// This resolves <Layout>, <Nav>, etc.
-	resolver := s.Compiler.Resolver
-	resolver.SetCurrentFile(route.FilePath)
-
-	imports := make([]compiler.Import, len(comp.Imports))
-	for i, imp := range comp.Imports {
-		imports[i] = compiler.Import{
-			Path:        imp.Path,
-			Alias:       imp.Alias,
-			IsComponent: imp.IsComponent,
-		}
-	}
-	resolver.ParseImports(imports)
+	render := s.Compiler.NewSession(route.FilePath, compiler.PageImports(comp))
 
 	// Create minimal executor context for component processing only
 	dummyCtx := executor.NewContext()
-	s.Compiler.CollectedStyles = nil
-	s.Compiler.ResetComponentTracking()
-	processedTemplate := s.Compiler.ProcessComponentTags(comp.Template, dummyCtx)
+	processedTemplate := render.ProcessComponentTags(comp.Template, dummyCtx)
 
-	if s.ComponentTracker != nil && len(s.Compiler.UsedComponents) > 0 {
-		s.ComponentTracker.TrackPageComponents(route.FilePath, s.Compiler.UsedComponents)
+	if s.ComponentTracker != nil && len(render.UsedComponents) > 0 {
+		s.ComponentTracker.TrackPageComponents(route.FilePath, render.UsedComponents)
 	}
 
 	// Update component with processed template
@@ -976,7 +1041,7 @@ func (s *DevServer) handlePageWithCodegen(route *router.Route, mwCtx *middleware
 	}
 
 	// Bundle styles, scripts, and WASM
-	allStyles := append(comp.Styles, s.Compiler.CollectedStyles...)
+	allStyles := append(comp.Styles, render.CollectedStyles...)
 	compWithStyles := &parser.Component{
 		Frontmatter: comp.Frontmatter,
 		Template:    comp.Template,
@@ -997,7 +1062,7 @@ func (s *DevServer) handlePageWithCodegen(route *router.Route, mwCtx *middleware
 		return
 	}
 
-	wasmAssets, err := s.Bundler.BundleWasmScripts(comp, route.FilePath)
+	wasmAssets, err := s.Bundler.BundleWasmScripts(comp, route.FilePath, render.WasmSources()...)
 	if err != nil {
 		http.Error(mwCtx.Response, fmt.Sprintf("WASM bundle error: %v", err), http.StatusInternalServerError)
 		return
@@ -1024,6 +1089,8 @@ func (s *DevServer) handlePageWithCodegen(route *router.Route, mwCtx *middleware
 
 	// Inject assets (WASM, CSS, JS)
 	rendered = s.Bundler.InjectAssetsWithWasm(rendered, cssPath, jsPath, scopeID, wasmAssets)
+	rendered = security.InjectCSRFToken(rendered, mwCtx.Request)
+	rendered = security.InjectCSPNonce(rendered, mwCtx.Request)
 
 	// Write final output to original writer
 	originalWriter.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -1084,18 +1151,7 @@ func (s *DevServer) handleHMRRender(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	resolver := s.Compiler.Resolver
-	resolver.SetCurrentFile(path)
-
-	imports := make([]compiler.Import, len(comp.Imports))
-	for i, imp := range comp.Imports {
-		imports[i] = compiler.Import{
-			Path:        imp.Path,
-			Alias:       imp.Alias,
-			IsComponent: imp.IsComponent,
-		}
-	}
-	resolver.ParseImports(imports)
+	render := s.Compiler.NewSession(path, compiler.PageImports(comp))
 
 	ctx := executor.NewContext()
 	if comp.Frontmatter != "" {
@@ -1109,8 +1165,7 @@ func (s *DevServer) handleHMRRender(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	s.Compiler.CollectedStyles = nil
-	processedTemplate := s.Compiler.ProcessComponentTags(comp.Template, ctx)
+	processedTemplate := render.ProcessComponentTags(comp.Template, ctx)
 
 	engine := template.NewEngine(ctx)
 	rendered, err := engine.Render(processedTemplate, nil)
@@ -1133,8 +1188,7 @@ func (s *DevServer) handleHMRRender(w http.ResponseWriter, r *http.Request) {
 func (s *DevServer) serveHMRMorph(w http.ResponseWriter, r *http.Request) {
 	morphJS, err := os.ReadFile(getHMRMorphPath())
 	if err != nil {
-		http.Error(w, "morph.js not found", http.StatusNotFound)
-		return
+		morphJS = []byte(hmr.MorphRuntime)
 	}
 	w.Header().Set("Content-Type", "application/javascript")
 	w.Write(morphJS)
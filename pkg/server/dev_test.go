@@ -1,12 +1,16 @@
 package server
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/withgalaxy/galaxy/pkg/assets"
 	"github.com/withgalaxy/galaxy/pkg/config"
 	"github.com/withgalaxy/galaxy/pkg/hmr"
 )
@@ -331,3 +335,51 @@ func TestDevServer_PendingRebuilds(t *testing.T) {
 		t.Fatal("pendingRebuilds not initialized")
 	}
 }
+
+func TestDevServer_ConcurrentRequests(t *testing.T) {
+	tmpDir := t.TempDir()
+	pagesDir := filepath.Join(tmpDir, "src", "pages")
+	for _, section := range []string{"a", "b"} {
+		dir := filepath.Join(pagesDir, section)
+		os.MkdirAll(dir, 0755)
+		os.WriteFile(filepath.Join(dir, "[name].gxc"), []byte("<html><head></head><body><Badge label={name} /></body></html>"), 0644)
+		os.WriteFile(filepath.Join(dir, "Badge.gxc"), []byte(fmt.Sprintf("<b>%s {label}</b>\n<style>.badge-%s { color: blue; }</style>", section, section)), 0644)
+	}
+
+	cfg := config.DefaultConfig()
+	srv := NewDevServer(cfg, tmpDir, pagesDir, tmpDir, 3000, false)
+	// Render in process, sharing the compiler and trackers across requests.
+	srv.UseCodegen = false
+	srv.Bundler = assets.NewBundler(filepath.Join(tmpDir, ".galaxy"))
+	srv.ReloadRoutes()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 32)
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			section := []string{"a", "b"}[i%2]
+			for j := 0; j < 10; j++ {
+				if i == 0 && j%3 == 0 {
+					srv.Compiler.ClearCache()
+				}
+
+				w := httptest.NewRecorder()
+				srv.handleRequest(w, httptest.NewRequest("GET", fmt.Sprintf("/%s/user%d", section, i), nil))
+
+				want := fmt.Sprintf("<b>%s user%d</b>", section, i)
+				if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), want) {
+					errs <- fmt.Errorf("GET /%s/user%d: status %d, body %q, want %q", section, i, w.Code, w.Body.String(), want)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -77,11 +77,7 @@ func (pc *PluginCompiler) CompilePage(route *router.Route, comp *parser.Componen
 
 	// Filter out imports that are already hardcoded
 	hardcodedImports := map[string]bool{
-		`"fmt"`:      true,
 		`"net/http"`: true,
-		`"reflect"`:  true,
-		`"regexp"`:   true,
-		`"strings"`:  true,
 	}
 	var filteredImports []string
 	for _, imp := range handler.Imports {
@@ -93,10 +89,7 @@ func (pc *PluginCompiler) CompilePage(route *router.Route, comp *parser.Componen
 	handlerCode := fmt.Sprintf(`package main
 
 import (
-	"fmt"
 	"net/http"
-	"github.com/withgalaxy/galaxy/pkg/executor"
-	"github.com/withgalaxy/galaxy/pkg/template"
 	%s
 )
 
@@ -0,0 +1,149 @@
+package session
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidCookie = errors.New("invalid session cookie")
+
+// codec signs (and optionally encrypts) cookie values. The first secret is
+// used for new cookies; the remaining secrets are only accepted when decoding
+// so keys can be rotated without logging everyone out.
+type codec struct {
+	signKeys [][]byte
+	encKeys  [][]byte
+	encrypt  bool
+}
+
+func newCodec(secrets []string, encrypt bool) (*codec, error) {
+	c := &codec{encrypt: encrypt}
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		c.signKeys = append(c.signKeys, deriveKey("sign", secret))
+		c.encKeys = append(c.encKeys, deriveKey("encrypt", secret))
+	}
+	if len(c.signKeys) == 0 {
+		return nil, fmt.Errorf("session secret is required")
+	}
+	return c, nil
+}
+
+func deriveKey(purpose, secret string) []byte {
+	sum := sha256.Sum256([]byte("galaxy-session-" + purpose + ":" + secret))
+	return sum[:]
+}
+
+func (c *codec) Encode(name string, value []byte) (string, error) {
+	payload := value
+	if c.encrypt {
+		sealed, err := seal(c.encKeys[0], name, value)
+		if err != nil {
+			return "", err
+		}
+		payload = sealed
+	}
+
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	mac := sign(c.signKeys[0], name, body)
+	return body + "." + base64.RawURLEncoding.EncodeToString(mac), nil
+}
+
+func (c *codec) Decode(name, cookie string) ([]byte, error) {
+	body, macStr, ok := strings.Cut(cookie, ".")
+	if !ok {
+		return nil, ErrInvalidCookie
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(macStr)
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+
+	keyIndex := -1
+	for i, key := range c.signKeys {
+		if hmac.Equal(mac, sign(key, name, body)) {
+			keyIndex = i
+			break
+		}
+	}
+	if keyIndex < 0 {
+		return nil, ErrInvalidCookie
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+
+	if !c.encrypt {
+		return payload, nil
+	}
+
+	return open(c.encKeys[keyIndex], name, payload)
+}
+
+func sign(key []byte, name, body string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(name))
+	h.Write([]byte{'|'})
+	h.Write([]byte(body))
+	return h.Sum(nil)
+}
+
+func seal(key []byte, name string, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generate nonce: %w", err)
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, []byte(name)), nil
+}
+
+func open(key []byte, name string, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, ErrInvalidCookie
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return nil, ErrInvalidCookie
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func generateID() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("session: generate id: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,142 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileStore keeps one JSON file per session in Dir. It suits single-instance
+// deployments that need sessions to survive restarts without an external
+// database.
+type FileStore struct {
+	Dir string
+}
+
+type fileEntry struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+	Record    *Record   `json:"record"`
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("create session dir: %w", err)
+	}
+	return &FileStore{Dir: dir}, nil
+}
+
+func (s *FileStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\.`) {
+		return "", fmt.Errorf("invalid session id")
+	}
+	return filepath.Join(s.Dir, id+".json"), nil
+}
+
+func (s *FileStore) Load(token string) (*Record, error) {
+	path, err := s.path(token)
+	if err != nil {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read session: %w", err)
+	}
+
+	var entry fileEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("decode session: %w", err)
+	}
+
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		os.Remove(path)
+		return nil, nil
+	}
+
+	return entry.Record, nil
+}
+
+func (s *FileStore) Save(rec *Record, ttl time.Duration) (string, error) {
+	path, err := s.path(rec.ID)
+	if err != nil {
+		return "", err
+	}
+
+	entry := fileEntry{Record: rec}
+	if ttl > 0 {
+		entry.ExpiresAt = time.Now().Add(ttl)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("encode session: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.Dir, rec.ID+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("write session: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("write session: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("write session: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("write session: %w", err)
+	}
+
+	return rec.ID, nil
+}
+
+func (s *FileStore) Delete(rec *Record) error {
+	path, err := s.path(rec.ID)
+	if err != nil {
+		return nil
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("delete session: %w", err)
+	}
+	return nil
+}
+
+// Cleanup removes expired session files and returns how many were deleted.
+func (s *FileStore) Cleanup() (int, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if err != nil {
+		return 0, err
+	}
+
+	removed := 0
+	now := time.Now()
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(s.Dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var entry fileEntry
+		if err := json.Unmarshal(data, &entry); err != nil || (!entry.ExpiresAt.IsZero() && now.After(entry.ExpiresAt)) {
+			if os.Remove(path) == nil {
+				removed++
+			}
+		}
+	}
+
+	return removed, nil
+}
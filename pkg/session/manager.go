@@ -0,0 +1,315 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/middleware"
+)
+
+// SecretEnv is consulted when [session].secret is empty so secrets can stay
+// out of galaxy.config.toml.
+const SecretEnv = "GALAXY_SESSION_SECRET"
+
+// PreviousSecretsEnv is a comma-separated list consulted when
+// [session].previousSecrets is empty.
+const PreviousSecretsEnv = "GALAXY_SESSION_PREVIOUS_SECRETS"
+
+const maxCookieSize = 4096
+
+// maxTouchInterval bounds how often an unmodified session is re-saved just to
+// extend its idle timeout.
+const maxTouchInterval = time.Minute
+
+type Manager struct {
+	config        config.SessionConfig
+	store         Store
+	codec         *codec
+	idleTimeout   time.Duration
+	maxAge        time.Duration
+	touchInterval time.Duration
+	now           func() time.Time
+}
+
+func NewManager(cfg config.SessionConfig) (*Manager, error) {
+	var store Store
+	switch cfg.Store {
+	case config.SessionStoreMemory:
+		store = NewMemoryStore()
+	case config.SessionStoreFile:
+		dir := cfg.Dir
+		if dir == "" {
+			dir = "./.galaxy/sessions"
+		}
+		fs, err := NewFileStore(dir)
+		if err != nil {
+			return nil, err
+		}
+		store = fs
+	case config.SessionStoreCookie, "":
+		store = NewCookieStore()
+	default:
+		return nil, fmt.Errorf("unknown session store: %s", cfg.Store)
+	}
+
+	return NewManagerWithStore(cfg, store)
+}
+
+func NewManagerWithStore(cfg config.SessionConfig, store Store) (*Manager, error) {
+	secret := cfg.Secret
+	if secret == "" {
+		secret = os.Getenv(SecretEnv)
+	}
+	if secret == "" {
+		log.Printf("⚠️  session: no secret configured (set [session].secret or %s); sessions will not survive restarts", SecretEnv)
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("generate session secret: %w", err)
+		}
+		secret = hex.EncodeToString(b)
+	}
+
+	previous := cfg.PreviousSecrets
+	if len(previous) == 0 && os.Getenv(PreviousSecretsEnv) != "" {
+		previous = strings.Split(os.Getenv(PreviousSecretsEnv), ",")
+	}
+
+	c, err := newCodec(append([]string{secret}, previous...), cfg.Encrypt)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.CookieName == "" {
+		cfg.CookieName = "galaxy_session"
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/"
+	}
+
+	idleTimeout := time.Duration(cfg.IdleTimeout) * time.Second
+	// Re-save at least twice per idle timeout so a visitor active more often
+	// than that is never logged out.
+	touchInterval := maxTouchInterval
+	if idleTimeout > 0 {
+		touchInterval = min(maxTouchInterval, idleTimeout/2)
+	}
+
+	return &Manager{
+		config:        cfg,
+		store:         store,
+		codec:         c,
+		idleTimeout:   idleTimeout,
+		maxAge:        time.Duration(cfg.MaxAge) * time.Second,
+		touchInterval: touchInterval,
+		now:           time.Now,
+	}, nil
+}
+
+func (m *Manager) Store() Store {
+	return m.store
+}
+
+func (m *Manager) CookieName() string {
+	return m.config.CookieName
+}
+
+// Load returns the session for r, starting a new one when the cookie is
+// missing, invalid or expired. It never fails: a broken cookie simply yields a
+// fresh session.
+func (m *Manager) Load(r *http.Request) *Session {
+	now := m.now()
+
+	if cookie, err := r.Cookie(m.config.CookieName); err == nil && cookie.Value != "" {
+		if token, err := m.codec.Decode(m.config.CookieName, cookie.Value); err == nil {
+			if rec, err := m.store.Load(string(token)); err == nil && rec != nil {
+				if m.expired(rec, now) {
+					m.store.Delete(rec)
+				} else {
+					if rec.Values == nil {
+						rec.Values = make(map[string]interface{})
+					}
+					if rec.Flashes == nil {
+						rec.Flashes = make(map[string][]string)
+					}
+					s := &Session{record: rec}
+					if now.Sub(rec.AccessedAt) >= m.touchInterval {
+						s.modified = true
+					}
+					rec.AccessedAt = now
+					return s
+				}
+			}
+		}
+	}
+
+	return &Session{record: newRecord(generateID(), now), isNew: true}
+}
+
+func (m *Manager) expired(rec *Record, now time.Time) bool {
+	if m.idleTimeout > 0 && now.Sub(rec.AccessedAt) > m.idleTimeout {
+		return true
+	}
+	if m.maxAge > 0 && now.Sub(rec.CreatedAt) > m.maxAge {
+		return true
+	}
+	return false
+}
+
+// Save writes the session cookie (and the server-side record) if anything
+// changed. It must run before the response headers are sent.
+func (m *Manager) Save(w http.ResponseWriter, s *Session) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.previous != nil {
+		m.store.Delete(s.previous)
+		s.previous = nil
+	}
+
+	if s.destroyed || (s.isNew && s.record.empty()) {
+		if s.destroyed {
+			m.store.Delete(s.record)
+			http.SetCookie(w, m.cookie("", -1))
+		}
+		s.destroyed = false
+		s.modified = false
+		return nil
+	}
+
+	if !s.modified {
+		return nil
+	}
+
+	now := m.now()
+	ttl := m.idleTimeout
+	cookieMaxAge := 0
+	if m.maxAge > 0 {
+		remaining := s.record.CreatedAt.Add(m.maxAge).Sub(now)
+		if ttl == 0 || remaining < ttl {
+			ttl = remaining
+		}
+		cookieMaxAge = int(remaining / time.Second)
+		if cookieMaxAge <= 0 {
+			cookieMaxAge = -1
+		}
+	}
+
+	token, err := m.store.Save(s.record, ttl)
+	if err != nil {
+		return err
+	}
+
+	value, err := m.codec.Encode(m.config.CookieName, []byte(token))
+	if err != nil {
+		return err
+	}
+
+	if len(value) > maxCookieSize {
+		return fmt.Errorf("session cookie exceeds %d bytes; use the memory or file store for larger sessions", maxCookieSize)
+	}
+
+	http.SetCookie(w, m.cookie(value, cookieMaxAge))
+	s.isNew = false
+	s.modified = false
+	return nil
+}
+
+func (m *Manager) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.config.CookieName,
+		Value:    value,
+		Path:     m.config.Path,
+		Domain:   m.config.Domain,
+		MaxAge:   maxAge,
+		Secure:   m.config.Secure,
+		HttpOnly: m.config.HTTPOnly,
+		SameSite: parseSameSite(m.config.SameSite),
+	}
+}
+
+func parseSameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	case "lax":
+		return http.SameSiteLaxMode
+	default:
+		return http.SameSiteDefaultMode
+	}
+}
+
+// Handler attaches the session to the request context for net/http servers.
+func (m *Manager) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := m.Load(r)
+		sw := &responseWriter{ResponseWriter: w, manager: m, session: s}
+		next.ServeHTTP(sw, r.WithContext(NewContext(r.Context(), s)))
+		sw.commit()
+	})
+}
+
+// Middleware attaches the session to ctx.Request, ctx.Locals["session"] and
+// wraps ctx.Response so the cookie is written before the first byte of the
+// response, or once next returns if nothing was written. next must serve the
+// request for changes made by its handler to be saved.
+func (m *Manager) Middleware(ctx *middleware.Context, next func() error) error {
+	s := m.Load(ctx.Request)
+	sw := &responseWriter{ResponseWriter: ctx.Response, manager: m, session: s}
+	ctx.Request = ctx.Request.WithContext(NewContext(ctx.Request.Context(), s))
+	ctx.Response = sw
+	ctx.Set(LocalsKey, s)
+	err := next()
+	sw.commit()
+	return err
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	manager   *Manager
+	session   *Session
+	committed bool
+}
+
+func (w *responseWriter) commit() {
+	if w.committed {
+		return
+	}
+	w.committed = true
+	if err := w.manager.Save(w.ResponseWriter, w.session); err != nil {
+		log.Printf("session: save failed: %v", err)
+	}
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	w.commit()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.commit()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *responseWriter) Flush() {
+	w.commit()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,358 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/middleware"
+)
+
+func testConfig() config.SessionConfig {
+	cfg := config.DefaultConfig().Session
+	cfg.Enabled = true
+	cfg.Secret = "test-secret"
+	return cfg
+}
+
+func newTestManager(t *testing.T, cfg config.SessionConfig, store Store) *Manager {
+	t.Helper()
+	m, err := NewManagerWithStore(cfg, store)
+	if err != nil {
+		t.Fatalf("NewManagerWithStore: %v", err)
+	}
+	return m
+}
+
+// roundTrip runs fn inside m.Handler and returns the Set-Cookie header value.
+func roundTrip(t *testing.T, m *Manager, cookie *http.Cookie, fn func(s *Session)) *http.Cookie {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/", nil)
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+
+	m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fn(FromRequest(r))
+		w.Write([]byte("ok"))
+	})).ServeHTTP(w, req)
+
+	for _, c := range w.Result().Cookies() {
+		if c.Name == m.CookieName() {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestManager_RoundTrip(t *testing.T) {
+	stores := map[string]Store{
+		"cookie": NewCookieStore(),
+		"memory": NewMemoryStore(),
+	}
+	fs, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stores["file"] = fs
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			m := newTestManager(t, testConfig(), store)
+
+			cookie := roundTrip(t, m, nil, func(s *Session) {
+				s.Set("user", "alice")
+			})
+			if cookie == nil {
+				t.Fatal("expected session cookie")
+			}
+			if !cookie.HttpOnly {
+				t.Error("expected HttpOnly cookie")
+			}
+
+			var got string
+			roundTrip(t, m, cookie, func(s *Session) {
+				got = s.GetString("user")
+			})
+			if got != "alice" {
+				t.Errorf("expected alice, got %q", got)
+			}
+		})
+	}
+}
+
+func TestManager_EmptySessionSetsNoCookie(t *testing.T) {
+	m := newTestManager(t, testConfig(), NewCookieStore())
+
+	cookie := roundTrip(t, m, nil, func(s *Session) {
+		s.Get("missing")
+	})
+	if cookie != nil {
+		t.Errorf("expected no cookie for untouched session, got %v", cookie)
+	}
+}
+
+func TestManager_Flash(t *testing.T) {
+	m := newTestManager(t, testConfig(), NewMemoryStore())
+
+	cookie := roundTrip(t, m, nil, func(s *Session) {
+		s.Flash("notice", "saved")
+	})
+
+	var first, second []string
+	cookie2 := roundTrip(t, m, cookie, func(s *Session) {
+		first = s.Flashes("notice")
+	})
+	if cookie2 != nil {
+		cookie = cookie2
+	}
+	roundTrip(t, m, cookie, func(s *Session) {
+		second = s.Flashes("notice")
+	})
+
+	if len(first) != 1 || first[0] != "saved" {
+		t.Errorf("expected [saved], got %v", first)
+	}
+	if len(second) != 0 {
+		t.Errorf("expected flash to be consumed, got %v", second)
+	}
+}
+
+func TestManager_MiddlewareSavesWithoutWrite(t *testing.T) {
+	m := newTestManager(t, testConfig(), NewCookieStore())
+	w := httptest.NewRecorder()
+	ctx := middleware.NewContext(w, httptest.NewRequest("GET", "/", nil))
+
+	err := m.Middleware(ctx, func() error {
+		FromRequest(ctx.Request).Set("user", "alice")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Result().Cookies()) != 1 {
+		t.Errorf("expected the session saved when the handler writes nothing, got %v", w.Header())
+	}
+}
+
+func TestManager_Regenerate(t *testing.T) {
+	store := NewMemoryStore()
+	m := newTestManager(t, testConfig(), store)
+
+	var oldID, newID string
+	cookie := roundTrip(t, m, nil, func(s *Session) {
+		s.Set("user", "alice")
+		oldID = s.ID()
+	})
+
+	regenerated := roundTrip(t, m, cookie, func(s *Session) {
+		s.Regenerate()
+		newID = s.ID()
+	})
+
+	if oldID == newID {
+		t.Fatal("expected a new session ID")
+	}
+	if store.Len() != 1 {
+		t.Errorf("expected old record to be deleted, have %d records", store.Len())
+	}
+
+	var oldUser string
+	roundTrip(t, m, cookie, func(s *Session) {
+		oldUser = s.GetString("user")
+	})
+	if oldUser != "" {
+		t.Error("old session cookie should no longer be valid")
+	}
+
+	var user string
+	roundTrip(t, m, regenerated, func(s *Session) {
+		user = s.GetString("user")
+	})
+	if user != "alice" {
+		t.Errorf("expected values to survive regeneration, got %q", user)
+	}
+}
+
+func TestManager_Destroy(t *testing.T) {
+	m := newTestManager(t, testConfig(), NewMemoryStore())
+
+	cookie := roundTrip(t, m, nil, func(s *Session) {
+		s.Set("user", "alice")
+	})
+
+	cleared := roundTrip(t, m, cookie, func(s *Session) {
+		s.Destroy()
+	})
+	if cleared == nil || cleared.MaxAge >= 0 {
+		t.Fatalf("expected expired cookie, got %v", cleared)
+	}
+
+	var user string
+	roundTrip(t, m, cookie, func(s *Session) {
+		user = s.GetString("user")
+	})
+	if user != "" {
+		t.Error("expected destroyed session to be gone")
+	}
+}
+
+func TestManager_Expiry(t *testing.T) {
+	tests := []struct {
+		name    string
+		advance time.Duration
+		expired bool
+	}{
+		{"active", 10 * time.Second, false},
+		{"idle", 31 * time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, testConfig(), NewCookieStore())
+			now := time.Now()
+			m.now = func() time.Time { return now }
+
+			cookie := roundTrip(t, m, nil, func(s *Session) {
+				s.Set("user", "alice")
+			})
+
+			now = now.Add(tt.advance)
+			var user string
+			roundTrip(t, m, cookie, func(s *Session) {
+				user = s.GetString("user")
+			})
+
+			if (user == "") != tt.expired {
+				t.Errorf("expired = %v, want %v", user == "", tt.expired)
+			}
+		})
+	}
+}
+
+func TestManager_ShortIdleTimeout(t *testing.T) {
+	cfg := testConfig()
+	cfg.IdleTimeout = 20
+	m := newTestManager(t, cfg, NewCookieStore())
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	cookie := roundTrip(t, m, nil, func(s *Session) {
+		s.Set("user", "alice")
+	})
+
+	for i := 1; i <= 4; i++ {
+		now = now.Add(15 * time.Second)
+		var user string
+		if touched := roundTrip(t, m, cookie, func(s *Session) {
+			user = s.GetString("user")
+		}); touched != nil {
+			cookie = touched
+		}
+		if user != "alice" {
+			t.Fatalf("session expired after %d requests 15s apart", i)
+		}
+	}
+}
+
+func TestManager_AbsoluteExpiry(t *testing.T) {
+	cfg := testConfig()
+	cfg.IdleTimeout = 0
+	cfg.MaxAge = 60
+	m := newTestManager(t, cfg, NewCookieStore())
+	now := time.Now()
+	m.now = func() time.Time { return now }
+
+	cookie := roundTrip(t, m, nil, func(s *Session) {
+		s.Set("user", "alice")
+	})
+
+	now = now.Add(2 * time.Minute)
+	var user string
+	roundTrip(t, m, cookie, func(s *Session) {
+		user = s.GetString("user")
+	})
+	if user != "" {
+		t.Error("expected session past maxAge to expire")
+	}
+}
+
+func TestManager_RejectsTamperedCookie(t *testing.T) {
+	m := newTestManager(t, testConfig(), NewCookieStore())
+
+	cookie := roundTrip(t, m, nil, func(s *Session) {
+		s.Set("role", "user")
+	})
+
+	body, mac, _ := strings.Cut(cookie.Value, ".")
+	tampered := &http.Cookie{Name: cookie.Name, Value: body + "x." + mac}
+
+	var role string
+	roundTrip(t, m, tampered, func(s *Session) {
+		role = s.GetString("role")
+	})
+	if role != "" {
+		t.Error("tampered cookie should be rejected")
+	}
+}
+
+func TestManager_SecretRotation(t *testing.T) {
+	for _, encrypt := range []bool{false, true} {
+		cfg := testConfig()
+		cfg.Secret = "old"
+		cfg.Encrypt = encrypt
+		old := newTestManager(t, cfg, NewCookieStore())
+
+		cookie := roundTrip(t, old, nil, func(s *Session) {
+			s.Set("user", "alice")
+		})
+		if encrypt && strings.Contains(cookie.Value, "alice") {
+			t.Fatal("expected encrypted cookie value")
+		}
+
+		cfg.Secret = "new"
+		cfg.PreviousSecrets = []string{"old"}
+		rotated := newTestManager(t, cfg, NewCookieStore())
+
+		var user string
+		roundTrip(t, rotated, cookie, func(s *Session) {
+			user = s.GetString("user")
+		})
+		if user != "alice" {
+			t.Errorf("encrypt=%v: expected previous secret to be accepted, got %q", encrypt, user)
+		}
+
+		cfg.PreviousSecrets = nil
+		strict := newTestManager(t, cfg, NewCookieStore())
+		user = ""
+		roundTrip(t, strict, cookie, func(s *Session) {
+			user = s.GetString("user")
+		})
+		if user != "" {
+			t.Errorf("encrypt=%v: expected retired secret to be rejected", encrypt)
+		}
+	}
+}
+
+func TestManager_CookieSizeLimit(t *testing.T) {
+	m := newTestManager(t, testConfig(), NewCookieStore())
+
+	cookie := roundTrip(t, m, nil, func(s *Session) {
+		s.Set("blob", strings.Repeat("x", maxCookieSize))
+	})
+	if cookie != nil {
+		t.Error("expected oversized session not to be written")
+	}
+}
+
+func TestNilSession(t *testing.T) {
+	var s *Session
+	s.Set("a", 1)
+	if s.Get("a") != nil || s.ID() != "" || s.Flashes("x") != nil {
+		t.Error("nil session should be inert")
+	}
+}
@@ -0,0 +1,244 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+type Record struct {
+	ID         string                 `json:"id"`
+	Values     map[string]interface{} `json:"values,omitempty"`
+	Flashes    map[string][]string    `json:"flashes,omitempty"`
+	CreatedAt  time.Time              `json:"createdAt"`
+	AccessedAt time.Time              `json:"accessedAt"`
+}
+
+func newRecord(id string, now time.Time) *Record {
+	return &Record{
+		ID:         id,
+		Values:     make(map[string]interface{}),
+		Flashes:    make(map[string][]string),
+		CreatedAt:  now,
+		AccessedAt: now,
+	}
+}
+
+func (r *Record) empty() bool {
+	return len(r.Values) == 0 && len(r.Flashes) == 0
+}
+
+// Session is the request-scoped view of a stored session record. All methods
+// are safe to call on a nil *Session so pages keep rendering when sessions are
+// disabled.
+type Session struct {
+	mu        sync.Mutex
+	record    *Record
+	previous  *Record
+	isNew     bool
+	modified  bool
+	destroyed bool
+	used      bool
+}
+
+func (s *Session) ID() string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.record.ID
+}
+
+func (s *Session) IsNew() bool {
+	if s == nil {
+		return false
+	}
+	return s.isNew
+}
+
+func (s *Session) Get(key string) interface{} {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used = true
+	return s.record.Values[key]
+}
+
+func (s *Session) GetString(key string) string {
+	if v, ok := s.Get(key).(string); ok {
+		return v
+	}
+	return ""
+}
+
+func (s *Session) Has(key string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used = true
+	_, ok := s.record.Values[key]
+	return ok
+}
+
+func (s *Session) Keys() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used = true
+	keys := make([]string, 0, len(s.record.Values))
+	for k := range s.record.Values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Session) Set(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used = true
+	s.record.Values[key] = value
+	s.modified = true
+}
+
+func (s *Session) Delete(key string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used = true
+	if _, ok := s.record.Values[key]; ok {
+		delete(s.record.Values, key)
+		s.modified = true
+	}
+}
+
+func (s *Session) Clear() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used = true
+	s.record.Values = make(map[string]interface{})
+	s.record.Flashes = make(map[string][]string)
+	s.modified = true
+}
+
+// Flash queues a message that is returned (and removed) by the next call to
+// Flashes for the same key, typically on the following request.
+func (s *Session) Flash(key, message string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used = true
+	s.record.Flashes[key] = append(s.record.Flashes[key], message)
+	s.modified = true
+}
+
+func (s *Session) Flashes(key string) []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used = true
+	messages, ok := s.record.Flashes[key]
+	if !ok {
+		return nil
+	}
+	delete(s.record.Flashes, key)
+	s.modified = true
+	return messages
+}
+
+// Regenerate issues a new session ID while keeping the stored values. Call it
+// after login or any privilege change to prevent session fixation.
+func (s *Session) Regenerate() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used = true
+	if s.previous == nil && !s.isNew {
+		old := *s.record
+		s.previous = &old
+	}
+	s.record.ID = generateID()
+	s.record.CreatedAt = time.Now()
+	s.modified = true
+}
+
+func (s *Session) Destroy() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used = true
+	s.destroyed = true
+	s.record.Values = make(map[string]interface{})
+	s.record.Flashes = make(map[string][]string)
+}
+
+func (s *Session) Modified() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.modified || s.destroyed
+}
+
+// Used reports whether the request read or changed the session, in which
+// case the response may differ between visitors.
+func (s *Session) Used() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.used
+}
+
+type contextKey struct{}
+
+// LocalsKey is the key under which the session is exposed in middleware Locals.
+const LocalsKey = "session"
+
+func NewContext(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, contextKey{}, s)
+}
+
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(contextKey{}).(*Session)
+	return s
+}
+
+func FromRequest(r *http.Request) *Session {
+	if r == nil {
+		return nil
+	}
+	return FromContext(r.Context())
+}
+
+func FromLocals(locals map[string]any) *Session {
+	s, _ := locals[LocalsKey].(*Session)
+	return s
+}
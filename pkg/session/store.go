@@ -0,0 +1,128 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Store persists session records. Load receives the (already verified) value
+// that Save returned for the cookie, which is the session ID for server-side
+// stores and the serialized record for CookieStore. Load returns nil, nil when
+// no record exists.
+type Store interface {
+	Load(token string) (*Record, error)
+	Save(rec *Record, ttl time.Duration) (string, error)
+	Delete(rec *Record) error
+}
+
+type CookieStore struct{}
+
+func NewCookieStore() *CookieStore {
+	return &CookieStore{}
+}
+
+func (s *CookieStore) Load(token string) (*Record, error) {
+	var rec Record
+	if err := json.Unmarshal([]byte(token), &rec); err != nil {
+		return nil, fmt.Errorf("decode session: %w", err)
+	}
+	return &rec, nil
+}
+
+func (s *CookieStore) Save(rec *Record, ttl time.Duration) (string, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", fmt.Errorf("encode session: %w", err)
+	}
+	return string(data), nil
+}
+
+func (s *CookieStore) Delete(rec *Record) error {
+	return nil
+}
+
+type memoryEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: make(map[string]memoryEntry),
+	}
+}
+
+func (s *MemoryStore) Load(token string) (*Record, error) {
+	s.mu.Lock()
+	entry, ok := s.entries[token]
+	if ok && !entry.expiresAt.IsZero() && time.Now().After(entry.expiresAt) {
+		delete(s.entries, token)
+		ok = false
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil, nil
+	}
+
+	// Records are stored serialized so callers never share maps across requests.
+	var rec Record
+	if err := json.Unmarshal(entry.data, &rec); err != nil {
+		return nil, fmt.Errorf("decode session: %w", err)
+	}
+	return &rec, nil
+}
+
+func (s *MemoryStore) Save(rec *Record, ttl time.Duration) (string, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", fmt.Errorf("encode session: %w", err)
+	}
+
+	entry := memoryEntry{data: data}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[rec.ID] = entry
+	s.sweepLocked()
+
+	return rec.ID, nil
+}
+
+func (s *MemoryStore) Delete(rec *Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, rec.ID)
+	return nil
+}
+
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func (s *MemoryStore) sweepLocked() {
+	now := time.Now()
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for id, entry := range s.entries {
+		if !entry.expiresAt.IsZero() && now.After(entry.expiresAt) {
+			delete(s.entries, id)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package ssr
+
+import "net/http"
+
+// HydrationPath is where the hydration runtime imported by islands is
+// served from.
+const HydrationPath = "/_galaxy/hydration.js"
+
+// HydrationRuntime waits for each island's strategy, then hands the island
+// to the hydrators its component's scripts registered in the page's WASM
+// module. Islands whose strategy fires before the module has loaded are
+// hydrated when it registers, as are all of them again after a hot reload.
+const HydrationRuntime = `const state = window.__galaxyHydration = window.__galaxyHydration || { hydrators: {}, islands: {} };
+
+function start(id, moduleId, props) {
+	const island = { id, props, root: document.querySelector('[data-island-id="' + id + '"]') };
+	(state.islands[moduleId] = state.islands[moduleId] || []).push(island);
+	Object.values(state.hydrators[moduleId] || {}).forEach((hydrator) => hydrator(island));
+}
+
+export function hydrate(id, moduleId, props, strategy, media) {
+	const run = () => start(id, moduleId, props);
+
+	switch (strategy) {
+	case 'idle':
+		if ('requestIdleCallback' in window) {
+			requestIdleCallback(run);
+		} else {
+			setTimeout(run, 200);
+		}
+		break;
+	case 'visible': {
+		const root = document.querySelector('[data-island-id="' + id + '"]');
+		if (!root || !('IntersectionObserver' in window)) {
+			run();
+			break;
+		}
+		const observer = new IntersectionObserver((entries) => {
+			if (entries.some((entry) => entry.isIntersecting)) {
+				observer.disconnect();
+				run();
+			}
+		});
+		observer.observe(root);
+		break;
+	}
+	case 'media': {
+		const query = window.matchMedia(media);
+		if (query.matches) {
+			run();
+			break;
+		}
+		const onChange = (event) => {
+			if (event.matches) {
+				query.removeEventListener('change', onChange);
+				run();
+			}
+		};
+		query.addEventListener('change', onChange);
+		break;
+	}
+	default:
+		run();
+	}
+}
+`
+
+// ServeHydration serves HydrationRuntime.
+func ServeHydration(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Write([]byte(HydrationRuntime))
+}
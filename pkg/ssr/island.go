@@ -5,13 +5,33 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"html"
+	"strings"
+)
+
+// Hydration strategies, set on a component tag with client:load,
+// client:idle, client:visible or client:media="(query)".
+const (
+	StrategyLoad    = "load"
+	StrategyIdle    = "idle"
+	StrategyVisible = "visible"
+	StrategyMedia   = "media"
 )
 
 type Island struct {
+	// ComponentPath names the component's script in the page's WASM module,
+	// see assets.WasmModuleID.
 	ComponentPath string
 	Props         map[string]interface{}
 	Strategy      string
-	ID            string
+	// Media is the query a StrategyMedia island waits for.
+	Media string
+	ID    string
+	// Nonce is set on the hydration script; use security.CSPNoncePlaceholder
+	// to have it filled per request.
+	Nonce string
+	// Base is the path the site is served under, see assets.Bundler.Base.
+	Base string
 }
 
 func NewIsland(componentPath string, props map[string]interface{}, strategy string) *Island {
@@ -30,18 +50,42 @@ func generateIslandID(path string, props map[string]interface{}) string {
 	return hex.EncodeToString(hash[:])[:12]
 }
 
+func (i *Island) url(p string) string {
+	if i.Base == "" || i.Base == "/" {
+		return p
+	}
+	return strings.TrimSuffix(i.Base, "/") + p
+}
+
 func (i *Island) RenderScript() string {
-	propsJSON, _ := json.Marshal(i.Props)
+	propsJSON, err := json.Marshal(i.Props)
+	if err != nil {
+		propsJSON = []byte("{}")
+	}
+
+	media := ""
+	if i.Strategy == StrategyMedia {
+		mediaJSON, _ := json.Marshal(i.Media)
+		media = ", " + string(mediaJSON)
+	}
+
+	nonceAttr := ""
+	if i.Nonce != "" {
+		nonceAttr = fmt.Sprintf(` nonce="%s"`, html.EscapeString(i.Nonce))
+	}
 
 	return fmt.Sprintf(
-		`<script type="module">
-  import { hydrate } from '/_galaxy/hydration.js';
-  hydrate('%s', '%s', %s, '%s');
+		`<script type="module"%s>
+  import { hydrate } from '%s';
+  hydrate('%s', '%s', %s, '%s'%s);
 </script>`,
+		nonceAttr,
+		i.url(HydrationPath),
 		i.ID,
 		i.ComponentPath,
 		string(propsJSON),
 		i.Strategy,
+		media,
 	)
 }
 
@@ -95,6 +95,27 @@ func TestIslandRenderScript(t *testing.T) {
 	}
 }
 
+func TestIslandRenderScriptNonce(t *testing.T) {
+	island := NewIsland("/components/Card.tsx", nil, "load")
+	island.Nonce = "abc123"
+
+	if !strings.Contains(island.RenderScript(), `<script type="module" nonce="abc123">`) {
+		t.Error("Expected nonce attribute on hydration script")
+	}
+}
+
+func TestIslandRenderScriptBase(t *testing.T) {
+	island := NewIsland("/components/Card.tsx", nil, "load")
+	if !strings.Contains(island.RenderScript(), "from '"+HydrationPath+"'") {
+		t.Error("Expected the hydration runtime at the root without a base")
+	}
+
+	island.Base = "/docs/"
+	if !strings.Contains(island.RenderScript(), "from '/docs"+HydrationPath+"'") {
+		t.Errorf("Expected the hydration runtime under the base, got %s", island.RenderScript())
+	}
+}
+
 func TestIslandRenderScriptEmptyProps(t *testing.T) {
 	island := NewIsland("/components/Simple.tsx", map[string]interface{}{}, "load")
 	script := island.RenderScript()
@@ -180,3 +201,16 @@ func TestIslandStrategies(t *testing.T) {
 		}
 	}
 }
+
+func TestIslandRenderScriptMedia(t *testing.T) {
+	island := NewIsland("/comp/Sidebar.gxc", nil, StrategyMedia)
+	island.Media = "(min-width: 800px)"
+
+	script := island.RenderScript()
+	if !strings.Contains(script, `'media', "(min-width: 800px)");`) {
+		t.Errorf("Expected media query passed to hydrate, got %s", script)
+	}
+	if !strings.Contains(script, "import { hydrate } from '"+HydrationPath+"'") {
+		t.Error("Expected script to import the hydration runtime")
+	}
+}
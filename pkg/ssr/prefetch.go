@@ -0,0 +1,273 @@
+package ssr
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/wasm"
+)
+
+const (
+	// PrefetchPath is where the prefetch script is served from.
+	PrefetchPath = "/_galaxy/prefetch.js"
+	// PrefetchManifestPath lists the CSS and WASM modules of each page, see
+	// wasm.WasmManifest.Prefetch, so they are fetched along with it.
+	PrefetchManifestPath = "/_galaxy/prefetch.json"
+)
+
+// PrefetchRuntime loads pages before their links are followed, as set by
+// the data-strategy and data-all attributes of its script tag or the
+// link's own data-galaxy-prefetch. Pages go into the client router's cache
+// when the page has one, and are otherwise prefetched with Speculation Rules
+// where the browser supports them or <link rel="prefetch">. The files
+// PrefetchManifestPath lists for them are prefetched too.
+//
+// Nothing is prefetched when the user asked to save data or is on a 2G
+// connection, and only hovered links are on 3G.
+const PrefetchRuntime = `(function () {
+	const script = document.currentScript;
+	const defaultStrategy = script.getAttribute('data-strategy') || 'hover';
+	const all = script.hasAttribute('data-all');
+	const nonce = script.nonce;
+	const manifestPath = '` + PrefetchManifestPath + `';
+	const done = new Set();
+	let manifest = null;
+
+	function allowed(strategy) {
+		const connection = navigator.connection;
+		if (!connection) {
+			return true;
+		}
+		if (connection.saveData || /2g$/.test(connection.effectiveType || '')) {
+			return false;
+		}
+		return connection.effectiveType !== '3g' || strategy === 'hover';
+	}
+
+	function strategyOf(link) {
+		const value = link.getAttribute('data-galaxy-prefetch');
+		if (value === null) {
+			return all ? defaultStrategy : null;
+		}
+		if (value === 'false' || value === 'none') {
+			return null;
+		}
+		return value === '' || value === 'true' ? defaultStrategy : value;
+	}
+
+	function target(link) {
+		if (!(link instanceof HTMLAnchorElement) || link.hasAttribute('download')) {
+			return null;
+		}
+		const url = new URL(link.href, location.href);
+		if (url.origin !== location.origin || (url.protocol !== 'http:' && url.protocol !== 'https:')) {
+			return null;
+		}
+		url.hash = '';
+		if (url.pathname === location.pathname && url.search === location.search) {
+			return null;
+		}
+		return url;
+	}
+
+	function addLink(href) {
+		const link = document.createElement('link');
+		link.rel = 'prefetch';
+		link.href = href;
+		document.head.appendChild(link);
+	}
+
+	function speculate(href) {
+		const rules = document.createElement('script');
+		rules.type = 'speculationrules';
+		if (nonce) {
+			rules.nonce = nonce;
+		}
+		rules.textContent = JSON.stringify({ prefetch: [{ source: 'list', urls: [href] }] });
+		document.head.appendChild(rules);
+	}
+
+	// routeScore ranks how closely a route pattern such as /blog/[slug]
+	// matches path, as the server's router does, or is -1 when it does not.
+	function routeScore(pattern, path) {
+		const want = pattern.split('/');
+		const have = path.replace(/\/$/, '').split('/');
+		let score = 0;
+		for (let i = 0; i < want.length; i++) {
+			if (/^\[\.\.\.\w+\]$/.test(want[i])) {
+				return score + 1;
+			}
+			if (i >= have.length) {
+				return -1;
+			}
+			if (/^\[\w+\]$/.test(want[i])) {
+				if (!have[i]) {
+					return -1;
+				}
+				score += 2;
+			} else if (want[i] === have[i]) {
+				score += 3;
+			} else {
+				return -1;
+			}
+		}
+		return want.length === have.length ? score : -1;
+	}
+
+	function prefetchFiles(path) {
+		if (!manifest) {
+			manifest = fetch(manifestPath).then((response) => (response.ok ? response.json() : {})).catch(() => ({}));
+		}
+		manifest.then((routes) => {
+			let best = null;
+			let bestScore = -1;
+			for (const pattern of Object.keys(routes)) {
+				const score = pattern === '/' ? (path === '/' ? 3 : -1) : routeScore(pattern, path);
+				if (score > bestScore) {
+					best = pattern;
+					bestScore = score;
+				}
+			}
+			if (best === null) {
+				return;
+			}
+			for (const file of routes[best]) {
+				const href = new URL(file, location.href).href;
+				if (!done.has(href) && !document.querySelector('[src="' + file + '"], [href="' + file + '"]')) {
+					done.add(href);
+					addLink(href);
+				}
+			}
+		});
+	}
+
+	function prefetch(link, strategy) {
+		const url = target(link);
+		if (!url || done.has(url.href) || !allowed(strategy)) {
+			return;
+		}
+		done.add(url.href);
+
+		const router = window.__galaxyRouter;
+		if (router && router.prefetch) {
+			router.prefetch(url.href);
+		} else if (window.HTMLScriptElement && HTMLScriptElement.supports && HTMLScriptElement.supports('speculationrules')) {
+			speculate(url.href);
+		} else {
+			addLink(url.href);
+		}
+		prefetchFiles(url.pathname);
+	}
+
+	function whenIdle(fn) {
+		if ('requestIdleCallback' in window) {
+			requestIdleCallback(fn);
+		} else {
+			setTimeout(fn, 200);
+		}
+	}
+
+	let hoverTimer = null;
+	function onHover(event) {
+		const link = event.target.closest && event.target.closest('a[href]');
+		if (!link || strategyOf(link) !== 'hover') {
+			return;
+		}
+		clearTimeout(hoverTimer);
+		if (event.type === 'touchstart' || event.type === 'focusin') {
+			prefetch(link, 'hover');
+			return;
+		}
+		hoverTimer = setTimeout(() => prefetch(link, 'hover'), 80);
+		link.addEventListener('mouseleave', () => clearTimeout(hoverTimer), { once: true });
+	}
+	document.addEventListener('mouseover', onHover, { passive: true });
+	document.addEventListener('focusin', onHover, { passive: true });
+	document.addEventListener('touchstart', onHover, { passive: true });
+
+	const observer = 'IntersectionObserver' in window ? new IntersectionObserver((entries) => {
+		for (const entry of entries) {
+			if (entry.isIntersecting) {
+				observer.unobserve(entry.target);
+				whenIdle(() => prefetch(entry.target, 'viewport'));
+			}
+		}
+	}) : null;
+
+	function scan() {
+		document.querySelectorAll('a[href]').forEach((link) => {
+			const strategy = strategyOf(link);
+			if (strategy === 'eager') {
+				whenIdle(() => prefetch(link, 'eager'));
+			} else if (strategy === 'viewport') {
+				if (observer) {
+					observer.observe(link);
+				} else {
+					whenIdle(() => prefetch(link, 'viewport'));
+				}
+			}
+		});
+	}
+
+	if (document.readyState === 'loading') {
+		document.addEventListener('DOMContentLoaded', scan);
+	} else {
+		scan();
+	}
+	document.addEventListener('galaxy:page-load', scan);
+})();
+`
+
+// ServePrefetch serves PrefetchRuntime.
+func ServePrefetch(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Write([]byte(PrefetchRuntime))
+}
+
+// ServePrefetchManifest serves the files each page in m loads, at
+// PrefetchManifestPath. A nil manifest lists none.
+func ServePrefetchManifest(m *wasm.WasmManifest) http.HandlerFunc {
+	routes := map[string][]string{}
+	if m != nil {
+		routes = m.Prefetch()
+	}
+	data, _ := json.Marshal(routes)
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// PrefetchScript is the tag loading PrefetchRuntime as cfg sets it up, or
+// "" when prefetching is disabled.
+func PrefetchScript(cfg config.PrefetchConfig) string {
+	if !cfg.Enabled {
+		return ""
+	}
+	strategy := cfg.Strategy
+	if strategy == "" {
+		strategy = config.PrefetchHover
+	}
+	all := ""
+	if cfg.All {
+		all = " data-all"
+	}
+	return fmt.Sprintf(`<script src="%s" defer data-strategy="%s"%s%s></script>`, PrefetchPath, strategy, all, security.CSPNonceAttr)
+}
+
+// InjectPrefetch adds the prefetch script to the page's head when cfg
+// enables it.
+func InjectPrefetch(html string, cfg config.PrefetchConfig) string {
+	tag := PrefetchScript(cfg)
+	if tag == "" {
+		return html
+	}
+	if strings.Contains(html, "</head>") {
+		return strings.Replace(html, "</head>", tag+"\n</head>", 1)
+	}
+	return tag + html
+}
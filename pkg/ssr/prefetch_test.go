@@ -0,0 +1,60 @@
+package ssr
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/withgalaxy/galaxy/pkg/config"
+	"github.com/withgalaxy/galaxy/pkg/security"
+	"github.com/withgalaxy/galaxy/pkg/wasm"
+)
+
+func TestInjectPrefetch(t *testing.T) {
+	html := "<html><head><title>x</title></head><body></body></html>"
+
+	if got := InjectPrefetch(html, config.PrefetchConfig{}); got != html {
+		t.Errorf("Expected no script when disabled, got %s", got)
+	}
+
+	got := InjectPrefetch(html, config.PrefetchConfig{Enabled: true})
+	want := `<script src="` + PrefetchPath + `" defer data-strategy="hover"` + security.CSPNonceAttr + `></script>` + "\n</head>"
+	if !strings.Contains(got, want) {
+		t.Errorf("Expected hover prefetching by default, got %s", got)
+	}
+
+	got = InjectPrefetch(html, config.PrefetchConfig{Enabled: true, Strategy: config.PrefetchViewport, All: true})
+	if !strings.Contains(got, `data-strategy="viewport" data-all`) {
+		t.Errorf("Expected viewport prefetching of all links, got %s", got)
+	}
+}
+
+func TestServePrefetchManifest(t *testing.T) {
+	m := wasm.NewManifest()
+	m.Assets["pages/blog/[slug].gxc"] = wasm.WasmPageAssets{
+		CSS:         []string{"/_assets/styles-1.css"},
+		WasmModules: []wasm.WasmModule{{WasmPath: "/_assets/wasm/a.wasm", LoaderPath: "/_assets/wasm/a-loader.js"}},
+	}
+
+	rec := httptest.NewRecorder()
+	ServePrefetchManifest(m)(rec, httptest.NewRequest("GET", PrefetchManifestPath, nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected JSON, got %q", ct)
+	}
+	var routes map[string][]string
+	if err := json.Unmarshal(rec.Body.Bytes(), &routes); err != nil {
+		t.Fatal(err)
+	}
+	files := routes["/blog/[slug]"]
+	if len(files) != 3 || files[0] != "/_assets/styles-1.css" {
+		t.Errorf("Unexpected files for /blog/[slug]: %v", files)
+	}
+
+	rec = httptest.NewRecorder()
+	ServePrefetchManifest(nil)(rec, httptest.NewRequest("GET", PrefetchManifestPath, nil))
+	if body := rec.Body.String(); body != "{}" {
+		t.Errorf("Expected no routes without a manifest, got %s", body)
+	}
+}
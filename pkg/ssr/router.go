@@ -0,0 +1,349 @@
+package ssr
+
+import (
+	"net/http"
+
+	"github.com/withgalaxy/galaxy/pkg/hmr"
+)
+
+// RouterPath is where the client router added by <ClientRouter/> is served
+// from.
+const RouterPath = "/_galaxy/router.js"
+
+// RouterRuntime turns clicks on same-origin links into client-side
+// navigations: it fetches the next page, morphs the document into it inside
+// a view transition, and keeps history, scroll and focus as a full load
+// would. Scripts the new page adds are run, except external ones already
+// loaded, so WASM modules start once and live across navigations.
+//
+// Around each navigation it dispatches these events on the document, with
+// from and to URLs in their detail:
+//
+//	galaxy:before-navigate  cancelable, before the page is fetched
+//	galaxy:before-swap      the fetched page is in detail.newDocument
+//	galaxy:after-swap       the document is the new page
+//	galaxy:page-load        the new page's scripts have been started
+//
+// Links with data-galaxy-reload, or inside an element with it, load
+// normally, as do pages without the router. Pages the prefetch script loads
+// ahead, see PrefetchRuntime, are kept for 30 seconds.
+const RouterRuntime = hmr.MorphRuntime + `
+const router = window.__galaxyRouter = window.__galaxyRouter || {};
+
+if (!router.navigate) {
+	const routerPath = '` + RouterPath + `';
+	const nonceSource = document.querySelector('script[nonce]');
+	const nonce = nonceSource ? nonceSource.nonce : '';
+	const loadedScripts = new Set(Array.from(document.scripts, (script) => script.src).filter(Boolean));
+	let controller = null;
+	// shown is the URL of the page in the document, which is behind location
+	// when the browser moves through history.
+	let shown = new URL(location.href);
+
+	function dispatch(name, detail, cancelable) {
+		return document.dispatchEvent(new CustomEvent('galaxy:' + name, { detail, cancelable: !!cancelable }));
+	}
+
+	function nameTransitions(root) {
+		root.querySelectorAll('[data-transition-name]').forEach((el) => {
+			el.style.viewTransitionName = el.getAttribute('data-transition-name');
+		});
+	}
+
+	function saveScroll() {
+		history.replaceState(Object.assign({}, history.state, { galaxy: true, scrollX: window.scrollX, scrollY: window.scrollY }), '');
+	}
+
+	function routable(link, event) {
+		if (event.defaultPrevented || event.button !== 0 || event.metaKey || event.ctrlKey || event.shiftKey || event.altKey) {
+			return false;
+		}
+		if ((link.target && link.target !== '_self') || link.hasAttribute('download') || link.closest('[data-galaxy-reload]')) {
+			return false;
+		}
+		const url = new URL(link.href, location.href);
+		if (url.origin !== location.origin || (url.protocol !== 'http:' && url.protocol !== 'https:')) {
+			return false;
+		}
+		// Let the browser scroll to anchors on this page.
+		return !(url.hash && url.pathname === location.pathname && url.search === location.search);
+	}
+
+	function keyOf(el) {
+		const copy = el.cloneNode(true);
+		copy.removeAttribute('nonce');
+		return copy.outerHTML;
+	}
+
+	function adopt(el) {
+		const copy = document.importNode(el, true);
+		if (nonce && copy.hasAttribute('nonce')) {
+			copy.setAttribute('nonce', nonce);
+		}
+		return copy;
+	}
+
+	function stylesheetLoaded(link) {
+		return new Promise((resolve) => {
+			link.addEventListener('load', resolve, { once: true });
+			link.addEventListener('error', resolve, { once: true });
+			setTimeout(resolve, 3000);
+		});
+	}
+
+	// swapHead keeps head elements both pages share, so stylesheets are not
+	// reloaded, and waits for new stylesheets before the body is swapped.
+	async function swapHead(doc) {
+		const current = new Map();
+		for (const el of Array.from(document.head.children)) {
+			current.set(keyOf(el), el);
+		}
+
+		const added = [];
+		const loading = [];
+		for (const el of Array.from(doc.head.children)) {
+			const key = keyOf(el);
+			if (current.has(key)) {
+				current.delete(key);
+				continue;
+			}
+			if (el.nodeName === 'TITLE') {
+				continue;
+			}
+			const copy = adopt(el);
+			document.head.appendChild(copy);
+			added.push(copy);
+			if (copy.nodeName === 'LINK' && copy.rel === 'stylesheet') {
+				loading.push(stylesheetLoaded(copy));
+			}
+		}
+		await Promise.all(loading);
+
+		current.forEach((el, key) => {
+			if (el.nodeName !== 'TITLE' && !(el.nodeName === 'SCRIPT' && el.src)) {
+				el.remove();
+			}
+		});
+		document.title = doc.title;
+		return added;
+	}
+
+	function runnable(script) {
+		const type = (script.getAttribute('type') || '').toLowerCase();
+		return type === '' || type === 'module' || type === 'text/javascript' || type === 'application/javascript';
+	}
+
+	// runScripts starts scripts the new page brought along. Scripts parsed by
+	// DOMParser never run, so each is replaced with a fresh copy. External
+	// scripts already loaded, such as the WASM runtime and the loaders of
+	// modules already started, are skipped.
+	function runScripts(scripts) {
+		for (const script of scripts) {
+			if (!script.isConnected || !runnable(script)) {
+				continue;
+			}
+			if (script.src) {
+				if (loadedScripts.has(script.src)) {
+					continue;
+				}
+				loadedScripts.add(script.src);
+			}
+			const fresh = document.createElement('script');
+			for (const attr of Array.from(script.attributes)) {
+				fresh.setAttribute(attr.name, attr.value);
+			}
+			if (nonce) {
+				fresh.setAttribute('nonce', nonce);
+			}
+			// Keep external scripts in document order, as on a full load.
+			fresh.async = false;
+			fresh.textContent = script.textContent;
+			script.replaceWith(fresh);
+		}
+	}
+
+	function restoreFocus() {
+		const target = document.querySelector('[autofocus]');
+		if (target) {
+			target.focus();
+		} else if (document.activeElement && document.activeElement !== document.body) {
+			document.activeElement.blur();
+		}
+
+		let announcer = document.getElementById('__galaxy_announcer');
+		if (!announcer) {
+			announcer = document.createElement('div');
+			announcer.id = '__galaxy_announcer';
+			announcer.setAttribute('aria-live', 'assertive');
+			announcer.setAttribute('aria-atomic', 'true');
+			announcer.setAttribute('style', 'position:absolute;width:1px;height:1px;overflow:hidden;clip:rect(0 0 0 0);white-space:nowrap');
+			document.body.appendChild(announcer);
+		}
+		announcer.textContent = document.title;
+	}
+
+	function restoreScroll(url, scroll) {
+		if (scroll) {
+			window.scrollTo(scroll.x, scroll.y);
+			return;
+		}
+		const anchor = url.hash && document.getElementById(decodeURIComponent(url.hash.slice(1)));
+		if (anchor) {
+			anchor.scrollIntoView();
+		} else {
+			window.scrollTo(0, 0);
+		}
+	}
+
+	async function load(href, signal) {
+		const response = await fetch(href, { headers: { Accept: 'text/html' }, signal });
+		if (!(response.headers.get('Content-Type') || '').includes('text/html')) {
+			throw new Error('not a page');
+		}
+		return { url: response.url, html: await response.text() };
+	}
+
+	// prefetched holds pages loaded ahead of a navigation, for a short while
+	// so they are not shown stale.
+	const prefetched = new Map();
+
+	function withoutHash(url) {
+		const copy = new URL(url, location.href);
+		copy.hash = '';
+		return copy.href;
+	}
+
+	function takePrefetched(url) {
+		const entry = prefetched.get(withoutHash(url));
+		prefetched.delete(withoutHash(url));
+		return entry && Date.now() - entry.at < 30000 ? entry.page : null;
+	}
+
+	// navigate loads href. mode is 'push' or 'replace' for a new history
+	// entry, or 'traverse' when the browser has already moved to it, in
+	// which case scroll is where the page was left.
+	async function navigate(href, mode, scroll) {
+		mode = mode || 'push';
+		const from = shown.href;
+		let url = new URL(href, location.href);
+		if (!dispatch('before-navigate', { from, to: url.href }, true)) {
+			return;
+		}
+
+		if (controller) {
+			controller.abort();
+		}
+		const signal = (controller = new AbortController()).signal;
+
+		let doc;
+		try {
+			const page = await (takePrefetched(url) || load(url.href, signal));
+			doc = new DOMParser().parseFromString(page.html, 'text/html');
+			if (page.url) {
+				const hash = url.hash;
+				url = new URL(page.url);
+				url.hash = hash;
+			}
+		} catch (err) {
+			if (!signal.aborted) {
+				location.assign(url.href);
+			}
+			return;
+		}
+		if (signal.aborted) {
+			return;
+		}
+		if (!doc.querySelector('script[src$="' + routerPath + '"]')) {
+			location.assign(url.href);
+			return;
+		}
+
+		shown = url;
+		if (mode === 'push') {
+			saveScroll();
+			history.pushState({ galaxy: true }, '', url.href);
+		} else if (mode === 'replace') {
+			history.replaceState({ galaxy: true }, '', url.href);
+		}
+
+		const detail = { from, to: url.href };
+		let added = [];
+		const swap = async () => {
+			dispatch('before-swap', Object.assign({ newDocument: doc }, detail));
+			nameTransitions(doc);
+			added = await swapHead(doc);
+			if (window.__galaxyHydration) {
+				window.__galaxyHydration.islands = {};
+			}
+			morph(document.body, doc.body);
+			dispatch('after-swap', detail);
+			restoreScroll(url, scroll);
+			restoreFocus();
+		};
+
+		const reduceMotion = window.matchMedia && window.matchMedia('(prefers-reduced-motion: reduce)').matches;
+		if (document.startViewTransition && !reduceMotion) {
+			nameTransitions(document);
+			await document.startViewTransition(swap).updateCallbackDone.catch(() => {});
+		} else {
+			await swap();
+		}
+
+		runScripts(added.filter((el) => el.nodeName === 'SCRIPT').concat(Array.from(document.body.querySelectorAll('script'))));
+		dispatch('page-load', detail);
+	}
+
+	router.navigate = (href, options) => navigate(href, options && options.replace ? 'replace' : 'push');
+	router.prefetch = (href) => {
+		const key = withoutHash(href);
+		const entry = prefetched.get(key);
+		if (!entry || Date.now() - entry.at >= 30000) {
+			const page = load(key);
+			page.catch(() => prefetched.delete(key));
+			prefetched.set(key, { at: Date.now(), page });
+		}
+	};
+
+	if ('scrollRestoration' in history) {
+		history.scrollRestoration = 'manual';
+	}
+	history.replaceState(Object.assign({}, history.state, { galaxy: true }), '');
+	nameTransitions(document);
+
+	document.addEventListener('click', (event) => {
+		const link = event.target.closest && event.target.closest('a[href]');
+		if (!link || !(link instanceof HTMLAnchorElement) || !routable(link, event)) {
+			return;
+		}
+		event.preventDefault();
+		navigate(link.href, link.href === location.href ? 'replace' : 'push');
+	});
+
+	window.addEventListener('popstate', (event) => {
+		const state = event.state;
+		if (!state || !state.galaxy) {
+			return;
+		}
+		if (location.pathname === shown.pathname && location.search === shown.search) {
+			shown = new URL(location.href);
+			restoreScroll(new URL(location.href), { x: state.scrollX || 0, y: state.scrollY || 0 });
+			return;
+		}
+		navigate(location.href, 'traverse', { x: state.scrollX || 0, y: state.scrollY || 0 });
+	});
+
+	// Record where the page was left before the browser moves away.
+	window.addEventListener('pagehide', saveScroll);
+	let scrollTimer = null;
+	window.addEventListener('scroll', () => {
+		clearTimeout(scrollTimer);
+		scrollTimer = setTimeout(saveScroll, 100);
+	}, { passive: true });
+}
+`
+
+// ServeRouter serves RouterRuntime.
+func ServeRouter(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/javascript")
+	w.Write([]byte(RouterRuntime))
+}
@@ -0,0 +1,25 @@
+package ssr
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/withgalaxy/galaxy/pkg/hmr"
+)
+
+func TestServeRouter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ServeRouter(rec, httptest.NewRequest("GET", RouterPath, nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/javascript" {
+		t.Errorf("Expected a script, got %q", ct)
+	}
+	body := rec.Body.String()
+	if !strings.HasPrefix(body, hmr.MorphRuntime) {
+		t.Error("Expected the router to swap pages with the HMR morph")
+	}
+	if !strings.Contains(body, "'"+RouterPath+"'") {
+		t.Error("Expected the router to recognise pages loading it")
+	}
+}
@@ -0,0 +1,41 @@
+package ssr
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+)
+
+// StateElementID is the id of the script element holding a page's server
+// state, which store.NewAtomFromServer reads in the browser.
+const StateElementID = "__galaxy_state"
+
+// State holds the values a page's frontmatter registered with
+// Galaxy.SetState, by key.
+type State map[string]interface{}
+
+// Set registers value under key.
+func (s State) Set(key string, value interface{}) {
+	s[key] = value
+}
+
+// InjectState serializes state into html as JSON, ahead of the page's
+// scripts. Pages without state are returned unchanged.
+func InjectState(html string, state State) string {
+	if len(state) == 0 {
+		return html
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		log.Printf("⚠️  ssr: cannot serialize server state: %v", err)
+		return html
+	}
+
+	// json.Marshal escapes <, > and &, so the payload cannot close the tag.
+	script := `<script type="application/json" id="` + StateElementID + `">` + string(data) + `</script>`
+	if strings.Contains(html, "</head>") {
+		return strings.Replace(html, "</head>", "\t"+script+"\n</head>", 1)
+	}
+	return script + "\n" + html
+}
@@ -0,0 +1,29 @@
+package ssr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInjectState(t *testing.T) {
+	state := State{}
+	state.Set("user", map[string]string{"name": "</script><b>"})
+	state.Set("count", 3)
+
+	html := InjectState("<html><head></head><body></body></html>", state)
+
+	want := `<script type="application/json" id="__galaxy_state">{"count":3,"user":{"name":"\u003c/script\u003e\u003cb\u003e"}}</script>`
+	if !strings.Contains(html, want) {
+		t.Errorf("Expected escaped state in the head, got %s", html)
+	}
+	if strings.Index(html, want) > strings.Index(html, "</head>") {
+		t.Error("Expected state before the page's scripts")
+	}
+}
+
+func TestInjectStateEmpty(t *testing.T) {
+	html := "<html><head></head></html>"
+	if got := InjectState(html, nil); got != html {
+		t.Errorf("Expected pages without state unchanged, got %s", got)
+	}
+}
@@ -1,6 +1,3 @@
-//go:build js && wasm
-// +build js,wasm
-
 package store
 
 import (
@@ -9,23 +6,21 @@ import (
 
 type Atom[T any] struct {
 	value       T
-	subscribers []func(T)
+	subscribers subscribers[T]
 	mu          sync.RWMutex
 	hmrKey      string
 }
 
 func NewAtom[T any](initial T) *Atom[T] {
 	return &Atom[T]{
-		value:       initial,
-		subscribers: make([]func(T), 0),
+		value: initial,
 	}
 }
 
 func NewAtomWithHMR[T any](initial T, hmrKey string) *Atom[T] {
 	a := &Atom[T]{
-		value:       initial,
-		subscribers: make([]func(T), 0),
-		hmrKey:      hmrKey,
+		value:  initial,
+		hmrKey: hmrKey,
 	}
 	a.loadFromHMR()
 	return a
@@ -44,8 +39,7 @@ func (a *Atom[T]) Value() T {
 func (a *Atom[T]) Set(value T) {
 	a.mu.Lock()
 	a.value = value
-	subs := make([]func(T), len(a.subscribers))
-	copy(subs, a.subscribers)
+	subs := a.subscribers.snapshot()
 	a.mu.Unlock()
 
 	a.saveToHMR()
@@ -59,8 +53,7 @@ func (a *Atom[T]) Update(fn func(T) T) {
 	a.mu.Lock()
 	a.value = fn(a.value)
 	newValue := a.value
-	subs := make([]func(T), len(a.subscribers))
-	copy(subs, a.subscribers)
+	subs := a.subscribers.snapshot()
 	a.mu.Unlock()
 
 	a.saveToHMR()
@@ -72,16 +65,13 @@ func (a *Atom[T]) Update(fn func(T) T) {
 
 func (a *Atom[T]) Subscribe(callback func(T)) Unsubscriber {
 	a.mu.Lock()
-	a.subscribers = append(a.subscribers, callback)
-	index := len(a.subscribers) - 1
+	id := a.subscribers.add(callback)
 	a.mu.Unlock()
 
 	return func() {
 		a.mu.Lock()
 		defer a.mu.Unlock()
-		if index < len(a.subscribers) {
-			a.subscribers = append(a.subscribers[:index], a.subscribers[index+1:]...)
-		}
+		a.subscribers.remove(id)
 	}
 }
 
@@ -0,0 +1,105 @@
+//go:build js && wasm
+// +build js,wasm
+
+package store
+
+import (
+	"fmt"
+	"syscall/js"
+)
+
+func readServerState() (string, bool) {
+	doc := js.Global().Get("document")
+	if doc.IsUndefined() {
+		return "", false
+	}
+	el := doc.Call("getElementById", serverStateID)
+	if el.IsNull() {
+		return "", false
+	}
+	return el.Get("textContent").String(), true
+}
+
+func warn(msg string) {
+	js.Global().Get("console").Call("warn", "galaxy: "+msg)
+}
+
+func storageArea(kind Storage) js.Value {
+	if kind == SessionStorage {
+		return js.Global().Get("sessionStorage")
+	}
+	return js.Global().Get("localStorage")
+}
+
+type webStorage struct {
+	area js.Value
+}
+
+// openStorage falls back to memory where Web Storage is unavailable.
+func openStorage(kind Storage) storage {
+	area := storageArea(kind)
+	if area.IsUndefined() || area.IsNull() {
+		return openMemoryStorage(kind)
+	}
+	return webStorage{area: area}
+}
+
+func (s webStorage) getItem(key string) (string, bool) {
+	val := s.area.Call("getItem", key)
+	if val.IsNull() {
+		return "", false
+	}
+	return val.String(), true
+}
+
+func (s webStorage) setItem(key, value string) {
+	// Browsers throw when the quota is exceeded.
+	defer func() {
+		if r := recover(); r != nil {
+			warn(fmt.Sprintf("persistent store %s: %v", key, r))
+		}
+	}()
+	s.area.Call("setItem", key, value)
+}
+
+func syncTabs(mode SyncMode, kind Storage, key string, onChange func(value string)) (publish func(string), stop func()) {
+	switch mode {
+	case SyncStorageEvent:
+		area := storageArea(kind)
+		listener := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			event := args[0]
+			if event.Get("key").String() != key || !event.Get("storageArea").Equal(area) {
+				return nil
+			}
+			if value := event.Get("newValue"); !value.IsNull() {
+				onChange(value.String())
+			}
+			return nil
+		})
+		js.Global().Call("addEventListener", "storage", listener)
+		return func(string) {}, func() {
+			js.Global().Call("removeEventListener", "storage", listener)
+			listener.Release()
+		}
+
+	case SyncBroadcastChannel:
+		ctor := js.Global().Get("BroadcastChannel")
+		if ctor.IsUndefined() {
+			return func(string) {}, func() {}
+		}
+		channel := ctor.New("galaxy:store:" + key)
+		listener := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+			onChange(args[0].Get("data").String())
+			return nil
+		})
+		channel.Set("onmessage", listener)
+		return func(value string) {
+				channel.Call("postMessage", value)
+			}, func() {
+				channel.Call("close")
+				listener.Release()
+			}
+	}
+
+	return func(string) {}, func() {}
+}
@@ -1,6 +1,3 @@
-//go:build js && wasm
-// +build js,wasm
-
 package store
 
 import (
@@ -9,7 +6,7 @@ import (
 
 type Computed[T any] struct {
 	value       T
-	subscribers []func(T)
+	subscribers subscribers[T]
 	mu          sync.RWMutex
 	unsub       Unsubscriber
 }
@@ -21,16 +18,14 @@ type ReadableStore[T any] interface {
 
 func NewComputed[S any, T any](source ReadableStore[S], transform func(S) T) *Computed[T] {
 	c := &Computed[T]{
-		value:       transform(source.Get()),
-		subscribers: make([]func(T), 0),
+		value: transform(source.Get()),
 	}
 
 	c.unsub = source.Subscribe(func(val S) {
 		c.mu.Lock()
 		c.value = transform(val)
 		newValue := c.value
-		subs := make([]func(T), len(c.subscribers))
-		copy(subs, c.subscribers)
+		subs := c.subscribers.snapshot()
 		c.mu.Unlock()
 
 		for _, callback := range subs {
@@ -53,16 +48,13 @@ func (c *Computed[T]) Value() T {
 
 func (c *Computed[T]) Subscribe(callback func(T)) Unsubscriber {
 	c.mu.Lock()
-	c.subscribers = append(c.subscribers, callback)
-	index := len(c.subscribers) - 1
+	id := c.subscribers.add(callback)
 	c.mu.Unlock()
 
 	return func() {
 		c.mu.Lock()
 		defer c.mu.Unlock()
-		if index < len(c.subscribers) {
-			c.subscribers = append(c.subscribers[:index], c.subscribers[index+1:]...)
-		}
+		c.subscribers.remove(id)
 	}
 }
 
@@ -0,0 +1,82 @@
+//go:build !js || !wasm
+// +build !js !wasm
+
+package store
+
+import (
+	"log"
+	"sync"
+)
+
+func saveStateToHMR[T any](key string, value T) {}
+
+func loadStateFromHMR[T any](key string) (T, bool) {
+	var zero T
+	return zero, false
+}
+
+func readServerState() (string, bool) {
+	return "", false
+}
+
+func warn(msg string) {
+	log.Printf("⚠️  store: %s", msg)
+}
+
+func openStorage(kind Storage) storage {
+	return openMemoryStorage(kind)
+}
+
+type tabKey struct {
+	kind Storage
+	key  string
+}
+
+// tab is a synced persistent atom. Atoms in a process sync with each other
+// as tabs would.
+type tab struct {
+	onChange func(value string)
+}
+
+var (
+	tabsMu sync.Mutex
+	tabs   = make(map[tabKey][]*tab)
+)
+
+func syncTabs(mode SyncMode, kind Storage, key string, onChange func(value string)) (publish func(string), stop func()) {
+	if mode == SyncNone {
+		return func(string) {}, func() {}
+	}
+
+	k := tabKey{kind: kind, key: key}
+	t := &tab{onChange: onChange}
+	tabsMu.Lock()
+	tabs[k] = append(tabs[k], t)
+	tabsMu.Unlock()
+
+	publish = func(value string) {
+		tabsMu.Lock()
+		var others []*tab
+		for _, other := range tabs[k] {
+			if other != t {
+				others = append(others, other)
+			}
+		}
+		tabsMu.Unlock()
+
+		for _, other := range others {
+			other.onChange(value)
+		}
+	}
+	stop = func() {
+		tabsMu.Lock()
+		defer tabsMu.Unlock()
+		for i, other := range tabs[k] {
+			if other == t {
+				tabs[k] = append(tabs[k][:i], tabs[k][i+1:]...)
+				break
+			}
+		}
+	}
+	return publish, stop
+}
@@ -1,6 +1,3 @@
-//go:build js && wasm
-// +build js,wasm
-
 package store
 
 import (
@@ -9,7 +6,7 @@ import (
 
 type MapStore struct {
 	value       map[string]any
-	subscribers []func(map[string]any)
+	subscribers subscribers[map[string]any]
 	mu          sync.RWMutex
 	hmrKey      string
 }
@@ -19,8 +16,7 @@ func NewMap(initial map[string]any) *MapStore {
 		initial = make(map[string]any)
 	}
 	return &MapStore{
-		value:       copyMap(initial),
-		subscribers: make([]func(map[string]any), 0),
+		value: copyMap(initial),
 	}
 }
 
@@ -29,9 +25,8 @@ func NewMapWithHMR(initial map[string]any, hmrKey string) *MapStore {
 		initial = make(map[string]any)
 	}
 	m := &MapStore{
-		value:       copyMap(initial),
-		subscribers: make([]func(map[string]any), 0),
-		hmrKey:      hmrKey,
+		value:  copyMap(initial),
+		hmrKey: hmrKey,
 	}
 	m.loadFromHMR()
 	return m
@@ -56,8 +51,7 @@ func (m *MapStore) GetKey(key string) any {
 func (m *MapStore) Set(value map[string]any) {
 	m.mu.Lock()
 	m.value = copyMap(value)
-	subs := make([]func(map[string]any), len(m.subscribers))
-	copy(subs, m.subscribers)
+	subs := m.subscribers.snapshot()
 	valueCopy := copyMap(m.value)
 	m.mu.Unlock()
 
@@ -71,8 +65,7 @@ func (m *MapStore) Set(value map[string]any) {
 func (m *MapStore) SetKey(key string, value any) {
 	m.mu.Lock()
 	m.value[key] = value
-	subs := make([]func(map[string]any), len(m.subscribers))
-	copy(subs, m.subscribers)
+	subs := m.subscribers.snapshot()
 	valueCopy := copyMap(m.value)
 	m.mu.Unlock()
 
@@ -86,8 +79,7 @@ func (m *MapStore) SetKey(key string, value any) {
 func (m *MapStore) DeleteKey(key string) {
 	m.mu.Lock()
 	delete(m.value, key)
-	subs := make([]func(map[string]any), len(m.subscribers))
-	copy(subs, m.subscribers)
+	subs := m.subscribers.snapshot()
 	valueCopy := copyMap(m.value)
 	m.mu.Unlock()
 
@@ -100,16 +92,13 @@ func (m *MapStore) DeleteKey(key string) {
 
 func (m *MapStore) Subscribe(callback func(map[string]any)) Unsubscriber {
 	m.mu.Lock()
-	m.subscribers = append(m.subscribers, callback)
-	index := len(m.subscribers) - 1
+	id := m.subscribers.add(callback)
 	m.mu.Unlock()
 
 	return func() {
 		m.mu.Lock()
 		defer m.mu.Unlock()
-		if index < len(m.subscribers) {
-			m.subscribers = append(m.subscribers[:index], m.subscribers[index+1:]...)
-		}
+		m.subscribers.remove(id)
 	}
 }
 
@@ -0,0 +1,33 @@
+package store
+
+import "sync"
+
+var (
+	memoryMu    sync.Mutex
+	memoryAreas = make(map[Storage]map[string]string)
+)
+
+// memoryStorage stands in for Web Storage outside the browser.
+type memoryStorage struct {
+	kind Storage
+}
+
+func openMemoryStorage(kind Storage) storage {
+	return memoryStorage{kind: kind}
+}
+
+func (s memoryStorage) getItem(key string) (string, bool) {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	val, ok := memoryAreas[s.kind][key]
+	return val, ok
+}
+
+func (s memoryStorage) setItem(key, value string) {
+	memoryMu.Lock()
+	defer memoryMu.Unlock()
+	if memoryAreas[s.kind] == nil {
+		memoryAreas[s.kind] = make(map[string]string)
+	}
+	memoryAreas[s.kind][key] = value
+}
@@ -0,0 +1,161 @@
+package store
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// Storage is the Web Storage area a persistent atom is saved in.
+type Storage int
+
+const (
+	// LocalStorage keeps values across visits.
+	LocalStorage Storage = iota
+	// SessionStorage keeps values until the tab is closed.
+	SessionStorage
+)
+
+// SyncMode is how a persistent atom picks up changes made in other tabs.
+type SyncMode int
+
+const (
+	// SyncNone leaves other tabs' changes for the next page load.
+	SyncNone SyncMode = iota
+	// SyncStorageEvent listens for the storage event, which browsers fire
+	// when another tab changes LocalStorage.
+	SyncStorageEvent
+	// SyncBroadcastChannel posts every change on a BroadcastChannel, which
+	// works for SessionStorage too.
+	SyncBroadcastChannel
+)
+
+// PersistOptions configure NewPersistentAtom. The zero value saves to
+// LocalStorage, unversioned, without syncing tabs.
+type PersistOptions struct {
+	Storage Storage
+	Sync    SyncMode
+
+	// Version is saved with the value. A value saved under another version
+	// is passed to Migrate, and dropped when there is no Migrate or it fails.
+	Version int
+	Migrate func(data json.RawMessage, version int) (json.RawMessage, error)
+}
+
+// PersistentAtom is an atom that saves its value to Web Storage as JSON
+// whenever it changes. Outside the browser it is kept in memory, shared
+// by the atoms of a process as if they were tabs.
+type PersistentAtom[T any] struct {
+	*Atom[T]
+	key     string
+	opts    PersistOptions
+	storage storage
+	publish func(value string)
+	stop    func()
+}
+
+// persisted is how a persistent atom's value is saved.
+type persisted struct {
+	Version int             `json:"v"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// NewPersistentAtom returns an atom holding the value saved under key, or
+// initial when there is none. Across hot reloads, it keeps its state like
+// NewAtomWithHMR.
+func NewPersistentAtom[T any](key string, initial T, opts PersistOptions) *PersistentAtom[T] {
+	p := &PersistentAtom[T]{
+		Atom:    NewAtomWithHMR(initial, "persist:"+key),
+		key:     key,
+		opts:    opts,
+		storage: openStorage(opts.Storage),
+	}
+	p.publish, p.stop = syncTabs(opts.Sync, opts.Storage, key, p.receive)
+
+	if _, ok := loadStateFromHMR[T](p.hmrKey); !ok {
+		if raw, ok := p.storage.getItem(key); ok {
+			if val, migrated, ok := p.decode(raw); ok {
+				p.value = val
+				if migrated {
+					p.save(val)
+				}
+			}
+		}
+	}
+	return p
+}
+
+func (p *PersistentAtom[T]) Set(value T) {
+	p.Atom.Set(value)
+	p.save(value)
+}
+
+func (p *PersistentAtom[T]) Update(fn func(T) T) {
+	p.Atom.Update(fn)
+	p.save(p.Get())
+}
+
+// Destroy stops syncing with other tabs.
+func (p *PersistentAtom[T]) Destroy() {
+	if p.stop != nil {
+		p.stop()
+	}
+}
+
+func (p *PersistentAtom[T]) save(value T) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		warn("persistent store " + p.key + ": " + err.Error())
+		return
+	}
+	raw, _ := json.Marshal(persisted{Version: p.opts.Version, Data: data})
+
+	p.storage.setItem(p.key, string(raw))
+	p.publish(string(raw))
+}
+
+// receive applies a change made in another tab.
+func (p *PersistentAtom[T]) receive(raw string) {
+	val, _, ok := p.decode(raw)
+	if !ok {
+		return
+	}
+	if p.opts.Sync == SyncBroadcastChannel {
+		p.storage.setItem(p.key, raw)
+	}
+	p.Atom.Set(val)
+}
+
+// decode reads a saved value, migrating it from the version it was saved
+// under.
+func (p *PersistentAtom[T]) decode(raw string) (value T, migrated, ok bool) {
+	var saved persisted
+	if err := json.Unmarshal([]byte(raw), &saved); err != nil {
+		warn("persistent store " + p.key + ": " + err.Error())
+		return value, false, false
+	}
+
+	data := saved.Data
+	if saved.Version != p.opts.Version {
+		if p.opts.Migrate == nil {
+			return value, false, false
+		}
+		var err error
+		if data, err = p.opts.Migrate(data, saved.Version); err != nil {
+			warn("persistent store " + p.key + ": migrating from version " + strconv.Itoa(saved.Version) + ": " + err.Error())
+			return value, false, false
+		}
+		migrated = true
+	}
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		warn("persistent store " + p.key + ": " + err.Error())
+		return value, false, false
+	}
+	return value, migrated, true
+}
+
+// storage is a Web Storage area.
+type storage interface {
+	getItem(key string) (string, bool)
+	setItem(key, value string)
+}
@@ -0,0 +1,75 @@
+//go:build !js || !wasm
+// +build !js !wasm
+
+package store
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPersistentAtomRestores(t *testing.T) {
+	theme := NewPersistentAtom("test:theme", "light", PersistOptions{})
+	theme.Set("dark")
+
+	if got := NewPersistentAtom("test:theme", "light", PersistOptions{}).Get(); got != "dark" {
+		t.Errorf("expected the saved value, got %q", got)
+	}
+	if got := NewPersistentAtom("test:theme", "light", PersistOptions{Storage: SessionStorage}).Get(); got != "light" {
+		t.Errorf("expected storage areas to be separate, got %q", got)
+	}
+}
+
+func TestPersistentAtomMigrates(t *testing.T) {
+	type cart struct {
+		Items []string `json:"items"`
+	}
+	openStorage(LocalStorage).setItem("test:cart", `{"v":1,"data":["book"]}`)
+
+	opts := PersistOptions{
+		Version: 2,
+		Migrate: func(data json.RawMessage, version int) (json.RawMessage, error) {
+			if version != 1 {
+				t.Errorf("expected version 1, got %d", version)
+			}
+			return json.Marshal(map[string]json.RawMessage{"items": data})
+		},
+	}
+	got := NewPersistentAtom("test:cart", cart{}, opts).Get()
+	if len(got.Items) != 1 || got.Items[0] != "book" {
+		t.Errorf("expected the migrated cart, got %+v", got)
+	}
+
+	if raw, _ := openStorage(LocalStorage).getItem("test:cart"); raw != `{"v":2,"data":{"items":["book"]}}` {
+		t.Errorf("expected the migrated value saved, got %s", raw)
+	}
+
+	opts.Version, opts.Migrate = 3, nil
+	if got := NewPersistentAtom("test:cart", cart{}, opts).Get(); got.Items != nil {
+		t.Errorf("expected values of another version dropped without Migrate, got %+v", got)
+	}
+}
+
+func TestPersistentAtomSyncsTabs(t *testing.T) {
+	opts := PersistOptions{Sync: SyncBroadcastChannel}
+	a := NewPersistentAtom("test:count", 0, opts)
+	b := NewPersistentAtom("test:count", 0, opts)
+	defer a.Destroy()
+
+	doubled := NewComputed[int, int](b, func(v int) int { return v * 2 })
+	var seen []int
+	b.Subscribe(func(v int) { seen = append(seen, v) })
+
+	a.Set(2)
+	a.Update(func(v int) int { return v + 1 })
+
+	if b.Get() != 3 || doubled.Get() != 6 || len(seen) != 2 {
+		t.Errorf("expected the other tab to follow, got %d %d %v", b.Get(), doubled.Get(), seen)
+	}
+
+	b.Destroy()
+	a.Set(10)
+	if b.Get() != 3 {
+		t.Error("expected a destroyed atom to stop syncing")
+	}
+}
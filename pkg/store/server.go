@@ -0,0 +1,64 @@
+package store
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// serverStateID matches ssr.StateElementID.
+const serverStateID = "__galaxy_state"
+
+var (
+	serverStateMu sync.Mutex
+	serverState   map[string]json.RawMessage
+	// serverStateRaw is what serverState was parsed from. The client router
+	// swaps in each page's state, so it is checked on every read.
+	serverStateRaw string
+)
+
+// NewAtomFromServer returns an atom holding the value the page's frontmatter
+// registered under key with Galaxy.SetState, or fallback when it did not
+// register one or the value does not decode into T.
+func NewAtomFromServer[T any](key string, fallback T) *Atom[T] {
+	a := NewAtom(fallback)
+	if val, ok := loadServerState[T](key); ok {
+		a.value = val
+	}
+	return a
+}
+
+func loadServerState[T any](key string) (T, bool) {
+	var zero T
+	raw, ok := currentServerState()[key]
+	if !ok {
+		return zero, false
+	}
+
+	var result T
+	if err := json.Unmarshal(raw, &result); err != nil {
+		warn("server state " + key + ": " + err.Error())
+		return zero, false
+	}
+
+	return result, true
+}
+
+func currentServerState() map[string]json.RawMessage {
+	serverStateMu.Lock()
+	defer serverStateMu.Unlock()
+
+	data, ok := readServerState()
+	if !ok {
+		serverState, serverStateRaw = nil, ""
+		return nil
+	}
+	if data == serverStateRaw {
+		return serverState
+	}
+
+	serverState, serverStateRaw = nil, data
+	if err := json.Unmarshal([]byte(data), &serverState); err != nil {
+		warn("server state: " + err.Error())
+	}
+	return serverState
+}
@@ -1,6 +1,3 @@
-//go:build js && wasm
-// +build js,wasm
-
 package store
 
 type Store[T any] interface {
@@ -17,3 +14,35 @@ type ReadonlyStore[T any] interface {
 	Subscribe(callback func(T)) Unsubscriber
 	Value() T
 }
+
+// subscribers are a store's callbacks, which can unsubscribe in any order.
+// Stores guard them with their own mutex.
+type subscribers[T any] struct {
+	nextID    int
+	ids       []int
+	callbacks []func(T)
+}
+
+func (s *subscribers[T]) add(callback func(T)) int {
+	s.nextID++
+	s.ids = append(s.ids, s.nextID)
+	s.callbacks = append(s.callbacks, callback)
+	return s.nextID
+}
+
+func (s *subscribers[T]) remove(id int) {
+	for i, other := range s.ids {
+		if other == id {
+			s.ids = append(s.ids[:i], s.ids[i+1:]...)
+			s.callbacks = append(s.callbacks[:i], s.callbacks[i+1:]...)
+			return
+		}
+	}
+}
+
+// snapshot returns the callbacks to notify, which may unsubscribe meanwhile.
+func (s *subscribers[T]) snapshot() []func(T) {
+	subs := make([]func(T), len(s.callbacks))
+	copy(subs, s.callbacks)
+	return subs
+}
@@ -4,6 +4,7 @@
 package store
 
 import (
+	"syscall/js"
 	"testing"
 )
 
@@ -108,6 +109,27 @@ func TestAtomUnsubscribe(t *testing.T) {
 	}
 }
 
+func TestAtomUnsubscribeInAnyOrder(t *testing.T) {
+	atom := NewAtom(0)
+
+	var calls [3]int
+	var unsubs [3]Unsubscriber
+	for i := range unsubs {
+		i := i
+		unsubs[i] = atom.Subscribe(func(val int) {
+			calls[i]++
+		})
+	}
+
+	unsubs[0]()
+	unsubs[2]()
+	atom.Set(1)
+
+	if calls != [3]int{0, 1, 0} {
+		t.Errorf("After unsubscribing 0 and 2, calls = %v, want [0 1 0]", calls)
+	}
+}
+
 func TestMapStoreGetSet(t *testing.T) {
 	m := NewMap(map[string]any{"name": "Alice", "age": 30})
 
@@ -429,3 +451,36 @@ func TestMapStoreIsolation(t *testing.T) {
 		t.Errorf("Map not isolated, val2[value] = %v, want 1", val2["value"])
 	}
 }
+
+func TestNewAtomFromServer(t *testing.T) {
+	el := js.Global().Get("Object").New()
+	el.Set("textContent", `{"user":{"name":"Ada"},"count":"three"}`)
+	doc := js.Global().Get("Object").New()
+	doc.Set("getElementById", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		if args[0].String() == serverStateID {
+			return el
+		}
+		return js.Null()
+	}))
+	js.Global().Set("document", doc)
+	defer js.Global().Delete("document")
+
+	type user struct {
+		Name string `json:"name"`
+	}
+	if got := NewAtomFromServer("user", user{}).Get(); got.Name != "Ada" {
+		t.Errorf("NewAtomFromServer(user) = %+v, want Ada", got)
+	}
+	if got := NewAtomFromServer("count", 1).Get(); got != 1 {
+		t.Errorf("expected fallback for a value of the wrong type, got %v", got)
+	}
+	if got := NewAtomFromServer("missing", "none").Get(); got != "none" {
+		t.Errorf("expected fallback for a missing key, got %v", got)
+	}
+
+	// The client router swaps in the next page's state.
+	el.Set("textContent", `{"user":{"name":"Grace"}}`)
+	if got := NewAtomFromServer("user", user{}).Get(); got.Name != "Grace" {
+		t.Errorf("expected the swapped-in state, got %+v", got)
+	}
+}
@@ -0,0 +1,358 @@
+package template
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/withgalaxy/galaxy/pkg/executor"
+)
+
+var (
+	identRegex    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	htmlExprRegex = regexp.MustCompile(`^\{@html\s+([^}]+)\}$`)
+)
+
+// CompileOptions configures the Go code produced by Compile.
+type CompileOptions struct {
+	// Builder names the *strings.Builder the generated code writes to.
+	Builder string
+	// Vars maps template identifiers to Go expressions in scope where the
+	// generated code runs.
+	Vars map[string]string
+	// Fallback is a map[string]interface{} expression consulted at render
+	// time for identifiers missing from Vars. When empty, such identifiers
+	// render literally.
+	Fallback string
+}
+
+// Compile translates template into Go statements that write the rendered
+// page to opts.Builder. The generated code refers to this package as
+// "template". For values matching an Engine's context the output matches
+// Engine.Render, except that directives nested inside galaxy:for are
+// evaluated once per item. Only the template's structure is compiled:
+// galaxy:for lists, struct fields and method calls are still resolved at
+// render time by the reflection helpers in values.go, and component tags
+// are not expanded, so callers render pages that use components with an
+// Engine instead.
+func Compile(template string, opts CompileOptions) string {
+	c := &compiler{opts: opts, out: &strings.Builder{}}
+	c.markup(NewEngine(executor.NewContext()).renderSlots(template))
+	c.flush()
+	return c.out.String()
+}
+
+type loopVar struct {
+	name   string
+	goName string
+	used   bool
+}
+
+type compiler struct {
+	opts   CompileOptions
+	out    *strings.Builder
+	text   strings.Builder
+	indent int
+	loops  []*loopVar
+	count  int
+}
+
+func (c *compiler) line(format string, args ...interface{}) {
+	c.flush()
+	c.writeLine(fmt.Sprintf(format, args...))
+}
+
+func (c *compiler) writeLine(code string) {
+	c.out.WriteString(strings.Repeat("\t", c.indent))
+	c.out.WriteString(code)
+	c.out.WriteByte('\n')
+}
+
+func (c *compiler) flush() {
+	if c.text.Len() == 0 {
+		return
+	}
+	c.writeLine(c.opts.Builder + ".WriteString(" + strconv.Quote(c.text.String()) + ")")
+	c.text.Reset()
+}
+
+// dedent closes a block, writing out its pending text first.
+func (c *compiler) dedent() {
+	c.flush()
+	c.indent--
+}
+
+// capture returns the code fn generates instead of writing it out.
+func (c *compiler) capture(fn func()) string {
+	c.flush()
+	saved := c.out
+	c.out = &strings.Builder{}
+	fn()
+	c.flush()
+	code := c.out.String()
+	c.out = saved
+	return code
+}
+
+func (c *compiler) markup(s string) {
+	for s != "" {
+		branches, ifStart, ifEnd, isIf := findConditionalBlock(s, "galaxy:if")
+		tag, attrs, content, forStart, forEnd, isFor := findDirectiveElement(s, "galaxy:for")
+
+		switch {
+		case isIf && (!isFor || ifStart <= forStart):
+			c.markupText(s[:ifStart])
+			c.conditional(branches)
+			s = s[ifEnd:]
+		case isFor:
+			c.markupText(s[:forStart])
+			c.loop(tag, attrs, content)
+			s = s[forEnd:]
+		default:
+			c.markupText(s)
+			return
+		}
+	}
+}
+
+func element(tag, attrs, content string) string {
+	if attrs != "" {
+		return fmt.Sprintf("<%s %s>%s</%s>", tag, attrs, content, tag)
+	}
+	return fmt.Sprintf("<%s>%s</%s>", tag, content, tag)
+}
+
+func (c *compiler) conditional(branches []ConditionalBranch) {
+	for i, branch := range branches {
+		switch {
+		case i == 0:
+			c.line("if %s {", c.condition(branch.Condition))
+		case branch.Type == "else":
+			c.line("} else {")
+		default:
+			c.line("} else if %s {", c.condition(branch.Condition))
+		}
+		c.indent++
+		c.markup(element(branch.Tag, branch.Attrs, branch.Content))
+		c.dedent()
+	}
+	c.line("}")
+}
+
+func (c *compiler) loop(tag, attrs, content string) {
+	raw := fmt.Sprintf("<%s %s>%s</%s>", tag, attrs, content, tag)
+
+	forStart := strings.Index(attrs, "galaxy:for={")
+	if forStart == -1 {
+		c.markupText(raw)
+		return
+	}
+	forStart += len("galaxy:for={")
+	forEnd := strings.Index(attrs[forStart:], "}")
+	if forEnd == -1 {
+		c.markupText(raw)
+		return
+	}
+
+	parts := strings.Fields(attrs[forStart : forStart+forEnd])
+	if len(parts) < 3 || parts[1] != "in" {
+		c.markupText(raw)
+		return
+	}
+	otherAttrs := strings.TrimSpace(attrs[:forStart-len("galaxy:for={")] + attrs[forStart+forEnd+1:])
+
+	c.count++
+	items := fmt.Sprintf("galaxyItems%d", c.count)
+	item := &loopVar{name: parts[0], goName: fmt.Sprintf("galaxyItem%d", c.count)}
+
+	c.line("if %s := template.Items(%s); len(%s) > 0 {", items, c.value(parts[2]), items)
+	c.indent++
+	c.loops = append(c.loops, item)
+	c.indent++
+	body := c.capture(func() { c.markup(element(tag, otherAttrs, content)) })
+	c.dedent()
+	c.loops = c.loops[:len(c.loops)-1]
+	if item.used {
+		c.line("for _, %s := range %s {", item.goName, items)
+	} else {
+		c.line("for range %s {", items)
+	}
+	c.out.WriteString(body)
+	c.line("}")
+	c.dedent()
+
+	// Like the engine, a missing or empty list leaves the element as written.
+	c.line("} else {")
+	c.indent++
+	c.markupText(raw)
+	c.dedent()
+	c.line("}")
+}
+
+// resolve returns the Go expression for a template identifier and whether it
+// is always defined, as opposed to a fallback map lookup.
+func (c *compiler) resolve(name string) (expr string, defined bool, ok bool) {
+	for i := len(c.loops) - 1; i >= 0; i-- {
+		if c.loops[i].name == name {
+			c.loops[i].used = true
+			return c.loops[i].goName, true, true
+		}
+	}
+	if expr, ok := c.opts.Vars[name]; ok {
+		return expr, true, true
+	}
+	if c.opts.Fallback != "" && identRegex.MatchString(name) {
+		return fmt.Sprintf("%s[%q]", c.opts.Fallback, name), false, true
+	}
+	return "", false, false
+}
+
+// value returns a Go expression for a condition operand, mirroring
+// Engine.evaluateValue.
+func (c *compiler) value(expr string) string {
+	expr = strings.TrimSpace(expr)
+	if v, ok := parseLiteral(expr); ok {
+		switch v := v.(type) {
+		case string:
+			return strconv.Quote(v)
+		case int64:
+			return fmt.Sprintf("int64(%d)", v)
+		case float64:
+			if !math.IsInf(v, 0) && !math.IsNaN(v) {
+				return "float64(" + strconv.FormatFloat(v, 'g', -1, 64) + ")"
+			}
+		}
+	}
+	if goExpr, _, ok := c.resolve(expr); ok {
+		return goExpr
+	}
+	return "nil"
+}
+
+func (c *compiler) condition(condition string) string {
+	left, op, right := splitCondition(condition)
+	if op == "" {
+		return fmt.Sprintf("template.Truthy(%s)", c.value(left))
+	}
+
+	l, r := c.value(left), c.value(right)
+	switch op {
+	case "==":
+		return fmt.Sprintf("template.Equal(%s, %s)", l, r)
+	case "!=":
+		return fmt.Sprintf("!template.Equal(%s, %s)", l, r)
+	default:
+		return fmt.Sprintf("template.Compare(%s, %s) %s 0", l, r, op)
+	}
+}
+
+func (c *compiler) classCondition(condition string) string {
+	condition = strings.TrimSpace(condition)
+	if strings.HasPrefix(condition, "!") {
+		return fmt.Sprintf("!template.Truthy(%s)", c.value(condition[1:]))
+	}
+	return c.condition(condition)
+}
+
+// markupText compiles markup without directives.
+func (c *compiler) markupText(s string) {
+	for {
+		loc := classListTagRegex.FindStringSubmatchIndex(s)
+		if loc == nil {
+			c.expressions(s)
+			return
+		}
+		c.expressions(s[:loc[0]])
+		c.classList(s[loc[2]:loc[3]], s[loc[4]:loc[5]], s[loc[0]:loc[1]])
+		s = s[loc[1]:]
+	}
+}
+
+func (c *compiler) classList(tag, attrs, match string) {
+	a, ok := parseClassListAttrs(attrs)
+	if !ok {
+		c.expressions(match)
+		return
+	}
+
+	conditions := make([]string, len(a.conditions))
+	for i, condition := range a.conditions {
+		conditions[i] = ", " + c.classCondition(condition)
+	}
+
+	c.text.WriteString("<" + tag)
+	c.line("if classes := template.ClassList(%#v, %#v%s); classes != \"\" {", a.existing, a.names, strings.Join(conditions, ""))
+	c.line("\t%s.WriteString(\" class=\\\"\" + classes + \"\\\"\")", c.opts.Builder)
+	c.line("}")
+	if a.rest != "" {
+		c.expressions(" " + a.rest)
+	}
+	c.text.WriteString(">")
+}
+
+func (c *compiler) expressions(s string) {
+	last := 0
+	for _, loc := range expressionRegex.FindAllStringSubmatchIndex(s, -1) {
+		c.text.WriteString(s[last:loc[0]])
+		match := s[loc[0]:loc[1]]
+		c.expression(match, strings.TrimSpace(strings.Trim(match, "{}")))
+		last = loc[1]
+	}
+	c.text.WriteString(s[last:])
+}
+
+func (c *compiler) expression(match, expr string) {
+	if strings.HasPrefix(expr, "@html") {
+		m := htmlExprRegex.FindStringSubmatch(match)
+		if m == nil {
+			c.text.WriteString(match)
+			return
+		}
+		expr = strings.TrimSpace(m[1])
+		if goExpr, defined, ok := c.resolve(expr); ok {
+			c.lookup(goExpr, defined, match)
+			return
+		}
+		c.text.WriteString(match)
+		return
+	}
+
+	if goExpr, defined, ok := c.resolve(expr); ok {
+		c.lookup(goExpr, defined, match)
+		return
+	}
+
+	if strings.Contains(expr, ".") {
+		parts := strings.Split(expr, ".")
+		if root, _, ok := c.resolve(parts[0]); ok {
+			fields := make([]string, len(parts)-1)
+			for i, part := range parts[1:] {
+				fields[i] = strconv.Quote(part)
+			}
+			c.line("if s, ok := template.Field(%s, %s); ok {", root, strings.Join(fields, ", "))
+			c.line("\t%s.WriteString(s)", c.opts.Builder)
+			c.line("} else {")
+			c.line("\t%s.WriteString(%q)", c.opts.Builder, match)
+			c.line("}")
+			return
+		}
+	}
+
+	c.text.WriteString(match)
+}
+
+// lookup writes the value of goExpr, or the original {expression} when a
+// fallback lookup misses.
+func (c *compiler) lookup(goExpr string, defined bool, match string) {
+	if defined {
+		c.line("%s.WriteString(template.Format(%s))", c.opts.Builder, goExpr)
+		return
+	}
+	c.line("if v, ok := %s; ok {", goExpr)
+	c.line("\t%s.WriteString(template.Format(v))", c.opts.Builder)
+	c.line("} else {")
+	c.line("\t%s.WriteString(%q)", c.opts.Builder, match)
+	c.line("}")
+}
@@ -0,0 +1,201 @@
+package template_test
+
+import (
+	"flag"
+	"go/format"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/withgalaxy/galaxy/pkg/executor"
+	"github.com/withgalaxy/galaxy/pkg/template"
+)
+
+var update = flag.Bool("update", false, "rewrite compiled_fixture_test.go")
+
+type author struct {
+	Name string
+}
+
+type post struct {
+	Title  string
+	Slug   string
+	Author *author
+	Draft  bool
+}
+
+type account struct {
+	Name  string
+	Admin bool
+}
+
+func (a account) Greeting(prefix string) string {
+	return prefix + ", " + a.Name
+}
+
+const benchPage = `<html>
+<head><title>{title}</title></head>
+<body>
+	<nav classList={{"admin": admin, "guest": !signedIn}} class="nav">
+		<a href="/">{siteName}</a>
+		<span>{user.Greeting("Hello")}</span>
+	</nav>
+	<div galaxy:if={count > 2}><p>Many posts</p></div>
+	<div galaxy:elsif={count == 1}><p>One post</p></div>
+	<div galaxy:else><p>No posts</p></div>
+	<ul>
+		<li galaxy:for={p in posts} class="post"><a href="/blog/{p.Slug}">{p.Title}</a> by {p.Author.Name}</li>
+	</ul>
+	<ol><li galaxy:for={t in tags}>{t}</li></ol>
+	<article>{@html body}</article>
+	<footer>{missing} {user.Missing} <slot name="footer">Default footer</slot></footer>
+	<script>function greet() { return 1; }</script>
+</body>
+</html>`
+
+var benchPosts = []post{
+	{Title: "First", Slug: "first", Author: &author{Name: "Ada"}},
+	{Title: "Second", Slug: "second", Author: &author{Name: "Grace"}},
+	{Title: "Third", Slug: "third", Author: &author{Name: "Linus"}, Draft: true},
+}
+
+func benchVars() map[string]interface{} {
+	return map[string]interface{}{
+		"siteName": "Galaxy",
+		"admin":    true,
+		"signedIn": false,
+		"body":     "<p>Rendered <em>markdown</em></p>",
+	}
+}
+
+const fixtureHeader = `// Code generated by "go test ./pkg/template -run TestCompiledFixture -update"; DO NOT EDIT.
+
+package template_test
+
+import (
+	"strings"
+
+	"github.com/withgalaxy/galaxy/pkg/template"
+)
+
+func renderBenchPage(b *strings.Builder, vars map[string]interface{}, title string, user account, count int64, posts []post, tags []string) {
+`
+
+func compileBenchPage() string {
+	return template.Compile(benchPage, template.CompileOptions{
+		Builder: "b",
+		Vars: map[string]string{
+			"title": "title",
+			"user":  "user",
+			"count": "count",
+			"posts": "posts",
+			"tags":  "tags",
+		},
+		Fallback: "vars",
+	})
+}
+
+func TestCompiledFixture(t *testing.T) {
+	src, err := format.Source([]byte(fixtureHeader + compileBenchPage() + "}\n"))
+	if err != nil {
+		t.Fatalf("generated code does not compile: %v", err)
+	}
+
+	if *update {
+		if err := os.WriteFile("compiled_fixture_test.go", src, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	existing, err := os.ReadFile("compiled_fixture_test.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(existing) != string(src) {
+		t.Error("compiled_fixture_test.go is out of date; rerun with -update")
+	}
+}
+
+func renderEngine(title string, user account, count int64, posts []post, tags []string) string {
+	ctx := executor.NewContext()
+	for k, v := range benchVars() {
+		ctx.Set(k, v)
+	}
+	ctx.Set("title", title)
+	ctx.Set("user", user)
+	ctx.Set("count", count)
+	ctx.Set("posts", posts)
+	ctx.Set("tags", tags)
+
+	html, _ := template.NewEngine(ctx).Render(benchPage, nil)
+	return html
+}
+
+func renderCompiled(title string, user account, count int64, posts []post, tags []string) string {
+	var b strings.Builder
+	renderBenchPage(&b, benchVars(), title, user, count, posts, tags)
+	return b.String()
+}
+
+func TestCompiledMatchesEngine(t *testing.T) {
+	tests := []struct {
+		name  string
+		user  account
+		count int64
+		posts []post
+		tags  []string
+	}{
+		{"many posts", account{Name: "Ada", Admin: true}, 3, benchPosts, []string{"go", "web"}},
+		{"one post", account{Name: "Grace"}, 1, benchPosts[:1], []string{"go"}},
+		{"empty lists", account{}, 0, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := renderEngine("Blog", tt.user, tt.count, tt.posts, tt.tags)
+			got := renderCompiled("Blog", tt.user, tt.count, tt.posts, tt.tags)
+			if got != want {
+				t.Errorf("compiled output differs from engine\ncompiled:\n%s\nengine:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestCompile_Output(t *testing.T) {
+	code := compileBenchPage()
+
+	for _, want := range []string{
+		`template.Compare(count, int64(2)) > 0`,
+		`for _, galaxyItem1 := range galaxyItems1 {`,
+		`template.Field(galaxyItem1, "Author", "Name")`,
+		`if v, ok := vars["siteName"]; ok {`,
+		`b.WriteString("{missing}")`,
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q\n%s", want, code)
+		}
+	}
+	if strings.Contains(code, "slot") {
+		t.Error("slots should be resolved at compile time")
+	}
+}
+
+func BenchmarkRender(b *testing.B) {
+	user := account{Name: "Ada", Admin: true}
+	tags := []string{"go", "web"}
+
+	b.Run("engine", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			renderEngine("Blog", user, 3, benchPosts, tags)
+		}
+	})
+
+	b.Run("compiled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			renderCompiled("Blog", user, 3, benchPosts, tags)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+// Code generated by "go test ./pkg/template -run TestCompiledFixture -update"; DO NOT EDIT.
+
+package template_test
+
+import (
+	"strings"
+
+	"github.com/withgalaxy/galaxy/pkg/template"
+)
+
+func renderBenchPage(b *strings.Builder, vars map[string]interface{}, title string, user account, count int64, posts []post, tags []string) {
+	b.WriteString("<html>\n<head><title>")
+	b.WriteString(template.Format(title))
+	b.WriteString("</title></head>\n<body>\n\t<nav")
+	if classes := template.ClassList([]string{"nav"}, []string{"admin", "guest"}, template.Truthy(vars["admin"]), !template.Truthy(vars["signedIn"])); classes != "" {
+		b.WriteString(" class=\"" + classes + "\"")
+	}
+	b.WriteString(">\n\t\t<a href=\"/\">")
+	if v, ok := vars["siteName"]; ok {
+		b.WriteString(template.Format(v))
+	} else {
+		b.WriteString("{siteName}")
+	}
+	b.WriteString("</a>\n\t\t<span>")
+	if s, ok := template.Field(user, "Greeting(\"Hello\")"); ok {
+		b.WriteString(s)
+	} else {
+		b.WriteString("{user.Greeting(\"Hello\")}")
+	}
+	b.WriteString("</span>\n\t</nav>\n\t")
+	if template.Compare(count, int64(2)) > 0 {
+		b.WriteString("<div><p>Many posts</p></div>")
+	} else if template.Equal(count, int64(1)) {
+		b.WriteString("<div><p>One post</p></div>")
+	} else {
+		b.WriteString("<div><p>No posts</p></div>")
+	}
+	b.WriteString("\n\t<ul>\n\t\t")
+	if galaxyItems1 := template.Items(posts); len(galaxyItems1) > 0 {
+		for _, galaxyItem1 := range galaxyItems1 {
+			b.WriteString("<li class=\"post\"><a href=\"/blog/")
+			if s, ok := template.Field(galaxyItem1, "Slug"); ok {
+				b.WriteString(s)
+			} else {
+				b.WriteString("{p.Slug}")
+			}
+			b.WriteString("\">")
+			if s, ok := template.Field(galaxyItem1, "Title"); ok {
+				b.WriteString(s)
+			} else {
+				b.WriteString("{p.Title}")
+			}
+			b.WriteString("</a> by ")
+			if s, ok := template.Field(galaxyItem1, "Author", "Name"); ok {
+				b.WriteString(s)
+			} else {
+				b.WriteString("{p.Author.Name}")
+			}
+			b.WriteString("</li>")
+		}
+	} else {
+		b.WriteString("<li galaxy:for={p in posts} class=\"post\"><a href=\"/blog/")
+		if s, ok := template.Field(vars["p"], "Slug"); ok {
+			b.WriteString(s)
+		} else {
+			b.WriteString("{p.Slug}")
+		}
+		b.WriteString("\">")
+		if s, ok := template.Field(vars["p"], "Title"); ok {
+			b.WriteString(s)
+		} else {
+			b.WriteString("{p.Title}")
+		}
+		b.WriteString("</a> by ")
+		if s, ok := template.Field(vars["p"], "Author", "Name"); ok {
+			b.WriteString(s)
+		} else {
+			b.WriteString("{p.Author.Name}")
+		}
+		b.WriteString("</li>")
+	}
+	b.WriteString("\n\t</ul>\n\t<ol>")
+	if galaxyItems2 := template.Items(tags); len(galaxyItems2) > 0 {
+		for _, galaxyItem2 := range galaxyItems2 {
+			b.WriteString("<li>")
+			b.WriteString(template.Format(galaxyItem2))
+			b.WriteString("</li>")
+		}
+	} else {
+		b.WriteString("<li galaxy:for={t in tags}>")
+		if v, ok := vars["t"]; ok {
+			b.WriteString(template.Format(v))
+		} else {
+			b.WriteString("{t}")
+		}
+		b.WriteString("</li>")
+	}
+	b.WriteString("</ol>\n\t<article>")
+	if v, ok := vars["body"]; ok {
+		b.WriteString(template.Format(v))
+	} else {
+		b.WriteString("{@html body}")
+	}
+	b.WriteString("</article>\n\t<footer>")
+	if v, ok := vars["missing"]; ok {
+		b.WriteString(template.Format(v))
+	} else {
+		b.WriteString("{missing}")
+	}
+	b.WriteString(" ")
+	if s, ok := template.Field(user, "Missing"); ok {
+		b.WriteString(s)
+	} else {
+		b.WriteString("{user.Missing}")
+	}
+	b.WriteString(" Default footer</footer>\n\t<script>function greet() { return 1; }</script>\n</body>\n</html>")
+}
@@ -2,7 +2,6 @@ package template
 
 import (
 	"fmt"
-	"reflect"
 	"regexp"
 	"strings"
 
@@ -75,11 +74,11 @@ func (e *Engine) renderHtmlExpressions(template string) string {
 		varName := strings.TrimSpace(matches[1])
 
 		if val, ok := e.ctx.Get(varName); ok {
-			return fmt.Sprintf("%v", val)
+			return Format(val)
 		}
 
 		if val, ok := e.ctx.GetProp(varName); ok {
-			return fmt.Sprintf("%v", val)
+			return Format(val)
 		}
 
 		return match
@@ -97,11 +96,11 @@ func (e *Engine) renderExpressions(template string) string {
 		}
 
 		if val, ok := e.ctx.Get(expr); ok {
-			return fmt.Sprintf("%v", val)
+			return Format(val)
 		}
 
 		if val, ok := e.ctx.GetProp(expr); ok {
-			return fmt.Sprintf("%v", val)
+			return Format(val)
 		}
 
 		if strings.Contains(expr, ".") {
@@ -547,34 +546,7 @@ func (e *Engine) renderForDirective(template string) string {
 		arrayName := parts[2]
 
 		if val, ok := e.ctx.Get(arrayName); ok {
-			var items []interface{}
-
-			switch v := val.(type) {
-			case []interface{}:
-				items = v
-			case []string:
-				items = make([]interface{}, len(v))
-				for i, s := range v {
-					items[i] = s
-				}
-			case []int:
-				items = make([]interface{}, len(v))
-				for i, n := range v {
-					items[i] = n
-				}
-			default:
-				// Use reflection to handle any slice type ([]MyStruct, []*MyStruct, etc.)
-				rv := reflect.ValueOf(val)
-				if rv.Kind() == reflect.Slice {
-					items = make([]interface{}, rv.Len())
-					for i := 0; i < rv.Len(); i++ {
-						items[i] = rv.Index(i).Interface()
-					}
-				} else {
-					return fmt.Sprintf("<%s %s>%s</%s>", tag, attrs, content, tag)
-				}
-			}
-
+			items := Items(val)
 			if len(items) > 0 {
 				var result strings.Builder
 
@@ -603,83 +575,51 @@ func (e *Engine) renderForDirective(template string) string {
 	})
 }
 
-func (e *Engine) evaluateCondition(condition string) bool {
-	condition = strings.TrimSpace(condition)
-
-	// Check two-char operators first (order matters!)
-	if strings.Contains(condition, "==") {
-		parts := strings.SplitN(condition, "==", 2)
-		left := e.evaluateValue(strings.TrimSpace(parts[0]))
-		right := e.evaluateValue(strings.TrimSpace(parts[1]))
-		return e.compareEqual(left, right)
-	}
-
-	if strings.Contains(condition, "!=") {
-		parts := strings.SplitN(condition, "!=", 2)
-		left := e.evaluateValue(strings.TrimSpace(parts[0]))
-		right := e.evaluateValue(strings.TrimSpace(parts[1]))
-		return !e.compareEqual(left, right)
-	}
-
-	if strings.Contains(condition, ">=") {
-		parts := strings.SplitN(condition, ">=", 2)
-		left := e.evaluateValue(strings.TrimSpace(parts[0]))
-		right := e.evaluateValue(strings.TrimSpace(parts[1]))
-		cmp := e.compareValues(left, right)
-		return cmp >= 0
-	}
-
-	if strings.Contains(condition, "<=") {
-		parts := strings.SplitN(condition, "<=", 2)
-		left := e.evaluateValue(strings.TrimSpace(parts[0]))
-		right := e.evaluateValue(strings.TrimSpace(parts[1]))
-		cmp := e.compareValues(left, right)
-		return cmp <= 0
-	}
+// conditionOperators are checked in order, so two-char operators win.
+var conditionOperators = []string{"==", "!=", ">=", "<=", ">", "<"}
 
-	// Check single-char operators after two-char
-	if strings.Contains(condition, ">") {
-		parts := strings.SplitN(condition, ">", 2)
-		left := e.evaluateValue(strings.TrimSpace(parts[0]))
-		right := e.evaluateValue(strings.TrimSpace(parts[1]))
-		cmp := e.compareValues(left, right)
-		return cmp > 0
+// splitCondition splits a galaxy:if condition at its comparison operator. op
+// is empty for a plain value.
+func splitCondition(condition string) (left, op, right string) {
+	condition = strings.TrimSpace(condition)
+	for _, op := range conditionOperators {
+		if l, r, ok := strings.Cut(condition, op); ok {
+			return strings.TrimSpace(l), op, strings.TrimSpace(r)
+		}
 	}
+	return condition, "", ""
+}
 
-	if strings.Contains(condition, "<") {
-		parts := strings.SplitN(condition, "<", 2)
-		left := e.evaluateValue(strings.TrimSpace(parts[0]))
-		right := e.evaluateValue(strings.TrimSpace(parts[1]))
-		cmp := e.compareValues(left, right)
-		return cmp < 0
+func (e *Engine) evaluateCondition(condition string) bool {
+	left, op, right := splitCondition(condition)
+	if op == "" {
+		// Simple variable lookup
+		return Truthy(e.evaluateValue(left))
+	}
+
+	l := e.evaluateValue(left)
+	r := e.evaluateValue(right)
+	switch op {
+	case "==":
+		return Equal(l, r)
+	case "!=":
+		return !Equal(l, r)
+	case ">=":
+		return Compare(l, r) >= 0
+	case "<=":
+		return Compare(l, r) <= 0
+	case ">":
+		return Compare(l, r) > 0
+	default:
+		return Compare(l, r) < 0
 	}
-
-	// Simple variable lookup
-	val := e.evaluateValue(condition)
-	return e.isTruthy(val)
 }
 
 func (e *Engine) evaluateValue(expr string) interface{} {
 	expr = strings.TrimSpace(expr)
 
-	// String literal
-	if (strings.HasPrefix(expr, "\"") && strings.HasSuffix(expr, "\"")) ||
-		(strings.HasPrefix(expr, "'") && strings.HasSuffix(expr, "'")) {
-		return expr[1 : len(expr)-1]
-	}
-
-	// Number literal
-	if len(expr) > 0 && (expr[0] >= '0' && expr[0] <= '9') || expr[0] == '-' {
-		if val, err := fmt.Sscanf(expr, "%d", new(int64)); err == nil && val == 1 {
-			var num int64
-			fmt.Sscanf(expr, "%d", &num)
-			return num
-		}
-		if val, err := fmt.Sscanf(expr, "%f", new(float64)); err == nil && val == 1 {
-			var num float64
-			fmt.Sscanf(expr, "%f", &num)
-			return num
-		}
+	if val, ok := parseLiteral(expr); ok {
+		return val
 	}
 
 	// Variable lookup
@@ -690,90 +630,6 @@ func (e *Engine) evaluateValue(expr string) interface{} {
 	return nil
 }
 
-func (e *Engine) isTruthy(val interface{}) bool {
-	if val == nil {
-		return false
-	}
-
-	switch v := val.(type) {
-	case bool:
-		return v
-	case int64:
-		return v != 0
-	case float64:
-		return v != 0
-	case string:
-		return v != ""
-	case []interface{}:
-		return len(v) > 0
-	default:
-		return true
-	}
-}
-
-func (e *Engine) compareEqual(left, right interface{}) bool {
-	if left == nil || right == nil {
-		return left == right
-	}
-
-	// Same type comparison
-	switch l := left.(type) {
-	case string:
-		if r, ok := right.(string); ok {
-			return l == r
-		}
-	case int64:
-		if r, ok := right.(int64); ok {
-			return l == r
-		}
-		if r, ok := right.(float64); ok {
-			return float64(l) == r
-		}
-	case float64:
-		if r, ok := right.(float64); ok {
-			return l == r
-		}
-		if r, ok := right.(int64); ok {
-			return l == float64(r)
-		}
-	case bool:
-		if r, ok := right.(bool); ok {
-			return l == r
-		}
-	}
-
-	return false
-}
-
-func (e *Engine) compareValues(left, right interface{}) int {
-	// Compare numerics
-	leftNum := e.toNumber(left)
-	rightNum := e.toNumber(right)
-
-	if leftNum < rightNum {
-		return -1
-	}
-	if leftNum > rightNum {
-		return 1
-	}
-	return 0
-}
-
-func (e *Engine) toNumber(val interface{}) float64 {
-	switch v := val.(type) {
-	case int64:
-		return float64(v)
-	case float64:
-		return v
-	case string:
-		var num float64
-		fmt.Sscanf(v, "%f", &num)
-		return num
-	default:
-		return 0
-	}
-}
-
 func ParseAttributes(attrString string) map[string]interface{} {
 	attrs := make(map[string]interface{})
 
@@ -799,207 +655,97 @@ func (e *Engine) evaluateExpression(expr string) (string, bool) {
 		return "", false
 	}
 
-	varName := parts[0]
-	val, ok := e.ctx.Get(varName)
+	val, ok := e.ctx.Get(parts[0])
 	if !ok {
 		return "", false
 	}
 
-	methodCall := parts[1]
-
-	// Check if it's a method call (has parentheses)
-	if strings.Contains(methodCall, "(") && strings.HasSuffix(methodCall, ")") {
-		// Extract method name and arguments
-		openParen := strings.Index(methodCall, "(")
-		methodName := methodCall[:openParen]
-		argsStr := methodCall[openParen+1 : len(methodCall)-1]
-
-		// Try reflection-based method invocation
-		v := reflect.ValueOf(val)
-		method := v.MethodByName(methodName)
-		if method.IsValid() {
-			// Parse arguments
-			var args []reflect.Value
-			if argsStr != "" {
-				// Simple string argument parsing (quoted strings)
-				argParts := strings.Split(argsStr, ",")
-				for _, arg := range argParts {
-					arg = strings.TrimSpace(arg)
-					// Remove quotes
-					arg = strings.Trim(arg, "\"'")
-					args = append(args, reflect.ValueOf(arg))
-				}
-			}
+	return Field(val, parts[1:]...)
+}
 
-			// Call method
-			results := method.Call(args)
-			if len(results) > 0 {
-				return fmt.Sprintf("%v", results[0].Interface()), true
-			}
-		}
-	} else if strings.HasSuffix(methodCall, "()") {
-		methodName := strings.TrimSuffix(methodCall, "()")
-
-		if reqCtx, ok := val.(interface {
-			Path() string
-			Method() string
-			URL() string
-		}); ok {
-			switch methodName {
-			case "Path":
-				return reqCtx.Path(), true
-			case "Method":
-				return reqCtx.Method(), true
-			case "URL":
-				return reqCtx.URL(), true
-			}
-		}
-	} else {
-		if m, ok := val.(map[string]interface{}); ok {
-			property := parts[1]
-			if propVal, ok := m[property]; ok {
-				return fmt.Sprintf("%v", propVal), true
-			}
-		}
-		if m, ok := val.(map[string]any); ok {
-			property := parts[1]
-			if propVal, ok := m[property]; ok {
-				return fmt.Sprintf("%v", propVal), true
-			}
-		}
+var (
+	classListTagRegex  = regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9]*)\s+([^>]*classList=\{\{[^}]+\}\}[^>]*)>`)
+	classListRegex     = regexp.MustCompile(`classList=\{\{([^}]+)\}\}`)
+	existingClassRegex = regexp.MustCompile(`class="([^"]*)"`)
+	classPairRegex     = regexp.MustCompile(`"([^"]+)"\s*:\s*([^,}]+)`)
+)
 
-		// Try reflection for struct fields (handle multi-level: project.Owner.Name)
-		v := reflect.ValueOf(val)
+// classListAttrs is the attribute list of a tag using classList={{...}},
+// split into the static classes, the conditional ones and everything else.
+type classListAttrs struct {
+	existing   []string
+	names      []string
+	conditions []string
+	rest       string
+}
 
-		// Navigate through all field parts (parts[1], parts[2], etc.)
-		for i := 1; i < len(parts); i++ {
-			// Dereference pointers
-			for v.Kind() == reflect.Ptr {
-				if v.IsNil() {
-					return "", false
-				}
-				v = v.Elem()
-			}
+func parseClassListAttrs(attrs string) (classListAttrs, bool) {
+	classListMatches := classListRegex.FindStringSubmatch(attrs)
+	if len(classListMatches) < 2 {
+		return classListAttrs{}, false
+	}
 
-			if v.Kind() == reflect.Struct {
-				field := v.FieldByName(parts[i])
-				if !field.IsValid() {
-					return "", false
-				}
-				v = field
-			} else {
-				return "", false
-			}
-		}
+	var c classListAttrs
+	for _, match := range classPairRegex.FindAllStringSubmatch(classListMatches[1], -1) {
+		c.names = append(c.names, match[1])
+		c.conditions = append(c.conditions, strings.TrimSpace(match[2]))
+	}
+
+	existingMatches := existingClassRegex.FindStringSubmatch(attrs)
+	if len(existingMatches) > 1 {
+		c.existing = strings.Fields(existingMatches[1])
+	}
 
-		return fmt.Sprintf("%v", v.Interface()), true
+	rest := classListRegex.ReplaceAllString(attrs, "")
+	if len(existingMatches) > 0 {
+		rest = existingClassRegex.ReplaceAllString(rest, "")
 	}
+	c.rest = strings.TrimSpace(rest)
 
-	return "", false
+	return c, true
 }
 
 func (e *Engine) processClassList(template string) string {
-	tagRegex := regexp.MustCompile(`<([a-zA-Z][a-zA-Z0-9]*)\s+([^>]*classList=\{\{[^}]+\}\}[^>]*)>`)
-
-	return tagRegex.ReplaceAllStringFunc(template, func(match string) string {
-		matches := tagRegex.FindStringSubmatch(match)
+	return classListTagRegex.ReplaceAllStringFunc(template, func(match string) string {
+		matches := classListTagRegex.FindStringSubmatch(match)
 		if len(matches) < 3 {
 			return match
 		}
 
 		tagName := matches[1]
-		attrs := matches[2]
-
-		classListRegex := regexp.MustCompile(`classList=\{\{([^}]+)\}\}`)
-		classListMatches := classListRegex.FindStringSubmatch(attrs)
-		if len(classListMatches) < 2 {
+		attrs, ok := parseClassListAttrs(matches[2])
+		if !ok {
 			return match
 		}
 
-		classListContent := classListMatches[1]
-		classListClasses := e.parseClassList(classListContent)
-
-		existingClassRegex := regexp.MustCompile(`class="([^"]*)"`)
-		existingMatches := existingClassRegex.FindStringSubmatch(attrs)
-
-		var allClasses []string
-		if len(existingMatches) > 1 && existingMatches[1] != "" {
-			existingClasses := strings.Fields(existingMatches[1])
-			allClasses = append(allClasses, existingClasses...)
+		on := make([]bool, len(attrs.conditions))
+		for i, condition := range attrs.conditions {
+			on[i] = e.evaluateClassCondition(condition)
 		}
 
-		classMap := make(map[string]bool)
-		for _, c := range allClasses {
-			classMap[c] = true
-		}
-		for _, c := range classListClasses {
-			if !classMap[c] {
-				allClasses = append(allClasses, c)
-				classMap[c] = true
-			}
-		}
-
-		newAttrs := classListRegex.ReplaceAllString(attrs, "")
-		if len(existingMatches) > 0 {
-			newAttrs = existingClassRegex.ReplaceAllString(newAttrs, "")
-		}
-
-		newAttrs = strings.TrimSpace(newAttrs)
-
-		if len(allClasses) > 0 {
-			classAttr := fmt.Sprintf(`class="%s"`, strings.Join(allClasses, " "))
-			if newAttrs != "" {
-				return fmt.Sprintf("<%s %s %s>", tagName, classAttr, newAttrs)
+		if classes := ClassList(attrs.existing, attrs.names, on...); classes != "" {
+			classAttr := fmt.Sprintf(`class="%s"`, classes)
+			if attrs.rest != "" {
+				return fmt.Sprintf("<%s %s %s>", tagName, classAttr, attrs.rest)
 			}
 			return fmt.Sprintf("<%s %s>", tagName, classAttr)
 		}
 
-		if newAttrs != "" {
-			return fmt.Sprintf("<%s %s>", tagName, newAttrs)
+		if attrs.rest != "" {
+			return fmt.Sprintf("<%s %s>", tagName, attrs.rest)
 		}
 		return fmt.Sprintf("<%s>", tagName)
 	})
 }
 
-func (e *Engine) parseClassList(content string) []string {
-	var classes []string
-	pairRegex := regexp.MustCompile(`"([^"]+)"\s*:\s*([^,}]+)`)
-	matches := pairRegex.FindAllStringSubmatch(content, -1)
-
-	for _, match := range matches {
-		if len(match) < 3 {
-			continue
-		}
-
-		className := match[1]
-		condition := strings.TrimSpace(match[2])
-
-		if e.evaluateClassCondition(condition) {
-			classes = append(classes, className)
-		}
-	}
-
-	return classes
-}
-
 func (e *Engine) evaluateClassCondition(condition string) bool {
 	condition = strings.TrimSpace(condition)
 
 	if strings.HasPrefix(condition, "!") {
 		varName := strings.TrimSpace(condition[1:])
 		val := e.evaluateValue(varName)
-		return !e.isTruthy(val)
-	}
-
-	if strings.Contains(condition, "==") ||
-		strings.Contains(condition, "!=") ||
-		strings.Contains(condition, ">=") ||
-		strings.Contains(condition, "<=") ||
-		strings.Contains(condition, ">") ||
-		strings.Contains(condition, "<") {
-		return e.evaluateCondition(condition)
+		return !Truthy(val)
 	}
 
-	val := e.evaluateValue(condition)
-	return e.isTruthy(val)
+	return e.evaluateCondition(condition)
 }
@@ -0,0 +1,271 @@
+package template
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// The helpers in this file hold the value semantics shared by Engine and the
+// Go code produced by Compile, so both render the same output.
+
+// Format returns v as it appears in rendered output.
+func Format(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return fmt.Sprint(v)
+}
+
+// Truthy reports whether v counts as true in galaxy:if and classList.
+func Truthy(val interface{}) bool {
+	if val == nil {
+		return false
+	}
+
+	switch v := normalize(val).(type) {
+	case bool:
+		return v
+	case int64:
+		return v != 0
+	case float64:
+		return v != 0
+	case string:
+		return v != ""
+	case []interface{}:
+		return len(v) > 0
+	default:
+		return true
+	}
+}
+
+// Equal compares two template values for == and !=.
+func Equal(left, right interface{}) bool {
+	if left == nil || right == nil {
+		return left == right
+	}
+	left, right = normalize(left), normalize(right)
+
+	// Same type comparison
+	switch l := left.(type) {
+	case string:
+		if r, ok := right.(string); ok {
+			return l == r
+		}
+	case int64:
+		if r, ok := right.(int64); ok {
+			return l == r
+		}
+		if r, ok := right.(float64); ok {
+			return float64(l) == r
+		}
+	case float64:
+		if r, ok := right.(float64); ok {
+			return l == r
+		}
+		if r, ok := right.(int64); ok {
+			return l == float64(r)
+		}
+	case bool:
+		if r, ok := right.(bool); ok {
+			return l == r
+		}
+	}
+
+	return false
+}
+
+// Compare orders two template values numerically, returning -1, 0 or 1.
+func Compare(left, right interface{}) int {
+	leftNum := toNumber(left)
+	rightNum := toNumber(right)
+
+	if leftNum < rightNum {
+		return -1
+	}
+	if leftNum > rightNum {
+		return 1
+	}
+	return 0
+}
+
+func toNumber(val interface{}) float64 {
+	switch v := normalize(val).(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	case string:
+		var num float64
+		fmt.Sscanf(v, "%f", &num)
+		return num
+	default:
+		return 0
+	}
+}
+
+// normalize widens Go integer and float values to the int64 and float64 the
+// frontmatter executor produces, so compiled pages comparing native Go
+// variables behave the same.
+func normalize(val interface{}) interface{} {
+	switch v := val.(type) {
+	case int:
+		return int64(v)
+	case int32:
+		return int64(v)
+	case float32:
+		return float64(v)
+	}
+	return val
+}
+
+// Items returns the elements of a galaxy:for list, or nil when val is not a
+// slice.
+func Items(val interface{}) []interface{} {
+	switch v := val.(type) {
+	case []interface{}:
+		return v
+	case []string:
+		items := make([]interface{}, len(v))
+		for i, s := range v {
+			items[i] = s
+		}
+		return items
+	case []int:
+		items := make([]interface{}, len(v))
+		for i, n := range v {
+			items[i] = n
+		}
+		return items
+	}
+
+	// Use reflection to handle any slice type ([]MyStruct, []*MyStruct, etc.)
+	rv := reflect.ValueOf(val)
+	if rv.Kind() != reflect.Slice {
+		return nil
+	}
+	items := make([]interface{}, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		items[i] = rv.Index(i).Interface()
+	}
+	return items
+}
+
+// Field evaluates the dotted path after a variable in {item.Name},
+// {post.Owner.Name} or {value.Method("arg")}.
+func Field(val interface{}, parts ...string) (string, bool) {
+	if val == nil || len(parts) == 0 {
+		return "", false
+	}
+
+	methodCall := parts[0]
+
+	// Check if it's a method call (has parentheses)
+	if strings.Contains(methodCall, "(") && strings.HasSuffix(methodCall, ")") {
+		// Extract method name and arguments
+		openParen := strings.Index(methodCall, "(")
+		methodName := methodCall[:openParen]
+		argsStr := methodCall[openParen+1 : len(methodCall)-1]
+
+		// Try reflection-based method invocation
+		method := reflect.ValueOf(val).MethodByName(methodName)
+		if !method.IsValid() {
+			return "", false
+		}
+
+		// Parse arguments
+		var args []reflect.Value
+		if argsStr != "" {
+			// Simple string argument parsing (quoted strings)
+			for _, arg := range strings.Split(argsStr, ",") {
+				arg = strings.TrimSpace(arg)
+				// Remove quotes
+				arg = strings.Trim(arg, "\"'")
+				args = append(args, reflect.ValueOf(arg))
+			}
+		}
+
+		// Call method
+		results := method.Call(args)
+		if len(results) > 0 {
+			return fmt.Sprintf("%v", results[0].Interface()), true
+		}
+		return "", false
+	}
+
+	if m, ok := val.(map[string]interface{}); ok {
+		if propVal, ok := m[parts[0]]; ok {
+			return fmt.Sprintf("%v", propVal), true
+		}
+	}
+
+	// Try reflection for struct fields (handle multi-level: project.Owner.Name)
+	v := reflect.ValueOf(val)
+	for _, part := range parts {
+		// Dereference pointers
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return "", false
+			}
+			v = v.Elem()
+		}
+
+		if v.Kind() != reflect.Struct {
+			return "", false
+		}
+		field := v.FieldByName(part)
+		if !field.IsValid() {
+			return "", false
+		}
+		v = field
+	}
+
+	return fmt.Sprintf("%v", v.Interface()), true
+}
+
+// ClassList merges the classes from a class attribute with the classList
+// entries whose conditions hold.
+func ClassList(existing, names []string, on ...bool) string {
+	classes := append([]string(nil), existing...)
+	seen := make(map[string]bool, len(classes))
+	for _, c := range classes {
+		seen[c] = true
+	}
+	for i, c := range names {
+		if on[i] && !seen[c] {
+			classes = append(classes, c)
+			seen[c] = true
+		}
+	}
+	return strings.Join(classes, " ")
+}
+
+// parseLiteral returns the value of a quoted string or number literal in a
+// condition.
+func parseLiteral(expr string) (interface{}, bool) {
+	if len(expr) >= 2 && ((expr[0] == '"' && expr[len(expr)-1] == '"') ||
+		(expr[0] == '\'' && expr[len(expr)-1] == '\'')) {
+		return expr[1 : len(expr)-1], true
+	}
+
+	if expr != "" && (expr[0] >= '0' && expr[0] <= '9' || expr[0] == '-') {
+		var i int64
+		if n, err := fmt.Sscanf(expr, "%d", &i); err == nil && n == 1 {
+			return i, true
+		}
+		var f float64
+		if n, err := fmt.Sscanf(expr, "%f", &f); err == nil && n == 1 {
+			return f, true
+		}
+	}
+
+	return nil, false
+}
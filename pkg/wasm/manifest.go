@@ -3,6 +3,9 @@ package wasm
 import (
 	"encoding/json"
 	"os"
+	"path"
+	"path/filepath"
+	"strings"
 )
 
 type WasmManifest struct {
@@ -12,6 +15,8 @@ type WasmManifest struct {
 type WasmPageAssets struct {
 	WasmModules []WasmModule `json:"wasmModules"`
 	JSScripts   []string     `json:"jsScripts"`
+	// CSS holds the page's bundled stylesheets.
+	CSS []string `json:"css,omitempty"`
 }
 
 type WasmModule struct {
@@ -20,6 +25,34 @@ type WasmModule struct {
 	LoaderPath string `json:"loaderPath"`
 }
 
+// PageRoute is the route pattern, as the router writes it, of the page a
+// manifest key names: "pages/blog/[slug].gxc" is "/blog/[slug]".
+func PageRoute(key string) string {
+	route := strings.TrimPrefix(filepath.ToSlash(key), "pages/")
+	route = strings.TrimSuffix("/"+strings.TrimSuffix(route, path.Ext(route)), "/index")
+	if route == "" {
+		return "/"
+	}
+	return route
+}
+
+// Prefetch lists the files each page loads, by route pattern, so they can be
+// fetched before the page is visited.
+func (m *WasmManifest) Prefetch() map[string][]string {
+	routes := make(map[string][]string, len(m.Assets))
+	for key, page := range m.Assets {
+		files := append([]string{}, page.CSS...)
+		for _, mod := range page.WasmModules {
+			files = append(files, mod.LoaderPath, mod.WasmPath)
+		}
+		files = append(files, page.JSScripts...)
+		if len(files) > 0 {
+			routes[PageRoute(key)] = files
+		}
+	}
+	return routes
+}
+
 func NewManifest() *WasmManifest {
 	return &WasmManifest{
 		Assets: make(map[string]WasmPageAssets),
@@ -250,3 +250,50 @@ func TestWasmPageAssetsFields(t *testing.T) {
 		t.Errorf("Expected 1 JS script, got %d", len(assets.JSScripts))
 	}
 }
+
+func TestManifestPrefetch(t *testing.T) {
+	manifest := NewManifest()
+	manifest.Assets["pages/index.gxc"] = WasmPageAssets{
+		CSS: []string{"/_assets/index.css"},
+	}
+	manifest.Assets["pages/blog/[slug].gxc"] = WasmPageAssets{
+		WasmModules: []WasmModule{{WasmPath: "/_assets/wasm/post.wasm", LoaderPath: "/_assets/post-loader.js"}},
+		JSScripts:   []string{"/_assets/post.js"},
+		CSS:         []string{"/_assets/post.css"},
+	}
+	manifest.Assets["pages/docs/index.gxc"] = WasmPageAssets{}
+
+	routes := manifest.Prefetch()
+	if len(routes) != 2 {
+		t.Errorf("Expected pages without assets left out, got %v", routes)
+	}
+	if got := routes["/"]; len(got) != 1 || got[0] != "/_assets/index.css" {
+		t.Errorf("Expected the index page's stylesheet, got %v", got)
+	}
+	want := []string{"/_assets/post.css", "/_assets/post-loader.js", "/_assets/wasm/post.wasm", "/_assets/post.js"}
+	got := routes["/blog/[slug]"]
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v, got %v", want, got)
+			break
+		}
+	}
+}
+
+func TestPageRoute(t *testing.T) {
+	tests := map[string]string{
+		"pages/index.gxc":          "/",
+		"pages/about.gxc":          "/about",
+		"pages/docs/index.gxc":     "/docs",
+		"pages/blog/[slug].gxc":    "/blog/[slug]",
+		"pages/files/[...path].md": "/files/[...path]",
+	}
+	for key, want := range tests {
+		if got := PageRoute(key); got != want {
+			t.Errorf("PageRoute(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
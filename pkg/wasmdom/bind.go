@@ -0,0 +1,274 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"syscall/js"
+
+	"github.com/withgalaxy/galaxy/pkg/store"
+)
+
+// Binding keeps an element in step with a store. It is undone when the
+// element is removed from the document, when the HMR module tracking it is
+// disposed, or by Unbind.
+type Binding struct {
+	el        Element
+	unsubs    []store.Unsubscriber
+	listeners []boundListener
+	// cleanup runs on Unbind, after the store and listeners are let go.
+	cleanup func()
+	// connected is set once the element has been seen in the document, so
+	// elements bound before they are inserted are not undone right away.
+	connected bool
+	done      bool
+}
+
+type boundListener struct {
+	event string
+	fn    js.Func
+}
+
+var (
+	bindingsMu sync.Mutex
+	bindings   = make(map[*Binding]struct{})
+	observer   js.Value
+)
+
+// Unbind unsubscribes from the store and removes the binding's event
+// listeners.
+func (b *Binding) Unbind() {
+	bindingsMu.Lock()
+	if b.done {
+		bindingsMu.Unlock()
+		return
+	}
+	b.done = true
+	delete(bindings, b)
+	bindingsMu.Unlock()
+
+	for _, unsub := range b.unsubs {
+		unsub()
+	}
+	for _, l := range b.listeners {
+		b.el.Value.Call("removeEventListener", l.event, l.fn)
+		l.fn.Release()
+	}
+	if b.cleanup != nil {
+		b.cleanup()
+	}
+}
+
+// BindText sets the element's text to the store's value.
+func BindText[T any](el Element, s store.ReadableStore[T]) *Binding {
+	return bind(el, s, func(v T) {
+		el.SetTextContent(fmt.Sprint(v))
+	})
+}
+
+// BindAttr sets an attribute to the store's value. Boolean values add the
+// attribute when true and remove it when false.
+func BindAttr[T any](el Element, name string, s store.ReadableStore[T]) *Binding {
+	return bind(el, s, func(v T) {
+		if on, ok := any(v).(bool); ok {
+			if on {
+				el.SetAttribute(name, "")
+			} else {
+				el.RemoveAttribute(name)
+			}
+			return
+		}
+		el.SetAttribute(name, fmt.Sprint(v))
+	})
+}
+
+// BindClass adds the class while the store is true.
+func BindClass(el Element, class string, s store.ReadableStore[bool]) *Binding {
+	return bind(el, s, func(on bool) {
+		el.Value.Get("classList").Call("toggle", class, on)
+	})
+}
+
+// BindStyle sets a style property, such as "backgroundColor", to the
+// store's value.
+func BindStyle[T any](el Element, property string, s store.ReadableStore[T]) *Binding {
+	return bind(el, s, func(v T) {
+		el.SetStyle(property, fmt.Sprint(v))
+	})
+}
+
+// BindValue keeps an input's value and the store in step both ways.
+func BindValue(el Element, s store.Store[string]) *Binding {
+	b := bind[string](el, s, func(v string) {
+		// Leave the caret alone while the user types.
+		if el.GetValue() != v {
+			el.SetValue(v)
+		}
+	})
+	b.listen("input", func() {
+		s.Set(el.GetValue())
+	})
+	return b
+}
+
+// BindChecked keeps a checkbox or radio button and the store in step both
+// ways.
+func BindChecked(el Element, s store.Store[bool]) *Binding {
+	b := bind[bool](el, s, func(on bool) {
+		el.Value.Set("checked", on)
+	})
+	b.listen("change", func() {
+		s.Set(el.Value.Get("checked").Bool())
+	})
+	return b
+}
+
+// BindList renders the store's items as the children of parent, replacing
+// what it held. Items are matched to the elements rendered for them by
+// key, so only new and changed items are rendered again and the rest are
+// moved into place. When an item is removed or rendered again, and when the
+// list is unbound, the bindings and On listeners inside its old element are
+// undone with it, without waiting for a MutationObserver.
+func BindList[T any, K comparable](parent Element, s store.ReadableStore[[]T], key func(T) K, render func(T) Element) *Binding {
+	type rendered struct {
+		item T
+		el   Element
+	}
+	current := make(map[K]rendered)
+	parent.SetInnerHTML("")
+
+	b := bind(parent, s, func(items []T) {
+		next := make(map[K]rendered, len(items))
+		order := make([]Element, 0, len(items))
+		for _, item := range items {
+			k := key(item)
+			if _, dup := next[k]; dup {
+				ConsoleError(fmt.Sprintf("wasmdom: duplicate list key %v", k))
+				continue
+			}
+			r, ok := current[k]
+			if !ok || !reflect.DeepEqual(r.item, item) {
+				r = rendered{item: item, el: render(item)}
+			}
+			next[k] = r
+			order = append(order, r.el)
+		}
+
+		for k, r := range current {
+			if n, ok := next[k]; !ok || !n.el.Value.Equal(r.el.Value) {
+				r.el.Remove()
+				release(r.el)
+			}
+		}
+		nodes := parent.Value.Get("childNodes")
+		for i, el := range order {
+			if ref := nodes.Call("item", i); !ref.Equal(el.Value) {
+				parent.Value.Call("insertBefore", el.Value, ref)
+			}
+		}
+		current = next
+	})
+	b.cleanup = func() {
+		for _, r := range current {
+			release(r.el)
+		}
+	}
+	return b
+}
+
+// release undoes the bindings and removes the On listeners on el and its
+// descendants.
+func release(el Element) {
+	within := func(v js.Value) bool {
+		// Listeners can be on the window, which contains will not take.
+		if v.Equal(el.Value) || v.Get("nodeType").IsUndefined() {
+			return v.Equal(el.Value)
+		}
+		return el.Value.Call("contains", v).Bool()
+	}
+
+	var bound []*Binding
+	bindingsMu.Lock()
+	for b := range bindings {
+		if within(b.el.Value) {
+			bound = append(bound, b)
+		}
+	}
+	bindingsMu.Unlock()
+	for _, b := range bound {
+		b.Unbind()
+	}
+
+	var listeners []Listener
+	listenersMu.Lock()
+	for _, l := range liveListeners {
+		if within(l.target) {
+			listeners = append(listeners, l)
+		}
+	}
+	listenersMu.Unlock()
+	for _, l := range listeners {
+		l.Remove()
+	}
+}
+
+func bind[T any](el Element, s store.ReadableStore[T], apply func(T)) *Binding {
+	b := &Binding{el: el}
+	apply(s.Get())
+	b.unsubs = append(b.unsubs, s.Subscribe(apply))
+	watch(b)
+	return b
+}
+
+func (b *Binding) listen(event string, handler func()) {
+	fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		handler()
+		return nil
+	})
+	b.el.Value.Call("addEventListener", event, fn)
+	b.listeners = append(b.listeners, boundListener{event: event, fn: fn})
+}
+
+// watch undoes b once its element leaves the document.
+func watch(b *Binding) {
+	bindingsMu.Lock()
+	defer bindingsMu.Unlock()
+
+	b.connected = b.el.Value.Get("isConnected").Truthy()
+	bindings[b] = struct{}{}
+
+	if !observer.IsUndefined() {
+		return
+	}
+	ctor := js.Global().Get("MutationObserver")
+	doc := js.Global().Get("document")
+	if ctor.IsUndefined() || doc.IsUndefined() {
+		observer = js.Null()
+		return
+	}
+	observer = ctor.New(js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		sweepBindings()
+		return nil
+	}))
+	observer.Call("observe", doc, map[string]interface{}{"childList": true, "subtree": true})
+}
+
+func sweepBindings() {
+	var removed []*Binding
+	bindingsMu.Lock()
+	for b := range bindings {
+		if b.el.Value.Get("isConnected").Truthy() {
+			b.connected = true
+		} else if b.connected {
+			removed = append(removed, b)
+		}
+	}
+	bindingsMu.Unlock()
+
+	for _, b := range removed {
+		b.Unbind()
+	}
+}
@@ -0,0 +1,195 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import (
+	"syscall/js"
+	"testing"
+
+	"github.com/withgalaxy/galaxy/pkg/store"
+)
+
+// fakeNode is the subset of a DOM element the bindings use. The test
+// runtime has no document, and so no MutationObserver either.
+var fakeNode = evalJS(`class extends EventTarget {
+	constructor() {
+		super()
+		this.nodeType = 1
+		this.parent = null
+		this.kids = []
+		this.textContent = ""
+		this.attrs = {}
+	}
+	get isConnected() { return false }
+	get childNodes() {
+		const kids = this.kids
+		return {length: kids.length, item: i => kids[i] ?? null}
+	}
+	set innerHTML(html) {
+		this.kids.forEach(kid => kid.parent = null)
+		this.kids = []
+	}
+	insertBefore(node, ref) {
+		node.remove()
+		node.parent = this
+		const i = ref ? this.kids.indexOf(ref) : -1
+		if (i < 0) this.kids.push(node)
+		else this.kids.splice(i, 0, node)
+		return node
+	}
+	appendChild(node) { return this.insertBefore(node, null) }
+	remove() {
+		if (!this.parent) return
+		this.parent.kids.splice(this.parent.kids.indexOf(this), 1)
+		this.parent = null
+	}
+	contains(node) {
+		for (; node; node = node.parent) if (node === this) return true
+		return false
+	}
+	setAttribute(name, value) { this.attrs[name] = value }
+	removeAttribute(name) { delete this.attrs[name] }
+}`)
+
+func newNode() Element {
+	return Element{Value: fakeNode.New()}
+}
+
+func children(el Element) []string {
+	kids := el.Value.Get("kids")
+	texts := make([]string, kids.Length())
+	for i := range texts {
+		texts[i] = kids.Index(i).Get("textContent").String()
+	}
+	return texts
+}
+
+func TestBindTextAndAttr(t *testing.T) {
+	el := newNode()
+	count := store.NewAtom(1)
+	disabled := store.NewAtom(true)
+	text := BindText[int](el, count)
+	attr := BindAttr[bool](el, "disabled", disabled)
+
+	if el.GetTextContent() != "1" || el.Value.Get("attrs").Get("disabled").IsUndefined() {
+		t.Fatal("bindings should apply the initial values")
+	}
+	count.Set(2)
+	disabled.Set(false)
+	if el.GetTextContent() != "2" || !el.Value.Get("attrs").Get("disabled").IsUndefined() {
+		t.Error("bindings should follow the stores")
+	}
+
+	text.Unbind()
+	attr.Unbind()
+	count.Set(3)
+	if el.GetTextContent() != "2" {
+		t.Error("Unbind() should stop following the store")
+	}
+}
+
+func TestBindValue(t *testing.T) {
+	el := newNode()
+	el.Value.Set("value", "")
+	name := store.NewAtom("ada")
+	b := BindValue(el, name)
+	defer b.Unbind()
+
+	if el.GetValue() != "ada" {
+		t.Errorf("value = %q, want ada", el.GetValue())
+	}
+	el.Value.Set("value", "grace")
+	el.Value.Call("dispatchEvent", js.Global().Get("Event").New("input"))
+	if name.Get() != "grace" {
+		t.Errorf("store = %q, want the typed value", name.Get())
+	}
+}
+
+type listItem struct {
+	ID    int
+	Label string
+}
+
+func TestBindList(t *testing.T) {
+	parent := newNode()
+	items := store.NewAtom([]listItem{{1, "a"}, {2, "b"}, {3, "c"}})
+	renders := 0
+	b := BindList(parent, items, func(i listItem) int { return i.ID }, func(i listItem) Element {
+		renders++
+		el := newNode()
+		el.SetTextContent(i.Label)
+		return el
+	})
+	defer b.Unbind()
+
+	if got := children(parent); len(got) != 3 || got[0] != "a" || got[2] != "c" {
+		t.Fatalf("children = %v", got)
+	}
+	first := parent.Value.Get("kids").Index(0)
+
+	items.Set([]listItem{{3, "c"}, {1, "a"}, {4, "d"}})
+	if got := children(parent); len(got) != 3 || got[0] != "c" || got[1] != "a" || got[2] != "d" {
+		t.Fatalf("children = %v", got)
+	}
+	if renders != 4 {
+		t.Errorf("rendered %d times, want only the new item rendered", renders)
+	}
+	if !parent.Value.Get("kids").Index(1).Equal(first) {
+		t.Error("unchanged items should keep their element")
+	}
+}
+
+func TestBindListReleasesItems(t *testing.T) {
+	parent := newNode()
+	items := store.NewAtom([]listItem{{1, "a"}, {2, "b"}})
+	count := store.NewAtom(0)
+	clicks := map[int]int{}
+	els := map[string]Element{}
+	b := BindList(parent, items, func(i listItem) int { return i.ID }, func(i listItem) Element {
+		el := newNode()
+		badge := newNode()
+		el.Value.Call("appendChild", badge.Value)
+		BindText[int](badge, count)
+		el.OnClick(func(MouseEvent) { clicks[i.ID]++ })
+		els[i.Label] = el
+		return el
+	})
+
+	click := func(el Element) {
+		el.Value.Call("dispatchEvent", js.Global().Get("Event").New("click"))
+	}
+	badge := func(el Element) string {
+		return el.Value.Get("kids").Index(0).Get("textContent").String()
+	}
+
+	// Item 1 is removed and item 2 is rendered again.
+	items.Set([]listItem{{2, "B"}})
+	count.Set(1)
+	click(els["a"])
+	click(els["b"])
+	if badge(els["a"]) != "0" || badge(els["b"]) != "0" {
+		t.Error("bindings inside removed items should be undone")
+	}
+	if clicks[1] != 0 || clicks[2] != 0 {
+		t.Errorf("listeners inside removed items ran: %v", clicks)
+	}
+	click(els["B"])
+	if badge(els["B"]) != "1" || clicks[2] != 1 {
+		t.Error("the current item should stay bound")
+	}
+
+	b.Unbind()
+	count.Set(2)
+	click(els["B"])
+	if badge(els["B"]) != "1" || clicks[2] != 1 {
+		t.Error("Unbind() should undo the bindings inside the items")
+	}
+
+	listenersMu.Lock()
+	live := len(liveListeners)
+	listenersMu.Unlock()
+	if live != 0 {
+		t.Errorf("%d listeners left after Unbind()", live)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import (
+	"context"
+	"syscall/js"
+)
+
+// ClipboardWriteText copies text to the clipboard. Browsers only allow it
+// in response to a user gesture. Like Await, call it from a goroutine.
+func ClipboardWriteText(ctx context.Context, text string) error {
+	_, err := AwaitContext(ctx, clipboard().Call("writeText", text))
+	return err
+}
+
+// ClipboardReadText returns the clipboard's text, once the user allows it.
+// Like Await, call it from a goroutine.
+func ClipboardReadText(ctx context.Context) (string, error) {
+	text, err := AwaitContext(ctx, clipboard().Call("readText"))
+	if err != nil {
+		return "", err
+	}
+	return text.String(), nil
+}
+
+func clipboard() js.Value {
+	return js.Global().Get("navigator").Get("clipboard")
+}
@@ -0,0 +1,267 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import (
+	"encoding/json"
+	"sync"
+	"syscall/js"
+)
+
+// Listener is an event listener or observer added by one of the On methods.
+// Remove it, or pass it to HMRModule.TrackListeners, to clean it up.
+type Listener struct {
+	target js.Value
+	event  string
+	fn     js.Func
+	// stop disconnects observers, which are not event listeners.
+	stop func()
+	// id keys the listener in liveListeners.
+	id uint64
+}
+
+// liveListeners holds the event listeners not yet removed, so BindList can
+// remove those on the elements it drops.
+var (
+	listenersMu    sync.Mutex
+	liveListeners  = make(map[uint64]Listener)
+	nextListenerID uint64
+)
+
+// Remove stops the listener and releases its callback.
+func (l Listener) Remove() {
+	if l.stop != nil {
+		l.stop()
+	} else {
+		listenersMu.Lock()
+		delete(liveListeners, l.id)
+		listenersMu.Unlock()
+		l.target.Call("removeEventListener", l.event, l.fn)
+	}
+	l.fn.Release()
+}
+
+func listen(target js.Value, event string, handler func(js.Value)) Listener {
+	fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		handler(firstArg(args))
+		return nil
+	})
+	target.Call("addEventListener", event, fn)
+
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	nextListenerID++
+	l := Listener{target: target, event: event, fn: fn, id: nextListenerID}
+	liveListeners[l.id] = l
+	return l
+}
+
+// Event is a DOM event.
+type Event struct {
+	Value js.Value
+}
+
+func (e Event) Type() string {
+	return e.Value.Get("type").String()
+}
+
+// Target is the element the event was dispatched to.
+func (e Event) Target() Element {
+	return Element{Value: e.Value.Get("target")}
+}
+
+// CurrentTarget is the element whose listener is running.
+func (e Event) CurrentTarget() Element {
+	return Element{Value: e.Value.Get("currentTarget")}
+}
+
+func (e Event) PreventDefault() {
+	e.Value.Call("preventDefault")
+}
+
+func (e Event) StopPropagation() {
+	e.Value.Call("stopPropagation")
+}
+
+func (e Event) StopImmediatePropagation() {
+	e.Value.Call("stopImmediatePropagation")
+}
+
+// MouseEvent is a click, mousedown, mousemove or other mouse event.
+type MouseEvent struct {
+	Event
+}
+
+func (e MouseEvent) ClientX() int   { return e.Value.Get("clientX").Int() }
+func (e MouseEvent) ClientY() int   { return e.Value.Get("clientY").Int() }
+func (e MouseEvent) Button() int    { return e.Value.Get("button").Int() }
+func (e MouseEvent) AltKey() bool   { return e.Value.Get("altKey").Bool() }
+func (e MouseEvent) CtrlKey() bool  { return e.Value.Get("ctrlKey").Bool() }
+func (e MouseEvent) MetaKey() bool  { return e.Value.Get("metaKey").Bool() }
+func (e MouseEvent) ShiftKey() bool { return e.Value.Get("shiftKey").Bool() }
+
+// KeyboardEvent is a keydown or keyup event.
+type KeyboardEvent struct {
+	Event
+}
+
+// Key is the key's value, such as "a", "Enter" or "ArrowUp".
+func (e KeyboardEvent) Key() string { return e.Value.Get("key").String() }
+
+// Code is the physical key, such as "KeyA".
+func (e KeyboardEvent) Code() string   { return e.Value.Get("code").String() }
+func (e KeyboardEvent) Repeat() bool   { return e.Value.Get("repeat").Bool() }
+func (e KeyboardEvent) AltKey() bool   { return e.Value.Get("altKey").Bool() }
+func (e KeyboardEvent) CtrlKey() bool  { return e.Value.Get("ctrlKey").Bool() }
+func (e KeyboardEvent) MetaKey() bool  { return e.Value.Get("metaKey").Bool() }
+func (e KeyboardEvent) ShiftKey() bool { return e.Value.Get("shiftKey").Bool() }
+
+// InputEvent is an input or change event.
+type InputEvent struct {
+	Event
+}
+
+// TargetValue is the field's value after the change.
+func (e InputEvent) TargetValue() string {
+	return e.Target().GetValue()
+}
+
+// TargetChecked is whether the checkbox or radio button is now checked.
+func (e InputEvent) TargetChecked() bool {
+	return e.Target().Value.Get("checked").Bool()
+}
+
+// SubmitEvent is a form's submit event.
+type SubmitEvent struct {
+	Event
+}
+
+// Form is the form being submitted.
+func (e SubmitEvent) Form() Element {
+	return e.Target()
+}
+
+// CustomEvent is an event dispatched with Element.Dispatch or by other
+// scripts.
+type CustomEvent struct {
+	Event
+}
+
+// Detail decodes the event's detail into v, as with json.Unmarshal.
+func (e CustomEvent) Detail(v interface{}) error {
+	return fromJS(e.Value.Get("detail"), v)
+}
+
+// On listens for any event.
+func (e Element) On(event string, handler func(Event)) Listener {
+	return listen(e.Value, event, func(v js.Value) {
+		handler(Event{Value: v})
+	})
+}
+
+// OnMouse listens for a mouse event, such as "click" or "mousemove".
+func (e Element) OnMouse(event string, handler func(MouseEvent)) Listener {
+	return listen(e.Value, event, func(v js.Value) {
+		handler(MouseEvent{Event{Value: v}})
+	})
+}
+
+func (e Element) OnClick(handler func(MouseEvent)) Listener {
+	return e.OnMouse("click", handler)
+}
+
+// OnKey listens for "keydown" or "keyup".
+func (e Element) OnKey(event string, handler func(KeyboardEvent)) Listener {
+	return listen(e.Value, event, func(v js.Value) {
+		handler(KeyboardEvent{Event{Value: v}})
+	})
+}
+
+// OnInput listens for every change to a field's value.
+func (e Element) OnInput(handler func(InputEvent)) Listener {
+	return listen(e.Value, "input", func(v js.Value) {
+		handler(InputEvent{Event{Value: v}})
+	})
+}
+
+// OnChange listens for committed changes to a field, such as a checkbox
+// being toggled.
+func (e Element) OnChange(handler func(InputEvent)) Listener {
+	return listen(e.Value, "change", func(v js.Value) {
+		handler(InputEvent{Event{Value: v}})
+	})
+}
+
+// OnSubmit listens for the form being submitted. Call PreventDefault to
+// handle it in Go instead.
+func (e Element) OnSubmit(handler func(SubmitEvent)) Listener {
+	return listen(e.Value, "submit", func(v js.Value) {
+		handler(SubmitEvent{Event{Value: v}})
+	})
+}
+
+// OnCustom listens for a custom event.
+func (e Element) OnCustom(event string, handler func(CustomEvent)) Listener {
+	return listen(e.Value, event, func(v js.Value) {
+		handler(CustomEvent{Event{Value: v}})
+	})
+}
+
+// Delegate listens for event on the element's descendants matching
+// selector, including ones added later. The handler gets the matching
+// element:
+//
+//	list.Delegate("click", "button.remove", func(e wasmdom.Event, button wasmdom.Element) {
+//		removeItem(button.GetAttribute("data-id"))
+//	})
+func (e Element) Delegate(event, selector string, handler func(Event, Element)) Listener {
+	return listen(e.Value, event, func(v js.Value) {
+		target := v.Get("target")
+		if target.Type() != js.TypeObject || target.Get("closest").IsUndefined() {
+			return
+		}
+		match := target.Call("closest", selector)
+		if match.IsNull() || !e.Value.Call("contains", match).Bool() {
+			return
+		}
+		handler(Event{Value: v}, Element{Value: match})
+	})
+}
+
+// Dispatch sends a bubbling CustomEvent from the element. detail is
+// converted to JavaScript through JSON.
+func (e Element) Dispatch(event string, detail interface{}) error {
+	init := js.Global().Get("Object").New()
+	init.Set("bubbles", true)
+	if detail != nil {
+		value, err := toJS(detail)
+		if err != nil {
+			return err
+		}
+		init.Set("detail", value)
+	}
+	e.Value.Call("dispatchEvent", js.Global().Get("CustomEvent").New(event, init))
+	return nil
+}
+
+// fromJS decodes value into v through JSON.
+func fromJS(value js.Value, v interface{}) error {
+	data := js.Global().Get("JSON").Call("stringify", value)
+	if data.IsUndefined() {
+		return nil
+	}
+	return json.Unmarshal([]byte(data.String()), v)
+}
+
+// toJS converts v through JSON, so structs keep their json tags.
+func toJS(v interface{}) (js.Value, error) {
+	if value, ok := v.(js.Value); ok {
+		return value, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return js.Undefined(), err
+	}
+	return js.Global().Get("JSON").Call("parse", string(data)), nil
+}
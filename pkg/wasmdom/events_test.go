@@ -0,0 +1,126 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import (
+	"syscall/js"
+	"testing"
+)
+
+// evalJS evaluates a JavaScript expression, for building fakes of browser
+// objects the test runtime lacks.
+func evalJS(src string) js.Value {
+	return js.Global().Call("eval", "("+src+")")
+}
+
+// newTarget returns an element stand-in that events can be dispatched to.
+func newTarget() Element {
+	return Element{Value: js.Global().Get("EventTarget").New()}
+}
+
+func TestOnMouse(t *testing.T) {
+	el := newTarget()
+	var got MouseEvent
+	l := el.OnClick(func(e MouseEvent) {
+		got = e
+		// The targets are only set while the event is being dispatched.
+		if !e.Target().Value.Equal(el.Value) || !e.CurrentTarget().Value.Equal(el.Value) {
+			t.Error("Target() and CurrentTarget() should be the element")
+		}
+	})
+	defer l.Remove()
+
+	el.Value.Call("dispatchEvent", evalJS(`Object.assign(new Event("click"), {clientX: 12, clientY: 34, button: 2, shiftKey: true, ctrlKey: false})`))
+
+	if got.Type() != "click" {
+		t.Fatalf("Type() = %q, want click", got.Type())
+	}
+	if got.ClientX() != 12 || got.ClientY() != 34 || got.Button() != 2 {
+		t.Errorf("position %d,%d button %d", got.ClientX(), got.ClientY(), got.Button())
+	}
+	if !got.ShiftKey() || got.CtrlKey() {
+		t.Errorf("ShiftKey() = %v, CtrlKey() = %v", got.ShiftKey(), got.CtrlKey())
+	}
+}
+
+func TestOnKey(t *testing.T) {
+	el := newTarget()
+	var got KeyboardEvent
+	l := el.OnKey("keydown", func(e KeyboardEvent) { got = e })
+	defer l.Remove()
+
+	el.Value.Call("dispatchEvent", evalJS(`Object.assign(new Event("keydown"), {key: "Enter", code: "Enter", repeat: true, altKey: false, metaKey: true})`))
+
+	if got.Key() != "Enter" || got.Code() != "Enter" || !got.Repeat() || !got.MetaKey() || got.AltKey() {
+		t.Errorf("decoded key %q code %q repeat %v meta %v alt %v", got.Key(), got.Code(), got.Repeat(), got.MetaKey(), got.AltKey())
+	}
+}
+
+func TestOnInput(t *testing.T) {
+	el := newTarget()
+	el.Value.Set("value", "hello")
+	el.Value.Set("checked", true)
+	var got InputEvent
+	l := el.OnInput(func(e InputEvent) { got = e })
+	defer l.Remove()
+
+	el.Value.Call("dispatchEvent", js.Global().Get("Event").New("input"))
+
+	if got.TargetValue() != "hello" || !got.TargetChecked() {
+		t.Errorf("TargetValue() = %q, TargetChecked() = %v", got.TargetValue(), got.TargetChecked())
+	}
+}
+
+func TestOnSubmitPreventDefault(t *testing.T) {
+	el := newTarget()
+	l := el.OnSubmit(func(e SubmitEvent) {
+		if !e.Form().Value.Equal(el.Value) {
+			t.Error("Form() should be the submitted form")
+		}
+		e.PreventDefault()
+	})
+	defer l.Remove()
+
+	event := evalJS(`new Event("submit", {cancelable: true})`)
+	el.Value.Call("dispatchEvent", event)
+	if !event.Get("defaultPrevented").Bool() {
+		t.Error("PreventDefault() should cancel the submission")
+	}
+}
+
+func TestDispatchCustomEvent(t *testing.T) {
+	el := newTarget()
+	type detail struct {
+		ID    int    `json:"id"`
+		Label string `json:"label"`
+	}
+	var got detail
+	var err error
+	l := el.OnCustom("item:added", func(e CustomEvent) { err = e.Detail(&got) })
+	defer l.Remove()
+
+	if err := el.Dispatch("item:added", detail{ID: 3, Label: "three"}); err != nil {
+		t.Fatalf("Dispatch() error = %v", err)
+	}
+	if err != nil {
+		t.Fatalf("Detail() error = %v", err)
+	}
+	if got.ID != 3 || got.Label != "three" {
+		t.Errorf("Detail() = %+v", got)
+	}
+}
+
+func TestListenerRemove(t *testing.T) {
+	el := newTarget()
+	calls := 0
+	l := el.On("ping", func(Event) { calls++ })
+
+	el.Value.Call("dispatchEvent", js.Global().Get("Event").New("ping"))
+	l.Remove()
+	el.Value.Call("dispatchEvent", js.Global().Get("Event").New("ping"))
+
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want once before Remove", calls)
+	}
+}
@@ -4,71 +4,267 @@
 package wasmdom
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
 	"syscall/js"
 )
 
-type FetchResponse struct {
-	jsValue js.Value
-}
+const csrfHeaderName = "X-CSRF-Token"
 
-func (r FetchResponse) Status() int {
-	return r.jsValue.Get("status").Int()
+// CSRFToken finds the page's CSRF token from a <CSRFInput/> field, a
+// <meta name="csrf-token"> tag or the double-submit cookie.
+func CSRFToken() string {
+	doc := js.Global().Get("document")
+
+	for _, selector := range []string{`input[name="_csrf"]`, `meta[name="csrf-token"]`} {
+		el := doc.Call("querySelector", selector)
+		if el.IsNull() {
+			continue
+		}
+		attr := "value"
+		if el.Get("tagName").String() == "META" {
+			attr = "content"
+		}
+		if token := el.Call("getAttribute", attr); !token.IsNull() && token.String() != "" {
+			return token.String()
+		}
+	}
+
+	for _, part := range strings.Split(doc.Get("cookie").String(), ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if ok && name == "galaxy_csrf" {
+			token, _, _ := strings.Cut(value, ".")
+			return token
+		}
+	}
+
+	return ""
 }
 
-func (r FetchResponse) JSON() js.Value {
-	return r.jsValue
+// FetchOptions configure a request. The zero value is a GET.
+type FetchOptions struct {
+	Method  string
+	Headers map[string]string
+	Body    []byte
+	// Credentials is "omit", "same-origin" (the browser's default) or
+	// "include".
+	Credentials string
 }
 
-func Fetch(url string, callback func(FetchResponse)) {
-	fetchOpts := js.Global().Get("Object").New()
-	fetchOpts.Set("method", "GET")
-
-	promise := js.Global().Call("fetch", url, fetchOpts)
-	
-	promise.Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		response := args[0]
-		return response.Call("json")
-	})).Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		jsonData := args[0]
-		callback(FetchResponse{jsValue: jsonData})
-		return nil
-	}))
+// Response is an HTTP response. Like net/http, statuses other than 2xx are
+// not errors; check OK or Status. Its body can be read once.
+type Response struct {
+	Status     int
+	StatusText string
+	OK         bool
+	URL        string
+	// Headers are keyed by lowercase name.
+	Headers map[string]string
+
+	ctx   context.Context
+	value js.Value
 }
 
-func FetchWithOptions(url string, method string, headers map[string]string, body string, callback func(FetchResponse)) {
+// FetchContext sends a request and blocks until the response headers
+// arrive. Cancelling ctx, or its deadline passing, aborts the request.
+// Unsafe methods send the page's CSRF token unless the caller already set
+// it. Like Await, call it from a goroutine.
+func FetchContext(ctx context.Context, url string, opts FetchOptions) (*Response, error) {
 	fetchOpts := js.Global().Get("Object").New()
+	method := strings.ToUpper(opts.Method)
+	if method == "" {
+		method = "GET"
+	}
 	fetchOpts.Set("method", method)
-	
-	if len(headers) > 0 {
+
+	if headers := withCSRFToken(method, opts.Headers); len(headers) > 0 {
 		headersObj := js.Global().Get("Object").New()
 		for k, v := range headers {
 			headersObj.Set(k, v)
 		}
 		fetchOpts.Set("headers", headersObj)
 	}
-	
-	if body != "" {
+
+	if opts.Body != nil {
+		body := js.Global().Get("Uint8Array").New(len(opts.Body))
+		js.CopyBytesToJS(body, opts.Body)
 		fetchOpts.Set("body", body)
 	}
 
-	promise := js.Global().Call("fetch", url, fetchOpts)
-	
-	promise.Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		response := args[0]
-		status := response.Get("status").Int()
-		
-		if status >= 200 && status < 300 {
-			return response.Call("json")
+	if opts.Credentials != "" {
+		fetchOpts.Set("credentials", opts.Credentials)
+	}
+
+	if done := ctx.Done(); done != nil {
+		controller := js.Global().Get("AbortController").New()
+		fetchOpts.Set("signal", controller.Get("signal"))
+		finished := make(chan struct{})
+		defer close(finished)
+		go func() {
+			select {
+			case <-done:
+				controller.Call("abort")
+			case <-finished:
+			}
+		}()
+	}
+
+	value, err := AwaitContext(ctx, js.Global().Call("fetch", url, fetchOpts))
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
 		}
-		
-		emptyObj := js.Global().Get("Object").New()
-		emptyObj.Set("status", status)
-		return emptyObj
-	})).Call("then", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
-		jsonData := args[0]
-		callback(FetchResponse{jsValue: jsonData})
+		return nil, err
+	}
+
+	resp := &Response{
+		Status:     value.Get("status").Int(),
+		StatusText: value.Get("statusText").String(),
+		OK:         value.Get("ok").Bool(),
+		URL:        value.Get("url").String(),
+		Headers:    make(map[string]string),
+		ctx:        ctx,
+		value:      value,
+	}
+	forEach := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		resp.Headers[args[1].String()] = args[0].String()
 		return nil
-	}))
+	})
+	value.Get("headers").Call("forEach", forEach)
+	forEach.Release()
+
+	return resp, nil
+}
+
+// Text reads the body as text.
+func (r *Response) Text() (string, error) {
+	text, err := r.read("text")
+	if err != nil {
+		return "", err
+	}
+	return text.String(), nil
+}
+
+// Bytes reads the body.
+func (r *Response) Bytes() ([]byte, error) {
+	buf, err := r.read("arrayBuffer")
+	if err != nil {
+		return nil, err
+	}
+	array := js.Global().Get("Uint8Array").New(buf)
+	data := make([]byte, array.Length())
+	js.CopyBytesToGo(data, array)
+	return data, nil
+}
+
+// JSON decodes the body into v, as with json.Unmarshal.
+func (r *Response) JSON(v interface{}) error {
+	text, err := r.Text()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(text), v)
+}
+
+func (r *Response) read(method string) (js.Value, error) {
+	value, err := AwaitContext(r.ctx, r.value.Call(method))
+	if err != nil && r.ctx.Err() != nil {
+		return js.Undefined(), r.ctx.Err()
+	}
+	return value, err
+}
+
+// FetchAsync sends a request in a goroutine and calls onResponse with the
+// response, or onError if it could not be sent. The callbacks run on that
+// goroutine, so they may read the body.
+func FetchAsync(ctx context.Context, url string, opts FetchOptions, onResponse func(*Response), onError func(error)) {
+	go func() {
+		resp, err := FetchContext(ctx, url, opts)
+		if err != nil {
+			if onError != nil {
+				onError(err)
+			}
+			return
+		}
+		onResponse(resp)
+	}()
+}
+
+// FetchResponse is the result of Fetch and FetchWithOptions.
+type FetchResponse struct {
+	jsValue js.Value
+	status  int
+	err     error
+}
+
+// Status is the HTTP status, or 0 when the request failed.
+func (r FetchResponse) Status() int {
+	return r.status
+}
+
+// JSON is the decoded body, or undefined when it is not JSON.
+func (r FetchResponse) JSON() js.Value {
+	return r.jsValue
+}
+
+// Err is why the request failed, if it did.
+func (r FetchResponse) Err() error {
+	return r.err
+}
+
+func Fetch(url string, callback func(FetchResponse)) {
+	FetchWithOptions(url, "GET", nil, "", callback)
+}
+
+// FetchWithOptions sends the CSRF token header on unsafe methods unless the
+// caller already set it.
+func FetchWithOptions(url string, method string, headers map[string]string, body string, callback func(FetchResponse)) {
+	opts := FetchOptions{Method: method, Headers: headers}
+	if body != "" {
+		opts.Body = []byte(body)
+	}
+	FetchAsync(context.Background(), url, opts, func(resp *Response) {
+		result := FetchResponse{jsValue: js.Undefined(), status: resp.Status}
+		if text, err := resp.Text(); err != nil {
+			result.err = err
+		} else if data, err := parseJSON(text); err == nil {
+			result.jsValue = data
+		}
+		callback(result)
+	}, func(err error) {
+		callback(FetchResponse{jsValue: js.Undefined(), err: err})
+	})
+}
+
+func parseJSON(text string) (value js.Value, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	return js.Global().Get("JSON").Call("parse", text), nil
+}
+
+func withCSRFToken(method string, headers map[string]string) map[string]string {
+	switch strings.ToUpper(method) {
+	case "", "GET", "HEAD", "OPTIONS":
+		return headers
+	}
+	if _, ok := headers[csrfHeaderName]; ok {
+		return headers
+	}
+	token := CSRFToken()
+	if token == "" {
+		return headers
+	}
+	withToken := make(map[string]string, len(headers)+1)
+	for k, v := range headers {
+		withToken[k] = v
+	}
+	withToken[csrfHeaderName] = token
+	return withToken
 }
 
 func Prompt(message string) string {
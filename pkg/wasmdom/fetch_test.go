@@ -0,0 +1,189 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"syscall/js"
+	"testing"
+	"time"
+)
+
+// stubFetch replaces the global fetch with respond for the rest of the test.
+func stubFetch(t *testing.T, respond func(url string, opts js.Value) js.Value) {
+	t.Helper()
+	orig := js.Global().Get("fetch")
+	fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		return respond(args[0].String(), args[1])
+	})
+	js.Global().Set("fetch", fn)
+	t.Cleanup(func() {
+		js.Global().Set("fetch", orig)
+		fn.Release()
+	})
+}
+
+// response resolves to a Response with status, a JSON content type and body.
+func response(status int, body string) js.Value {
+	init := js.Global().Get("Object").New()
+	init.Set("status", status)
+	headers := js.Global().Get("Object").New()
+	headers.Set("Content-Type", "application/json")
+	init.Set("headers", headers)
+	return js.Global().Get("Promise").Call("resolve", js.Global().Get("Response").New(body, init))
+}
+
+func rejected(err js.Value) js.Value {
+	return js.Global().Get("Promise").Call("reject", err)
+}
+
+func TestAwaitResolve(t *testing.T) {
+	value, err := Await(js.Global().Get("Promise").Call("resolve", 42))
+	if err != nil {
+		t.Fatalf("Await() error = %v", err)
+	}
+	if value.Int() != 42 {
+		t.Errorf("Await() = %v, want 42", value)
+	}
+
+	if value, err := Await(js.ValueOf("plain")); err != nil || value.String() != "plain" {
+		t.Errorf("Await(non-promise) = %v, %v, want the value itself", value, err)
+	}
+}
+
+func TestAwaitReject(t *testing.T) {
+	_, err := Await(rejected(js.Global().Get("Error").New("boom")))
+	var jsErr JSError
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("Await() error = %v, want a JSError", err)
+	}
+	if err.Error() != "Error: boom" {
+		t.Errorf("Error() = %q, want %q", err.Error(), "Error: boom")
+	}
+
+	if _, err := Await(rejected(js.ValueOf("reason"))); err == nil || err.Error() != "reason" {
+		t.Errorf("Await() error = %v, want the rejection reason", err)
+	}
+}
+
+func TestAwaitContextDeadline(t *testing.T) {
+	executor := js.FuncOf(func(this js.Value, args []js.Value) interface{} { return nil })
+	defer executor.Release()
+	pending := js.Global().Get("Promise").New(executor)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := AwaitContext(ctx, pending); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("AwaitContext() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestFetchContextResolve(t *testing.T) {
+	var method, token string
+	stubFetch(t, func(url string, opts js.Value) js.Value {
+		method = opts.Get("method").String()
+		token = opts.Get("headers").Get(csrfHeaderName).String()
+		return response(201, `{"id": 7, "name": "galaxy"}`)
+	})
+
+	resp, err := FetchContext(context.Background(), "/api/items", FetchOptions{
+		Method:  "post",
+		Headers: map[string]string{csrfHeaderName: "token"},
+		Body:    []byte(`{"name": "galaxy"}`),
+	})
+	if err != nil {
+		t.Fatalf("FetchContext() error = %v", err)
+	}
+	if method != "POST" || token != "token" {
+		t.Errorf("sent method %q and token %q", method, token)
+	}
+	if resp.Status != 201 || !resp.OK {
+		t.Errorf("Status = %d, OK = %v, want 201 and true", resp.Status, resp.OK)
+	}
+	if resp.Headers["content-type"] != "application/json" {
+		t.Errorf("Headers = %v, want lowercase content-type", resp.Headers)
+	}
+
+	var item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := resp.JSON(&item); err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	if item.ID != 7 || item.Name != "galaxy" {
+		t.Errorf("JSON() decoded %+v", item)
+	}
+}
+
+func TestFetchContextNon2xx(t *testing.T) {
+	stubFetch(t, func(url string, opts js.Value) js.Value {
+		return response(404, "missing")
+	})
+
+	resp, err := FetchContext(context.Background(), "/missing", FetchOptions{})
+	if err != nil {
+		t.Fatalf("FetchContext() error = %v, want none for a 404", err)
+	}
+	if resp.OK || resp.Status != 404 {
+		t.Errorf("Status = %d, OK = %v, want 404 and false", resp.Status, resp.OK)
+	}
+	if text, err := resp.Text(); err != nil || text != "missing" {
+		t.Errorf("Text() = %q, %v", text, err)
+	}
+}
+
+func TestFetchContextReject(t *testing.T) {
+	stubFetch(t, func(url string, opts js.Value) js.Value {
+		return rejected(js.Global().Get("TypeError").New("Failed to fetch"))
+	})
+
+	resp, err := FetchContext(context.Background(), "/offline", FetchOptions{})
+	if err == nil || resp != nil {
+		t.Fatalf("FetchContext() = %v, %v, want an error", resp, err)
+	}
+	if !strings.Contains(err.Error(), "Failed to fetch") {
+		t.Errorf("error = %q", err.Error())
+	}
+}
+
+func TestResponseJSONDecodeError(t *testing.T) {
+	stubFetch(t, func(url string, opts js.Value) js.Value {
+		return response(200, "<html>not json</html>")
+	})
+
+	resp, err := FetchContext(context.Background(), "/page", FetchOptions{})
+	if err != nil {
+		t.Fatalf("FetchContext() error = %v", err)
+	}
+	var v map[string]interface{}
+	var syntaxErr *json.SyntaxError
+	if err := resp.JSON(&v); !errors.As(err, &syntaxErr) {
+		t.Errorf("JSON() error = %v, want a *json.SyntaxError", err)
+	}
+}
+
+func TestFetchWithOptions(t *testing.T) {
+	stubFetch(t, func(url string, opts js.Value) js.Value {
+		if url == "/broken" {
+			return response(200, "{")
+		}
+		return response(200, `{"ok": true}`)
+	})
+
+	results := make(chan FetchResponse, 1)
+	Fetch("/status", func(r FetchResponse) { results <- r })
+	r := <-results
+	if r.Err() != nil || r.Status() != 200 || !r.JSON().Get("ok").Bool() {
+		t.Errorf("Fetch() = %d %v %v", r.Status(), r.JSON(), r.Err())
+	}
+
+	Fetch("/broken", func(r FetchResponse) { results <- r })
+	if r := <-results; r.Err() != nil || !r.JSON().IsUndefined() {
+		t.Errorf("Fetch() of invalid JSON = %v %v, want an undefined body", r.JSON(), r.Err())
+	}
+}
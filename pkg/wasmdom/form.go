@@ -0,0 +1,29 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import (
+	"net/url"
+	"syscall/js"
+)
+
+// FormValues serializes a form's fields as the browser would submit them.
+// File inputs contribute their file names.
+func (e Element) FormValues() url.Values {
+	values := url.Values{}
+	entries := js.Global().Get("FormData").New(e.Value).Call("entries")
+	for {
+		next := entries.Call("next")
+		if next.Get("done").Bool() {
+			break
+		}
+		pair := next.Get("value")
+		value := pair.Index(1)
+		if value.Type() == js.TypeObject {
+			value = value.Get("name")
+		}
+		values.Add(pair.Index(0).String(), value.String())
+	}
+	return values
+}
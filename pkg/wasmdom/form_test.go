@@ -0,0 +1,45 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import (
+	"syscall/js"
+	"testing"
+)
+
+func TestFormValues(t *testing.T) {
+	// The test runtime's FormData can't read a form, so this one yields
+	// the fields a fake form lists, in order, as the browser's would.
+	orig := js.Global().Get("FormData")
+	js.Global().Set("FormData", evalJS(`class {
+		constructor(form) { this.fields = form.fields }
+		entries() { return this.fields[Symbol.iterator]() }
+	}`))
+	defer js.Global().Set("FormData", orig)
+
+	form := Element{Value: evalJS(`{fields: [
+		["name", "Ada"],
+		["tags", "go"],
+		["tags", "wasm"],
+		["avatar", {name: "ada.png", size: 42}],
+		["empty", ""],
+	]}`)}
+
+	values := form.FormValues()
+	if values.Get("name") != "Ada" || values.Get("empty") != "" {
+		t.Errorf("FormValues() = %v", values)
+	}
+	if tags := values["tags"]; len(tags) != 2 || tags[0] != "go" || tags[1] != "wasm" {
+		t.Errorf("repeated fields = %v, want both in order", tags)
+	}
+	if values.Get("avatar") != "ada.png" {
+		t.Errorf("file field = %q, want its name", values.Get("avatar"))
+	}
+	if _, ok := values["empty"]; !ok {
+		t.Error("empty fields should be submitted")
+	}
+	if got := values.Encode(); got != "avatar=ada.png&empty=&name=Ada&tags=go&tags=wasm" {
+		t.Errorf("Encode() = %q", got)
+	}
+}
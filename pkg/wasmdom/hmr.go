@@ -8,8 +8,12 @@ import (
 )
 
 type HMRModule struct {
-	moduleID string
-	cleanup  []js.Func
+	moduleID   string
+	cleanup    []js.Func
+	bindings   []*Binding
+	observers  []Listener
+	onDispose  func()
+	registered bool
 }
 
 func NewHMRModule(moduleID string) *HMRModule {
@@ -31,6 +35,20 @@ func (m *HMRModule) Accept(callback func()) {
 }
 
 func (m *HMRModule) OnDispose(handler func()) {
+	m.onDispose = handler
+	m.registerDispose()
+}
+
+// Track undoes bindings when the module is disposed.
+func (m *HMRModule) Track(bindings ...*Binding) {
+	m.bindings = append(m.bindings, bindings...)
+	m.registerDispose()
+}
+
+func (m *HMRModule) registerDispose() {
+	if m.registered {
+		return
+	}
 	ensureGlobals()
 
 	modules := js.Global().Get("__galaxyWasmModules")
@@ -41,10 +59,19 @@ func (m *HMRModule) OnDispose(handler func()) {
 			for _, fn := range m.cleanup {
 				fn.Release()
 			}
-			handler()
+			for _, b := range m.bindings {
+				b.Unbind()
+			}
+			for _, l := range m.observers {
+				l.Remove()
+			}
+			if m.onDispose != nil {
+				m.onDispose()
+			}
 			return nil
 		})
 		module.Set("disposeHandler", cb)
+		m.registered = true
 	}
 }
 
@@ -71,6 +98,19 @@ func (m *HMRModule) TrackListener(el Element, event string, handler js.Func) {
 	}
 }
 
+// TrackListeners removes listeners and observers added with the On and
+// Observe functions when the module is disposed.
+func (m *HMRModule) TrackListeners(listeners ...Listener) {
+	for _, l := range listeners {
+		if l.stop != nil {
+			m.observers = append(m.observers, l)
+			continue
+		}
+		m.TrackListener(Element{Value: l.target}, l.event, l.fn)
+	}
+	m.registerDispose()
+}
+
 func (m *HMRModule) SaveState(key string, value interface{}) {
 	ensureGlobals()
 	state := js.Global().Get("__galaxyWasmState")
@@ -0,0 +1,56 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import (
+	"encoding/json"
+	"syscall/js"
+)
+
+// Island is a component rendered with a client: directive, which its script
+// is hydrating.
+type Island struct {
+	ID string
+	// Root wraps the server-rendered component.
+	Root  Element
+	props js.Value
+}
+
+// CurrentIsland returns the island the script is starting for. Call it
+// while the script starts, not from event handlers; ok is false for scripts
+// of components that are not islands.
+func CurrentIsland() (island Island, ok bool) {
+	state := js.Global().Get("__galaxyHydration")
+	if state.IsUndefined() {
+		return Island{}, false
+	}
+	current := state.Get("current")
+	if current.IsUndefined() || current.IsNull() {
+		return Island{}, false
+	}
+	return Island{
+		ID:    current.Get("id").String(),
+		Root:  Element{Value: current.Get("root")},
+		props: current.Get("props"),
+	}, true
+}
+
+// Prop returns the prop the island was rendered with, or undefined.
+func (i Island) Prop(name string) js.Value {
+	if i.props.IsUndefined() || i.props.IsNull() {
+		return js.Undefined()
+	}
+	return i.props.Get(name)
+}
+
+// Props decodes the island's props into v, as with json.Unmarshal.
+func (i Island) Props(v interface{}) error {
+	data := js.Global().Get("JSON").Call("stringify", i.props).String()
+	return json.Unmarshal([]byte(data), v)
+}
+
+// QuerySelector finds an element inside the island.
+func (i Island) QuerySelector(selector string) Element {
+	return Element{Value: i.Root.Value.Call("querySelector", selector)}
+}
@@ -0,0 +1,79 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import (
+	"net/url"
+	"syscall/js"
+)
+
+// Location returns the page's current URL.
+func Location() *url.URL {
+	u, err := url.Parse(js.Global().Get("location").Get("href").String())
+	if err != nil {
+		return &url.URL{}
+	}
+	return u
+}
+
+// ReloadPage reloads the current page.
+func ReloadPage() {
+	js.Global().Get("location").Call("reload")
+}
+
+// PushState adds a history entry for url without loading it. state is
+// converted through JSON and handed back by OnPopState.
+func PushState(url string, state interface{}) error {
+	return changeHistory("pushState", url, state)
+}
+
+// ReplaceState is PushState, replacing the current entry.
+func ReplaceState(url string, state interface{}) error {
+	return changeHistory("replaceState", url, state)
+}
+
+func changeHistory(method, url string, state interface{}) error {
+	value := js.Null()
+	if state != nil {
+		var err error
+		if value, err = toJS(state); err != nil {
+			return err
+		}
+	}
+	js.Global().Get("history").Call(method, value, "", url)
+	return nil
+}
+
+func HistoryBack() {
+	js.Global().Get("history").Call("back")
+}
+
+func HistoryForward() {
+	js.Global().Get("history").Call("forward")
+}
+
+// PopStateEvent is fired when the user moves through history entries.
+type PopStateEvent struct {
+	Event
+}
+
+// State decodes the entry's state into v, as with json.Unmarshal.
+func (e PopStateEvent) State(v interface{}) error {
+	return fromJS(e.Value.Get("state"), v)
+}
+
+// OnPopState listens for the user moving through history entries.
+func OnPopState(handler func(PopStateEvent)) Listener {
+	return listen(js.Global(), "popstate", func(v js.Value) {
+		handler(PopStateEvent{Event{Value: v}})
+	})
+}
+
+// OnWindow listens for an event on the window, such as "resize" or
+// "online".
+func OnWindow(event string, handler func(Event)) Listener {
+	return listen(js.Global(), event, func(v js.Value) {
+		handler(Event{Value: v})
+	})
+}
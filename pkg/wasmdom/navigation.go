@@ -0,0 +1,72 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import "syscall/js"
+
+// NavigationEvent is dispatched on the document by the client router added
+// with <ClientRouter/> as it moves to another page. WASM modules keep
+// running across these navigations: re-query the elements they work on
+// from OnPageLoad, or leave elements marked data-galaxy-persist in place.
+type NavigationEvent struct {
+	Event
+}
+
+// From is the URL of the page being left.
+func (e NavigationEvent) From() string {
+	return e.Value.Get("detail").Get("from").String()
+}
+
+// To is the URL of the page being navigated to, after any redirects once
+// the page has been fetched.
+func (e NavigationEvent) To() string {
+	return e.Value.Get("detail").Get("to").String()
+}
+
+// NewDocument is the fetched page before it is swapped in, during
+// OnBeforeSwap. Changes made to it are carried into the document.
+func (e NavigationEvent) NewDocument() js.Value {
+	return e.Value.Get("detail").Get("newDocument")
+}
+
+// OnBeforeNavigate is called before the next page is fetched. Call
+// PreventDefault to stay on the current page.
+func OnBeforeNavigate(handler func(NavigationEvent)) Listener {
+	return onNavigation("galaxy:before-navigate", handler)
+}
+
+// OnBeforeSwap is called with the fetched page, before it replaces the
+// current one.
+func OnBeforeSwap(handler func(NavigationEvent)) Listener {
+	return onNavigation("galaxy:before-swap", handler)
+}
+
+// OnAfterSwap is called once the document is the new page, before its
+// scripts run.
+func OnAfterSwap(handler func(NavigationEvent)) Listener {
+	return onNavigation("galaxy:after-swap", handler)
+}
+
+// OnPageLoad is called after a navigation, once the new page's scripts
+// have been started. It is not called for the page the module was loaded
+// on.
+func OnPageLoad(handler func(NavigationEvent)) Listener {
+	return onNavigation("galaxy:page-load", handler)
+}
+
+func onNavigation(event string, handler func(NavigationEvent)) Listener {
+	return listen(js.Global().Get("document"), event, func(v js.Value) {
+		handler(NavigationEvent{Event{Value: v}})
+	})
+}
+
+// Navigate goes to url through the client router, or with a full page load
+// when the page has none.
+func Navigate(url string) {
+	if router := js.Global().Get("__galaxyRouter"); router.Truthy() && router.Get("navigate").Truthy() {
+		router.Call("navigate", url)
+		return
+	}
+	js.Global().Get("location").Call("assign", url)
+}
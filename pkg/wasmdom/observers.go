@@ -0,0 +1,105 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import "syscall/js"
+
+// IntersectionOptions configure ObserveIntersection. The zero value
+// observes the element against the viewport, reporting any overlap.
+type IntersectionOptions struct {
+	Root Element
+	// RootMargin grows or shrinks the root, as in CSS: "100px 0px".
+	RootMargin string
+	Threshold  []float64
+}
+
+// IntersectionEntry reports how much of an element is visible.
+type IntersectionEntry struct {
+	Target         Element
+	IsIntersecting bool
+	Ratio          float64
+}
+
+// ObserveIntersection calls handler whenever el crosses one of the
+// options' thresholds, such as when it scrolls into view.
+func ObserveIntersection(el Element, opts IntersectionOptions, handler func(IntersectionEntry)) Listener {
+	init := js.Global().Get("Object").New()
+	if !opts.Root.Value.IsUndefined() {
+		init.Set("root", opts.Root.Value)
+	}
+	if opts.RootMargin != "" {
+		init.Set("rootMargin", opts.RootMargin)
+	}
+	if len(opts.Threshold) > 0 {
+		thresholds := make([]interface{}, len(opts.Threshold))
+		for i, t := range opts.Threshold {
+			thresholds[i] = t
+		}
+		init.Set("threshold", thresholds)
+	}
+
+	return observe("IntersectionObserver", el, init, func(entry js.Value) {
+		handler(IntersectionEntry{
+			Target:         Element{Value: entry.Get("target")},
+			IsIntersecting: entry.Get("isIntersecting").Bool(),
+			Ratio:          entry.Get("intersectionRatio").Float(),
+		})
+	})
+}
+
+// ResizeEntry is an element's new content size, in CSS pixels.
+type ResizeEntry struct {
+	Target Element
+	Width  float64
+	Height float64
+}
+
+// ObserveResize calls handler whenever el changes size.
+func ObserveResize(el Element, handler func(ResizeEntry)) Listener {
+	return observe("ResizeObserver", el, js.Undefined(), func(entry js.Value) {
+		rect := entry.Get("contentRect")
+		handler(ResizeEntry{
+			Target: Element{Value: entry.Get("target")},
+			Width:  rect.Get("width").Float(),
+			Height: rect.Get("height").Float(),
+		})
+	})
+}
+
+func observe(ctor string, el Element, init js.Value, handler func(entry js.Value)) Listener {
+	fn := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		entries := firstArg(args)
+		for i := 0; i < entries.Length(); i++ {
+			handler(entries.Index(i))
+		}
+		return nil
+	})
+	observer := js.Global().Get(ctor).New(fn, init)
+	observer.Call("observe", el.Value)
+	return Listener{fn: fn, stop: func() {
+		observer.Call("disconnect")
+	}}
+}
+
+// MediaQuery is the result of MatchMedia.
+type MediaQuery struct {
+	Value js.Value
+}
+
+// MatchMedia evaluates a CSS media query, such as
+// "(prefers-color-scheme: dark)".
+func MatchMedia(query string) MediaQuery {
+	return MediaQuery{Value: js.Global().Call("matchMedia", query)}
+}
+
+func (m MediaQuery) Matches() bool {
+	return m.Value.Get("matches").Bool()
+}
+
+// OnChange calls handler whenever the query starts or stops matching.
+func (m MediaQuery) OnChange(handler func(matches bool)) Listener {
+	return listen(m.Value, "change", func(v js.Value) {
+		handler(v.Get("matches").Bool())
+	})
+}
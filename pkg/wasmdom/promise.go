@@ -0,0 +1,92 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import (
+	"context"
+	"syscall/js"
+)
+
+// JSError is the reason a promise was rejected, or an exception thrown by a
+// browser API.
+type JSError struct {
+	Value js.Value
+}
+
+func (e JSError) Error() string {
+	if e.Value.Type() == js.TypeObject {
+		if msg := e.Value.Get("message"); msg.Type() == js.TypeString {
+			if name := e.Value.Get("name"); name.Type() == js.TypeString {
+				return name.String() + ": " + msg.String()
+			}
+			return msg.String()
+		}
+	}
+	return js.Global().Call("String", e.Value).String()
+}
+
+// Await blocks until promise settles and returns its value, or a JSError
+// if it was rejected. Values that are not promises are returned as they
+// are.
+//
+// Call it from a goroutine: blocking inside an event handler or other
+// callback from JavaScript stalls the page.
+func Await(promise js.Value) (js.Value, error) {
+	return AwaitContext(context.Background(), promise)
+}
+
+// AwaitContext is Await, giving up with ctx's error when it is done first.
+// The promise itself is not cancelled.
+func AwaitContext(ctx context.Context, promise js.Value) (js.Value, error) {
+	type result struct {
+		value js.Value
+		err   error
+	}
+	done := make(chan result, 1)
+	Then(promise, func(value js.Value) {
+		done <- result{value: value}
+	}, func(err error) {
+		done <- result{err: err}
+	})
+
+	select {
+	case r := <-done:
+		return r.value, r.err
+	case <-ctx.Done():
+		return js.Undefined(), ctx.Err()
+	}
+}
+
+// Then calls onFulfilled or onRejected when promise settles, without
+// blocking. Either may be nil.
+func Then(promise js.Value, onFulfilled func(js.Value), onRejected func(error)) {
+	var fulfilled, rejected js.Func
+	release := func() {
+		fulfilled.Release()
+		rejected.Release()
+	}
+	fulfilled = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		release()
+		if onFulfilled != nil {
+			onFulfilled(firstArg(args))
+		}
+		return nil
+	})
+	rejected = js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		release()
+		if onRejected != nil {
+			onRejected(JSError{Value: firstArg(args)})
+		}
+		return nil
+	})
+
+	js.Global().Get("Promise").Call("resolve", promise).Call("then", fulfilled, rejected)
+}
+
+func firstArg(args []js.Value) js.Value {
+	if len(args) == 0 {
+		return js.Undefined()
+	}
+	return args[0]
+}
@@ -0,0 +1,85 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import "syscall/js"
+
+// WebStorage is localStorage or sessionStorage. For values that should
+// update the page when they change, see store.NewPersistentAtom.
+type WebStorage struct {
+	Value js.Value
+}
+
+func LocalStorage() WebStorage {
+	return WebStorage{Value: js.Global().Get("localStorage")}
+}
+
+func SessionStorage() WebStorage {
+	return WebStorage{Value: js.Global().Get("sessionStorage")}
+}
+
+// GetItem returns the value stored under key; ok is false when there is
+// none.
+func (s WebStorage) GetItem(key string) (value string, ok bool) {
+	v := s.Value.Call("getItem", key)
+	if v.IsNull() {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// SetItem stores value under key. It fails when the storage quota is
+// exceeded or storage is disabled.
+func (s WebStorage) SetItem(key, value string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if jsErr, ok := r.(js.Error); ok {
+				err = JSError{Value: jsErr.Value}
+				return
+			}
+			panic(r)
+		}
+	}()
+	s.Value.Call("setItem", key, value)
+	return nil
+}
+
+func (s WebStorage) RemoveItem(key string) {
+	s.Value.Call("removeItem", key)
+}
+
+func (s WebStorage) Clear() {
+	s.Value.Call("clear")
+}
+
+func (s WebStorage) Keys() []string {
+	keys := make([]string, s.Value.Get("length").Int())
+	for i := range keys {
+		keys[i] = s.Value.Call("key", i).String()
+	}
+	return keys
+}
+
+// StorageEvent reports a change another tab made to localStorage.
+type StorageEvent struct {
+	Event
+}
+
+func (e StorageEvent) Key() string { return e.Value.Get("key").String() }
+
+// NewValue is the new value; ok is false when the key was removed.
+func (e StorageEvent) NewValue() (value string, ok bool) {
+	v := e.Value.Get("newValue")
+	if v.IsNull() {
+		return "", false
+	}
+	return v.String(), true
+}
+
+// OnStorage listens for other tabs changing localStorage.
+func OnStorage(handler func(StorageEvent)) Listener {
+	return listen(js.Global(), "storage", func(v js.Value) {
+		handler(StorageEvent{Event{Value: v}})
+	})
+}
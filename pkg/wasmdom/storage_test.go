@@ -0,0 +1,84 @@
+//go:build js && wasm
+// +build js,wasm
+
+package wasmdom
+
+import (
+	"errors"
+	"syscall/js"
+	"testing"
+)
+
+// fakeStorage implements the Storage interface over a Map. Setting the key
+// "full" fails like an exceeded quota.
+const fakeStorage = `{
+	items: new Map(),
+	get length() { return this.items.size },
+	key(i) { return [...this.items.keys()][i] ?? null },
+	getItem(k) { return this.items.has(k) ? this.items.get(k) : null },
+	setItem(k, v) {
+		if (k === "full") throw new DOMException("quota exceeded", "QuotaExceededError")
+		this.items.set(k, String(v))
+	},
+	removeItem(k) { this.items.delete(k) },
+	clear() { this.items.clear() },
+}`
+
+func TestWebStorage(t *testing.T) {
+	js.Global().Set("localStorage", evalJS(fakeStorage))
+	defer js.Global().Delete("localStorage")
+	s := LocalStorage()
+
+	if _, ok := s.GetItem("theme"); ok {
+		t.Error("GetItem() of a missing key should not be ok")
+	}
+	if err := s.SetItem("theme", "dark"); err != nil {
+		t.Fatalf("SetItem() error = %v", err)
+	}
+	if v, ok := s.GetItem("theme"); !ok || v != "dark" {
+		t.Errorf("GetItem() = %q, %v, want dark", v, ok)
+	}
+	if err := s.SetItem("empty", ""); err != nil {
+		t.Fatal(err)
+	}
+	if v, ok := s.GetItem("empty"); !ok || v != "" {
+		t.Errorf("GetItem() of an empty value = %q, %v, want ok", v, ok)
+	}
+	if keys := s.Keys(); len(keys) != 2 || keys[0] != "theme" || keys[1] != "empty" {
+		t.Errorf("Keys() = %v", keys)
+	}
+
+	s.RemoveItem("theme")
+	if _, ok := s.GetItem("theme"); ok {
+		t.Error("RemoveItem() should delete the key")
+	}
+	s.Clear()
+	if keys := s.Keys(); len(keys) != 0 {
+		t.Errorf("Keys() after Clear() = %v", keys)
+	}
+}
+
+func TestWebStorageQuota(t *testing.T) {
+	s := WebStorage{Value: evalJS(fakeStorage)}
+
+	err := s.SetItem("full", "x")
+	var jsErr JSError
+	if !errors.As(err, &jsErr) {
+		t.Fatalf("SetItem() error = %v, want a JSError", err)
+	}
+	if err.Error() != "QuotaExceededError: quota exceeded" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+func TestStorageEvent(t *testing.T) {
+	e := StorageEvent{Event{Value: evalJS(`{type: "storage", key: "theme", newValue: "light"}`)}}
+	if v, ok := e.NewValue(); e.Key() != "theme" || !ok || v != "light" {
+		t.Errorf("Key() = %q, NewValue() = %q, %v", e.Key(), v, ok)
+	}
+
+	removed := StorageEvent{Event{Value: evalJS(`{type: "storage", key: "theme", newValue: null}`)}}
+	if _, ok := removed.NewValue(); ok {
+		t.Error("NewValue() of a removed key should not be ok")
+	}
+}